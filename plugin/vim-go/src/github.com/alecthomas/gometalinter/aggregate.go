@@ -32,6 +32,7 @@ func AggregateIssueChan(issues chan *Issue) chan *Issue {
 			}
 			if existing, ok := issueMap[key]; ok {
 				existing.linterNames = append(existing.linterNames, issue.Linter)
+				existing.stats = append(existing.stats, issue.stats...)
 			} else {
 				issueMap[key] = &multiIssue{
 					Issue:       issue,
@@ -43,6 +44,7 @@ func AggregateIssueChan(issues chan *Issue) chan *Issue {
 			issue := multi.Issue
 			sort.Strings(multi.linterNames)
 			issue.Linter = strings.Join(multi.linterNames, ", ")
+			issue.aggregatedLinters = multi.linterNames
 			out <- issue
 		}
 		close(out)
@@ -0,0 +1,119 @@
+// Package analysis defines the interface of linters which gometalinter runs
+// in-process on packages that were loaded and type-checked once, rather than
+// as subprocesses whose output is parsed with a regular expression.
+//
+// An analyzer is made available to gometalinter by registering it from the
+// init function of its package:
+//
+//	func init() {
+//		analysis.Register("mylinter", myAnalyzer{})
+//	}
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/go/loader"
+)
+
+// Program is a set of packages that have been parsed and type-checked once,
+// and are shared by all in-process linters.
+type Program struct {
+	Fset     *token.FileSet
+	Packages []*Package
+
+	// Loader is the program the packages were loaded into, along with their
+	// dependencies, and Config the configuration it was loaded with. Both
+	// are nil if there is no package to lint.
+	Loader *loader.Program
+	Config *loader.Config
+}
+
+// Package is a single parsed and type-checked package of a Program.
+type Package struct {
+	Dir   string
+	Files []*ast.File
+	Types *types.Package
+	Info  *types.Info
+
+	// Loader is the package as loaded into Program.Loader.
+	Loader *loader.PackageInfo
+}
+
+// Problem is an issue reported by an in-process linter.
+type Problem struct {
+	Position token.Position
+	Message  string
+}
+
+// Options are the settings of the gometalinter run which apply to analyzers.
+type Options struct {
+	// MinConfidence is the minimum confidence of golint problems to report.
+	MinConfidence float64
+}
+
+// Analyzer is implemented by linters which run in-process on an already
+// loaded Program. cancel is closed when the deadline of the linter passes,
+// and Analyze should then stop as soon as possible and return ErrCanceled.
+type Analyzer interface {
+	Analyze(program *Program, options Options, cancel <-chan struct{}) ([]Problem, error)
+}
+
+// ErrCanceled is returned by analyzers which stopped because they were
+// canceled.
+var ErrCanceled = errors.New("analysis canceled")
+
+// Canceled returns true if cancel is closed.
+func Canceled(cancel <-chan struct{}) bool {
+	select {
+	case <-cancel:
+		return true
+	default:
+		return false
+	}
+}
+
+var registry = struct {
+	sync.Mutex
+	analyzers map[string]Analyzer
+}{analyzers: map[string]Analyzer{}}
+
+// Register makes analyzer the in-process implementation of the linter name.
+// It panics if an analyzer is already registered for name.
+func Register(name string, analyzer Analyzer) {
+	registry.Lock()
+	defer registry.Unlock()
+	if analyzer == nil {
+		panic("analysis: Register analyzer is nil")
+	}
+	if _, dup := registry.analyzers[name]; dup {
+		panic(fmt.Sprintf("analysis: Register called twice for %s", name))
+	}
+	registry.analyzers[name] = analyzer
+}
+
+// Lookup returns the analyzer registered for the linter name.
+func Lookup(name string) (Analyzer, bool) {
+	registry.Lock()
+	defer registry.Unlock()
+	analyzer, ok := registry.analyzers[name]
+	return analyzer, ok
+}
+
+// Names returns the sorted names of all registered analyzers.
+func Names() []string {
+	registry.Lock()
+	defer registry.Unlock()
+	names := make([]string, 0, len(registry.analyzers))
+	for name := range registry.analyzers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"go/build"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/gometalinter/analysis"
+	"github.com/alecthomas/gometalinter/internal/errcheck"
+	"github.com/golang/lint"
+	honnef "honnef.co/go/tools/lint"
+	"honnef.co/go/tools/simple"
+	"honnef.co/go/tools/staticcheck"
+	"honnef.co/go/tools/unused"
+)
+
+// Linters with a built-in in-process implementation. These are only used
+// when --in-process is set and the linter's command has not been overridden.
+func init() {
+	analysis.Register("errcheck", errcheckAnalyzer{})
+	analysis.Register("golint", golintAnalyzer{})
+	analysis.Register("gosimple", honnefAnalyzer{newGosimpleChecker})
+	analysis.Register("megacheck", honnefAnalyzer{newGosimpleChecker, newStaticcheckChecker, newUnusedChecker})
+	analysis.Register("staticcheck", honnefAnalyzer{newStaticcheckChecker})
+	analysis.Register("unused", honnefAnalyzer{newUnusedChecker})
+}
+
+// golintAnalyzer runs golint in-process on the files of each package.
+type golintAnalyzer struct{}
+
+func (golintAnalyzer) Analyze(program *analysis.Program, options analysis.Options, cancel <-chan struct{}) ([]analysis.Problem, error) {
+	problems := []analysis.Problem{}
+	for _, pkg := range program.Packages {
+		if analysis.Canceled(cancel) {
+			return nil, analysis.ErrCanceled
+		}
+		files := map[string][]byte{}
+		for _, file := range pkg.Files {
+			filename := program.Fset.File(file.Pos()).Name()
+			src, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return nil, err
+			}
+			files[filename] = src
+		}
+		linter := &lint.Linter{}
+		found, err := linter.LintFiles(files)
+		if err != nil {
+			return nil, err
+		}
+		for _, problem := range found {
+			if problem.Confidence >= options.MinConfidence {
+				problems = append(problems, analysis.Problem{Position: problem.Position, Message: problem.Text})
+			}
+		}
+	}
+	return problems, nil
+}
+
+// errcheckAnalyzer runs errcheck in-process with the same options as the
+// errcheck linter: the fmt package is ignored.
+type errcheckAnalyzer struct{}
+
+func (errcheckAnalyzer) Analyze(program *analysis.Program, options analysis.Options, cancel <-chan struct{}) ([]analysis.Problem, error) {
+	problems := []analysis.Problem{}
+	checker := errcheck.NewChecker()
+	checker.Ignore = map[string]*regexp.Regexp{"fmt": regexp.MustCompile(".*")}
+	for _, pkg := range program.Packages {
+		for _, file := range pkg.Files {
+			if analysis.Canceled(cancel) {
+				return nil, analysis.ErrCanceled
+			}
+			for _, unchecked := range checker.CheckFile(program.Loader, pkg.Loader, file) {
+				problems = append(problems, analysis.Problem{Position: unchecked.Pos, Message: unchecked.Line})
+			}
+		}
+	}
+	if analysis.Canceled(cancel) {
+		return nil, analysis.ErrCanceled
+	}
+	return problems, nil
+}
+
+// honnefAnalyzer runs checkers from honnef.co/go/tools, as used by gosimple,
+// staticcheck, unused and megacheck.
+type honnefAnalyzer []func() honnef.Checker
+
+func (h honnefAnalyzer) Analyze(program *analysis.Program, options analysis.Options, cancel <-chan struct{}) ([]analysis.Problem, error) {
+	problems := []analysis.Problem{}
+	for _, newChecker := range h {
+		if analysis.Canceled(cancel) {
+			return nil, analysis.ErrCanceled
+		}
+		if program.Loader == nil {
+			continue
+		}
+		checker := &cancelableChecker{Checker: newChecker(), cancel: cancel}
+		linter := &honnef.Linter{Checker: checker, GoVersion: goMinorVersion()}
+		for _, problem := range linter.Lint(program.Loader, program.Config) {
+			problems = append(problems, analysis.Problem{Position: problem.Position, Message: problem.String()})
+		}
+	}
+	if analysis.Canceled(cancel) {
+		return nil, analysis.ErrCanceled
+	}
+	return problems, nil
+}
+
+// cancelableChecker skips the remaining checks of a checker once cancel is
+// closed.
+type cancelableChecker struct {
+	honnef.Checker
+	cancel <-chan struct{}
+}
+
+func (c *cancelableChecker) Funcs() map[string]honnef.Func {
+	funcs := map[string]honnef.Func{}
+	for name, fn := range c.Checker.Funcs() {
+		fn := fn
+		funcs[name] = func(job *honnef.Job) {
+			if !analysis.Canceled(c.cancel) {
+				fn(job)
+			}
+		}
+	}
+	return funcs
+}
+
+func newGosimpleChecker() honnef.Checker {
+	return simple.NewChecker()
+}
+
+func newStaticcheckChecker() honnef.Checker {
+	return staticcheck.NewChecker()
+}
+
+func newUnusedChecker() honnef.Checker {
+	checker := unused.NewChecker(unused.CheckAll)
+	checker.ConsiderReflection = true
+	return unused.NewLintChecker(checker)
+}
+
+// goMinorVersion returns the minor version of the Go release being targeted,
+// eg. 10 for Go 1.10.
+func goMinorVersion() int {
+	tags := build.Default.ReleaseTags
+	n, _ := strconv.Atoi(strings.TrimPrefix(tags[len(tags)-1], "go1."))
+	return n
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	kingpin "gopkg.in/alecthomas/kingpin.v3-unstable"
+)
+
+// baselineEntry is a fingerprint of a single issue recorded in a baseline
+// file. Line is informational only and is not used for matching, so that
+// entries survive unrelated edits to the file.
+type baselineEntry struct {
+	Linter   string `json:"linter"`
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+	LineHash string `json:"line_hash"`
+	Line     int    `json:"line"`
+
+	matched bool
+}
+
+func (e *baselineEntry) key() string {
+	return strings.Join([]string{e.Linter, e.Path, e.Message, e.LineHash}, "\x00")
+}
+
+func (e *baselineEntry) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", e.Path, e.Line, e.Message, e.Linter)
+}
+
+type baselineFile struct {
+	Issues []*baselineEntry `json:"issues"`
+}
+
+// sourceLines caches the lines of source files so that issues can be
+// fingerprinted by the content of the line they refer to.
+type sourceLines struct {
+	ws    *workspace
+	lock  sync.Mutex
+	files map[string][]string
+}
+
+func newSourceLines(ws *workspace) *sourceLines {
+	return &sourceLines{ws: ws, files: map[string][]string{}}
+}
+
+// line returns the whitespace-trimmed source line n of path, or the empty
+// string if the line can not be read.
+func (s *sourceLines) line(path string, n int) string {
+	s.lock.Lock()
+	lines, ok := s.files[path]
+	if !ok {
+		content, err := s.ws.readFile(path)
+		if err != nil {
+			debug("failed to read %s: %s", path, err)
+		}
+		lines = strings.Split(string(content), "\n")
+		s.files[path] = lines
+	}
+	s.lock.Unlock()
+
+	if n > 0 && n <= len(lines) {
+		return strings.TrimSpace(lines[n-1])
+	}
+	return ""
+}
+
+// hash returns a hash of the source line an issue refers to.
+func (s *sourceLines) hash(issue *Issue) string {
+	sum := sha256.Sum256([]byte(s.line(issue.Path.Abs(), issue.Line)))
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprints returns one entry for each linter that reported the issue, so
+// that baselines match whether or not issues are aggregated.
+func (s *sourceLines) fingerprints(issue *Issue) []*baselineEntry {
+	out := []*baselineEntry{}
+	for _, linter := range issue.linterNames() {
+		out = append(out, &baselineEntry{
+			Linter:   linter,
+			Path:     issue.Path.Relative(),
+			Message:  strings.TrimSpace(issue.Message),
+			LineHash: s.hash(issue),
+			Line:     issue.Line,
+		})
+	}
+	return out
+}
+
+type baseline struct {
+	lines   *sourceLines
+	entries map[string][]*baselineEntry
+}
+
+func newBaseline(ws *workspace, entries []*baselineEntry) *baseline {
+	b := &baseline{
+		lines:   newSourceLines(ws),
+		entries: map[string][]*baselineEntry{},
+	}
+	for _, entry := range entries {
+		key := entry.key()
+		b.entries[key] = append(b.entries[key], entry)
+	}
+	return b
+}
+
+func loadBaseline(ws *workspace, filename string) (*baseline, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	file := &baselineFile{}
+	if err := json.Unmarshal(content, file); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %s", filename, err)
+	}
+	return newBaseline(ws, file.Issues), nil
+}
+
+// IsKnown returns true if the issue is recorded in the baseline for every
+// linter that reported it. Each entry matches at most one issue, so new
+// duplicates of a known issue are reported.
+func (b *baseline) IsKnown(issue *Issue) bool {
+	matches := []*baselineEntry{}
+	for _, fingerprint := range b.lines.fingerprints(issue) {
+		match := b.unmatched(fingerprint.key())
+		if match == nil {
+			return false
+		}
+		matches = append(matches, match)
+	}
+	for _, entry := range matches {
+		debug("baseline: matched %s to issue %s", entry, issue)
+		entry.matched = true
+	}
+	return true
+}
+
+func (b *baseline) unmatched(key string) *baselineEntry {
+	for _, entry := range b.entries[key] {
+		if !entry.matched {
+			return entry
+		}
+	}
+	return nil
+}
+
+// Unmatched returns all the entries which were never matched to an issue.
+func (b *baseline) Unmatched() []*baselineEntry {
+	unmatched := []*baselineEntry{}
+	for _, entries := range b.entries {
+		for _, entry := range entries {
+			if !entry.matched {
+				unmatched = append(unmatched, entry)
+			}
+		}
+	}
+	sort.Slice(unmatched, func(i, j int) bool {
+		if unmatched[i].Path != unmatched[j].Path {
+			return unmatched[i].Path < unmatched[j].Path
+		}
+		return unmatched[i].Line < unmatched[j].Line
+	})
+	return unmatched
+}
+
+func filterIssuesViaBaseline(known *baseline, paths []string, issues chan *Issue) chan *Issue {
+	out := make(chan *Issue, 1000000)
+	go func() {
+		for issue := range issues {
+			if !known.IsKnown(issue) {
+				out <- issue
+			}
+		}
+
+		if config.WarnStaleBaseline {
+			for _, issue := range warnOnStaleBaseline(known, paths) {
+				out <- issue
+			}
+		}
+		close(out)
+	}()
+	return out
+}
+
+// warnOnStaleBaseline returns issues for the unmatched entries in files
+// under the linted paths. Entries for other files can not have been matched.
+func warnOnStaleBaseline(known *baseline, paths []string) []*Issue {
+	out := []*Issue{}
+	ws := known.lines.ws
+
+	linted := map[string]bool{}
+	for _, path := range paths {
+		linted[ws.abs(path)] = true
+	}
+
+	for _, entry := range known.Unmatched() {
+		if !linted[filepath.Dir(ws.abs(entry.Path))] {
+			continue
+		}
+		issue, _ := NewIssue("baseline", config.formatTemplate)
+		issue.Path = newIssuePath(ws.dir, entry.Path)
+		issue.Line = entry.Line
+		issue.Message = fmt.Sprintf("baseline entry did not match any issue: %s (%s)", entry.Message, entry.Linter)
+		out = append(out, issue)
+	}
+	return out
+}
+
+// writeBaselineIssueChan passes issues through unchanged, recording a
+// fingerprint of each one, and writes the fingerprints to filename once the
+// input channel is closed.
+func writeBaselineIssueChan(ws *workspace, filename string, issues chan *Issue) chan *Issue {
+	out := make(chan *Issue, 1000000)
+	lines := newSourceLines(ws)
+	go func() {
+		file := &baselineFile{Issues: []*baselineEntry{}}
+		for issue := range issues {
+			file.Issues = append(file.Issues, lines.fingerprints(issue)...)
+			out <- issue
+		}
+		if err := writeBaseline(filename, file); err != nil {
+			warning("failed to write baseline %s: %s", filename, err)
+		}
+		close(out)
+	}()
+	return out
+}
+
+func writeBaseline(filename string, file *baselineFile) error {
+	sort.Slice(file.Issues, func(i, j int) bool {
+		l, r := file.Issues[i], file.Issues[j]
+		if l.Path != r.Path {
+			return l.Path < r.Path
+		}
+		if l.Line != r.Line {
+			return l.Line < r.Line
+		}
+		return l.key() < r.key()
+	})
+	d, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(d, '\n'), 0644)
+}
+
+func maybeFilterIssuesViaBaseline(ws *workspace, paths []string, issues chan *Issue) chan *Issue {
+	if config.Baseline == "" {
+		return issues
+	}
+	known, err := loadBaseline(ws, ws.abs(config.Baseline))
+	kingpin.FatalIfError(err, "failed to load baseline")
+	return filterIssuesViaBaseline(known, paths, issues)
+}
+
+func maybeWriteBaseline(ws *workspace, issues chan *Issue) chan *Issue {
+	if config.WriteBaseline == "" {
+		return issues
+	}
+	return writeBaselineIssueChan(ws, ws.abs(config.WriteBaseline), issues)
+}
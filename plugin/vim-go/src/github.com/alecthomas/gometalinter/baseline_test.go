@@ -0,0 +1,105 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaselineFingerprintSurvivesLineShift(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+
+	mkFile(t, tmpdir, "file.go", "package foo\n\nfunc Foo() {}\n")
+	before := &Issue{Linter: "golint", Path: newIssuePath(tmpdir, "file.go"), Line: 3, Message: "comment"}
+	entry := newSourceLines(ws).fingerprints(before)[0]
+
+	mkFile(t, tmpdir, "file.go", "package foo\n\nimport \"fmt\"\n\n  func Foo() {}\n")
+	after := &Issue{Linter: "golint", Path: newIssuePath(tmpdir, "file.go"), Line: 5, Message: "comment"}
+	assert.Equal(t, entry.key(), newSourceLines(ws).fingerprints(after)[0].key())
+
+	moved := &Issue{Linter: "golint", Path: newIssuePath(tmpdir, "file.go"), Line: 3, Message: "comment"}
+	assert.NotEqual(t, entry.key(), newSourceLines(ws).fingerprints(moved)[0].key())
+}
+
+func TestBaselineFilter(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+
+	mkFile(t, tmpdir, "file.go", "package foo\n\nvar a = 1\nvar b = 2\n")
+	issueA := &Issue{Linter: "varcheck", Path: newIssuePath(tmpdir, "file.go"), Line: 3, Message: "unused a"}
+	issueB := &Issue{Linter: "varcheck", Path: newIssuePath(tmpdir, "file.go"), Line: 4, Message: "unused b"}
+	gone := &Issue{Linter: "golint", Path: newIssuePath(tmpdir, "other.go"), Line: 1, Message: "gone"}
+
+	filename := filepath.Join(tmpdir, "baseline.json")
+	written := make(chan *Issue, 2)
+	written <- issueA
+	written <- gone
+	close(written)
+	for range writeBaselineIssueChan(ws, filename, written) {
+	}
+
+	known, err := loadBaseline(ws, filename)
+	require.NoError(t, err)
+
+	assert.True(t, known.IsKnown(issueA))
+	assert.False(t, known.IsKnown(issueA), "each entry should only match once")
+	assert.False(t, known.IsKnown(issueB))
+
+	unmatched := known.Unmatched()
+	require.Len(t, unmatched, 1)
+	assert.Equal(t, "other.go", unmatched[0].Path)
+	assert.Equal(t, "gone", unmatched[0].Message)
+}
+
+func TestBaselineAggregatedIssues(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+
+	mkFile(t, tmpdir, "file.go", "package foo\n\nvar a = 1\n")
+	path := newIssuePath(tmpdir, "file.go")
+	aggregated := &Issue{Linter: "deadcode, varcheck", Path: path, Line: 3, Message: "unused a",
+		aggregatedLinters: []string{"deadcode", "varcheck"}}
+
+	entries := newSourceLines(ws).fingerprints(aggregated)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "deadcode", entries[0].Linter)
+	assert.Equal(t, "varcheck", entries[1].Linter)
+
+	known := newBaseline(ws, entries)
+	assert.True(t, known.IsKnown(&Issue{Linter: "varcheck", Path: path, Line: 3, Message: "unused a"}))
+	assert.True(t, known.IsKnown(&Issue{Linter: "deadcode", Path: path, Line: 3, Message: "unused a"}))
+
+	known = newBaseline(ws, newSourceLines(ws).fingerprints(aggregated)[:1])
+	assert.False(t, known.IsKnown(aggregated), "issues are known only if every linter is")
+	assert.Len(t, known.Unmatched(), 1)
+}
+
+func TestWarnOnStaleBaselineOnlyInLintedPaths(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+
+	known := newBaseline(ws, []*baselineEntry{
+		{Linter: "golint", Path: "file.go", Line: 1, Message: "gone"},
+		{Linter: "golint", Path: "sub/file.go", Line: 2, Message: "not linted"},
+	})
+
+	stale := warnOnStaleBaseline(known, []string{"."})
+	require.Len(t, stale, 1)
+	assert.Equal(t, filepath.Join(tmpdir, "file.go"), stale[0].Path.Abs())
+
+	stale = warnOnStaleBaseline(known, []string{filepath.Join(tmpdir, "sub")})
+	require.Len(t, stale, 1)
+	assert.Equal(t, 2, stale[0].Line)
+}
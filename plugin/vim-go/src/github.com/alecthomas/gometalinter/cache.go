@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// resultCache stores the raw output of linter executions on disk, keyed by
+// the command line and a hash of the content of every file it inspects.
+// Cached output is replayed through processOutput, so excludes, message
+// overrides and severities are always applied with the current config.
+type resultCache struct {
+	dir    string
+	hits   int64
+	misses int64
+}
+
+func newResultCache(dir string) *resultCache {
+	return &resultCache{dir: dir}
+}
+
+func defaultCacheDir() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "gometalinter")
+	}
+	return filepath.Join(getHomeDir(), ".cache", "gometalinter")
+}
+
+// cacheImports is a CacheDependencies pattern which stands for the Go files of
+// every package imported, directly or indirectly, by the linted packages.
+const cacheImports = "{imports}"
+
+// Key returns the cache key for executing args on behalf of state. It returns
+// an error if any of the inputs could not be hashed, in which case the result
+// must not be cached.
+func (c *resultCache) Key(state *linterState, args []string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "linter\x00%s\x00%s\x00%s\x00", state.Name, state.Command, state.Pattern)
+	fmt.Fprintf(h, "env\x00%s\x00%s\x00", os.Getenv("GOPATH"), os.Getenv("GOROOT"))
+	// Linters report paths relative to the directory they run in.
+	fmt.Fprintf(h, "dir\x00%s\x00", state.ws.dir)
+
+	// Invalidate results when the linter binary itself is updated.
+	if info, err := os.Stat(args[0]); err == nil {
+		fmt.Fprintf(h, "exe\x00%s\x00%d\x00%d\x00", args[0], info.Size(), info.ModTime().UnixNano())
+	}
+
+	paths := map[string]bool{}
+	for _, path := range state.partition(args) {
+		paths[path] = true
+	}
+	dirs := []string{}
+	for _, arg := range args[1:] {
+		// Temporary copies of packages with unsaved changes get a new name
+		// on every request in server mode, so they would never be hit.
+		if abs := state.ws.abs(arg); state.ws.overlay.Original(abs) != abs {
+			return "", fmt.Errorf("%s holds unsaved changes", arg)
+		}
+		fmt.Fprintf(h, "arg\x00%s\x00", arg)
+		files, dir, err := resolveCacheArg(state.ws.dir, arg)
+		if err != nil {
+			if paths[arg] {
+				return "", err
+			}
+			// Flags and their values are hashed verbatim.
+			continue
+		}
+		// Import paths of packages with unsaved changes resolve to their
+		// temporary copies.
+		if state.ws.overlay.modifies(dir) {
+			return "", fmt.Errorf("%s holds unsaved changes", arg)
+		}
+		if err := hashFiles(h, files); err != nil {
+			return "", err
+		}
+		dirs = append(dirs, dir)
+	}
+
+	for _, pattern := range state.CacheDependencies {
+		files, err := cacheDependencies(state.ws.dir, pattern, dirs)
+		if err != nil {
+			return "", err
+		}
+		for _, file := range files {
+			if state.ws.overlay.modifies(filepath.Dir(file)) {
+				return "", fmt.Errorf("dependency %s holds unsaved changes", file)
+			}
+		}
+		fmt.Fprintf(h, "deps\x00%s\x00", pattern)
+		if err := hashFiles(h, files); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// resolveCacheArg returns the files to hash for a linter argument naming a
+// file, a directory or the import path of a package, and the absolute
+// directory of the package they belong to. Relative paths are resolved
+// against dir.
+func resolveCacheArg(dir, arg string) ([]string, string, error) {
+	if strings.HasPrefix(arg, "-") {
+		return nil, "", fmt.Errorf("%s is a flag", arg)
+	}
+	path := absPath(dir, arg)
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && !info.IsDir():
+		return []string{path}, filepath.Dir(path), nil
+	case err == nil:
+		arg = path
+	default:
+		pkg, err := build.Import(arg, dir, build.FindOnly)
+		if err != nil {
+			return nil, "", err
+		}
+		arg = pkg.Dir
+	}
+	files, err := pathsToFileGlobs([]string{arg})
+	return files, arg, err
+}
+
+func cacheDependencies(dir, pattern string, dirs []string) ([]string, error) {
+	if pattern == cacheImports {
+		return importedFiles(dirs)
+	}
+	return filepath.Glob(absPath(dir, pattern))
+}
+
+// importedFiles returns the Go files of the packages outside of GOROOT which
+// are imported, directly or indirectly, by the packages in dirs.
+func importedFiles(dirs []string) ([]string, error) {
+	files := []string{}
+	seen := map[string]bool{}
+	var visit func(path, srcDir string, root bool) error
+	visit = func(path, srcDir string, root bool) error {
+		pkg, err := build.Import(path, srcDir, 0)
+		if _, ok := err.(*build.NoGoError); err != nil && !ok {
+			return err
+		}
+		if pkg.Goroot || seen[pkg.Dir] {
+			return nil
+		}
+		seen[pkg.Dir] = true
+
+		imports := pkg.Imports
+		if root {
+			imports = append(append(imports, pkg.TestImports...), pkg.XTestImports...)
+		} else {
+			for _, name := range append(pkg.GoFiles, pkg.CgoFiles...) {
+				files = append(files, filepath.Join(pkg.Dir, name))
+			}
+		}
+		for _, path := range imports {
+			if path == "C" {
+				continue
+			}
+			if err := visit(path, pkg.Dir, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, dir := range dirs {
+		if err := visit(".", dir, true); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// cacheable returns true if the output of a linter which exited with err can
+// be cached. Linters which exit with an error only because they reported
+// issues are cached, but not failures, such as a package that does not
+// compile, which produce output the linter pattern does not match.
+func cacheable(state *linterState, out []byte, err error) bool {
+	if err == nil {
+		return true
+	}
+	if _, ok := err.(*exec.ExitError); !ok || !state.regex.Match(out) {
+		return false
+	}
+	return len(bytes.TrimSpace(state.regex.ReplaceAll(out, nil))) == 0
+}
+
+func hashFiles(h io.Writer, files []string) error {
+	sort.Strings(files)
+	for _, file := range files {
+		r, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "file\x00%s\x00", file)
+		_, err = io.Copy(h, r)
+		r.Close() // nolint: errcheck
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *resultCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// Get returns the cached linter output for key, if any.
+func (c *resultCache) Get(key string) ([]byte, bool) {
+	out, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		atomic.AddInt64(&c.misses, 1)
+		return nil, false
+	}
+	atomic.AddInt64(&c.hits, 1)
+	return out, true
+}
+
+// Put stores linter output under key.
+func (c *resultCache) Put(key string, out []byte) error {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), key+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()           // nolint: errcheck
+		os.Remove(tmp.Name()) // nolint: errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name()) // nolint: errcheck
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Clear removes all cached results.
+func (c *resultCache) Clear() error {
+	return os.RemoveAll(c.dir)
+}
+
+func (c *resultCache) String() string {
+	return fmt.Sprintf("%d hits, %d misses (%s)",
+		atomic.LoadInt64(&c.hits), atomic.LoadInt64(&c.misses), c.dir)
+}
+
+func cacheDirFromConfig(config *Config) string {
+	if config.CacheDir != "" {
+		return config.CacheDir
+	}
+	return defaultCacheDir()
+}
+
+func newResultCacheFromConfig(config *Config) *resultCache {
+	if config.NoCache {
+		return nil
+	}
+	return newResultCache(cacheDirFromConfig(config))
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"go/build"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultCacheKey(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	mkDir(t, tmpdir, "pkg")
+	mkFile(t, tmpdir, "deps.txt", "v1")
+
+	cache := newResultCache(filepath.Join(tmpdir, "cache"))
+	state := &linterState{Linter: getLinterByName("golint", LinterConfig{}), ws: &workspace{dir: tmpdir}}
+	state.CacheDependencies = []string{"deps.txt"}
+	args := []string{"golint", "./pkg"}
+
+	key, err := cache.Key(state, args)
+	require.NoError(t, err)
+
+	again, err := cache.Key(state, args)
+	require.NoError(t, err)
+	assert.Equal(t, key, again)
+
+	mkFile(t, filepath.Join(tmpdir, "pkg"), "file.go", "package foo // changed")
+	changed, err := cache.Key(state, args)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, changed)
+
+	mkFile(t, tmpdir, "deps.txt", "v2")
+	depsChanged, err := cache.Key(state, args)
+	require.NoError(t, err)
+	assert.NotEqual(t, changed, depsChanged)
+
+	other, err := cache.Key(state, []string{"golint", "-min_confidence", "0.5", "./pkg"})
+	require.NoError(t, err)
+	assert.NotEqual(t, depsChanged, other)
+}
+
+func TestResultCacheGetPut(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	cache := newResultCache(filepath.Join(tmpdir, "cache"))
+	key := "0123456789abcdef"
+
+	_, ok := cache.Get(key)
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Put(key, []byte("a.go:1:1: message")))
+	out, ok := cache.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, "a.go:1:1: message", string(out))
+	assert.Equal(t, int64(1), cache.hits)
+	assert.Equal(t, int64(1), cache.misses)
+
+	require.NoError(t, cache.Clear())
+	_, ok = cache.Get(key)
+	assert.False(t, ok)
+}
+
+func TestNewResultCacheFromConfig(t *testing.T) {
+	assert.NotNil(t, newResultCacheFromConfig(&Config{}))
+	assert.Nil(t, newResultCacheFromConfig(&Config{NoCache: true}))
+}
+
+func TestResultCacheKeyResolvesImportPaths(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	defer func(gopath string) { build.Default.GOPATH = gopath }(build.Default.GOPATH)
+	build.Default.GOPATH = tmpdir
+	mkDir(t, tmpdir, "src", "example.com", "dep")
+	mkDir(t, tmpdir, "src", "example.com", "pkg")
+	pkgDir := filepath.Join(tmpdir, "src", "example.com", "pkg")
+	mkFile(t, pkgDir, "file.go", "package foo\n\nimport _ \"example.com/dep\"\n")
+
+	cache := newResultCache(filepath.Join(tmpdir, "cache"))
+	state := &linterState{Linter: getLinterByName("errcheck", LinterConfig{Command: "true"}), ws: &workspace{dir: tmpdir}}
+	args := []string{"true", "example.com/pkg"}
+
+	key, err := cache.Key(state, args)
+	require.NoError(t, err)
+
+	mkFile(t, pkgDir, "file.go", "package foo\n\nimport _ \"example.com/dep\" // changed\n")
+	changed, err := cache.Key(state, args)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, changed)
+
+	mkFile(t, filepath.Join(tmpdir, "src", "example.com", "dep"), "file.go", "package foo // changed")
+	depChanged, err := cache.Key(state, args)
+	require.NoError(t, err)
+	assert.NotEqual(t, changed, depChanged)
+
+	_, err = cache.Key(state, []string{"true", "example.com/missing"})
+	assert.Error(t, err)
+}
+
+func TestCacheable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires /bin/sh")
+	}
+	state := &linterState{Linter: getLinterByName("golint", LinterConfig{})}
+	exitErr := exec.Command("/bin/sh", "-c", "exit 1").Run()
+	require.Error(t, exitErr)
+
+	assert.True(t, cacheable(state, nil, nil))
+	assert.True(t, cacheable(state, []byte("a.go:1:2: first\nb.go:3:4: second\n"), exitErr))
+	assert.False(t, cacheable(state, []byte("a.go:1:2: first\ncannot find package\n"), exitErr))
+	assert.False(t, cacheable(state, nil, exitErr))
+	assert.False(t, cacheable(state, []byte("a.go:1:2: first\n"), errors.New("killed")))
+}
+
+func TestExecuteLinterCacheHitWithoutStats(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires /bin/sh")
+	}
+	originalStats := stats
+	defer func() { stats = originalStats }()
+	stats = nil
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	state := &linterState{
+		Linter:   getLinterByName("golint", LinterConfig{}),
+		ws:       &workspace{dir: tmpdir},
+		config:   &Config{},
+		issues:   make(chan *Issue, 10),
+		deadline: time.After(time.Minute),
+		cache:    newResultCache(filepath.Join(tmpdir, "cache")),
+	}
+	args := []string{"/bin/sh", "-c", "exit 0"}
+	require.NoError(t, executeLinter(1, state, args))
+	require.NoError(t, executeLinter(2, state, args))
+	assert.Equal(t, int64(1), state.cache.hits)
+}
+
+func TestExecuteLinterCachesIssues(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires /bin/sh")
+	}
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	cache := newResultCache(filepath.Join(tmpdir, "cache"))
+	state := &linterState{
+		Linter:   getLinterByName("golint", LinterConfig{}),
+		ws:       &workspace{dir: tmpdir},
+		config:   &Config{},
+		issues:   make(chan *Issue, 10),
+		deadline: time.After(time.Minute),
+		cache:    cache,
+	}
+
+	failing := []string{"/bin/sh", "-c", "echo cannot find package; exit 1"}
+	require.NoError(t, executeLinter(1, state, failing))
+	key, err := cache.Key(state, failing)
+	require.NoError(t, err)
+	_, ok := cache.Get(key)
+	assert.False(t, ok)
+
+	issues := []string{"/bin/sh", "-c", "echo a.go:1:2: message; exit 1"}
+	require.NoError(t, executeLinter(2, state, issues))
+	key, err = cache.Key(state, issues)
+	require.NoError(t, err)
+	_, ok = cache.Get(key)
+	assert.True(t, ok)
+
+	passing := []string{"/bin/sh", "-c", "exit 0"}
+	require.NoError(t, executeLinter(3, state, passing))
+	key, err = cache.Key(state, passing)
+	require.NoError(t, err)
+	_, ok = cache.Get(key)
+	assert.True(t, ok)
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"text/template"
 	"time"
 )
@@ -51,6 +53,7 @@ type Config struct { // nolint: maligned
 	Errors          bool
 	JSON            bool
 	Checkstyle      bool
+	SARIF           bool
 	EnableGC        bool
 	Aggregate       bool
 	EnableAll       bool
@@ -58,6 +61,46 @@ type Config struct { // nolint: maligned
 	// Warn if a nolint directive was never matched to a linter issue
 	WarnUnmatchedDirective bool
 
+	// Require nolint directives to give a reason.
+	StrictNolint bool
+	// List all nolint directives and exit.
+	ListDirectives bool `json:"-"`
+
+	// Suppress issues recorded in this baseline file.
+	Baseline string
+	// Write all reported issues to this baseline file.
+	WriteBaseline string
+	// Warn if a baseline entry was never matched to a linter issue
+	WarnStaleBaseline bool
+
+	// Only report issues on lines changed since this git revision.
+	NewFromRev string
+
+	// Linter results are cached in CacheDir, which defaults to
+	// $XDG_CACHE_HOME/gometalinter or ~/.cache/gometalinter.
+	NoCache    bool
+	CacheDir   string
+	ClearCache bool
+
+	// Attach suggested edits to issues from linters that support them.
+	SuggestFixes bool
+	// Apply suggested edits and only report issues that were not fixed.
+	Fix bool
+
+	// Run linters with a built-in implementation in-process, sharing a single
+	// load of the packages being linted.
+	InProcess bool
+
+	// Print the resource usage and issue counts of each linter execution.
+	Stats bool `json:"-"`
+	// Write the same statistics as JSON to this file.
+	StatsJSON string `json:"-"`
+
+	// Disable automatic loading of config files.
+	NoConfig bool `json:"-"`
+	// Print the effective configuration of this directory and exit.
+	PrintEffectiveConfig string `json:"-"`
+
 	formatTemplate *template.Template
 }
 
@@ -87,6 +130,10 @@ func (c *StringOrLinterConfig) UnmarshalJSON(raw []byte) error {
 
 type jsonDuration time.Duration
 
+func (td jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(td.Duration().String())
+}
+
 func (td *jsonDuration) UnmarshalJSON(raw []byte) error {
 	var durationAsString string
 	if err := json.Unmarshal(raw, &durationAsString); err != nil {
@@ -140,7 +187,16 @@ var config = &Config{
 	Deadline:        jsonDuration(time.Second * 30),
 }
 
+// configDefaults is the configuration before any config file or flag is
+// applied to it.
+var configDefaults = config.clone()
+
 func loadConfigFile(filename string) error {
+	return decodeConfigFile(filename, config)
+}
+
+// decodeConfigFile decodes a JSON configuration file over an existing config.
+func decodeConfigFile(filename string, config *Config) error {
 	r, err := os.Open(filename)
 	if err != nil {
 		return err
@@ -161,13 +217,164 @@ func loadConfigFile(filename string) error {
 	return err
 }
 
-func findDefaultConfigFile() (fullPath string, found bool, err error) {
-	prevPath := ""
-	dirPath, err := os.Getwd()
+// clone returns a copy of the config which can be modified without affecting
+// the original.
+func (c *Config) clone() *Config {
+	out := *c
+	out.Linters = map[string]StringOrLinterConfig{}
+	for k, v := range c.Linters {
+		out.Linters[k] = v
+	}
+	out.MessageOverride = copyStringMap(c.MessageOverride)
+	out.Severity = copyStringMap(c.Severity)
+	out.Enable = append([]string{}, c.Enable...)
+	out.Disable = append([]string{}, c.Disable...)
+	out.Exclude = append([]string{}, c.Exclude...)
+	out.Include = append([]string{}, c.Include...)
+	out.Skip = append([]string{}, c.Skip...)
+	out.Sort = append([]string{}, c.Sort...)
+	return &out
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// configHierarchy resolves the effective configuration of a directory by
+// merging every config file found between the root directory and that
+// directory over the base configuration, parents first.
+type configHierarchy struct {
+	base  *Config
+	root  string
+	dirs  map[string]*Config
+	files map[*Config][]string
+	// Modification time of the config file of each directory in dirs, zero
+	// if the directory has none.
+	mtimes map[string]time.Time
+}
+
+func newConfigHierarchy(base *Config, root string) (*configHierarchy, error) {
+	root, err := filepath.EvalSymlinks(root)
 	if err != nil {
-		return "", false, err
+		return nil, err
+	}
+	h := &configHierarchy{
+		base:   base,
+		root:   root,
+		dirs:   map[string]*Config{root: base},
+		files:  map[*Config][]string{base: nil},
+		mtimes: map[string]time.Time{},
 	}
+	return h, nil
+}
 
+// ForPath returns the effective configuration for a directory, relative to
+// the root directory. Directories outside the root use the base
+// configuration.
+func (h *configHierarchy) ForPath(path string) (*Config, error) {
+	abs := absPath(h.root, path)
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(h.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return h.base, nil
+	}
+	return h.forDir(abs)
+}
+
+func (h *configHierarchy) forDir(dir string) (*Config, error) {
+	if config, ok := h.dirs[dir]; ok {
+		return config, nil
+	}
+	parent, err := h.forDir(filepath.Dir(dir))
+	if err != nil {
+		return nil, err
+	}
+	config := parent
+	h.mtimes[dir] = configFileModTime(dir)
+	configFile, found, err := findConfigFileInDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		debug("merging config %s", configFile)
+		config = parent.clone()
+		if err := decodeConfigFile(configFile, config); err != nil {
+			return nil, fmt.Errorf("%s: %s", configFile, err)
+		}
+		if err := applyCommandLine(config); err != nil {
+			return nil, err
+		}
+		h.files[config] = append(append([]string{}, h.files[parent]...), configFile)
+	}
+	h.dirs[dir] = config
+	return config, nil
+}
+
+// watch records the modification time of the config file in each of dirs,
+// which the base configuration was loaded from, so that Stale also detects
+// changes to them.
+func (h *configHierarchy) watch(dirs []string) {
+	for _, dir := range dirs {
+		h.mtimes[dir] = configFileModTime(dir)
+	}
+}
+
+// Stale returns true if a config file has been created, changed or removed
+// in any directory resolved by the hierarchy since it was resolved.
+func (h *configHierarchy) Stale() bool {
+	for dir, mtime := range h.mtimes {
+		if !configFileModTime(dir).Equal(mtime) {
+			return true
+		}
+	}
+	return false
+}
+
+func configFileModTime(dir string) time.Time {
+	info, err := os.Stat(filepath.Join(dir, defaultConfigPath))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// Files returns the config files that were merged to produce config.
+func (h *configHierarchy) Files(config *Config) []string {
+	return h.files[config]
+}
+
+// groupPathsByConfig partitions paths by their effective configuration,
+// preserving the order of paths within each group.
+func groupPathsByConfig(h *configHierarchy, paths []string) ([]*linterGroup, error) {
+	groups := []*linterGroup{}
+	byConfig := map[*Config]*linterGroup{}
+	for _, path := range paths {
+		config, err := h.ForPath(path)
+		if err != nil {
+			return nil, err
+		}
+		group, ok := byConfig[config]
+		if !ok {
+			group = &linterGroup{config: config}
+			byConfig[config] = group
+			groups = append(groups, group)
+		}
+		group.paths = append(group.paths, path)
+	}
+	return groups, nil
+}
+
+// findDefaultConfigFile returns the config file of dir or of its nearest
+// parent directory.
+func findDefaultConfigFile(dir string) (fullPath string, found bool, err error) {
+	prevPath := ""
+	dirPath := dir
 	for dirPath != prevPath {
 		fullPath, found, err = findConfigFileInDir(dirPath)
 		if err != nil || found {
@@ -179,6 +386,35 @@ func findDefaultConfigFile() (fullPath string, found bool, err error) {
 	return "", false, nil
 }
 
+// loadConfigForDir returns the configuration gometalinter starts from when
+// run in dir without --config: the defaults, the config file found by
+// findDefaultConfigFile and the command line. It also returns the directories
+// that were searched for the config file.
+func loadConfigForDir(dir string) (*Config, []string, error) {
+	config := configDefaults.clone()
+	configFile, found, err := findDefaultConfigFile(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	searched := []string{}
+	for d := dir; ; d = filepath.Dir(d) {
+		searched = append(searched, d)
+		if (found && d == filepath.Dir(configFile)) || d == filepath.Dir(d) {
+			break
+		}
+	}
+	if found {
+		debug("loading config %s", configFile)
+		if err := decodeConfigFile(configFile, config); err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", configFile, err)
+		}
+	}
+	if err := applyCommandLine(config); err != nil {
+		return nil, nil, err
+	}
+	return config, searched, nil
+}
+
 func findConfigFileInDir(dirPath string) (fullPath string, found bool, err error) {
 	fullPath = filepath.Join(dirPath, defaultConfigPath)
 	if _, err := os.Stat(fullPath); err != nil {
@@ -2,7 +2,6 @@ package main
 
 import (
 	"encoding/json"
-	"os"
 	"path/filepath"
 	"testing"
 
@@ -73,10 +72,104 @@ func TestFindDefaultConfigFile(t *testing.T) {
 	}
 
 	for _, testcase := range testcases {
-		require.NoError(t, os.Chdir(testcase.dir))
-		configFile, found, err := findDefaultConfigFile()
+		configFile, found, err := findDefaultConfigFile(testcase.dir)
 		assert.Equal(t, testcase.expected, configFile)
 		assert.Equal(t, testcase.found, found)
 		assert.NoError(t, err)
 	}
 }
+
+func TestConfigHierarchy(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	mkDir(t, tmpdir, "legacy", "generated")
+	mkDir(t, tmpdir, "service")
+	mkFile(t, filepath.Join(tmpdir, "legacy"), defaultConfigPath,
+		`{"Cyclo": 30, "Enable": ["golint", "vet"], "Severity": {"golint": "error"}}`)
+	mkFile(t, filepath.Join(tmpdir, "legacy", "generated"), defaultConfigPath,
+		`{"Disable": ["golint"], "Exclude": ["generated"]}`)
+
+	base := &Config{
+		Enable:   []string{"vet"},
+		Cyclo:    10,
+		Severity: map[string]string{"vet": "error"},
+	}
+	hierarchy, err := newConfigHierarchy(base, tmpdir)
+	require.NoError(t, err)
+
+	root, err := hierarchy.ForPath(".")
+	require.NoError(t, err)
+	assert.True(t, root == base)
+
+	service, err := hierarchy.ForPath("./service")
+	require.NoError(t, err)
+	assert.True(t, service == base)
+
+	legacy, err := hierarchy.ForPath("./legacy")
+	require.NoError(t, err)
+	assert.Equal(t, 30, legacy.Cyclo)
+	assert.Equal(t, []string{"golint", "vet"}, legacy.Enable)
+	assert.Equal(t, map[string]string{"vet": "error", "golint": "error"}, legacy.Severity)
+
+	generated, err := hierarchy.ForPath(filepath.Join(tmpdir, "legacy", "generated"))
+	require.NoError(t, err)
+	assert.Equal(t, 30, generated.Cyclo)
+	assert.Equal(t, []string{"vet"}, generated.Enable)
+	assert.Equal(t, []string{"generated"}, generated.Exclude)
+	assert.Len(t, hierarchy.Files(generated), 2)
+
+	// Parents must not be modified by merging children.
+	assert.Equal(t, []string{"vet"}, base.Enable)
+	assert.Equal(t, []string{"golint", "vet"}, legacy.Enable)
+	assert.Empty(t, legacy.Exclude)
+
+	groups, err := groupPathsByConfig(hierarchy, []string{".", "./legacy", "./service", "./legacy/generated"})
+	require.NoError(t, err)
+	require.Len(t, groups, 3)
+	assert.Equal(t, []string{".", "./service"}, groups[0].paths)
+	assert.Equal(t, []string{"./legacy"}, groups[1].paths)
+	assert.Equal(t, []string{"./legacy/generated"}, groups[2].paths)
+}
+
+func TestConfigMarshalJSON(t *testing.T) {
+	source := `{
+		"Linters": {
+			"custom": {"Command": "custom", "PartitionStrategy": "files-by-package"},
+			"other": {"Command": "other"}
+		},
+		"Deadline": "1m30s"
+	}`
+	var config Config
+	require.NoError(t, json.Unmarshal([]byte(source), &config))
+	d, err := json.Marshal(&config)
+	require.NoError(t, err)
+
+	var roundtrip Config
+	require.NoError(t, json.Unmarshal(d, &roundtrip))
+	assert.Equal(t, config.Deadline, roundtrip.Deadline)
+	assert.Equal(t,
+		functionName(partitionPathsAsFilesGroupedByPackage),
+		functionName(roundtrip.Linters["custom"].PartitionStrategy))
+	assert.Nil(t, roundtrip.Linters["other"].PartitionStrategy)
+}
+
+func TestConfigHierarchyAppliesCommandLineLast(t *testing.T) {
+	defer func() { commandLine = nil }()
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	mkDir(t, tmpdir, "legacy")
+	mkFile(t, filepath.Join(tmpdir, "legacy"), defaultConfigPath,
+		`{"Cyclo": 30, "Enable": ["golint", "vet"]}`)
+
+	commandLine = []string{"-D", "vet", "--cyclo-over=5", "./..."}
+	base := &Config{Enable: []string{"golint"}, Cyclo: 5}
+	hierarchy, err := newConfigHierarchy(base, tmpdir)
+	require.NoError(t, err)
+
+	legacy, err := hierarchy.ForPath("./legacy")
+	require.NoError(t, err)
+	assert.Equal(t, 5, legacy.Cyclo)
+	assert.Equal(t, []string{"golint"}, legacy.Enable)
+}
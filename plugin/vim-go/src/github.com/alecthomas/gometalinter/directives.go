@@ -1,11 +1,13 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
-	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
@@ -17,10 +19,20 @@ type ignoredRange struct {
 	start, end int
 	linters    []string
 	matched    bool
+	// The justification following the directive, eg. "// nolint: errcheck // reason".
+	reason string
+	// The last day on which the directive applies, if any.
+	until time.Time
+	// Set if the directive could not be parsed.
+	err error
+}
+
+func (i *ignoredRange) expired() bool {
+	return !i.until.IsZero() && !now().Before(i.until.AddDate(0, 0, 1))
 }
 
 func (i *ignoredRange) matches(issue *Issue) bool {
-	if issue.Line < i.start || issue.Line > i.end {
+	if issue.Line < i.start || issue.Line > i.end || i.expired() {
 		return false
 	}
 	if len(i.linters) == 0 {
@@ -52,14 +64,18 @@ func (ir ignoredRanges) Len() int           { return len(ir) }
 func (ir ignoredRanges) Swap(i, j int)      { ir[i], ir[j] = ir[j], ir[i] }
 func (ir ignoredRanges) Less(i, j int) bool { return ir[i].end < ir[j].end }
 
+// directiveParser parses the directives of files on demand. Files are keyed
+// by their path relative to the workspace, as issue paths are.
 type directiveParser struct {
+	ws    *workspace
 	lock  sync.Mutex
 	files map[string]ignoredRanges
 	fset  *token.FileSet
 }
 
-func newDirectiveParser() *directiveParser {
+func newDirectiveParser(ws *workspace) *directiveParser {
 	return &directiveParser{
+		ws:    ws,
 		files: map[string]ignoredRanges{},
 		fset:  token.NewFileSet(),
 	}
@@ -91,7 +107,7 @@ func (d *directiveParser) Unmatched() map[string]ignoredRanges {
 	unmatched := map[string]ignoredRanges{}
 	for path, ranges := range d.files {
 		for _, ignore := range ranges {
-			if !ignore.matched {
+			if !ignore.matched && !ignore.expired() {
 				unmatched[path] = append(unmatched[path], ignore)
 			}
 		}
@@ -103,11 +119,16 @@ func (d *directiveParser) Unmatched() map[string]ignoredRanges {
 func (d *directiveParser) LoadFiles(paths []string) error {
 	d.lock.Lock()
 	defer d.lock.Unlock()
-	filenames, err := pathsToFileGlobs(paths)
+	abs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		abs = append(abs, d.ws.abs(path))
+	}
+	filenames, err := pathsToFileGlobs(abs)
 	if err != nil {
 		return err
 	}
 	for _, filename := range filenames {
+		filename, _ = d.ws.rel(filename)
 		ranges := d.parseFile(filename)
 		sort.Sort(ranges)
 		d.files[filename] = ranges
@@ -127,6 +148,12 @@ func (a *rangeExpander) Visit(node ast.Node) ast.Visitor {
 	if node == nil {
 		return a
 	}
+	// Trailing comments on consecutive lines are not constructs to expand
+	// into, otherwise each directive would also cover the following line.
+	switch node.(type) {
+	case *ast.CommentGroup, *ast.Comment:
+		return nil
+	}
 	startPos := a.fset.Position(node.Pos())
 	start := startPos.Line
 	end := a.fset.Position(node.End()).Line
@@ -147,8 +174,17 @@ func (a *rangeExpander) Visit(node ast.Node) ast.Visitor {
 
 func (d *directiveParser) parseFile(path string) ignoredRanges {
 	start := time.Now()
+	src, err := d.ws.readFile(path)
+	if err != nil {
+		debug("nolint: failed to read %q: %s", path, err)
+		return nil
+	}
+	if ranges, ok := parsedDirectives.Get(src); ok {
+		debug("nolint: using cached directives for %s", path)
+		return ranges
+	}
 	debug("nolint: parsing %s for directives", path)
-	file, err := parser.ParseFile(d.fset, path, nil, parser.ParseComments)
+	file, err := parser.ParseFile(d.fset, path, src, parser.ParseComments)
 	if err != nil {
 		debug("nolint: failed to parse %q: %s", path, err)
 		return nil
@@ -156,28 +192,71 @@ func (d *directiveParser) parseFile(path string) ignoredRanges {
 	ranges := extractCommentGroupRange(d.fset, file.Comments...)
 	visitor := &rangeExpander{fset: d.fset, ranges: ranges}
 	ast.Walk(visitor, file)
+	parsedDirectives.Put(src, visitor.ranges)
 	debug("nolint: parsing %s took %s", path, time.Since(start))
 	return visitor.ranges
 }
 
+// directiveCache retains the directives parsed from files between runs in
+// server mode, keyed by a hash of the file content.
+type directiveCache struct {
+	lock   sync.Mutex
+	ranges map[[sha256.Size]byte]ignoredRanges
+}
+
+// Set in server mode.
+var parsedDirectives *directiveCache
+
+func newDirectiveCache() *directiveCache {
+	return &directiveCache{ranges: map[[sha256.Size]byte]ignoredRanges{}}
+}
+
+// Get returns a fresh copy of the directives parsed from src, if any.
+func (c *directiveCache) Get(src []byte) (ignoredRanges, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ranges, ok := c.ranges[sha256.Sum256(src)]
+	return ranges.copy(), ok
+}
+
+func (c *directiveCache) Put(src []byte, ranges ignoredRanges) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.ranges[sha256.Sum256(src)] = ranges.copy()
+}
+
+// copy returns a deep copy of the ranges with their matched state reset.
+func (ir ignoredRanges) copy() ignoredRanges {
+	if ir == nil {
+		return nil
+	}
+	out := make(ignoredRanges, 0, len(ir))
+	for _, r := range ir {
+		c := *r
+		c.matched = false
+		out = append(out, &c)
+	}
+	return out
+}
+
 func extractCommentGroupRange(fset *token.FileSet, comments ...*ast.CommentGroup) (ranges ignoredRanges) {
 	for _, g := range comments {
 		for _, c := range g.List {
 			text := strings.TrimLeft(c.Text, "/ ")
-			var linters []string
 			if strings.HasPrefix(text, "nolint") {
-				if strings.HasPrefix(text, "nolint:") {
-					for _, linter := range strings.Split(text[7:], ",") {
-						linters = append(linters, strings.TrimSpace(linter))
-					}
-				}
 				pos := fset.Position(g.Pos())
 				rng := &ignoredRange{
-					col:     pos.Column,
-					start:   pos.Line,
-					end:     fset.Position(g.End()).Line,
-					linters: linters,
+					col:   pos.Column,
+					start: pos.Line,
+					end:   fset.Position(g.End()).Line,
 				}
+				rng.linters, rng.reason, rng.until, rng.err = parseDirective(text)
 				ranges = append(ranges, rng)
 			}
 		}
@@ -185,6 +264,31 @@ func extractCommentGroupRange(fset *token.FileSet, comments ...*ast.CommentGroup
 	return
 }
 
+var directiveUntilRegex = regexp.MustCompile(`(?:^|\s)until=(\S*)`)
+
+// parseDirective parses the text of a nolint comment of the form:
+//
+//	nolint[: <linter>[, <linter>, ...]] [until=YYYY-MM-DD] [// <reason>]
+func parseDirective(text string) (linters []string, reason string, until time.Time, err error) {
+	if i := strings.Index(text, "//"); i >= 0 {
+		text, reason = text[:i], strings.TrimSpace(text[i+2:])
+	}
+	if match := directiveUntilRegex.FindStringSubmatchIndex(text); match != nil {
+		value := text[match[2]:match[3]]
+		text = text[:match[0]] + text[match[1]:]
+		if until, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			err = fmt.Errorf("invalid expiry date %q, expected YYYY-MM-DD", value)
+		}
+	}
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "nolint:") {
+		for _, linter := range strings.Split(text[7:], ",") {
+			linters = append(linters, strings.TrimSpace(linter))
+		}
+	}
+	return linters, reason, until, err
+}
+
 func filterIssuesViaDirectives(directives *directiveParser, issues chan *Issue) chan *Issue {
 	out := make(chan *Issue, 1000000)
 	go func() {
@@ -199,6 +303,9 @@ func filterIssuesViaDirectives(directives *directiveParser, issues chan *Issue)
 				out <- issue
 			}
 		}
+		for _, issue := range checkDirectives(directives, config.StrictNolint) {
+			out <- issue
+		}
 		close(out)
 	}()
 	return out
@@ -206,16 +313,10 @@ func filterIssuesViaDirectives(directives *directiveParser, issues chan *Issue)
 
 func warnOnUnusedDirective(directives *directiveParser) []*Issue {
 	out := []*Issue{}
-
-	cwd, err := os.Getwd()
-	if err != nil {
-		warning("failed to get working directory %s", err)
-	}
-
 	for path, ranges := range directives.Unmatched() {
 		for _, ignore := range ranges {
 			issue, _ := NewIssue("nolint", config.formatTemplate)
-			issue.Path = newIssuePath(cwd, path)
+			issue.Path = newIssuePath(directives.ws.dir, path)
 			issue.Line = ignore.start
 			issue.Col = ignore.col
 			issue.Message = "nolint directive did not match any issue"
@@ -224,3 +325,118 @@ func warnOnUnusedDirective(directives *directiveParser) []*Issue {
 	}
 	return out
 }
+
+// checkDirectives returns issues for directives which are invalid or have
+// expired and, in strict mode, for directives without a reason.
+func checkDirectives(directives *directiveParser, strict bool) []*Issue {
+	out := []*Issue{}
+	for _, path := range directives.Paths() {
+		for _, ignore := range directives.files[path] {
+			var message string
+			switch {
+			case ignore.err != nil:
+				message = fmt.Sprintf("invalid nolint directive: %s", ignore.err)
+			case ignore.expired():
+				message = fmt.Sprintf("nolint directive expired after %s", ignore.until.Format("2006-01-02"))
+			case strict && ignore.reason == "":
+				message = "nolint directive is missing a reason (// nolint: <linter> // <reason>)"
+			default:
+				continue
+			}
+			issue, _ := NewIssue("nolint", config.formatTemplate)
+			issue.Path = newIssuePath(directives.ws.dir, path)
+			issue.Line = ignore.start
+			issue.Col = ignore.col
+			issue.Message = message
+			out = append(out, issue)
+		}
+	}
+	return out
+}
+
+// Paths returns the sorted paths of all files parsed for directives.
+func (d *directiveParser) Paths() []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	paths := make([]string, 0, len(d.files))
+	for path := range d.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+type directiveListing struct {
+	Path    string   `json:"path"`
+	Start   int      `json:"start"`
+	End     int      `json:"end"`
+	Linters []string `json:"linters"`
+	Reason  string   `json:"reason"`
+	Until   string   `json:"until,omitempty"`
+	Expired bool     `json:"expired,omitempty"`
+}
+
+func listDirectives(directives *directiveParser) []*directiveListing {
+	out := []*directiveListing{}
+	for _, path := range directives.Paths() {
+		for _, ignore := range directives.files[path] {
+			listing := &directiveListing{
+				Path:    path,
+				Start:   ignore.start,
+				End:     ignore.end,
+				Linters: ignore.linters,
+				Reason:  ignore.reason,
+				Expired: ignore.expired(),
+			}
+			if listing.Linters == nil {
+				listing.Linters = []string{}
+			}
+			if !ignore.until.IsZero() {
+				listing.Until = ignore.until.Format("2006-01-02")
+			}
+			out = append(out, listing)
+		}
+	}
+	return out
+}
+
+// outputDirectives prints every nolint directive found in paths, for
+// auditing suppressions.
+func outputDirectives(ws *workspace, paths []string, asJSON bool) error {
+	directives := newDirectiveParser(ws)
+	if err := directives.LoadFiles(paths); err != nil {
+		return err
+	}
+	listings := listDirectives(directives)
+	if asJSON {
+		d, err := json.MarshalIndent(listings, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", d)
+		return nil
+	}
+	for _, listing := range listings {
+		linters := strings.Join(listing.Linters, ",")
+		if linters == "" {
+			linters = "all"
+		}
+		reason := listing.Reason
+		if reason == "" {
+			reason = "(no reason)"
+		}
+		fmt.Printf("%s:%d-%d: %s: %s", listing.Path, listing.Start, listing.End, linters, reason)
+		if listing.Until != "" {
+			state := "until"
+			if listing.Expired {
+				state = "expired"
+			}
+			fmt.Printf(" (%s %s)", state, listing.Until)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// now is replaced in tests.
+var now = time.Now
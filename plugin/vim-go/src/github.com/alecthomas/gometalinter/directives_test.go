@@ -2,8 +2,11 @@ package main
 
 import (
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIgnoreRangeMatch(t *testing.T) {
@@ -40,3 +43,153 @@ func TestIgnoreRangeMatch(t *testing.T) {
 		assert.Equal(t, testcase.expected, ir.matches(&testcase.issue), testcase.doc)
 	}
 }
+
+func TestParseDirective(t *testing.T) {
+	var testcases = []struct {
+		text    string
+		linters []string
+		reason  string
+		until   string
+		err     bool
+	}{
+		{text: "nolint"},
+		{text: "nolint: errcheck, vet", linters: []string{"errcheck", "vet"}},
+		{
+			text:    "nolint:errcheck // Close never fails here",
+			linters: []string{"errcheck"},
+			reason:  "Close never fails here",
+		},
+		{
+			text:    "nolint: gocyclo until=2027-01-01 // to be split up",
+			linters: []string{"gocyclo"},
+			reason:  "to be split up",
+			until:   "2027-01-01",
+		},
+		{text: "nolint until=tomorrow", err: true},
+	}
+
+	for _, testcase := range testcases {
+		linters, reason, until, err := parseDirective(testcase.text)
+		assert.Equal(t, testcase.linters, linters, testcase.text)
+		assert.Equal(t, testcase.reason, reason, testcase.text)
+		if testcase.until != "" {
+			assert.Equal(t, testcase.until, until.Format("2006-01-02"), testcase.text)
+		} else {
+			assert.True(t, until.IsZero(), testcase.text)
+		}
+		assert.Equal(t, testcase.err, err != nil, testcase.text)
+	}
+}
+
+func TestCheckDirectives(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2027, 1, 2, 0, 0, 0, 0, time.Local) }
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+
+	mkFile(t, tmpdir, "file.go", `package foo
+
+var a = 1 // nolint: varcheck // kept for compatibility
+var b = 2 // nolint: varcheck
+var c = 3 // nolint: varcheck until=2027-01-01 // expired
+var d = 4 // nolint: varcheck until=2027-01-02 // not expired yet
+`)
+
+	directives := newDirectiveParser(ws)
+	require.NoError(t, directives.LoadFiles([]string{"."}))
+
+	messages := func(issues []*Issue) map[int]string {
+		out := map[int]string{}
+		for _, issue := range issues {
+			out[issue.Line] = issue.Message
+		}
+		return out
+	}
+	assert.Equal(t, map[int]string{
+		5: "nolint directive expired after 2027-01-01",
+	}, messages(checkDirectives(directives, false)))
+	assert.Equal(t, map[int]string{
+		4: "nolint directive is missing a reason (// nolint: <linter> // <reason>)",
+		5: "nolint directive expired after 2027-01-01",
+	}, messages(checkDirectives(directives, true)))
+
+	assert.False(t, directives.IsIgnored(&Issue{Path: newIssuePath(tmpdir, "file.go"), Line: 5, Linter: "varcheck"}))
+	assert.True(t, directives.IsIgnored(&Issue{Path: newIssuePath(tmpdir, "file.go"), Line: 6, Linter: "varcheck"}))
+
+	listings := listDirectives(directives)
+	require.Len(t, listings, 4)
+	assert.Equal(t, &directiveListing{
+		Path:    "file.go",
+		Start:   3,
+		End:     3,
+		Linters: []string{"varcheck"},
+		Reason:  "kept for compatibility",
+	}, listings[0])
+	assert.True(t, listings[2].Expired)
+}
+
+func TestTrailingDirectiveDoesNotCoverNextLine(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+
+	mkFile(t, tmpdir, "file.go", `package foo
+
+var a = 1 // nolint: deadcode
+var b = 2 // nolint: varcheck
+`)
+
+	directives := newDirectiveParser(ws)
+	require.NoError(t, directives.LoadFiles([]string{"."}))
+
+	assert.True(t, directives.IsIgnored(&Issue{Path: newIssuePath(tmpdir, "file.go"), Line: 3, Linter: "deadcode"}))
+	assert.False(t, directives.IsIgnored(&Issue{Path: newIssuePath(tmpdir, "file.go"), Line: 4, Linter: "deadcode"}))
+	assert.True(t, directives.IsIgnored(&Issue{Path: newIssuePath(tmpdir, "file.go"), Line: 4, Linter: "varcheck"}))
+}
+
+func TestRunLintersReportsExpiredDirectivesWithoutIssues(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+	config.StrictNolint = true
+
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2027, 1, 2, 0, 0, 0, 0, time.Local) }
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+
+	mkFile(t, tmpdir, "file.go", `package foo
+
+var a = 1 // nolint: varcheck until=2027-01-01
+`)
+
+	issues, errch := runLinters(ws, []*linterGroup{{config: config, paths: []string{"."}}}, 1)
+	var messages []string
+	for issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	for err := range errch {
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"nolint directive expired after 2027-01-01"}, messages)
+
+	// Without strict mode, files without issues are not parsed.
+	config.StrictNolint = false
+	issues, errch = runLinters(ws, []*linterGroup{{config: config, paths: []string{"."}}}, 1)
+	messages = nil
+	for issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	for err := range errch {
+		require.NoError(t, err)
+	}
+	assert.Empty(t, messages)
+}
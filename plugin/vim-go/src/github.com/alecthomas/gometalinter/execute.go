@@ -3,7 +3,6 @@ package main
 import (
 	"bytes"
 	"fmt"
-	"os"
 	"os/exec"
 	"reflect"
 	"regexp"
@@ -41,42 +40,65 @@ func (v Vars) Replace(s string) string {
 
 type linterState struct {
 	*Linter
+	ws       *workspace
+	config   *Config
 	issues   chan *Issue
 	vars     Vars
 	exclude  *regexp.Regexp
 	include  *regexp.Regexp
 	deadline <-chan time.Time
+	cache    *resultCache
+	program  *sharedProgram
 }
 
-func (l *linterState) Partitions(paths []string) ([][]string, error) {
-	cmdArgs, err := parseCommand(l.command())
-	if err != nil {
-		return nil, err
+// linterGroup is a set of paths which share the same effective configuration.
+type linterGroup struct {
+	config  *Config
+	paths   []string
+	linters map[string]*Linter
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// newLinterGroups resolves the effective configuration of each path, relative
+// to dir, and groups the paths accordingly.
+func newLinterGroups(config *Config, dir string, paths []string) ([]*linterGroup, error) {
+	if config.NoConfig {
+		return []*linterGroup{newLinterGroup(config, paths)}, nil
 	}
-	parts, err := l.Linter.PartitionStrategy(cmdArgs, paths)
+	hierarchy, err := newConfigHierarchy(config, dir)
 	if err != nil {
 		return nil, err
 	}
-	return parts, nil
+	return newLinterGroupsFromHierarchy(hierarchy, paths)
 }
 
-func (l *linterState) command() string {
-	return l.vars.Replace(l.Command)
+func newLinterGroupsFromHierarchy(hierarchy *configHierarchy, paths []string) ([]*linterGroup, error) {
+	groups, err := groupPathsByConfig(hierarchy, paths)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		*group = *newLinterGroup(group.config, group.paths)
+		if files := hierarchy.Files(group.config); len(files) > 0 {
+			debug("using config from %s for %s", strings.Join(files, ", "), strings.Join(group.paths, " "))
+		}
+	}
+	return groups, nil
 }
 
-func runLinters(linters map[string]*Linter, paths []string, concurrency int, exclude, include *regexp.Regexp) (chan *Issue, chan error) {
-	errch := make(chan error, len(linters))
-	concurrencych := make(chan bool, concurrency)
-	incomingIssues := make(chan *Issue, 1000000)
-
-	directiveParser := newDirectiveParser()
-	if config.WarnUnmatchedDirective {
-		directiveParser.LoadFiles(paths)
+func newLinterGroup(config *Config, paths []string) *linterGroup {
+	include, exclude := compileIssueFilters(config)
+	return &linterGroup{
+		config:  config,
+		paths:   paths,
+		linters: lintersFromConfig(config),
+		include: include,
+		exclude: exclude,
 	}
+}
 
-	processedIssues := maybeSortIssues(filterIssuesViaDirectives(
-		directiveParser, maybeAggregateIssues(incomingIssues)))
-
+func linterVars(config *Config) Vars {
 	vars := Vars{
 		"duplthreshold":    fmt.Sprintf("%d", config.DuplThreshold),
 		"mincyclo":         fmt.Sprintf("%d", config.Cyclo),
@@ -92,45 +114,140 @@ func runLinters(linters map[string]*Linter, paths []string, concurrency int, exc
 		vars["tests"] = "true"
 		vars["not_tests"] = ""
 	}
+	return vars
+}
+
+// Partitions splits paths into the argument lists to execute the linter with.
+// Paths are partitioned as absolute paths, so that partition strategies do
+// not depend on the working directory, and then mapped to linter arguments by
+// the workspace.
+func (l *linterState) Partitions(paths []string) ([][]string, error) {
+	cmdArgs, err := parseCommand(l.command())
+	if err != nil {
+		return nil, err
+	}
+	abs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		abs = append(abs, l.ws.abs(path))
+	}
+	parts, err := l.Linter.PartitionStrategy(cmdArgs, abs)
+	if err != nil {
+		return nil, err
+	}
+	for i, part := range parts {
+		args := append([]string{}, part[:len(cmdArgs)]...)
+		for _, arg := range part[len(cmdArgs):] {
+			args = append(args, l.ws.linterArg(arg))
+		}
+		parts[i] = args
+	}
+	return parts, nil
+}
+
+// partition returns the paths passed to the linter in args.
+func (l *linterState) partition(args []string) []string {
+	cmdArgs, err := parseCommand(l.command())
+	if err != nil || len(cmdArgs) > len(args) {
+		return nil
+	}
+	return args[len(cmdArgs):]
+}
+
+func (l *linterState) command() string {
+	return l.vars.Replace(l.Command)
+}
+
+// runLinters runs the linters of each group over its paths, which are
+// relative to the workspace.
+func runLinters(ws *workspace, groups []*linterGroup, concurrency int) (chan *Issue, chan error) {
+	numLinters := 0
+	paths := []string{}
+	for _, group := range groups {
+		numLinters += len(group.linters)
+		paths = append(paths, group.paths...)
+	}
+	errch := make(chan error, numLinters)
+	concurrencych := make(chan bool, concurrency)
+	incomingIssues := make(chan *Issue, 1000000)
+
+	directiveParser := newDirectiveParser(ws)
+	// Files are otherwise only parsed when an issue is reported in them. Parse
+	// all of them up front when every directive is checked, so that unmatched
+	// and reasonless directives are reported even in files without issues.
+	if config.StrictNolint || config.WarnUnmatchedDirective {
+		directiveParser.LoadFiles(paths)
+	}
+
+	processedIssues := countReportedIssues(maybeSortIssues(maybeApplyFixes(maybeSuggestFixes(ws,
+		maybeFilterIssuesViaChangedLines(ws, maybeFilterIssuesViaBaseline(ws, paths, maybeWriteBaseline(ws,
+			filterIssuesViaDirectives(directiveParser, maybeAggregateIssues(mapOverlayIssues(ws, incomingIssues))))))))))
+
+	cache := newResultCacheFromConfig(config)
 
 	wg := &sync.WaitGroup{}
 	id := 1
-	for _, linter := range linters {
-		deadline := time.After(config.Deadline.Duration())
-		state := &linterState{
-			Linter:   linter,
-			issues:   incomingIssues,
-			vars:     vars,
-			exclude:  exclude,
-			include:  include,
-			deadline: deadline,
-		}
+	for _, group := range groups {
+		vars := linterVars(group.config)
+		program := &sharedProgram{ws: ws, paths: group.paths, tests: group.config.Test}
+		for _, linter := range group.linters {
+			deadline := time.After(group.config.Deadline.Duration())
+			state := &linterState{
+				Linter:   linter,
+				ws:       ws,
+				config:   group.config,
+				issues:   incomingIssues,
+				vars:     vars,
+				exclude:  group.exclude,
+				include:  group.include,
+				deadline: deadline,
+				cache:    cache,
+				program:  program,
+			}
 
-		partitions, err := state.Partitions(paths)
-		if err != nil {
-			errch <- err
-			continue
-		}
-		for _, args := range partitions {
-			wg.Add(1)
-			concurrencych <- true
-			// Call the goroutine with a copy of the args array so that the
-			// contents of the array are not modified by the next iteration of
-			// the above for loop
-			go func(id int, args []string) {
-				err := executeLinter(id, state, args)
-				if err != nil {
-					errch <- err
-				}
-				<-concurrencych
-				wg.Done()
-			}(id, args)
-			id++
+			if analyzer := state.analyzer(); analyzer != nil {
+				wg.Add(1)
+				concurrencych <- true
+				go func(id int) {
+					err := executeAnalyzer(id, state, analyzer)
+					if err != nil {
+						errch <- err
+					}
+					<-concurrencych
+					wg.Done()
+				}(id)
+				id++
+				continue
+			}
+
+			partitions, err := state.Partitions(group.paths)
+			if err != nil {
+				errch <- err
+				continue
+			}
+			for _, args := range partitions {
+				wg.Add(1)
+				concurrencych <- true
+				// Call the goroutine with a copy of the args array so that the
+				// contents of the array are not modified by the next iteration of
+				// the above for loop
+				go func(id int, args []string) {
+					err := executeLinter(id, state, args)
+					if err != nil {
+						errch <- err
+					}
+					<-concurrencych
+					wg.Done()
+				}(id, args)
+				id++
+			}
 		}
 	}
 
 	go func() {
 		wg.Wait()
+		if cache != nil {
+			debug("cache: %s", cache)
+		}
 		close(incomingIssues)
 		close(errch)
 	}()
@@ -144,10 +261,32 @@ func executeLinter(id int, state *linterState, args []string) error {
 
 	start := time.Now()
 	dbg := namespacedDebug(fmt.Sprintf("[%s.%d]: ", state.Name, id))
+	record := stats.Start(state.Name, state.partition(args))
+
+	cacheKey := ""
+	if state.cache != nil {
+		key, err := state.cache.Key(state, args)
+		if err != nil {
+			dbg("not caching results: %s", err)
+		} else if out, ok := state.cache.Get(key); ok {
+			dbg("using cached results for %s", strings.Join(args, " "))
+			if record != nil {
+				record.Cached = true
+			}
+			processOutput(dbg, state, out, record)
+			record.Finish(nil)
+			return nil
+		} else {
+			cacheKey = key
+		}
+	}
+
 	dbg("executing %s", strings.Join(args, " "))
 	buf := bytes.NewBuffer(nil)
 	command := args[0]
 	cmd := exec.Command(command, args[1:]...) // nolint: gas
+	cmd.Dir = state.ws.dir
+	cmd.Env = state.ws.environ()
 	cmd.Stdout = buf
 	cmd.Stderr = buf
 	err := cmd.Start()
@@ -166,20 +305,30 @@ func executeLinter(id int, state *linterState, args []string) error {
 	case <-done:
 
 	case <-state.deadline:
-		err = fmt.Errorf("deadline exceeded by linter %s (try increasing --deadline)",
-			state.Name)
 		kerr := cmd.Process.Kill()
 		if kerr != nil {
 			warning("failed to kill %s: %s", state.Name, kerr)
+		} else if record != nil {
+			// Reap the process so that its resource usage is available.
+			<-done
+			record.DeadlineExceeded = true
+			record.Finish(cmd.ProcessState)
 		}
-		return err
+		return fmt.Errorf("deadline exceeded by linter %s (try increasing --deadline)",
+			state.Name)
 	}
+	record.Finish(cmd.ProcessState)
 
 	if err != nil {
 		dbg("warning: %s returned %s: %s", command, err, buf.String())
 	}
 
-	processOutput(dbg, state, buf.Bytes())
+	processOutput(dbg, state, buf.Bytes(), record)
+	if cacheKey != "" && cacheable(state, buf.Bytes(), err) {
+		if err := state.cache.Put(cacheKey, buf.Bytes()); err != nil {
+			dbg("failed to cache results: %s", err)
+		}
+	}
 	elapsed := time.Since(start)
 	dbg("%s linter took %s", state.Name, elapsed)
 	return nil
@@ -193,24 +342,40 @@ func parseCommand(command string) ([]string, error) {
 	if len(args) == 0 {
 		return nil, fmt.Errorf("invalid command %q", command)
 	}
-	exe, err := exec.LookPath(args[0])
+	exe, err := lookPath(args[0])
 	if err != nil {
 		return nil, err
 	}
 	return append([]string{exe}, args[1:]...), nil
 }
 
+var lookPathCache = struct {
+	sync.Mutex
+	paths map[string]string
+}{paths: map[string]string{}}
+
+// lookPath is exec.LookPath, remembering executables that were found so that
+// a server does not search $PATH for every linter on every request.
+func lookPath(file string) (string, error) {
+	lookPathCache.Lock()
+	defer lookPathCache.Unlock()
+	if exe, ok := lookPathCache.paths[file]; ok {
+		return exe, nil
+	}
+	exe, err := exec.LookPath(file)
+	if err != nil {
+		return "", err
+	}
+	lookPathCache.paths[file] = exe
+	return exe, nil
+}
+
 // nolint: gocyclo
-func processOutput(dbg debugFunction, state *linterState, out []byte) {
+func processOutput(dbg debugFunction, state *linterState, out []byte, record *linterStats) {
 	re := state.regex
 	all := re.FindAllSubmatchIndex(out, -1)
 	dbg("%s hits %d: %s", state.Name, len(all), state.Pattern)
 
-	cwd, err := os.Getwd()
-	if err != nil {
-		warning("failed to get working directory %s", err)
-	}
-
 	// Create a local copy of vars so they can be modified by the linter output
 	vars := state.vars.Copy()
 
@@ -237,7 +402,7 @@ func processOutput(dbg debugFunction, state *linterState, out []byte) {
 			}
 			switch name {
 			case "path":
-				issue.Path, err = newIssuePathFromAbsPath(cwd, part)
+				issue.Path, err = newIssuePathFromAbsPath(state.ws.dir, state.ws.abs(part))
 				if err != nil {
 					warning("failed to make %s a relative path: %s", part, err)
 				}
@@ -257,22 +422,29 @@ func processOutput(dbg debugFunction, state *linterState, out []byte) {
 			case "":
 			}
 		}
-		// TODO: set messageOveride and severity on the Linter instead of reading
-		// them directly from the static config
-		if m, ok := config.MessageOverride[state.Name]; ok {
-			issue.Message = vars.Replace(m)
-		}
-		if sev, ok := config.Severity[state.Name]; ok {
-			issue.Severity = Severity(sev)
-		}
-		if state.exclude != nil && state.exclude.MatchString(issue.String()) {
-			continue
-		}
-		if state.include != nil && !state.include.MatchString(issue.String()) {
-			continue
-		}
-		state.issues <- issue
+		record.countIssue(issue)
+		emitIssue(state, vars, issue)
+	}
+}
+
+// emitIssue applies message overrides, severities and include/exclude
+// filters to an issue before passing it on.
+func emitIssue(state *linterState, vars Vars, issue *Issue) {
+	// TODO: set messageOveride and severity on the Linter instead of reading
+	// them directly from the config
+	if m, ok := state.config.MessageOverride[state.Name]; ok {
+		issue.Message = vars.Replace(m)
+	}
+	if sev, ok := state.config.Severity[state.Name]; ok {
+		issue.Severity = Severity(sev)
+	}
+	if state.exclude != nil && state.exclude.MatchString(issue.String()) {
+		return
+	}
+	if state.include != nil && !state.include.MatchString(issue.String()) {
+		return
 	}
+	state.issues <- issue
 }
 
 func maybeSortIssues(issues chan *Issue) chan *Issue {
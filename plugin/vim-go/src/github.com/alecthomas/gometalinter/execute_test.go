@@ -1,9 +1,13 @@
 package main
 
 import (
+	"runtime"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLinterStateCommand(t *testing.T) {
@@ -65,3 +69,37 @@ func TestLinterStateCommand(t *testing.T) {
 		assert.Equal(t, testcase.expected, ls.command())
 	}
 }
+
+func TestRunLintersUsesGroupDeadline(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires /bin/sh")
+	}
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+	config.Deadline = jsonDuration(time.Minute)
+	config.NoCache = true
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+	mkGoFile(t, tmpdir, "file.go")
+
+	groupConfig := *config
+	groupConfig.Deadline = jsonDuration(10 * time.Millisecond)
+	linter, err := NewLinter("sleep", LinterConfig{Command: `sh -c "sleep 10" sh`, Pattern: "PATH:LINE:MESSAGE"})
+	require.NoError(t, err)
+	groups := []*linterGroup{{config: &groupConfig, paths: []string{"."}, linters: map[string]*Linter{"sleep": linter}}}
+
+	start := time.Now()
+	issues, errch := runLinters(ws, groups, 1)
+	for range issues {
+	}
+	var errs []error
+	for err := range errch {
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "deadline exceeded")
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
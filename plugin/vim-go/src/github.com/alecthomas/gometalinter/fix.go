@@ -0,0 +1,522 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Edit replaces Length bytes at byte Offset of the file an issue refers to
+// with Text.
+type Edit struct {
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	Text   string `json:"text"`
+}
+
+func (e Edit) end() int {
+	return e.Offset + e.Length
+}
+
+// A fixer computes the edits which resolve an issue, given the current source
+// of the file the issue refers to. It returns no edits if the issue can not
+// be fixed automatically.
+type fixer func(issue *Issue, src []byte) ([]Edit, error)
+
+var fixers = map[string]fixer{
+	"gofmt":     diffFixer("gofmt", "-s", "-d"),
+	"goimports": diffFixer("goimports", "-d"),
+	"misspell":  fixMisspelling,
+	"unconvert": fixUnnecessaryConversion,
+	"gosimple":  fixSimplification,
+	"megacheck": fixSimplification,
+}
+
+// sourceFiles caches the content of files that edits are computed against.
+type sourceFiles struct {
+	ws    *workspace
+	lock  sync.Mutex
+	files map[string][]byte
+}
+
+func newSourceFiles(ws *workspace) *sourceFiles {
+	return &sourceFiles{ws: ws, files: map[string][]byte{}}
+}
+
+func (s *sourceFiles) read(path string) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if src, ok := s.files[path]; ok {
+		return src, nil
+	}
+	src, err := s.ws.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s.files[path] = src
+	return src, nil
+}
+
+func suggestFixes(sources *sourceFiles, issue *Issue) {
+	for _, linter := range issue.linterNames() {
+		fix, ok := fixers[linter]
+		if !ok {
+			continue
+		}
+		src, err := sources.read(issue.Path.Abs())
+		if err != nil {
+			debug("fix: failed to read %s: %s", issue.Path, err)
+			return
+		}
+		edits, err := fix(issue, src)
+		if err != nil {
+			debug("fix: failed to compute fix for %s: %s", issue, err)
+			continue
+		}
+		if len(edits) > 0 {
+			issue.Edits = edits
+			return
+		}
+	}
+}
+
+// suggestFixesIssueChan attaches suggested edits to issues reported by
+// linters with a registered fixer.
+func suggestFixesIssueChan(ws *workspace, issues chan *Issue) chan *Issue {
+	out := make(chan *Issue, 1000000)
+	sources := newSourceFiles(ws)
+	go func() {
+		for issue := range issues {
+			suggestFixes(sources, issue)
+			out <- issue
+		}
+		close(out)
+	}()
+	return out
+}
+
+// applyFixesIssueChan applies the edits attached to issues once all issues
+// have been received. Issues which were fixed are dropped, and issues whose
+// edits conflict with those of another issue are passed through unchanged.
+func applyFixesIssueChan(issues chan *Issue) chan *Issue {
+	out := make(chan *Issue, 1000000)
+	go func() {
+		byPath := map[string][]*Issue{}
+		paths := []string{}
+		for issue := range issues {
+			if len(issue.Edits) == 0 {
+				out <- issue
+				continue
+			}
+			path := issue.Path.Abs()
+			if _, ok := byPath[path]; !ok {
+				paths = append(paths, path)
+			}
+			byPath[path] = append(byPath[path], issue)
+		}
+		for _, path := range paths {
+			for _, issue := range applyFixes(path, byPath[path]) {
+				out <- issue
+			}
+		}
+		close(out)
+	}()
+	return out
+}
+
+// applyFixes writes the edits of issues to the file at path, returning the
+// issues which could not be fixed.
+func applyFixes(path string, issues []*Issue) []*Issue {
+	info, err := os.Stat(path)
+	if err != nil {
+		warning("failed to read %s: %s", path, err)
+		return issues
+	}
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		warning("failed to read %s: %s", path, err)
+		return issues
+	}
+	fixed, applied, skipped := applyEdits(src, issues)
+	for _, issue := range skipped {
+		warning("skipped fix for %s: conflicts with another fix", issue)
+	}
+	if len(applied) == 0 {
+		return issues
+	}
+	if err := ioutil.WriteFile(path, fixed, info.Mode().Perm()); err != nil {
+		warning("failed to write fixes to %s: %s", path, err)
+		return issues
+	}
+	debug("fix: applied %d fixes to %s", len(applied), path)
+	return skipped
+}
+
+// applyEdits applies the edits of each issue to src. All edits of an issue are
+// applied together or not at all; an issue is skipped if any of its edits
+// overlaps an edit that was already accepted. Identical edits from different
+// issues are only applied once.
+func applyEdits(src []byte, issues []*Issue) (out []byte, applied, skipped []*Issue) {
+	accepted := []Edit{}
+	overlaps := func(edit Edit) (duplicate bool, conflict bool) {
+		for _, other := range accepted {
+			if edit == other {
+				return true, false
+			}
+			if edit.Offset < other.end() && other.Offset < edit.end() ||
+				edit.Offset == other.Offset && (edit.Length == 0 || other.Length == 0) {
+				return false, true
+			}
+		}
+		return false, false
+	}
+
+	for _, issue := range issues {
+		pending := []Edit{}
+		ok := true
+		for _, edit := range issue.Edits {
+			if edit.Offset < 0 || edit.end() > len(src) {
+				ok = false
+				break
+			}
+			duplicate, conflict := overlaps(edit)
+			if conflict {
+				ok = false
+				break
+			}
+			if !duplicate {
+				pending = append(pending, edit)
+			}
+		}
+		if !ok {
+			skipped = append(skipped, issue)
+			continue
+		}
+		accepted = append(accepted, pending...)
+		applied = append(applied, issue)
+	}
+
+	sort.Slice(accepted, func(i, j int) bool { return accepted[i].Offset < accepted[j].Offset })
+	buf := bytes.NewBuffer(nil)
+	last := 0
+	for _, edit := range accepted {
+		buf.Write(src[last:edit.Offset])
+		buf.WriteString(edit.Text)
+		last = edit.end()
+	}
+	buf.Write(src[last:])
+	return buf.Bytes(), applied, skipped
+}
+
+// lineOffsets returns the byte offset of the start of each line in src, with
+// an additional entry for the end of the file.
+func lineOffsets(src []byte) []int {
+	offsets := []int{0}
+	for i, b := range src {
+		if b == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	if offsets[len(offsets)-1] != len(src) {
+		offsets = append(offsets, len(src))
+	}
+	return offsets
+}
+
+// lineOffset returns the byte offset of the start of the 1-based line.
+func lineOffset(offsets []int, line int) int {
+	if line < 1 {
+		return 0
+	}
+	if line > len(offsets) {
+		return offsets[len(offsets)-1]
+	}
+	return offsets[line-1]
+}
+
+// diffFixer returns a fixer which runs a formatting command that prints a
+// unified diff of its changes, such as "gofmt -d", and converts each changed
+// block of lines into an edit.
+func diffFixer(command string, args ...string) fixer {
+	return func(issue *Issue, src []byte) ([]Edit, error) {
+		buf := bytes.NewBuffer(nil)
+		cmdArgs := append(append([]string{}, args...), issue.Path.Abs())
+		cmd := exec.Command(command, cmdArgs...) // nolint: gas
+		cmd.Stdout = buf
+		err := cmd.Run()
+		// Newer versions of gofmt exit with status 1 when printing a diff.
+		if err != nil && buf.Len() == 0 {
+			return nil, err
+		}
+		return editsFromUnifiedDiff(src, buf.Bytes())
+	}
+}
+
+var hunkRangeRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\d+(?:,\d+)? @@`)
+
+// editsFromUnifiedDiff converts a unified diff against src into edits, one
+// per contiguous block of removed and added lines.
+func editsFromUnifiedDiff(src []byte, diff []byte) ([]Edit, error) {
+	offsets := lineOffsets(src)
+	edits := []Edit{}
+
+	inHunk := false
+	oldLine := 0
+	blockStart := 0
+	removed := 0
+	added := bytes.NewBuffer(nil)
+	flush := func() {
+		if removed == 0 && added.Len() == 0 {
+			return
+		}
+		start := lineOffset(offsets, blockStart)
+		edits = append(edits, Edit{
+			Offset: start,
+			Length: lineOffset(offsets, blockStart+removed) - start,
+			Text:   added.String(),
+		})
+		removed = 0
+		added.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := hunkRangeRegex.FindStringSubmatch(line); match != nil {
+			flush()
+			start, err := strconv.Atoi(match[1])
+			if err != nil {
+				return nil, err
+			}
+			// A hunk which only adds lines at the start of the file refers to
+			// line 0.
+			if match[2] == "0" {
+				start++
+			}
+			oldLine = start
+			inHunk = true
+			continue
+		}
+		if !inHunk {
+			continue
+		}
+		if line == "" {
+			line = " "
+		}
+		switch line[0] {
+		case ' ':
+			flush()
+			oldLine++
+		case '-':
+			if removed == 0 && added.Len() == 0 {
+				blockStart = oldLine
+			}
+			removed++
+			oldLine++
+		case '+':
+			if removed == 0 && added.Len() == 0 {
+				blockStart = oldLine
+			}
+			added.WriteString(line[1:])
+			added.WriteByte('\n')
+		case '\\':
+			// "\ No newline at end of file"
+		default:
+			flush()
+			inHunk = false
+		}
+	}
+	flush()
+	return edits, scanner.Err()
+}
+
+var misspellingRegex = regexp.MustCompile(`^"([^"]+)" is a misspelling of "([^"]+)"`)
+
+// fixMisspelling replaces the misspelt word. misspell reports 0-based
+// columns, so the word is searched for around the reported column.
+func fixMisspelling(issue *Issue, src []byte) ([]Edit, error) {
+	match := misspellingRegex.FindStringSubmatch(issue.Message)
+	if match == nil {
+		return nil, nil
+	}
+	original, corrected := match[1], match[2]
+	offsets := lineOffsets(src)
+	start := lineOffset(offsets, issue.Line)
+	end := lineOffset(offsets, issue.Line+1)
+	line := string(src[start:end])
+
+	col := -1
+	for _, candidate := range []int{issue.Col, issue.Col - 1} {
+		if candidate >= 0 && strings.HasPrefix(line[minInt(candidate, len(line)):], original) {
+			col = candidate
+			break
+		}
+	}
+	if col < 0 {
+		if col = strings.Index(line, original); col < 0 {
+			return nil, fmt.Errorf("%q not found on line %d", original, issue.Line)
+		}
+	}
+	return []Edit{{Offset: start + col, Length: len(original), Text: corrected}}, nil
+}
+
+// parseIssueFile parses src and returns the position within it of the issue.
+func parseIssueFile(issue *Issue, src []byte) (*token.FileSet, *ast.File, token.Pos, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, issue.Path.Abs(), src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, token.NoPos, err
+	}
+	tfile := fset.File(file.Pos())
+	if issue.Line < 1 || issue.Line > tfile.LineCount() {
+		return nil, nil, token.NoPos, fmt.Errorf("line %d out of range", issue.Line)
+	}
+	col := issue.Col
+	if col < 1 {
+		col = 1
+	}
+	return fset, file, tfile.LineStart(issue.Line) + token.Pos(col-1), nil
+}
+
+// innermostNode returns the innermost node starting at pos for which match
+// returns true.
+func innermostNode(file *ast.File, pos token.Pos, match func(ast.Node) bool) ast.Node {
+	var found ast.Node
+	ast.Inspect(file, func(node ast.Node) bool {
+		if node == nil || pos < node.Pos() || pos >= node.End() {
+			return false
+		}
+		if node.Pos() == pos && match(node) {
+			found = node
+		}
+		return true
+	})
+	return found
+}
+
+func nodeEdit(fset *token.FileSet, node ast.Node, text string) Edit {
+	start := fset.Position(node.Pos()).Offset
+	return Edit{Offset: start, Length: fset.Position(node.End()).Offset - start, Text: text}
+}
+
+// fixUnnecessaryConversion replaces a conversion reported by unconvert, whose
+// position is that of the opening parenthesis, with its operand.
+func fixUnnecessaryConversion(issue *Issue, src []byte) ([]Edit, error) {
+	fset, file, pos, err := parseIssueFile(issue, src)
+	if err != nil {
+		return nil, err
+	}
+	var call *ast.CallExpr
+	ast.Inspect(file, func(node ast.Node) bool {
+		if c, ok := node.(*ast.CallExpr); ok && c.Lparen == pos && len(c.Args) == 1 {
+			call = c
+		}
+		return call == nil
+	})
+	if call == nil {
+		return nil, fmt.Errorf("no conversion found")
+	}
+	arg := call.Args[0]
+	text := string(src[fset.Position(arg.Pos()).Offset:fset.Position(arg.End()).Offset])
+	switch arg.(type) {
+	case *ast.BinaryExpr, *ast.UnaryExpr, *ast.StarExpr:
+		text = "(" + text + ")"
+	}
+	return []Edit{nodeEdit(fset, call, text)}, nil
+}
+
+type simplification struct {
+	pattern *regexp.Regexp
+	match   func(ast.Node) bool
+}
+
+func isNode(types ...ast.Node) func(ast.Node) bool {
+	return func(node ast.Node) bool {
+		for _, t := range types {
+			if reflect.TypeOf(t) == reflect.TypeOf(node) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Simplifications reported by gosimple whose message contains the complete
+// replacement source for the reported node, in the first submatch.
+var simplifications = []simplification{
+	{
+		pattern: regexp.MustCompile(`^should omit comparison to bool constant, can be simplified to (.+)$`),
+		match:   isNode(&ast.BinaryExpr{}),
+	},
+	{
+		pattern: regexp.MustCompile(`^should use (.+\.(?:String|Bytes)\(\)) instead of .+$`),
+		match:   isNode(&ast.CallExpr{}),
+	},
+	{
+		pattern: regexp.MustCompile(`^should use (!?(?:strings|bytes)\.\w+\(.*\)) instead$`),
+		match:   isNode(&ast.BinaryExpr{}, &ast.CallExpr{}),
+	},
+	{
+		pattern: regexp.MustCompile(`^should use (copy\(.*\)) instead$`),
+		match:   isNode(&ast.ForStmt{}),
+	},
+	{
+		pattern: regexp.MustCompile(`^should write (.+) instead of .+$`),
+		match:   isNode(&ast.AssignStmt{}, &ast.IncDecStmt{}),
+	},
+}
+
+// fixSimplification applies gosimple suggestions which spell out their
+// replacement in full.
+func fixSimplification(issue *Issue, src []byte) ([]Edit, error) {
+	for _, s := range simplifications {
+		match := s.pattern.FindStringSubmatch(issue.Message)
+		if match == nil {
+			continue
+		}
+		fset, file, pos, err := parseIssueFile(issue, src)
+		if err != nil {
+			return nil, err
+		}
+		node := innermostNode(file, pos, s.match)
+		if node == nil {
+			return nil, fmt.Errorf("no node found for %q", issue.Message)
+		}
+		return []Edit{nodeEdit(fset, node, match[1])}, nil
+	}
+	return nil, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maybeSuggestFixes(ws *workspace, issues chan *Issue) chan *Issue {
+	if !config.Fix && !config.SuggestFixes {
+		return issues
+	}
+	return suggestFixesIssueChan(ws, issues)
+}
+
+func maybeApplyFixes(issues chan *Issue) chan *Issue {
+	if !config.Fix {
+		return issues
+	}
+	return applyFixesIssueChan(issues)
+}
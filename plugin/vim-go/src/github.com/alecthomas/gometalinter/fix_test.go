@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyEdits(t *testing.T) {
+	src := []byte("abcdefghij")
+	first := &Issue{Edits: []Edit{{Offset: 0, Length: 2, Text: "AB"}, {Offset: 8, Length: 2, Text: "IJ"}}}
+	duplicate := &Issue{Edits: []Edit{{Offset: 0, Length: 2, Text: "AB"}}}
+	conflict := &Issue{Edits: []Edit{{Offset: 4, Length: 1, Text: "E"}, {Offset: 9, Length: 1, Text: "x"}}}
+	insert := &Issue{Edits: []Edit{{Offset: 5, Length: 0, Text: "-"}}}
+
+	out, applied, skipped := applyEdits(src, []*Issue{first, duplicate, conflict, insert})
+	assert.Equal(t, "ABcde-fghIJ", string(out))
+	assert.Equal(t, []*Issue{first, duplicate, insert}, applied)
+	assert.Equal(t, []*Issue{conflict}, skipped)
+}
+
+func TestApplyFixesKeepsFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on windows")
+	}
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	path := filepath.Join(tmpdir, "file.go")
+	require.NoError(t, ioutil.WriteFile(path, []byte("abcdefghij"), 0600))
+	require.NoError(t, os.Chmod(path, 0750))
+	issue := &Issue{Edits: []Edit{{Offset: 0, Length: 2, Text: "AB"}}}
+
+	assert.Empty(t, applyFixes(path, []*Issue{issue}))
+	out, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ABcdefghij", string(out))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+}
+
+func TestEditsFromUnifiedDiff(t *testing.T) {
+	src := []byte("package foo\nfunc  A() {\nx:=1\n_ = x\n}\n")
+	diff := []byte(`diff /tmp/f.go.orig /tmp/f.go
+--- /tmp/f.go.orig
++++ /tmp/f.go
+@@ -1,5 +1,6 @@
+ package foo
+-func  A() {
+-x:=1
+-_ = x
++
++func A() {
++	x := 1
++	_ = x
+ }
+`)
+	edits, err := editsFromUnifiedDiff(src, diff)
+	require.NoError(t, err)
+	expected := []Edit{{Offset: 12, Length: 23, Text: "\nfunc A() {\n\tx := 1\n\t_ = x\n"}}
+	assert.Equal(t, expected, edits)
+
+	out, _, _ := applyEdits(src, []*Issue{{Edits: edits}})
+	assert.Equal(t, "package foo\n\nfunc A() {\n\tx := 1\n\t_ = x\n}\n", string(out))
+}
+
+func TestFixMisspelling(t *testing.T) {
+	src := []byte("package foo\n\n// Teh comment\n")
+	issue := &Issue{Line: 3, Col: 3, Message: `"Teh" is a misspelling of "The"`}
+	edits, err := fixMisspelling(issue, src)
+	require.NoError(t, err)
+	assert.Equal(t, []Edit{{Offset: 16, Length: 3, Text: "The"}}, edits)
+}
+
+func TestFixUnnecessaryConversion(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	src := []byte("package foo\n\nvar a, b int\nvar c = int(a+b) * 2\n")
+	mkFile(t, tmpdir, "file.go", string(src))
+	issue := &Issue{Path: newIssuePath(tmpdir, "file.go"), Line: 4, Col: 12}
+	edits, err := fixUnnecessaryConversion(issue, src)
+	require.NoError(t, err)
+	out, _, _ := applyEdits(src, []*Issue{{Edits: edits}})
+	assert.Equal(t, "package foo\n\nvar a, b int\nvar c = (a+b) * 2\n", string(out))
+}
+
+func TestFixSimplification(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+
+	src := []byte("package foo\n\nfunc f(ok, other bool) bool {\n\treturn ok == true && other\n}\n")
+	mkFile(t, tmpdir, "file.go", string(src))
+	issue := &Issue{
+		Path:    newIssuePath(tmpdir, "file.go"),
+		Line:    4,
+		Col:     9,
+		Message: "should omit comparison to bool constant, can be simplified to ok",
+	}
+	edits, err := fixSimplification(issue, src)
+	require.NoError(t, err)
+	out, _, _ := applyEdits(src, []*Issue{{Edits: edits}})
+	assert.Equal(t, "package foo\n\nfunc f(ok, other bool) bool {\n\treturn ok && other\n}\n", string(out))
+
+	issue.Message = "something else entirely"
+	edits, err = fixSimplification(issue, src)
+	require.NoError(t, err)
+	assert.Empty(t, edits)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/gometalinter/analysis"
+	"golang.org/x/tools/go/loader"
+	kingpin "gopkg.in/alecthomas/kingpin.v3-unstable"
+)
+
+// analyzer returns the in-process implementation of the linter, or nil if
+// it should be run as a subprocess. Built-in linters whose command has been
+// overridden are always run as subprocesses.
+func (l *linterState) analyzer() analysis.Analyzer {
+	if !l.config.InProcess {
+		return nil
+	}
+	analyzer, ok := analysis.Lookup(l.Name)
+	if !ok {
+		return nil
+	}
+	if linter, ok := defaultLinters[l.Name]; ok && l.Command != linter.Command {
+		return nil
+	}
+	return analyzer
+}
+
+// sharedProgram loads a Program the first time it is required.
+type sharedProgram struct {
+	ws    *workspace
+	paths []string
+	tests bool
+
+	once    sync.Once
+	program *analysis.Program
+	err     error
+}
+
+func (s *sharedProgram) Load() (*analysis.Program, error) {
+	s.once.Do(func() {
+		start := time.Now()
+		s.program, s.err = loadProgram(s.ws, s.paths, s.tests)
+		debug("loaded packages for in-process linters in %s", time.Since(start))
+	})
+	return s.program, s.err
+}
+
+// loadProgram parses and type-checks the packages in paths, which may be
+// directories or individual files relative to the workspace. Packages with
+// unsaved changes are loaded from their temporary copies. Type errors are
+// ignored, as they are reported by the gotype linter.
+func loadProgram(ws *workspace, paths []string, tests bool) (*analysis.Program, error) {
+	ctx := ws.buildContext()
+	conf := &loader.Config{
+		Build:       ctx,
+		ParserMode:  parser.ParseComments,
+		AllowErrors: true,
+	}
+	conf.TypeChecker.Error = func(error) {}
+
+	dirs := []string{}
+	files := map[string][]string{}
+	for _, path := range paths {
+		path = ws.overlay.Copy(ws.abs(path))
+		dir := path
+		if strings.HasSuffix(path, ".go") {
+			dir = filepath.Dir(path)
+		}
+		if _, ok := files[dir]; !ok {
+			dirs = append(dirs, dir)
+			files[dir] = nil
+		}
+		if dir != path {
+			files[dir] = append(files[dir], path)
+		}
+	}
+
+	created := map[string]string{}
+	for _, dir := range dirs {
+		pkg, err := ctx.ImportDir(dir, 0)
+		if _, ok := err.(*build.NoGoError); ok {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		importPath := pkg.ImportPath
+		if importPath == "." {
+			importPath = dir
+		}
+
+		filenames := files[dir]
+		xtests := []string{}
+		if filenames == nil {
+			filenames = joinPaths(dir, pkg.GoFiles, pkg.CgoFiles)
+			if tests {
+				filenames = append(filenames, joinPaths(dir, pkg.TestGoFiles)...)
+				xtests = joinPaths(dir, pkg.XTestGoFiles)
+			}
+		}
+		conf.CreateFromFilenames(importPath, filenames...)
+		created[importPath] = dir
+		if len(xtests) > 0 {
+			conf.CreateFromFilenames(importPath+"_test", xtests...)
+			created[importPath+"_test"] = dir
+		}
+	}
+	if len(created) == 0 {
+		return &analysis.Program{Fset: token.NewFileSet()}, nil
+	}
+
+	prog, err := conf.Load()
+	if err != nil {
+		return nil, err
+	}
+	program := &analysis.Program{Fset: prog.Fset, Loader: prog, Config: conf}
+	for _, info := range prog.Created {
+		program.Packages = append(program.Packages, &analysis.Package{
+			Dir:    created[info.Pkg.Path()],
+			Files:  info.Files,
+			Types:  info.Pkg,
+			Info:   &info.Info,
+			Loader: info,
+		})
+	}
+	return program, nil
+}
+
+func joinPaths(dir string, names ...[]string) []string {
+	out := []string{}
+	for _, group := range names {
+		for _, name := range group {
+			out = append(out, filepath.Join(dir, name))
+		}
+	}
+	return out
+}
+
+func executeAnalyzer(id int, state *linterState, analyzer analysis.Analyzer) error {
+	start := time.Now()
+	dbg := namespacedDebug(fmt.Sprintf("[%s.%d]: ", state.Name, id))
+	dbg("running in-process")
+	record := stats.Start(state.Name, state.program.paths)
+	if record != nil {
+		record.InProcess = true
+	}
+
+	type result struct {
+		problems []analysis.Problem
+		err      error
+	}
+	done := make(chan result, 1)
+	cancel := make(chan struct{})
+	go func() {
+		var r result
+		defer func() {
+			if p := recover(); p != nil {
+				r.err = fmt.Errorf("panic: %v", p)
+			}
+			done <- r
+		}()
+		program, err := state.program.Load()
+		if err != nil {
+			r.err = err
+			return
+		}
+		options := analysis.Options{MinConfidence: state.config.MinConfidence}
+		r.problems, r.err = analyzer.Analyze(program, options, cancel)
+	}()
+
+	var r result
+	select {
+	case r = <-done:
+		record.Finish(nil)
+
+	case <-state.deadline:
+		// The analyzer stops at its next cancellation check, and its result
+		// is discarded.
+		close(cancel)
+		if record != nil {
+			record.DeadlineExceeded = true
+			record.Finish(nil)
+		}
+		return fmt.Errorf("deadline exceeded by linter %s (try increasing --deadline)",
+			state.Name)
+	}
+	if r.err != nil {
+		return fmt.Errorf("in-process linter %s failed: %s", state.Name, r.err)
+	}
+
+	processProblems(dbg, state, r.problems, record)
+	dbg("%s linter took %s", state.Name, time.Since(start))
+	return nil
+}
+
+func processProblems(dbg debugFunction, state *linterState, problems []analysis.Problem, record *linterStats) {
+	dbg("%s hits %d", state.Name, len(problems))
+
+	for _, problem := range problems {
+		issue, err := NewIssue(state.Linter.Name, config.formatTemplate)
+		kingpin.FatalIfError(err, "Invalid output format")
+
+		issue.Path, err = newIssuePathFromAbsPath(state.ws.dir, state.ws.abs(problem.Position.Filename))
+		if err != nil {
+			warning("failed to make %s a relative path: %s", problem.Position.Filename, err)
+		}
+		issue.Line = problem.Position.Line
+		issue.Col = problem.Position.Column
+		issue.Message = problem.Message
+
+		vars := state.vars.Copy()
+		vars["path"] = problem.Position.Filename
+		vars["line"] = fmt.Sprintf("%d", issue.Line)
+		vars["col"] = fmt.Sprintf("%d", issue.Col)
+		vars["message"] = issue.Message
+		record.countIssue(issue)
+		emitIssue(state, vars, issue)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/gometalinter/analysis"
+	honnef "honnef.co/go/tools/lint"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProgram(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+
+	mkFile(t, tmpdir, "file.go", "package foo\n\nfunc Foo() int { return 1 }\n")
+	mkFile(t, tmpdir, "file_test.go", "package foo\n")
+	mkFile(t, tmpdir, "external_test.go", "package foo_test\n")
+
+	program, err := loadProgram(ws, []string{"."}, false)
+	require.NoError(t, err)
+	require.Len(t, program.Packages, 1)
+	pkg := program.Packages[0]
+	assert.Len(t, pkg.Files, 1)
+	assert.NotNil(t, pkg.Types.Scope().Lookup("Foo"))
+	assert.NotEmpty(t, pkg.Info.Defs)
+
+	program, err = loadProgram(ws, []string{"."}, true)
+	require.NoError(t, err)
+	require.Len(t, program.Packages, 2)
+	assert.Len(t, program.Packages[0].Files, 2)
+	assert.Equal(t, "foo_test", program.Packages[1].Types.Name())
+
+	program, err = loadProgram(ws, []string{"file.go"}, true)
+	require.NoError(t, err)
+	require.Len(t, program.Packages, 1)
+	assert.Len(t, program.Packages[0].Files, 1)
+}
+
+func TestErrcheckAnalyzer(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+
+	mkFile(t, tmpdir, "file.go", `package foo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+func Foo(f *os.File) {
+	defer f.Close()
+	os.Remove("a")
+	_ = os.Remove("b")
+	if err := os.Remove("c"); err != nil {
+		return
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteString("d")
+	fmt.Println("e")
+	go f.Sync()
+}
+`)
+	program, err := loadProgram(ws, []string{"."}, false)
+	require.NoError(t, err)
+
+	problems, err := errcheckAnalyzer{}.Analyze(program, analysis.Options{}, nil)
+	require.NoError(t, err)
+	actual := []string{}
+	for _, problem := range problems {
+		assert.Equal(t, filepath.Join(tmpdir, "file.go"), problem.Position.Filename)
+		actual = append(actual, problem.Message)
+	}
+	assert.Equal(t, []string{`defer f.Close()`, `os.Remove("a")`, `go f.Sync()`}, actual)
+}
+
+func TestGolintAnalyzer(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+
+	mkFile(t, tmpdir, "file.go", "package foo\n\nfunc Foo() {}\n")
+	program, err := loadProgram(ws, []string{"."}, false)
+	require.NoError(t, err)
+
+	problems, err := golintAnalyzer{}.Analyze(program, analysis.Options{MinConfidence: 0.8}, nil)
+	require.NoError(t, err)
+	actual := []string{}
+	for _, problem := range problems {
+		actual = append(actual, problem.Message)
+	}
+	assert.Contains(t, actual, "exported function Foo should have comment or be unexported")
+}
+
+func TestAnalyzersStopWhenCanceled(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	ws := &workspace{dir: tmpdir}
+
+	mkFile(t, tmpdir, "file.go", "package foo\n")
+	program, err := loadProgram(ws, []string{"."}, false)
+	require.NoError(t, err)
+
+	cancel := make(chan struct{})
+	close(cancel)
+	for _, name := range analysis.Names() {
+		analyzer, _ := analysis.Lookup(name)
+		_, err := analyzer.Analyze(program, analysis.Options{}, cancel)
+		assert.Equal(t, analysis.ErrCanceled, err, name)
+	}
+}
+
+func TestLinterStateAnalyzer(t *testing.T) {
+	state := &linterState{
+		Linter: getLinterByName("errcheck", LinterConfig{}),
+		config: &Config{InProcess: true},
+	}
+	assert.NotNil(t, state.analyzer())
+
+	state.config.InProcess = false
+	assert.Nil(t, state.analyzer())
+
+	state.config.InProcess = true
+	state.Linter = getLinterByName("errcheck", LinterConfig{Command: "errcheck -blank"})
+	assert.Nil(t, state.analyzer())
+
+	state.Linter = getLinterByName("vet", LinterConfig{})
+	assert.Nil(t, state.analyzer())
+}
+
+type testAnalyzer struct{}
+
+func (testAnalyzer) Analyze(program *analysis.Program, options analysis.Options, cancel <-chan struct{}) ([]analysis.Problem, error) {
+	return nil, nil
+}
+
+func TestLinterStateRegisteredAnalyzer(t *testing.T) {
+	analysis.Register("test-analyzer", testAnalyzer{})
+	state := &linterState{
+		Linter: getLinterByName("test-analyzer", LinterConfig{Command: "test-analyzer"}),
+		config: &Config{InProcess: true},
+	}
+	assert.Equal(t, testAnalyzer{}, state.analyzer())
+	assert.Panics(t, func() { analysis.Register("test-analyzer", testAnalyzer{}) })
+}
+
+type countingChecker struct {
+	calls int
+}
+
+func (c *countingChecker) Name() string         { return "counting" }
+func (c *countingChecker) Prefix() string       { return "C" }
+func (c *countingChecker) Init(*honnef.Program) {}
+func (c *countingChecker) Funcs() map[string]honnef.Func {
+	return map[string]honnef.Func{"C1000": func(*honnef.Job) { c.calls++ }}
+}
+
+func TestCancelableCheckerSkipsChecks(t *testing.T) {
+	counting := &countingChecker{}
+	cancel := make(chan struct{})
+	checker := &cancelableChecker{Checker: counting, cancel: cancel}
+
+	checker.Funcs()["C1000"](nil)
+	assert.Equal(t, 1, counting.calls)
+
+	close(cancel)
+	checker.Funcs()["C1000"](nil)
+	assert.Equal(t, 1, counting.calls)
+}
@@ -76,7 +76,15 @@ type Issue struct {
 	Line       int       `json:"line"`
 	Col        int       `json:"col"`
 	Message    string    `json:"message"`
+	Edits      []Edit    `json:"edits,omitempty"`
 	formatTmpl *template.Template
+
+	// aggregatedLinters holds the individual linters that reported this issue
+	// when it was produced by AggregateIssueChan.
+	aggregatedLinters []string
+	// stats records the linter executions which produced this issue, when
+	// --stats is enabled. Aggregated issues have one per linter.
+	stats []*linterStats
 }
 
 // NewIssue returns a new issue. Returns an error if formatTmpl is not a valid
@@ -92,6 +100,14 @@ func NewIssue(linter string, formatTmpl *template.Template) (*Issue, error) {
 	return issue, err
 }
 
+// linterNames returns the individual linters that reported the issue.
+func (i *Issue) linterNames() []string {
+	if len(i.aggregatedLinters) > 0 {
+		return i.aggregatedLinters
+	}
+	return []string{i.Linter}
+}
+
 func (i *Issue) String() string {
 	if i.formatTmpl == nil {
 		col := ""
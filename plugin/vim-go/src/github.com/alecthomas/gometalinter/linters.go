@@ -17,6 +17,11 @@ type LinterConfig struct {
 	InstallFrom       string
 	PartitionStrategy partitionStrategy
 	IsFast            bool
+	// Glob patterns of additional files whose content affects the linter's
+	// results, and so must invalidate cached results when they change.
+	// "{imports}" matches the Go files of the packages imported by the
+	// linted packages, for linters which type check them.
+	CacheDependencies []string
 	defaultEnabled    bool
 }
 
@@ -71,6 +76,9 @@ func getLinterByName(name string, overrideConf LinterConfig) *Linter {
 	if val := overrideConf.PartitionStrategy; val != nil {
 		conf.PartitionStrategy = val
 	}
+	if val := overrideConf.CacheDependencies; val != nil {
+		conf.CacheDependencies = val
+	}
 
 	linter, _ := NewLinter(name, conf)
 	return linter
@@ -208,6 +216,7 @@ var defaultLinters = map[string]LinterConfig{
 		Pattern:           `^(?:[^:]+: )?(?P<path>.*?\.go):(?P<line>\d+):(?P<col>\d+):\s*(?P<message>.+)$`,
 		InstallFrom:       "github.com/mdempsky/maligned",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 	},
 	"deadcode": {
@@ -229,6 +238,7 @@ var defaultLinters = map[string]LinterConfig{
 		Pattern:           `PATH:LINE:COL:MESSAGE`,
 		InstallFrom:       "github.com/kisielk/errcheck",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 	},
 	"gas": {
@@ -236,6 +246,7 @@ var defaultLinters = map[string]LinterConfig{
 		Pattern:           `^(?P<path>.*?\.go),(?P<line>\d+),(?P<message>[^,]+,[^,]+,[^,]+)`,
 		InstallFrom:       "github.com/GoASTScanner/gas",
 		PartitionStrategy: partitionPathsAsFiles,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 		IsFast:            true,
 	},
@@ -273,6 +284,7 @@ var defaultLinters = map[string]LinterConfig{
 		Pattern:           `PATH:LINE:COL:MESSAGE`,
 		InstallFrom:       "github.com/golang/lint/golint",
 		PartitionStrategy: partitionPathsAsDirectories,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 		IsFast:            true,
 	},
@@ -281,12 +293,14 @@ var defaultLinters = map[string]LinterConfig{
 		Pattern:           `PATH:LINE:COL:MESSAGE`,
 		InstallFrom:       "honnef.co/go/tools/cmd/gosimple",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 	},
 	"gotype": {
 		Command:           `gotype -e {tests=-t}`,
 		Pattern:           `PATH:LINE:COL:MESSAGE`,
 		InstallFrom:       "golang.org/x/tools/cmd/gotype",
 		PartitionStrategy: partitionPathsByDirectory,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 		IsFast:            true,
 	},
@@ -295,6 +309,7 @@ var defaultLinters = map[string]LinterConfig{
 		Pattern:           `PATH:LINE:COL:MESSAGE`,
 		InstallFrom:       "golang.org/x/tools/cmd/gotype",
 		PartitionStrategy: partitionPathsByDirectory,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 		IsFast:            true,
 	},
@@ -311,6 +326,7 @@ var defaultLinters = map[string]LinterConfig{
 		Pattern:           `PATH:LINE:COL:MESSAGE`,
 		InstallFrom:       "mvdan.cc/interfacer",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 	},
 	"lll": {
@@ -325,6 +341,7 @@ var defaultLinters = map[string]LinterConfig{
 		Pattern:           `PATH:LINE:COL:MESSAGE`,
 		InstallFrom:       "honnef.co/go/tools/cmd/megacheck",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 	},
 	"misspell": {
@@ -345,35 +362,41 @@ var defaultLinters = map[string]LinterConfig{
 		Pattern:           `^- (?P<path>.*?\.go):(?P<line>\d+):(?P<col>\d+)$`,
 		InstallFrom:       "github.com/stripe/safesql",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 	},
 	"staticcheck": {
 		Command:           `staticcheck`,
 		Pattern:           `PATH:LINE:COL:MESSAGE`,
 		InstallFrom:       "honnef.co/go/tools/cmd/staticcheck",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 	},
 	"structcheck": {
 		Command:           `structcheck {tests=-t}`,
 		Pattern:           `^(?:[^:]+: )?(?P<path>.*?\.go):(?P<line>\d+):(?P<col>\d+):\s*(?P<message>.+)$`,
 		InstallFrom:       "github.com/opennota/check/cmd/structcheck",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 	},
 	"test": {
 		Command:           `go test`,
 		Pattern:           `^--- FAIL: .*$\s+(?P<path>.*?\.go):(?P<line>\d+): (?P<message>.*)$`,
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 	},
 	"testify": {
 		Command:           `go test`,
 		Pattern:           `Location:\s+(?P<path>.*?\.go):(?P<line>\d+)$\s+Error:\s+(?P<message>[^\n]+)`,
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 	},
 	"unconvert": {
 		Command:           `unconvert`,
 		Pattern:           `PATH:LINE:COL:MESSAGE`,
 		InstallFrom:       "github.com/mdempsky/unconvert",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 	},
 	"unparam": {
@@ -381,24 +404,28 @@ var defaultLinters = map[string]LinterConfig{
 		Pattern:           `PATH:LINE:COL:MESSAGE`,
 		InstallFrom:       "mvdan.cc/unparam",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 	},
 	"unused": {
 		Command:           `unused`,
 		Pattern:           `PATH:LINE:COL:MESSAGE`,
 		InstallFrom:       "honnef.co/go/tools/cmd/unused",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 	},
 	"varcheck": {
 		Command:           `varcheck`,
 		Pattern:           `^(?:[^:]+: )?(?P<path>.*?\.go):(?P<line>\d+):(?P<col>\d+):\s*(?P<message>.*)$`,
 		InstallFrom:       "github.com/opennota/check/cmd/varcheck",
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 	},
 	"vet": {
 		Command:           `go vet`,
 		Pattern:           vetPattern,
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 		IsFast:            true,
 	},
@@ -406,6 +433,7 @@ var defaultLinters = map[string]LinterConfig{
 		Command:           `go vet --shadow`,
 		Pattern:           vetPattern,
 		PartitionStrategy: partitionPathsAsPackages,
+		CacheDependencies: []string{cacheImports},
 		defaultEnabled:    true,
 		IsFast:            true,
 	},
@@ -32,16 +32,40 @@ var (
 	date    = ""
 )
 
+// commandLine holds the arguments gometalinter was run with. They are
+// parsed again over the config merged for each subdirectory, so that the
+// command line takes precedence over every config file.
+var commandLine []string
+
+// searchConfigFile is true if no config file was given on the command line,
+// so the config file of the working directory, or of its nearest parent, is
+// loaded. Requests in server mode search from their own directory instead.
+var searchConfigFile bool
+
+func setupCommands(app *kingpin.Application) (serveCmd *kingpin.CmdClause, pathsArg *[]string, socketFlag *string) {
+	lintCmd := app.Command("lint", "Lint Go source code.").Default()
+	pathsArg = lintCmd.Arg("path", "Directories to lint. Defaults to \".\". <path>/... will recurse.").Strings()
+	serveCmd = app.Command("serve", "Lint files on request from editors, over a unix socket.")
+	socketFlag = serveCmd.Flag("socket", "Path of the unix socket to listen on.").Default(defaultSocketPath()).String()
+	return serveCmd, pathsArg, socketFlag
+}
+
 func setupFlags(app *kingpin.Application) {
+	bindFlags(app, config, loadConfig)
+}
+
+// bindFlags defines the flags of gometalinter, storing their values in
+// config. loadConfig is the action of --config.
+func bindFlags(app *kingpin.Application, config *Config, loadConfig kingpin.Action) {
 	app.Flag("config", "Load JSON configuration from file.").Envar("GOMETALINTER_CONFIG").Action(loadConfig).String()
-	app.Flag("no-config", "Disable automatic loading of config file.").Bool()
-	app.Flag("disable", "Disable previously enabled linters.").PlaceHolder("LINTER").Short('D').Action(disableAction).Strings()
-	app.Flag("enable", "Enable previously disabled linters.").PlaceHolder("LINTER").Short('E').Action(enableAction).Strings()
-	app.Flag("linter", "Define a linter.").PlaceHolder("NAME:COMMAND:PATTERN").Action(cliLinterOverrides).StringMap()
+	app.Flag("no-config", "Disable automatic loading of config files.").BoolVar(&config.NoConfig)
+	app.Flag("disable", "Disable previously enabled linters.").PlaceHolder("LINTER").Short('D').Action(disableAction(config)).Strings()
+	app.Flag("enable", "Enable previously disabled linters.").PlaceHolder("LINTER").Short('E').Action(enableAction(config)).Strings()
+	app.Flag("linter", "Define a linter.").PlaceHolder("NAME:COMMAND:PATTERN").Action(cliLinterOverrides(config)).StringMap()
 	app.Flag("message-overrides", "Override message from linter. {message} will be expanded to the original message.").PlaceHolder("LINTER:MESSAGE").StringMapVar(&config.MessageOverride)
 	app.Flag("severity", "Map of linter severities.").PlaceHolder("LINTER:SEVERITY").StringMapVar(&config.Severity)
-	app.Flag("disable-all", "Disable all linters.").Action(disableAllAction).Bool()
-	app.Flag("enable-all", "Enable all linters.").Action(enableAllAction).Bool()
+	app.Flag("disable-all", "Disable all linters.").Action(disableAllAction(config)).Bool()
+	app.Flag("enable-all", "Enable all linters.").Action(enableAllAction(config)).Bool()
 	app.Flag("format", "Output format.").PlaceHolder(config.Format).StringVar(&config.Format)
 	app.Flag("vendored-linters", "Use vendored linters (recommended) (DEPRECATED - use binary packages).").BoolVar(&config.VendoredLinters)
 	app.Flag("fast", "Only run fast linters.").BoolVar(&config.Fast)
@@ -68,26 +92,44 @@ func setupFlags(app *kingpin.Application) {
 	app.Flag("errors", "Only show errors.").BoolVar(&config.Errors)
 	app.Flag("json", "Generate structured JSON rather than standard line-based output.").BoolVar(&config.JSON)
 	app.Flag("checkstyle", "Generate checkstyle XML rather than standard line-based output.").BoolVar(&config.Checkstyle)
+	app.Flag("sarif", "Generate SARIF 2.1.0 JSON rather than standard line-based output.").BoolVar(&config.SARIF)
 	app.Flag("enable-gc", "Enable GC for linters (useful on large repositories).").BoolVar(&config.EnableGC)
 	app.Flag("aggregate", "Aggregate issues reported by several linters.").BoolVar(&config.Aggregate)
 	app.Flag("warn-unmatched-nolint", "Warn if a nolint directive is not matched with an issue.").BoolVar(&config.WarnUnmatchedDirective)
+	app.Flag("strict-nolint", "Report nolint directives which do not give a reason.").BoolVar(&config.StrictNolint)
+	app.Flag("list-directives", "List all nolint directives with their reasons and exit.").BoolVar(&config.ListDirectives)
+	app.Flag("baseline", "Suppress issues recorded in a baseline file and only report new ones.").PlaceHolder("FILE").StringVar(&config.Baseline)
+	app.Flag("write-baseline", "Record all reported issues in a baseline file.").PlaceHolder("FILE").StringVar(&config.WriteBaseline)
+	app.Flag("warn-stale-baseline", "Warn if a baseline entry is not matched with an issue.").BoolVar(&config.WarnStaleBaseline)
+	app.Flag("new-from-rev", "Only report issues on lines changed since a git revision.").PlaceHolder("REV").StringVar(&config.NewFromRev)
+	app.Flag("no-cache", "Do not cache linter results keyed by the content of the linted files.").BoolVar(&config.NoCache)
+	app.Flag("cache-dir", "Directory in which to cache linter results.").PlaceHolder("DIR").StringVar(&config.CacheDir)
+	app.Flag("clear-cache", "Remove all cached linter results and exit.").BoolVar(&config.ClearCache)
+	app.Flag("suggest-fixes", "Include suggested edits for fixable issues in JSON output.").BoolVar(&config.SuggestFixes)
+	app.Flag("fix", "Apply suggested edits for fixable issues.").BoolVar(&config.Fix)
+	app.Flag("in-process", "Run golint, errcheck and the honnef.co linters in-process, sharing a single load of the packages.").BoolVar(&config.InProcess)
+	app.Flag("stats", "Print the time, memory and issue counts of each linter execution to stderr.").BoolVar(&config.Stats)
+	app.Flag("stats-json", "Write the time, memory and issue counts of each linter execution to a JSON file.").PlaceHolder("FILE").StringVar(&config.StatsJSON)
+	app.Flag("print-effective-config", "Print the configuration that applies to a directory, merged from all config files, and exit.").PlaceHolder("PATH").StringVar(&config.PrintEffectiveConfig)
 	app.GetFlag("help").Short('h')
 }
 
-func cliLinterOverrides(app *kingpin.Application, element *kingpin.ParseElement, ctx *kingpin.ParseContext) error {
-	// expected input structure - <name>:<command-spec>
-	parts := strings.SplitN(*element.Value, ":", 2)
-	if len(parts) < 2 {
-		return fmt.Errorf("incorrectly formatted input: %s", *element.Value)
-	}
-	name := parts[0]
-	spec := parts[1]
-	conf, err := parseLinterConfigSpec(name, spec)
-	if err != nil {
-		return fmt.Errorf("incorrectly formatted input: %s", *element.Value)
+func cliLinterOverrides(config *Config) kingpin.Action {
+	return func(app *kingpin.Application, element *kingpin.ParseElement, ctx *kingpin.ParseContext) error {
+		// expected input structure - <name>:<command-spec>
+		parts := strings.SplitN(*element.Value, ":", 2)
+		if len(parts) < 2 {
+			return fmt.Errorf("incorrectly formatted input: %s", *element.Value)
+		}
+		name := parts[0]
+		spec := parts[1]
+		conf, err := parseLinterConfigSpec(name, spec)
+		if err != nil {
+			return fmt.Errorf("incorrectly formatted input: %s", *element.Value)
+		}
+		config.Linters[name] = StringOrLinterConfig(conf)
+		return nil
 	}
-	config.Linters[name] = StringOrLinterConfig(conf)
-	return nil
 }
 
 func loadDefaultConfig(app *kingpin.Application, element *kingpin.ParseElement, ctx *kingpin.ParseContext) error {
@@ -101,7 +143,12 @@ func loadDefaultConfig(app *kingpin.Application, element *kingpin.ParseElement,
 		}
 	}
 
-	configFile, found, err := findDefaultConfigFile()
+	searchConfigFile = true
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	configFile, found, err := findDefaultConfigFile(cwd)
 	if err != nil || !found {
 		return err
 	}
@@ -113,33 +160,56 @@ func loadConfig(app *kingpin.Application, element *kingpin.ParseElement, ctx *ki
 	return loadConfigFile(*element.Value)
 }
 
-func disableAction(app *kingpin.Application, element *kingpin.ParseElement, ctx *kingpin.ParseContext) error {
-	out := []string{}
-	for _, linter := range config.Enable {
-		if linter != *element.Value {
-			out = append(out, linter)
+// applyCommandLine parses the command line again over config. --config is
+// skipped, the file it names is already part of the base config.
+func applyCommandLine(config *Config) error {
+	if commandLine == nil {
+		return nil
+	}
+	app := kingpin.New("gometalinter", "")
+	setupCommands(app)
+	bindFlags(app, config, func(*kingpin.Application, *kingpin.ParseElement, *kingpin.ParseContext) error {
+		return nil
+	})
+	_, err := app.Parse(commandLine)
+	return err
+}
+
+func disableAction(config *Config) kingpin.Action {
+	return func(app *kingpin.Application, element *kingpin.ParseElement, ctx *kingpin.ParseContext) error {
+		out := []string{}
+		for _, linter := range config.Enable {
+			if linter != *element.Value {
+				out = append(out, linter)
+			}
 		}
+		config.Enable = out
+		return nil
 	}
-	config.Enable = out
-	return nil
 }
 
-func enableAction(app *kingpin.Application, element *kingpin.ParseElement, ctx *kingpin.ParseContext) error {
-	config.Enable = append(config.Enable, *element.Value)
-	return nil
+func enableAction(config *Config) kingpin.Action {
+	return func(app *kingpin.Application, element *kingpin.ParseElement, ctx *kingpin.ParseContext) error {
+		config.Enable = append(config.Enable, *element.Value)
+		return nil
+	}
 }
 
-func disableAllAction(app *kingpin.Application, element *kingpin.ParseElement, ctx *kingpin.ParseContext) error {
-	config.Enable = []string{}
-	return nil
+func disableAllAction(config *Config) kingpin.Action {
+	return func(app *kingpin.Application, element *kingpin.ParseElement, ctx *kingpin.ParseContext) error {
+		config.Enable = []string{}
+		return nil
+	}
 }
 
-func enableAllAction(app *kingpin.Application, element *kingpin.ParseElement, ctx *kingpin.ParseContext) error {
-	for linter := range defaultLinters {
-		config.Enable = append(config.Enable, linter)
+func enableAllAction(config *Config) kingpin.Action {
+	return func(app *kingpin.Application, element *kingpin.ParseElement, ctx *kingpin.ParseContext) error {
+		for linter := range defaultLinters {
+			config.Enable = append(config.Enable, linter)
+		}
+		config.EnableAll = true
+		return nil
 	}
-	config.EnableAll = true
-	return nil
 }
 
 type debugFunction func(format string, args ...interface{})
@@ -195,8 +265,8 @@ func formatSeverity() string {
 
 func main() {
 	kingpin.Version(fmt.Sprintf("gometalinter version %s built from %s on %s", version, commit, date))
-	pathsArg := kingpin.Arg("path", "Directories to lint. Defaults to \".\". <path>/... will recurse.").Strings()
 	app := kingpin.CommandLine
+	serveCmd, pathsArg, socketFlag := setupCommands(app)
 	app.Action(loadDefaultConfig)
 	setupFlags(app)
 	app.Help = fmt.Sprintf(`Aggregate and normalise the output of a whole bunch of Go linters.
@@ -209,7 +279,8 @@ Severity override map (default is "warning"):
 
 %s
 `, formatLinters(), formatSeverity())
-	kingpin.Parse()
+	command := kingpin.Parse()
+	commandLine = os.Args[1:]
 
 	if config.Install {
 		if config.VendoredLinters {
@@ -219,22 +290,53 @@ Severity override map (default is "warning"):
 		return
 	}
 
+	if config.ClearCache {
+		err := newResultCache(cacheDirFromConfig(config)).Clear()
+		kingpin.FatalIfError(err, "failed to clear cache")
+		return
+	}
+
+	if config.PrintEffectiveConfig != "" {
+		kingpin.FatalIfError(printEffectiveConfig(config, config.PrintEffectiveConfig), "")
+		return
+	}
+
 	configureEnvironment()
-	include, exclude := processConfig(config)
+	processConfig(config)
+
+	if command == serveCmd.FullCommand() {
+		kingpin.FatalIfError(serve(*socketFlag), "")
+		return
+	}
 
 	start := time.Now()
-	paths := resolvePaths(*pathsArg, config.Skip)
+	ws, err := newWorkspace()
+	kingpin.FatalIfError(err, "")
+	paths := resolvePaths(ws.dir, *pathsArg, config.Skip)
+
+	if config.ListDirectives {
+		kingpin.FatalIfError(outputDirectives(ws, paths, config.JSON), "")
+		return
+	}
 
-	linters := lintersFromConfig(config)
-	err := validateLinters(linters, config)
+	groups, err := newLinterGroups(config, ws.dir, paths)
 	kingpin.FatalIfError(err, "")
+	for _, group := range groups {
+		err := validateLinters(group.linters, group.config)
+		kingpin.FatalIfError(err, "")
+	}
 
-	issues, errch := runLinters(linters, paths, config.Concurrency, exclude, include)
+	if config.Stats || config.StatsJSON != "" {
+		stats = &statsCollector{}
+	}
+	issues, errch := runLinters(ws, groups, config.Concurrency)
 	status := 0
 	if config.JSON {
 		status |= outputToJSON(issues)
 	} else if config.Checkstyle {
 		status |= outputToCheckstyle(issues)
+	} else if config.SARIF {
+		status |= outputToSARIF(issues)
 	} else {
 		status |= outputToConsole(issues)
 	}
@@ -242,13 +344,14 @@ Severity override map (default is "warning"):
 		warning("%s", err)
 		status |= 2
 	}
+	outputStats()
 	elapsed := time.Since(start)
 	debug("total elapsed time %s", elapsed)
 	os.Exit(status)
 }
 
 // nolint: gocyclo
-func processConfig(config *Config) (include *regexp.Regexp, exclude *regexp.Regexp) {
+func processConfig(config *Config) {
 	tmpl, err := template.New("output").Parse(config.Format)
 	kingpin.FatalIfError(err, "invalid format %q", config.Format)
 	config.formatTemplate = tmpl
@@ -271,6 +374,11 @@ func processConfig(config *Config) (include *regexp.Regexp, exclude *regexp.Rege
 		config.Skip = append(config.Skip, "vendor")
 		config.Vendor = true
 	}
+
+	runtime.GOMAXPROCS(config.Concurrency)
+}
+
+func compileIssueFilters(config *Config) (include *regexp.Regexp, exclude *regexp.Regexp) {
 	if len(config.Exclude) > 0 {
 		exclude = regexp.MustCompile(strings.Join(config.Exclude, "|"))
 	}
@@ -278,11 +386,37 @@ func processConfig(config *Config) (include *regexp.Regexp, exclude *regexp.Rege
 	if len(config.Include) > 0 {
 		include = regexp.MustCompile(strings.Join(config.Include, "|"))
 	}
-
-	runtime.GOMAXPROCS(config.Concurrency)
 	return include, exclude
 }
 
+// printEffectiveConfig prints the configuration that applies to path once
+// all config files between the working directory and path are merged.
+func printEffectiveConfig(config *Config, path string) error {
+	effective := config
+	if !config.NoConfig {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		hierarchy, err := newConfigHierarchy(config, cwd)
+		if err != nil {
+			return err
+		}
+		if effective, err = hierarchy.ForPath(path); err != nil {
+			return err
+		}
+		for _, file := range hierarchy.Files(effective) {
+			debug("merged config %s", file)
+		}
+	}
+	d, err := json.MarshalIndent(effective, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", d)
+	return nil
+}
+
 func outputToConsole(issues chan *Issue) int {
 	status := 0
 	for issue := range issues {
@@ -314,7 +448,10 @@ func outputToJSON(issues chan *Issue) int {
 	return status
 }
 
-func resolvePaths(paths, skip []string) []string {
+// resolvePaths expands paths ending in /... into the directories below them
+// containing Go files. Relative paths are resolved against dir, and remain
+// relative.
+func resolvePaths(dir string, paths, skip []string) []string {
 	if len(paths) == 0 {
 		return []string{"."}
 	}
@@ -324,11 +461,16 @@ func resolvePaths(paths, skip []string) []string {
 	for _, path := range paths {
 		if strings.HasSuffix(path, "/...") {
 			root := filepath.Dir(path)
-			_ = filepath.Walk(root, func(p string, i os.FileInfo, err error) error {
+			_ = filepath.Walk(absPath(dir, root), func(p string, i os.FileInfo, err error) error {
 				if err != nil {
 					warning("invalid path %q: %s", p, err)
 					return err
 				}
+				if !filepath.IsAbs(root) {
+					if p, err = filepath.Rel(dir, p); err != nil {
+						return err
+					}
+				}
 
 				skip := skipPath(p)
 				switch {
@@ -445,13 +587,17 @@ func findVendoredLinters() string {
 func getGoPath() string {
 	path := os.Getenv("GOPATH")
 	if path == "" {
-		user, err := user.Current()
-		kingpin.FatalIfError(err, "")
-		path = filepath.Join(user.HomeDir, "go")
+		path = filepath.Join(getHomeDir(), "go")
 	}
 	return path
 }
 
+func getHomeDir() string {
+	user, err := user.Current()
+	kingpin.FatalIfError(err, "")
+	return user.HomeDir
+}
+
 func getGoPathList() []string {
 	return strings.Split(getGoPath(), string(os.PathListSeparator))
 }
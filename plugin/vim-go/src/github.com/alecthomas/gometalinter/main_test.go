@@ -41,13 +41,13 @@ func TestRelativePackagePath(t *testing.T) {
 }
 
 func TestResolvePathsNoPaths(t *testing.T) {
-	paths := resolvePaths(nil, nil)
+	paths := resolvePaths(".", nil, nil)
 	assert.Equal(t, []string{"."}, paths)
 }
 
 func TestResolvePathsNoExpands(t *testing.T) {
 	// Non-expanded paths should not be filtered by the skip path list
-	paths := resolvePaths([]string{".", "foo", "foo/bar"}, []string{"foo/bar"})
+	paths := resolvePaths(".", []string{".", "foo", "foo/bar"}, []string{"foo/bar"})
 	expected := []string{".", "./foo", "./foo/bar"}
 	assert.Equal(t, expected, paths)
 }
@@ -68,7 +68,7 @@ func TestResolvePathsWithExpands(t *testing.T) {
 	mkDir(t, tmpdir, "include", "_exclude")
 
 	filterPaths := []string{"exclude", "other/exclude"}
-	paths := resolvePaths([]string{"./...", "foo", "duplicate"}, filterPaths)
+	paths := resolvePaths(".", []string{"./...", "foo", "duplicate"}, filterPaths)
 
 	expected := []string{
 		".",
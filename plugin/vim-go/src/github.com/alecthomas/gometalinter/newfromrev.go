@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	kingpin "gopkg.in/alecthomas/kingpin.v3-unstable"
+)
+
+type changedRange struct {
+	start, end int
+}
+
+func (c *changedRange) matches(issue *Issue) bool {
+	return issue.Line >= c.start && issue.Line <= c.end
+}
+
+func (c *changedRange) String() string {
+	return fmt.Sprintf("%d-%d", c.start, c.end)
+}
+
+type changedRanges []*changedRange
+
+// changedLines records the lines changed since a git revision, keyed by
+// absolute file path.
+type changedLines struct {
+	files map[string]changedRanges
+	// Files which are entirely new, such as untracked files.
+	whole map[string]bool
+	// Issue paths with symlinks resolved, the same way as the git root.
+	resolved map[string]string
+}
+
+// IsChanged returns true if the issue is on a line changed since the revision.
+func (c *changedLines) IsChanged(issue *Issue) bool {
+	path := c.resolve(issue.Path.Abs())
+	if c.whole[path] {
+		return true
+	}
+	for _, r := range c.files[path] {
+		if r.matches(issue) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve evaluates the symlinks in path, falling back to path itself if it
+// can not be resolved.
+func (c *changedLines) resolve(path string) string {
+	if resolved, ok := c.resolved[path]; ok {
+		return resolved
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		resolved = path
+	}
+	if c.resolved == nil {
+		c.resolved = map[string]string{}
+	}
+	c.resolved[path] = resolved
+	return resolved
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// parseUnifiedDiff extracts the ranges of added or modified lines from a
+// unified diff. Paths are resolved relative to root.
+func parseUnifiedDiff(root string, r io.Reader) (map[string]changedRanges, error) {
+	files := map[string]changedRanges{}
+	current := ""
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			current = ""
+			name := strings.TrimPrefix(line, "+++ ")
+			if name == "/dev/null" {
+				continue
+			}
+			name = strings.TrimPrefix(name, "b/")
+			current = filepath.Join(root, filepath.FromSlash(name))
+
+		case strings.HasPrefix(line, "@@ "):
+			if current == "" {
+				continue
+			}
+			match := hunkHeaderRegex.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("invalid hunk header %q", line)
+			}
+			start, err := strconv.Atoi(match[1])
+			if err != nil {
+				return nil, err
+			}
+			count := 1
+			if match[2] != "" {
+				if count, err = strconv.Atoi(match[2]); err != nil {
+					return nil, err
+				}
+			}
+			// Pure deletions do not add any lines to the new file.
+			if count == 0 {
+				continue
+			}
+			files[current] = append(files[current], &changedRange{start: start, end: start + count - 1})
+		}
+	}
+	return files, scanner.Err()
+}
+
+func gitOutput(dir string, args ...string) ([]byte, error) {
+	debug("git %s", strings.Join(args, " "))
+	buf := bytes.NewBuffer(nil)
+	cmd := exec.Command("git", args...) // nolint: gas
+	cmd.Dir = dir
+	cmd.Stdout = buf
+	cmd.Stderr = buf
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s failed: %s: %s", strings.Join(args, " "), err, strings.TrimSpace(buf.String()))
+	}
+	return buf.Bytes(), nil
+}
+
+// loadChangedLines runs git in dir to find the lines changed in the working
+// tree since rev, including uncommitted and untracked files.
+func loadChangedLines(dir, rev string) (*changedLines, error) {
+	out, err := gitOutput(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.EvalSymlinks(strings.TrimSpace(string(out)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Explicit prefixes override diff.noprefix and diff.mnemonicPrefix.
+	diff, err := gitOutput(dir, "diff", "--no-color", "--no-ext-diff", "--unified=0", "--src-prefix=a/", "--dst-prefix=b/", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	files, err := parseUnifiedDiff(root, bytes.NewReader(diff))
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := gitOutput(dir, "ls-files", "--others", "--exclude-standard", "--full-name", root)
+	if err != nil {
+		return nil, err
+	}
+	whole := map[string]bool{}
+	for _, name := range strings.Split(string(untracked), "\n") {
+		if name = strings.TrimSpace(name); name != "" {
+			whole[filepath.Join(root, filepath.FromSlash(name))] = true
+		}
+	}
+	debug("new-from-rev: %d changed and %d untracked files since %s", len(files), len(whole), rev)
+	return &changedLines{files: files, whole: whole}, nil
+}
+
+func filterIssuesViaChangedLines(changes *changedLines, issues chan *Issue) chan *Issue {
+	out := make(chan *Issue, 1000000)
+	go func() {
+		for issue := range issues {
+			if changes.IsChanged(issue) {
+				out <- issue
+			}
+		}
+		close(out)
+	}()
+	return out
+}
+
+func maybeFilterIssuesViaChangedLines(ws *workspace, issues chan *Issue) chan *Issue {
+	if config.NewFromRev == "" {
+		return issues
+	}
+	changes, err := loadChangedLines(ws.dir, config.NewFromRev)
+	kingpin.FatalIfError(err, "failed to determine lines changed since %s", config.NewFromRev)
+	return filterIssuesViaChangedLines(changes, issues)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	diff := `diff --git a/foo/a.go b/foo/a.go
+index 1111111..2222222 100644
+--- a/foo/a.go
++++ b/foo/a.go
+@@ -3 +3 @@ package foo
+-var a = 1
++var a = 2
+@@ -10,0 +11,3 @@ func Foo() {
++	one()
++	two()
++	three()
+@@ -20,2 +23,0 @@ func Bar() {
+-	gone()
+-	gone()
+diff --git a/b.go b/b.go
+deleted file mode 100644
+--- a/b.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package foo
+-
+`
+	files, err := parseUnifiedDiff("/root", strings.NewReader(diff))
+	require.NoError(t, err)
+	expected := map[string]changedRanges{
+		"/root/foo/a.go": {
+			{start: 3, end: 3},
+			{start: 11, end: 13},
+		},
+	}
+	assert.Equal(t, expected, files)
+}
+
+func TestChangedLinesIsChanged(t *testing.T) {
+	changes := &changedLines{
+		files: map[string]changedRanges{
+			"/root/a.go": {{start: 5, end: 10}},
+		},
+		whole: map[string]bool{"/root/new.go": true},
+	}
+
+	var testcases = []struct {
+		doc      string
+		issue    Issue
+		expected bool
+	}{
+		{
+			doc:      "changed line",
+			issue:    Issue{Path: newIssuePath("/root", "a.go"), Line: 7},
+			expected: true,
+		},
+		{
+			doc:   "unchanged line",
+			issue: Issue{Path: newIssuePath("/root", "a.go"), Line: 11},
+		},
+		{
+			doc:   "unchanged file",
+			issue: Issue{Path: newIssuePath("/root", "b.go"), Line: 7},
+		},
+		{
+			doc:      "untracked file",
+			issue:    Issue{Path: newIssuePath("/root", "new.go"), Line: 100},
+			expected: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		assert.Equal(t, testcase.expected, changes.IsChanged(&testcase.issue), testcase.doc)
+	}
+}
+
+func TestChangedLinesIsChangedResolvesSymlinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gometalinter-newfromrev")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	real := filepath.Join(dir, "real")
+	require.NoError(t, os.Mkdir(real, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(real, "a.go"), []byte("package a\n"), 0644))
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink(real, link))
+
+	changes := &changedLines{
+		files: map[string]changedRanges{
+			filepath.Join(real, "a.go"): {{start: 1, end: 1}},
+		},
+	}
+	issue := &Issue{Path: newIssuePath(link, "a.go"), Line: 1}
+	assert.True(t, changes.IsChanged(issue))
+}
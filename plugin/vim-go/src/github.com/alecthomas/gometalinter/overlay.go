@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/buildutil"
+)
+
+// sourceOverlay holds the unsaved contents of files open in an editor.
+//
+// Linters only read files from disk, so each package containing a modified
+// file is copied into a temporary GOPATH, with the unsaved contents in place
+// of the originals, and linted there instead. Packages inside a GOPATH are
+// copied to the same import path and the vendor directories which apply to
+// them are linked alongside, so that the copies resolve imports in the same
+// way as the original packages when the temporary GOPATH is searched first.
+//
+// A nil overlay has no unsaved changes.
+type sourceOverlay struct {
+	gopath string
+	// Unsaved contents keyed by absolute path.
+	files map[string][]byte
+	// Temporary copy of each directory containing a modified file, and the
+	// original directory of each copy, keyed by absolute paths.
+	copies    map[string]string
+	originals map[string]string
+}
+
+// newSourceOverlay parses an archive in the format accepted by the -modified
+// flag of guru and gogetdoc: a file name, a decimal file size and the file
+// contents, separated by newlines. Relative file names are resolved against
+// dir. The packages containing modified files are copied straight away.
+func newSourceOverlay(dir string, archive []byte) (*sourceOverlay, error) {
+	modified, err := buildutil.ParseOverlayArchive(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	gopath, err := ioutil.TempDir("", "gometalinter")
+	if err != nil {
+		return nil, err
+	}
+	o := &sourceOverlay{
+		gopath:    gopath,
+		files:     map[string][]byte{},
+		copies:    map[string]string{},
+		originals: map[string]string{},
+	}
+	for filename, content := range modified {
+		o.files[absPath(dir, filename)] = content
+	}
+	if err := o.copyDirs(); err != nil {
+		o.Close() // nolint: errcheck
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *sourceOverlay) file(path string) ([]byte, bool) {
+	if o == nil {
+		return nil, false
+	}
+	content, ok := o.files[path]
+	return content, ok
+}
+
+// modifies returns true if dir contains a file with unsaved changes.
+func (o *sourceOverlay) modifies(dir string) bool {
+	if o == nil {
+		return false
+	}
+	_, ok := o.copies[dir]
+	return ok
+}
+
+func (o *sourceOverlay) copyDirs() error {
+	vendors := []vendorDir{}
+	for filename := range o.files {
+		dir := filepath.Dir(filename)
+		if _, ok := o.copies[dir]; ok {
+			continue
+		}
+		target := filepath.Join(o.gopath, "_", strings.TrimPrefix(dir, filepath.VolumeName(dir)))
+		if src, importPath, ok := gopathImportPath(dir); ok {
+			target = filepath.Join(o.gopath, "src", importPath)
+			vendors = append(vendors, vendorDirs(src, importPath)...)
+		}
+		if err := o.copyDir(dir, target); err != nil {
+			return err
+		}
+		debug("linting %s with unsaved changes in %s", dir, target)
+	}
+	// Vendor directories are linked once every copy exists, so that a copy
+	// is never written through a link into the original vendor directory.
+	for _, vendor := range vendors {
+		link := filepath.Join(o.gopath, "src", vendor.rel)
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+			return err
+		}
+		if err := os.Symlink(vendor.dir, link); err != nil {
+			debug("failed to link vendor directory %s: %s", vendor.dir, err)
+		}
+	}
+	return nil
+}
+
+func (o *sourceOverlay) copyDir(dir, target string) error {
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	o.copies[dir] = target
+	o.originals[target] = dir
+	// Linters may report paths with symlinks resolved.
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		o.originals[resolved] = dir
+	}
+
+	files := map[string][]byte{}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		files[entry.Name()] = content
+	}
+	for filename, content := range o.files {
+		if filepath.Dir(filename) == dir {
+			files[filepath.Base(filename)] = content
+		}
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(target, name), content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gopathImportPath returns the src directory of the GOPATH containing dir,
+// and the import path of dir within it.
+func gopathImportPath(dir string) (string, string, bool) {
+	for _, gopath := range getGoPathList() {
+		src := filepath.Join(gopath, "src")
+		rel, err := filepath.Rel(src, dir)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return src, filepath.ToSlash(rel), true
+	}
+	return "", "", false
+}
+
+type vendorDir struct {
+	// Original vendor directory, and its path relative to the GOPATH src
+	// directory.
+	dir string
+	rel string
+}
+
+// vendorDirs returns the vendor directories which apply to the package at
+// importPath in the GOPATH src directory.
+func vendorDirs(src, importPath string) []vendorDir {
+	out := []vendorDir{}
+	for rel := filepath.FromSlash(importPath); rel != "."; rel = filepath.Dir(rel) {
+		dir := filepath.Join(src, rel, "vendor")
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			out = append(out, vendorDir{dir: dir, rel: filepath.Join(rel, "vendor")})
+		}
+	}
+	return out
+}
+
+// Copy maps an absolute path to a directory or Go file with unsaved changes
+// to its temporary copy. Other paths are returned unchanged.
+func (o *sourceOverlay) Copy(path string) string {
+	if o == nil {
+		return path
+	}
+	if target, ok := o.copies[path]; ok {
+		return target
+	}
+	if target, ok := o.copies[filepath.Dir(path)]; ok && strings.HasSuffix(path, ".go") {
+		return filepath.Join(target, filepath.Base(path))
+	}
+	return path
+}
+
+// Original maps an absolute path inside a temporary copy back to the
+// original path. Other paths are returned unchanged.
+func (o *sourceOverlay) Original(path string) string {
+	if o == nil {
+		return path
+	}
+	for target, dir := range o.originals {
+		if path == target || strings.HasPrefix(path, target+string(filepath.Separator)) {
+			return filepath.Join(dir, strings.TrimPrefix(path, target))
+		}
+	}
+	return path
+}
+
+// Close removes the temporary GOPATH.
+func (o *sourceOverlay) Close() error {
+	if o == nil {
+		return nil
+	}
+	return os.RemoveAll(o.gopath)
+}
+
+// mapOverlayIssues rewrites the paths of issues reported in temporary copies
+// of packages to the original files.
+func mapOverlayIssues(ws *workspace, issues chan *Issue) chan *Issue {
+	if ws.overlay == nil {
+		return issues
+	}
+	out := make(chan *Issue, 1000000)
+	go func() {
+		for issue := range issues {
+			original := ws.overlay.Original(issue.Path.Abs())
+			if rel, err := filepath.Rel(issue.Path.root, original); err == nil {
+				issue.Path = newIssuePath(issue.Path.root, rel)
+			}
+			out <- issue
+		}
+		close(out)
+	}()
+	return out
+}
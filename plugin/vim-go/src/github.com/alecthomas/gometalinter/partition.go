@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"reflect"
 )
 
 // MaxCommandBytes is the maximum number of bytes used when executing a command
@@ -12,6 +13,10 @@ const MaxCommandBytes = 32000
 type partitionStrategy func([]string, []string) ([][]string, error)
 
 func (ps *partitionStrategy) UnmarshalJSON(raw []byte) error {
+	if string(raw) == "null" {
+		*ps = nil
+		return nil
+	}
 	var strategyName string
 	if err := json.Unmarshal(raw, &strategyName); err != nil {
 		return err
@@ -34,6 +39,24 @@ func (ps *partitionStrategy) UnmarshalJSON(raw []byte) error {
 	return nil
 }
 
+func (ps partitionStrategy) MarshalJSON() ([]byte, error) {
+	if ps == nil {
+		return []byte("null"), nil
+	}
+	names := map[uintptr]string{
+		reflect.ValueOf(partitionPathsAsDirectories).Pointer():           "directories",
+		reflect.ValueOf(partitionPathsAsFiles).Pointer():                 "files",
+		reflect.ValueOf(partitionPathsAsPackages).Pointer():              "packages",
+		reflect.ValueOf(partitionPathsAsFilesGroupedByPackage).Pointer(): "files-by-package",
+		reflect.ValueOf(partitionPathsByDirectory).Pointer():             "single-directory",
+	}
+	name, ok := names[reflect.ValueOf(ps).Pointer()]
+	if !ok {
+		return nil, fmt.Errorf("unknown partition strategy")
+	}
+	return json.Marshal(name)
+}
+
 func pathsToFileGlobs(paths []string) ([]string, error) {
 	filePaths := []string{}
 	for _, dir := range paths {
@@ -140,18 +163,17 @@ func pathsToPackagePaths(paths []string) ([]string, error) {
 	return packages, nil
 }
 
+// packageNameFromPath returns the import path of the package in the absolute
+// directory path. Directories outside GOPATH are returned unchanged, and are
+// passed to linters relative to the workspace.
 func packageNameFromPath(path string) (string, error) {
 	if !filepath.IsAbs(path) {
 		return path, nil
 	}
-	for _, gopath := range getGoPathList() {
-		rel, err := filepath.Rel(filepath.Join(gopath, "src"), path)
-		if err != nil {
-			continue
-		}
-		return rel, nil
+	if _, importPath, ok := gopathImportPath(path); ok {
+		return importPath, nil
 	}
-	return "", fmt.Errorf("%s not in GOPATH", path)
+	return path, nil
 }
 
 func partitionPathsByDirectory(cmdArgs []string, paths []string) ([][]string, error) {
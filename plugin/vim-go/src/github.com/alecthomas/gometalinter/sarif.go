@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	kingpin "gopkg.in/alecthomas/kingpin.v3-unstable"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool      `json:"tool"`
+	Results []*sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver     *sarifToolComponent   `json:"driver"`
+	Extensions []*sarifToolComponent `json:"extensions,omitempty"`
+}
+
+type sarifToolComponent struct {
+	Name           string                 `json:"name"`
+	Version        string                 `json:"version,omitempty"`
+	InformationURI string                 `json:"informationUri,omitempty"`
+	Rules          []*sarifReportingDescr `json:"rules,omitempty"`
+}
+
+type sarifReportingDescr struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID           string                 `json:"ruleId"`
+	Rule             sarifReportingDescrRef `json:"rule"`
+	Level            string                 `json:"level"`
+	Message          sarifMessage           `json:"message"`
+	Locations        []*sarifLocation       `json:"locations"`
+	RelatedLocations []*sarifLocation       `json:"relatedLocations,omitempty"`
+}
+
+type sarifReportingDescrRef struct {
+	ID            string                `json:"id"`
+	Index         int                   `json:"index"`
+	ToolComponent sarifToolComponentRef `json:"toolComponent"`
+}
+
+type sarifToolComponentRef struct {
+	Name  string `json:"name"`
+	Index int    `json:"index"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifLevel maps an issue severity to a SARIF result level.
+func sarifLevel(severity Severity) string {
+	switch severity {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifBuilder accumulates issues into a single SARIF run, registering each
+// linter as a tool extension with one rule.
+type sarifBuilder struct {
+	run        *sarifRun
+	components map[string]int
+}
+
+func newSARIFBuilder() *sarifBuilder {
+	return &sarifBuilder{
+		run: &sarifRun{
+			Tool: sarifTool{
+				Driver: &sarifToolComponent{
+					Name:           "gometalinter",
+					Version:        version,
+					InformationURI: "https://github.com/alecthomas/gometalinter",
+				},
+			},
+			Results: []*sarifResult{},
+		},
+		components: map[string]int{},
+	}
+}
+
+func (b *sarifBuilder) rule(linter string) sarifReportingDescrRef {
+	index, ok := b.components[linter]
+	if !ok {
+		index = len(b.run.Tool.Extensions)
+		b.components[linter] = index
+		b.run.Tool.Extensions = append(b.run.Tool.Extensions, &sarifToolComponent{
+			Name: linter,
+			Rules: []*sarifReportingDescr{{
+				ID:               linter,
+				ShortDescription: sarifMessage{Text: fmt.Sprintf("Issues reported by %s", linter)},
+			}},
+		})
+	}
+	return sarifReportingDescrRef{
+		ID:            linter,
+		ToolComponent: sarifToolComponentRef{Name: linter, Index: index},
+	}
+}
+
+func (b *sarifBuilder) add(issue *Issue) {
+	linters := issue.linterNames()
+	result := &sarifResult{
+		RuleID:    linters[0],
+		Rule:      b.rule(linters[0]),
+		Level:     sarifLevel(issue.Severity),
+		Message:   sarifMessage{Text: issue.Message},
+		Locations: []*sarifLocation{newSARIFLocation(issue)},
+	}
+	for i, linter := range linters[1:] {
+		b.rule(linter)
+		related := newSARIFLocation(issue)
+		related.ID = i + 1
+		related.Message = &sarifMessage{Text: fmt.Sprintf("also reported by %s", linter)}
+		result.RelatedLocations = append(result.RelatedLocations, related)
+	}
+	b.run.Results = append(b.run.Results, result)
+}
+
+func (b *sarifBuilder) log() *sarifLog {
+	return &sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []*sarifRun{b.run},
+	}
+}
+
+func newSARIFLocation(issue *Issue) *sarifLocation {
+	return &sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{
+				URI:       filepath.ToSlash(issue.Path.Relative()),
+				URIBaseID: "%SRCROOT%",
+			},
+			Region: sarifRegion{
+				StartLine:   issue.Line,
+				StartColumn: issue.Col,
+			},
+		},
+	}
+}
+
+func outputToSARIF(issues chan *Issue) int {
+	builder := newSARIFBuilder()
+	status := 0
+	for issue := range issues {
+		if config.Errors && issue.Severity != Error {
+			continue
+		}
+		builder.add(issue)
+		status = 1
+	}
+	d, err := json.MarshalIndent(builder.log(), "", "  ")
+	kingpin.FatalIfError(err, "")
+	fmt.Printf("%s\n", d)
+	return status
+}
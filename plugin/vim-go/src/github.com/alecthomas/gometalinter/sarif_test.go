@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSARIFLevel(t *testing.T) {
+	assert.Equal(t, "error", sarifLevel(Error))
+	assert.Equal(t, "warning", sarifLevel(Warning))
+	assert.Equal(t, "note", sarifLevel(Severity("info")))
+}
+
+func TestSARIFBuilder(t *testing.T) {
+	builder := newSARIFBuilder()
+	builder.add(&Issue{
+		Linter:   "golint",
+		Severity: Warning,
+		Path:     newIssuePath("", "a.go"),
+		Line:     3,
+		Col:      2,
+		Message:  "exported func should have comment",
+	})
+	builder.add(&Issue{
+		Linter:            "errcheck, vet",
+		Severity:          Error,
+		Path:              newIssuePath("", "b.go"),
+		Line:              10,
+		Message:           "unchecked error",
+		aggregatedLinters: []string{"errcheck", "vet"},
+	})
+
+	log := builder.log()
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "2.1.0", log.Version)
+
+	var names []string
+	for _, component := range run.Tool.Extensions {
+		names = append(names, component.Name)
+		require.Len(t, component.Rules, 1)
+		assert.Equal(t, component.Name, component.Rules[0].ID)
+	}
+	assert.Equal(t, []string{"golint", "errcheck", "vet"}, names)
+
+	require.Len(t, run.Results, 2)
+	first := run.Results[0]
+	assert.Equal(t, "golint", first.RuleID)
+	assert.Equal(t, "warning", first.Level)
+	assert.Equal(t, 0, first.Rule.ToolComponent.Index)
+	assert.Equal(t, "a.go", first.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, sarifRegion{StartLine: 3, StartColumn: 2}, first.Locations[0].PhysicalLocation.Region)
+	assert.Empty(t, first.RelatedLocations)
+
+	second := run.Results[1]
+	assert.Equal(t, "errcheck", second.RuleID)
+	assert.Equal(t, "error", second.Level)
+	assert.Equal(t, 1, second.Rule.ToolComponent.Index)
+	require.Len(t, second.RelatedLocations, 1)
+	assert.Equal(t, "also reported by vet", second.RelatedLocations[0].Message.Text)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// serveRequest asks the server to lint paths, as they would be given on the
+// command line.
+type serveRequest struct {
+	// Absolute working directory of the request. Paths, config files and the
+	// paths of reported issues are resolved relative to it.
+	Dir   string   `json:"dir"`
+	Paths []string `json:"paths"`
+	// Unsaved file contents, in the archive format of guru's -modified flag.
+	Modified string `json:"modified,omitempty"`
+}
+
+// serveError is sent in place of an issue when a request or linter fails.
+type serveError struct {
+	Error string `json:"error"`
+}
+
+// server lints files on behalf of editors, keeping the configuration and
+// parsed directives in memory between requests. Requests are handled
+// concurrently, each in its own workspace.
+type server struct {
+	// Guards hierarchies, which are resolved lazily.
+	lock        sync.Mutex
+	hierarchies map[string]*configHierarchy
+}
+
+func newServer() *server {
+	return &server{hierarchies: map[string]*configHierarchy{}}
+}
+
+func defaultSocketPath() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gometalinter-%d.sock", os.Getuid()))
+}
+
+// serve accepts requests on a unix socket until interrupted. Each connection
+// carries a single JSON encoded serveRequest, and receives a stream of JSON
+// encoded issues, one per line.
+func serve(socket string) error {
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close() // nolint: errcheck
+		return fmt.Errorf("a server is already listening on %s", socket)
+	}
+	// Remove the socket of a server which did not shut down cleanly.
+	os.Remove(socket) // nolint: errcheck
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+
+	stopped := make(chan bool)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stopped)
+		listener.Close() // nolint: errcheck
+	}()
+
+	if config.Fix {
+		warning("--fix is ignored in server mode")
+		config.Fix = false
+	}
+	parsedDirectives = newDirectiveCache()
+	s := newServer()
+	debug("listening on %s", socket)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-stopped:
+				return nil
+			default:
+				return err
+			}
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close() // nolint: errcheck
+	enc := json.NewEncoder(conn)
+	req := &serveRequest{}
+	if err := json.NewDecoder(conn).Decode(req); err != nil {
+		enc.Encode(&serveError{Error: fmt.Sprintf("invalid request: %s", err)}) // nolint: errcheck
+		return
+	}
+	err := s.lint(req, func(v interface{}) error { return enc.Encode(v) })
+	if err != nil {
+		warning("%s", err)
+	}
+}
+
+// lint runs the linters for a request, passing each issue or error to emit.
+func (s *server) lint(req *serveRequest, emit func(interface{}) error) (err error) {
+	fail := func(err error) error {
+		emit(&serveError{Error: err.Error()}) // nolint: errcheck
+		return err
+	}
+	if !filepath.IsAbs(req.Dir) {
+		return fail(fmt.Errorf("dir %q is not an absolute path", req.Dir))
+	}
+	debug("serving request for %v in %s", req.Paths, req.Dir)
+
+	ws := &workspace{dir: filepath.Clean(req.Dir)}
+	if req.Modified != "" {
+		if ws.overlay, err = newSourceOverlay(ws.dir, []byte(req.Modified)); err != nil {
+			return fail(fmt.Errorf("invalid modified archive: %s", err))
+		}
+		defer func() {
+			if cerr := ws.overlay.Close(); cerr != nil {
+				warning("failed to remove temporary files: %s", cerr)
+			}
+		}()
+	}
+
+	paths := resolvePaths(ws.dir, req.Paths, config.Skip)
+	groups, err := s.linterGroups(ws.dir, paths)
+	if err != nil {
+		return fail(err)
+	}
+	for _, group := range groups {
+		if err := validateLinters(group.linters, group.config); err != nil {
+			return fail(err)
+		}
+	}
+
+	// Keep draining issues after the client goes away, so that temporary
+	// files are not removed while linters are still running.
+	issues, errch := runLinters(ws, groups, config.Concurrency)
+	for issue := range issues {
+		if config.Errors && issue.Severity != Error {
+			continue
+		}
+		if err == nil {
+			err = emit(issue)
+		}
+	}
+	for lerr := range errch {
+		warning("%s", lerr)
+		if err == nil {
+			err = emit(&serveError{Error: lerr.Error()})
+		}
+	}
+	return err
+}
+
+// linterGroups resolves the configuration of each path, reusing the config
+// files parsed by previous requests in the same directory unless one of them
+// has changed since.
+//
+// Unless a config file was given on the command line, the base configuration
+// of a request is loaded from the config file of its directory, or of the
+// nearest parent, rather than from that of the server.
+func (s *server) linterGroups(dir string, paths []string) ([]*linterGroup, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if config.NoConfig {
+		return []*linterGroup{newLinterGroup(config, paths)}, nil
+	}
+	hierarchy, ok := s.hierarchies[dir]
+	if ok && hierarchy.Stale() {
+		debug("config files changed in %s, reloading", dir)
+		ok = false
+	}
+	if !ok {
+		base, searched := config, []string(nil)
+		var err error
+		if searchConfigFile {
+			if base, searched, err = loadConfigForDir(dir); err != nil {
+				return nil, err
+			}
+		}
+		if hierarchy, err = newConfigHierarchy(base, dir); err != nil {
+			return nil, err
+		}
+		hierarchy.watch(searched)
+		s.hierarchies[dir] = hierarchy
+	}
+	return newLinterGroupsFromHierarchy(hierarchy, paths)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func modifiedArchive(files map[string]string) string {
+	archive := ""
+	for name, content := range files {
+		archive += fmt.Sprintf("%s\n%d\n%s", name, len(content), content)
+	}
+	return archive
+}
+
+func TestSourceOverlay(t *testing.T) {
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	defer fakeGoPath(t, tmpdir)()
+
+	pkg := filepath.Join(tmpdir, "src", "example.com", "foo")
+	mkDir(t, pkg)
+	mkDir(t, pkg, "vendor", "dep")
+	mkFile(t, pkg, "other.go", "package foo\n")
+	mkDir(t, tmpdir, "outside")
+
+	o, err := newSourceOverlay(tmpdir, []byte(modifiedArchive(map[string]string{
+		"src/example.com/foo/file.go": "package foo // modified\n",
+		"outside/file.go":             "package outside // modified\n",
+	})))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(o.gopath, os.TempDir()))
+
+	copied := o.Copy(pkg)
+	assert.Equal(t, filepath.Join(o.gopath, "src", "example.com", "foo"), copied)
+	assert.Equal(t, filepath.Join(copied, "file.go"), o.Copy(filepath.Join(pkg, "file.go")))
+	content, err := ioutil.ReadFile(filepath.Join(copied, "file.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package foo // modified\n", string(content))
+	content, err = ioutil.ReadFile(filepath.Join(copied, "other.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package foo\n", string(content))
+	_, err = os.Stat(filepath.Join(copied, "vendor", "dep", "file.go"))
+	assert.NoError(t, err, "vendor directories should be linked")
+
+	outside := o.Copy(filepath.Join(tmpdir, "outside"))
+	assert.True(t, strings.HasPrefix(outside, o.gopath+string(filepath.Separator)))
+	assert.Equal(t, filepath.Join(tmpdir, "outside", "file.go"), o.Original(filepath.Join(outside, "file.go")))
+
+	assert.Equal(t, filepath.Join(pkg, "file.go"), o.Original(filepath.Join(copied, "file.go")))
+	assert.Equal(t, filepath.Join(pkg, "vendor"), o.Copy(filepath.Join(pkg, "vendor")))
+	assert.Equal(t, filepath.Join(pkg, "other.go"), o.Original(filepath.Join(pkg, "other.go")))
+
+	entries, err := ioutil.ReadDir(pkg)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3, "packages should not be modified")
+
+	require.NoError(t, o.Close())
+	_, err = os.Stat(o.gopath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestServerLintsUnsavedFiles(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.NoConfig = true
+	config.Enable = []string{"todo"}
+	config.Linters = map[string]StringOrLinterConfig{
+		"todo": {Command: "grep -Hn TODO", Pattern: "PATH:LINE:MESSAGE", PartitionStrategy: partitionPathsAsFiles},
+	}
+	config.formatTemplate = template.Must(template.New("output").Parse(DefaultIssueFormat))
+	config.Deadline = jsonDuration(10 * time.Second)
+	cacheDir, err := ioutil.TempDir("", "gometalinter-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(cacheDir)
+	config.CacheDir = cacheDir
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkFile(t, tmpdir, "file.go", "package foo\n")
+	mkFile(t, tmpdir, "other.go", "package foo\n\n// TODO: saved\n")
+	// Requests do not depend on the working directory of the server.
+	require.NoError(t, os.Chdir(os.TempDir()))
+
+	req := &serveRequest{
+		Dir:      tmpdir,
+		Paths:    []string{"."},
+		Modified: modifiedArchive(map[string]string{"file.go": "package foo\n\n\n// TODO: unsaved\n"}),
+	}
+	issues := []*Issue{}
+	err = newServer().lint(req, func(v interface{}) error {
+		issue, ok := v.(*Issue)
+		require.True(t, ok, "unexpected error %#v", v)
+		issues = append(issues, issue)
+		return nil
+	})
+	require.NoError(t, err)
+
+	actual := map[string]int{}
+	for _, issue := range issues {
+		actual[issue.Path.Relative()] = issue.Line
+	}
+	assert.Equal(t, map[string]int{"file.go": 4, "other.go": 3}, actual)
+
+	entries, err := ioutil.ReadDir(tmpdir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "temporary copies should be removed")
+	content, err := ioutil.ReadFile(filepath.Join(tmpdir, "file.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package foo\n", string(content))
+
+	entries, err = ioutil.ReadDir(cacheDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "results for temporary copies should not be cached")
+}
+
+func TestServerReloadsChangedConfig(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.NoConfig = false
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkDir(t, tmpdir, "sub")
+	mkFile(t, filepath.Join(tmpdir, "sub"), defaultConfigPath, `{"Cyclo": 20}`)
+
+	s := newServer()
+	groups, err := s.linterGroups(tmpdir, []string{"./sub"})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, 20, groups[0].config.Cyclo)
+
+	groups, err = s.linterGroups(tmpdir, []string{"./sub"})
+	require.NoError(t, err)
+	assert.Equal(t, 20, groups[0].config.Cyclo)
+
+	configFile := filepath.Join(tmpdir, "sub", defaultConfigPath)
+	mkFile(t, filepath.Join(tmpdir, "sub"), defaultConfigPath, `{"Cyclo": 30}`)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(configFile, later, later))
+
+	groups, err = s.linterGroups(tmpdir, []string{"./sub"})
+	require.NoError(t, err)
+	assert.Equal(t, 30, groups[0].config.Cyclo)
+}
+
+func TestServerLoadsConfigOfRequestDir(t *testing.T) {
+	originalConfig := *config
+	defer func() { config = &originalConfig }()
+	config.NoConfig = false
+	defer func() { searchConfigFile = false }()
+	searchConfigFile = true
+
+	tmpdir, cleanup := setupTempDir(t)
+	defer cleanup()
+	mkDir(t, tmpdir, "server")
+	mkFile(t, filepath.Join(tmpdir, "server"), defaultConfigPath, `{"Cyclo": 15}`)
+	mkDir(t, tmpdir, "project")
+	mkFile(t, filepath.Join(tmpdir, "project"), defaultConfigPath, `{"Cyclo": 20}`)
+	require.NoError(t, os.Chdir(filepath.Join(tmpdir, "server")))
+
+	project := filepath.Join(tmpdir, "project")
+	s := newServer()
+	groups, err := s.linterGroups(project, []string{"."})
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, 20, groups[0].config.Cyclo)
+
+	configFile := filepath.Join(project, defaultConfigPath)
+	mkFile(t, project, defaultConfigPath, `{"Cyclo": 30}`)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(configFile, later, later))
+
+	groups, err = s.linterGroups(project, []string{"."})
+	require.NoError(t, err)
+	assert.Equal(t, 30, groups[0].config.Cyclo)
+}
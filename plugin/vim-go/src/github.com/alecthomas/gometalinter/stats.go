@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// linterStats records the resource usage and results of a single execution
+// of a linter over one partition of paths.
+type linterStats struct {
+	Linter    string   `json:"linter"`
+	Partition []string `json:"partition"`
+	InProcess bool     `json:"in_process,omitempty"`
+	Cached    bool     `json:"cached,omitempty"`
+
+	Wall   jsonDuration `json:"wall"`
+	User   jsonDuration `json:"user"`
+	System jsonDuration `json:"system"`
+	// Maximum resident set size of the linter process, in kilobytes.
+	MaxRSS     int64 `json:"max_rss_kb"`
+	ExitStatus int   `json:"exit_status"`
+	// Set if the linter was killed because --deadline expired.
+	DeadlineExceeded bool `json:"deadline_exceeded,omitempty"`
+
+	// Issues parsed from the linter output, and those remaining after
+	// excludes, directives and all other filters were applied.
+	Issues   int64 `json:"issues"`
+	Reported int64 `json:"reported"`
+
+	start time.Time
+}
+
+// statsCollector gathers linterStats for every linter execution.
+type statsCollector struct {
+	lock    sync.Mutex
+	records []*linterStats
+}
+
+// Set by --stats or --stats-json.
+var stats *statsCollector
+
+// Start records the start of a linter execution. It returns nil if stats are
+// not being collected; all linterStats methods accept a nil receiver.
+func (s *statsCollector) Start(linter string, partition []string) *linterStats {
+	if s == nil {
+		return nil
+	}
+	record := &linterStats{Linter: linter, Partition: partition, start: time.Now()}
+	s.lock.Lock()
+	s.records = append(s.records, record)
+	s.lock.Unlock()
+	return record
+}
+
+// Records returns all recorded executions, slowest first.
+func (s *statsCollector) Records() []*linterStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	records := append([]*linterStats{}, s.records...)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Wall > records[j].Wall
+	})
+	return records
+}
+
+// Finish records the wall time and, if the linter was run as a subprocess,
+// its resource usage.
+func (l *linterStats) Finish(state *os.ProcessState) {
+	if l == nil {
+		return
+	}
+	l.Wall = jsonDuration(time.Since(l.start))
+	if state == nil {
+		return
+	}
+	l.User = jsonDuration(state.UserTime())
+	l.System = jsonDuration(state.SystemTime())
+	l.ExitStatus, l.MaxRSS = processStatus(state)
+}
+
+func (l *linterStats) countIssue(issue *Issue) {
+	if l == nil {
+		return
+	}
+	atomic.AddInt64(&l.Issues, 1)
+	issue.stats = []*linterStats{l}
+}
+
+func (l *linterStats) countReported() {
+	if l == nil {
+		return
+	}
+	atomic.AddInt64(&l.Reported, 1)
+}
+
+// countReportedIssues is the final stage of the issue pipeline, counting the
+// issues which survived filtering against the execution that produced them.
+func countReportedIssues(issues chan *Issue) chan *Issue {
+	if stats == nil {
+		return issues
+	}
+	out := make(chan *Issue, 1000000)
+	go func() {
+		for issue := range issues {
+			if !config.Errors || issue.Severity == Error {
+				for _, record := range issue.stats {
+					record.countReported()
+				}
+			}
+			out <- issue
+		}
+		close(out)
+	}()
+	return out
+}
+
+func outputStatsTable(w io.Writer, records []*linterStats) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "LINTER\tWALL\tUSER\tSYS\tMAX RSS\tEXIT\tISSUES\tREPORTED\t\tPARTITION")
+	for _, r := range records {
+		linter := r.Linter
+		switch {
+		case r.DeadlineExceeded:
+			linter += " (deadline)"
+		case r.Cached:
+			linter += " (cached)"
+		case r.InProcess:
+			linter += " (in-process)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%dK\t%d\t%d\t%d\t\t%s\n",
+			linter, formatStatsDuration(r.Wall), formatStatsDuration(r.User), formatStatsDuration(r.System),
+			r.MaxRSS, r.ExitStatus, r.Issues, r.Reported, strings.Join(r.Partition, " "))
+	}
+	return tw.Flush()
+}
+
+func formatStatsDuration(d jsonDuration) string {
+	return d.Duration().Round(time.Millisecond).String()
+}
+
+func writeStatsJSON(filename string, records []*linterStats) error {
+	d, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(d, '\n'), 0644)
+}
+
+func outputStats() {
+	if stats == nil {
+		return
+	}
+	records := stats.Records()
+	if config.Stats {
+		if err := outputStatsTable(os.Stderr, records); err != nil {
+			warning("failed to write stats: %s", err)
+		}
+	}
+	if config.StatsJSON != "" {
+		if err := writeStatsJSON(config.StatsJSON, records); err != nil {
+			warning("failed to write stats to %s: %s", config.StatsJSON, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsCollectorRecords(t *testing.T) {
+	var collector *statsCollector
+	assert.Nil(t, collector.Start("vet", nil))
+
+	collector = &statsCollector{}
+	fast := collector.Start("vet", []string{"./a"})
+	slow := collector.Start("golint", []string{"./b"})
+	fast.Finish(nil)
+	slow.Finish(nil)
+	slow.Wall = fast.Wall + jsonDuration(time.Second)
+
+	assert.Equal(t, []*linterStats{slow, fast}, collector.Records())
+}
+
+func TestCountReportedIssues(t *testing.T) {
+	originalStats, originalConfig := stats, *config
+	defer func() { stats, config = originalStats, &originalConfig }()
+	stats = &statsCollector{}
+	config.Errors = true
+
+	record := stats.Start("vet", nil)
+	issues := make(chan *Issue, 3)
+	for _, severity := range []Severity{Error, Warning, Error} {
+		issue := &Issue{Severity: severity}
+		record.countIssue(issue)
+		issues <- issue
+	}
+	close(issues)
+
+	count := 0
+	for range countReportedIssues(issues) {
+		count++
+	}
+	assert.Equal(t, 3, count)
+	assert.Equal(t, int64(3), record.Issues)
+	assert.Equal(t, int64(2), record.Reported)
+}
+
+func TestCountReportedAggregatedIssues(t *testing.T) {
+	originalStats, originalConfig := stats, *config
+	defer func() { stats, config = originalStats, &originalConfig }()
+	stats = &statsCollector{}
+
+	vet := stats.Start("vet", nil)
+	golint := stats.Start("golint", nil)
+	issues := make(chan *Issue, 2)
+	for _, record := range []*linterStats{vet, golint} {
+		issue := &Issue{Linter: record.Linter, Path: newIssuePath("", "file.go"), Line: 1, Message: "same"}
+		record.countIssue(issue)
+		issues <- issue
+	}
+	close(issues)
+
+	count := 0
+	for range countReportedIssues(AggregateIssueChan(issues)) {
+		count++
+	}
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int64(1), vet.Reported)
+	assert.Equal(t, int64(1), golint.Reported)
+}
+
+func TestOutputStatsTable(t *testing.T) {
+	records := []*linterStats{
+		{Linter: "gotype", Partition: []string{"./a", "./b"}, Wall: jsonDuration(1500 * time.Millisecond),
+			User: jsonDuration(2 * time.Second), MaxRSS: 1024, ExitStatus: 1, Issues: 3, Reported: 2},
+		{Linter: "golint", InProcess: true, Partition: []string{"./a"}, Wall: jsonDuration(time.Second)},
+		{Linter: "vet", Cached: true, Partition: []string{"./a"}},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, outputStatsTable(buf, records))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"LINTER", "WALL", "USER", "SYS", "MAX", "RSS", "EXIT", "ISSUES", "REPORTED", "PARTITION"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"gotype", "1.5s", "2s", "0s", "1024K", "1", "3", "2", "./a", "./b"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"golint", "(in-process)", "1s", "0s", "0s", "0K", "0", "0", "0", "./a"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"vet", "(cached)", "0s", "0s", "0s", "0K", "0", "0", "0", "./a"}, strings.Fields(lines[3]))
+}
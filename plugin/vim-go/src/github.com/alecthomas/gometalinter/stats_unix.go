@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// processStatus returns the exit status and maximum resident set size, in
+// kilobytes, of an exited process.
+func processStatus(state *os.ProcessState) (int, int64) {
+	status := -1
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok {
+		status = ws.ExitStatus()
+	}
+	var maxRSS int64
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		maxRSS = int64(usage.Maxrss)
+		// Darwin reports bytes rather than kilobytes.
+		if runtime.GOOS == "darwin" {
+			maxRSS /= 1024
+		}
+	}
+	return status, maxRSS
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// processStatus returns the exit status of an exited process. The maximum
+// resident set size is not available on Windows.
+func processStatus(state *os.ProcessState) (int, int64) {
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok {
+		return ws.ExitStatus(), 0
+	}
+	return -1, 0
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// workspace is the directory a lint run resolves relative paths against,
+// along with the unsaved files of the editor that requested it, if any.
+//
+// A server lints on behalf of several editors at once, so nothing may depend
+// on the working directory of the process: linters are run in dir, and the
+// paths they report are resolved against it.
+type workspace struct {
+	dir     string
+	overlay *sourceOverlay
+}
+
+// newWorkspace returns a workspace for the working directory of the process.
+func newWorkspace() (*workspace, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return &workspace{dir: cwd}, nil
+}
+
+// absPath resolves path against dir.
+func absPath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
+
+// abs resolves path against the workspace directory.
+func (w *workspace) abs(path string) string {
+	return absPath(w.dir, path)
+}
+
+// rel returns path relative to the workspace directory if it is inside it.
+func (w *workspace) rel(path string) (string, bool) {
+	rel, err := filepath.Rel(w.dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path, false
+	}
+	return rel, true
+}
+
+// readFile returns the content of a file, including unsaved changes.
+func (w *workspace) readFile(path string) ([]byte, error) {
+	path = w.abs(path)
+	if content, ok := w.overlay.file(path); ok {
+		return content, nil
+	}
+	return ioutil.ReadFile(path)
+}
+
+// linterArg returns the argument to pass to a linter for an absolute path
+// produced by a partition strategy. Paths with unsaved changes are replaced
+// by their temporary copy, and paths given relative to the workspace are
+// passed as such, as linters which load packages reject absolute directories.
+func (w *workspace) linterArg(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	if _, ok := w.rel(path); !ok {
+		return w.overlay.Copy(path)
+	}
+	path = w.overlay.Copy(path)
+	rel, err := filepath.Rel(w.dir, path)
+	if err != nil {
+		return path
+	}
+	if strings.HasSuffix(rel, ".go") {
+		return rel
+	}
+	return relativePackagePath(rel)
+}
+
+// environ returns the environment to run linters in, or nil to inherit the
+// environment of the process.
+func (w *workspace) environ() []string {
+	if w.overlay == nil {
+		return nil
+	}
+	env := []string{"GOPATH=" + w.overlay.gopath + string(os.PathListSeparator) + getGoPath()}
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, "GOPATH=") {
+			env = append(env, v)
+		}
+	}
+	return env
+}
+
+// buildContext returns the context to load packages with in-process, which
+// finds the temporary copies of packages with unsaved changes first.
+func (w *workspace) buildContext() *build.Context {
+	ctx := build.Default
+	if w.overlay != nil {
+		ctx.GOPATH = w.overlay.gopath + string(os.PathListSeparator) + ctx.GOPATH
+	}
+	return &ctx
+}
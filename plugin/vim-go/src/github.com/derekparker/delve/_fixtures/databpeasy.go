@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+var globalvar1 = 0
+var globalvar2 = 0
+
+func main() { // Position 0
+	runtime.LockOSThread()
+	globalvar1 = 2
+	globalvar2 = globalvar1 + 1 // Position 1
+	globalvar1 = globalvar2 + 1
+	fmt.Printf("%d %d\n", globalvar1, globalvar2) // Position 2
+	watchLocal()
+	fmt.Printf("done\n") // Position 5
+}
+
+func watchLocal() {
+	n := 0
+	for i := 1; i < 3; i++ { // Position 3
+		n += i // Position 4
+	}
+	fmt.Printf("%d\n", n)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func watchLocal() {
+	w := 0
+	grow(100) // Position 0
+	w = 1     // Position 1
+	fmt.Printf("%d\n", w)
+}
+
+func grow(n int) {
+	var buf [1024]byte
+	if n > 0 {
+		grow(n - 1)
+	}
+	buf[0] = byte(n)
+}
+
+func main() {
+	runtime.LockOSThread()
+	watchLocal()
+	fmt.Printf("done\n")
+}
@@ -0,0 +1,34 @@
+package main
+
+import "runtime"
+
+func f1() {
+}
+
+func f2(a int8, b int32) {
+}
+
+func f3() {
+	runtime.Breakpoint()
+}
+
+func call1() {
+	defer f2(1, -1)
+	defer f1()
+	call2()
+}
+
+func call2() {
+	defer f2(2, 3)
+	call3()
+}
+
+func call3() {
+	defer f2(4, 5)
+	defer f2(6, 7)
+	f3()
+}
+
+func main() {
+	call1()
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func main() {
+	buf := []byte("examine memory!")
+	nums := [4]uint32{0xdeadbeef, 0x12345678, 1, 2}
+	runtime.Breakpoint()
+	fmt.Println(string(buf), nums)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+var call = "this is a variable named `call`"
+
+func callstacktrace() (stacktrace string) {
+	for skip := 0; ; skip++ {
+		pc, file, line, ok := runtime.Caller(skip)
+		if !ok {
+			break
+		}
+		fn := runtime.FuncForPC(pc)
+		stacktrace += fmt.Sprintf("in %s at %s:%d\n", fn.Name(), file, line)
+	}
+	return stacktrace
+}
+
+func call0(a, b int) {
+	fmt.Printf("call0: first: %d second: %d\n", a, b)
+}
+
+func call1(a, b int) int {
+	fmt.Printf("first: %d second: %d\n", a, b)
+	return a + b
+}
+
+func callpanic() {
+	fmt.Printf("about to panic\n")
+	panic("callpanic panicked")
+}
+
+func stringsJoin(v []string, sep string) string {
+	// This is needed because strings.Join is in an optimized package and
+	// because of a bug in the compiler arguments of optimized functions don't
+	// have a location.
+	return strings.Join(v, sep)
+}
+
+type astruct struct {
+	X int
+}
+
+func (a astruct) VRcvrable(b int) string {
+	return fmt.Sprintf("%d + %d = %d", a.X, b, a.X+b)
+}
+
+func (pa *astruct) PRcvrable(b int) string {
+	return fmt.Sprintf("%d - %d = %d", pa.X, b, pa.X-b)
+}
+
+func main() {
+	one, two := 1, 2
+	intslice := []int{1, 2, 3}
+	stringslice := []string{"one", "two", "three"}
+	comma := ","
+	a := astruct{X: 3}
+	pa := &astruct{X: 6}
+
+	call1(one, two)
+	fmt.Println(one, two, intslice, stringslice, comma, a, pa, call)
+}
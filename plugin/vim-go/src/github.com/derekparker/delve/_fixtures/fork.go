@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func traceme(pid int) int {
+	return pid + 1
+}
+
+func main() {
+	pid, _, errno := syscall.RawSyscall(syscall.SYS_FORK, 0, 0, 0)
+	if errno != 0 {
+		fmt.Println(errno)
+		return
+	}
+	if pid == 0 {
+		// only the forking thread exists in the child, stay out of the runtime
+		traceme(0)
+		syscall.RawSyscall(syscall.SYS_EXIT_GROUP, 0, 0, 0)
+	}
+	traceme(int(pid))
+	var ws syscall.WaitStatus
+	syscall.Wait4(int(pid), &ws, 0, nil)
+	fmt.Println("child exited", ws.ExitStatus())
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type Ring struct {
+	buf        [8]int
+	head, tail int
+}
+
+type Pair struct {
+	Key   string
+	Value int
+}
+
+func main() {
+	r := Ring{head: 2, tail: 5}
+	for i := range r.buf {
+		r.buf[i] = i * 10
+	}
+	pr := &r
+	rings := []Ring{r, {head: 1, tail: 1}}
+	p := Pair{"answer", 42}
+	runtime.Breakpoint()
+	fmt.Println(r, pr, rings, p)
+}
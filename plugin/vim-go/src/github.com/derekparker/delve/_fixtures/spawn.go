@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+func traceme(who string) {
+	fmt.Println(who)
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		traceme("child")
+		return
+	}
+	cmd := exec.Command(os.Args[0], "child")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Println(err)
+	}
+	traceme("parent")
+}
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"syscall"
@@ -15,10 +16,12 @@ import (
 	"github.com/derekparker/delve/pkg/config"
 	"github.com/derekparker/delve/pkg/goversion"
 	"github.com/derekparker/delve/pkg/logflags"
+	"github.com/derekparker/delve/pkg/proc"
 	"github.com/derekparker/delve/pkg/terminal"
 	"github.com/derekparker/delve/pkg/version"
 	"github.com/derekparker/delve/service"
 	"github.com/derekparker/delve/service/api"
+	"github.com/derekparker/delve/service/dap"
 	"github.com/derekparker/delve/service/rpc2"
 	"github.com/derekparker/delve/service/rpccommon"
 	"github.com/spf13/cobra"
@@ -39,6 +42,8 @@ var (
 	Addr string
 	// InitFile is the path to initialization file.
 	InitFile string
+	// InitSession is the path to a session file saved by save-session.
+	InitSession string
 	// BuildFlags is the flags passed during compiler invocation.
 	BuildFlags string
 	// WorkingDir is the working directory for running the program.
@@ -47,11 +52,17 @@ var (
 	// Backend selection
 	Backend string
 
+	// FollowExec and FollowFork select which child processes of the target
+	// are debugged.
+	FollowExec bool
+	FollowFork bool
+
 	// RootCommand is the root of the command tree.
 	RootCommand *cobra.Command
 
-	traceAttachPid  int
-	traceStackDepth int
+	traceAttachPid    int
+	traceStackDepth   int
+	traceOutputFormat string
 
 	conf *config.Config
 )
@@ -98,6 +109,7 @@ Defaults to "debugger" when logging is enabled with --log.`)
 	RootCommand.PersistentFlags().BoolVarP(&AcceptMulti, "accept-multiclient", "", false, "Allows a headless server to accept multiple client connections. Note that the server API is not reentrant and clients will have to coordinate.")
 	RootCommand.PersistentFlags().IntVar(&APIVersion, "api-version", 1, "Selects API version when headless.")
 	RootCommand.PersistentFlags().StringVar(&InitFile, "init", "", "Init file, executed by the terminal client.")
+	RootCommand.PersistentFlags().StringVar(&InitSession, "init-session", "", "Session file, saved by the save-session command and restored by the terminal client.")
 	RootCommand.PersistentFlags().StringVar(&BuildFlags, "build-flags", buildFlagsDefault, "Build flags, to be passed to the compiler.")
 	RootCommand.PersistentFlags().StringVar(&WorkingDir, "wd", ".", "Working directory for running the program.")
 	RootCommand.PersistentFlags().StringVar(&Backend, "backend", "default", `Backend selection:
@@ -106,6 +118,8 @@ Defaults to "debugger" when logging is enabled with --log.`)
 	lldb		Uses lldb-server or debugserver.
 	rr		Uses mozilla rr (https://github.com/mozilla/rr).
 `)
+	RootCommand.PersistentFlags().BoolVar(&FollowExec, "follow-exec", false, "Debug the programs executed by the target and by its child processes (linux, native backend only).")
+	RootCommand.PersistentFlags().BoolVar(&FollowFork, "follow-fork", false, "Debug the child processes forked by the target (linux, native backend only).")
 
 	// 'attach' subcommand.
 	attachCommand := &cobra.Command{
@@ -142,6 +156,33 @@ option to let the process continue or kill it.
 	}
 	RootCommand.AddCommand(connectCommand)
 
+	// 'dap' subcommand.
+	dapCommand := &cobra.Command{
+		Use:   "dap",
+		Short: "[EXPERIMENTAL] Starts a headless TCP server communicating via Debug Adapter Protocol (DAP).",
+		Long: `[EXPERIMENTAL] Starts a headless TCP server communicating via Debug Adapter Protocol (DAP).
+
+The server waits for a single DAP client to connect, the program is
+started by the launch or attach request of the client. The launch request
+supports the following attributes:
+
+	program		path to the executable to debug, or of the executable that produced the core dump
+	args		arguments of the program
+	cwd		working directory of the program
+	mode		"exec" (default) to run the program, "core" to open a core dump
+	coreFilePath	path to the core dump, in "core" mode
+	stopOnEntry	stop the program before it runs
+	backend		overrides the --backend flag
+
+The attach request supports the processId, program, stopOnEntry and
+backend attributes.
+
+The server exits when the client disconnects. Only the --listen, --log,
+--log-output and --backend flags are used, the other flags are ignored.`,
+		Run: dapCmd,
+	}
+	RootCommand.AddCommand(dapCommand)
+
 	// 'debug' subcommand.
 	debugCommand := &cobra.Command{
 		Use:   "debug [package]",
@@ -214,12 +255,20 @@ that package instead.`,
 The trace sub command will set a tracepoint on every function matching the
 provided regular expression and output information when tracepoint is hit.  This
 is useful if you do not want to begin an entire debug session, but merely want
-to know what functions your process is executing.`,
+to know what functions your process is executing.
+
+Tracepoints are also set on the return instructions of the traced functions,
+printing the values they return and how long each call took. With
+--output-format=jsonl every event is printed as a JSON object on its own line.`,
 		Run: traceCmd,
 	}
 	traceCommand.Flags().IntVarP(&traceAttachPid, "pid", "p", 0, "Pid to attach to.")
 	traceCommand.Flags().IntVarP(&traceStackDepth, "stack", "s", 0, "Show stack trace with given depth.")
 	traceCommand.Flags().String("output", "debug", "Output path for the binary.")
+	traceCommand.Flags().StringVar(&traceOutputFormat, "output-format", "text", `Format of the trace events:
+	text		Human readable text.
+	jsonl		One JSON object per line, with the goroutine, function, arguments or return values, and time of each event.
+`)
 	RootCommand.AddCommand(traceCommand)
 
 	coreCommand := &cobra.Command{
@@ -286,6 +335,48 @@ func remove(path string) {
 	}
 }
 
+func dapCmd(cmd *cobra.Command, args []string) {
+	status := func() int {
+		if err := logflags.Setup(Log, LogOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+
+		listener, err := net.Listen("tcp", Addr)
+		if err != nil {
+			fmt.Printf("couldn't start listener: %s\n", err)
+			return 1
+		}
+		defer listener.Close()
+
+		disconnectChan := make(chan struct{})
+		server := dap.NewServer(&service.Config{
+			Listener:       listener,
+			Backend:        Backend,
+			PrettyPrinters: prettyPrinters(conf),
+			DisconnectChan: disconnectChan,
+		}, logflags.Debugger())
+		if err := server.Run(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		fmt.Printf("DAP server listening at: %s\n", listener.Addr())
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGINT)
+		select {
+		case <-ch:
+		case <-disconnectChan:
+		}
+		if err := server.Stop(true); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}()
+	os.Exit(status)
+}
+
 func debugCmd(cmd *cobra.Command, args []string) {
 	status := func() int {
 		debugname, err := filepath.Abs(cmd.Flag("output").Value.String())
@@ -319,6 +410,11 @@ func traceCmd(cmd *cobra.Command, args []string) {
 			return 1
 		}
 
+		if traceOutputFormat != "text" && traceOutputFormat != "jsonl" {
+			fmt.Fprintf(os.Stderr, "unknown output format %q\n", traceOutputFormat)
+			return 1
+		}
+
 		debugname, err := filepath.Abs(cmd.Flag("output").Value.String())
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -362,6 +458,8 @@ func traceCmd(cmd *cobra.Command, args []string) {
 			APIVersion:  2,
 			WorkingDir:  WorkingDir,
 			Backend:     Backend,
+			FollowExec:  FollowExec,
+			FollowFork:  FollowFork,
 		}, logflags.Debugger())
 		if err := server.Run(); err != nil {
 			fmt.Fprintln(os.Stderr, err)
@@ -379,9 +477,22 @@ func traceCmd(cmd *cobra.Command, args []string) {
 				fmt.Fprintln(os.Stderr, err)
 				return 1
 			}
+			addrs, err := client.FunctionReturnLocations(funcs[i])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			for _, addr := range addrs {
+				_, err = client.CreateBreakpoint(&api.Breakpoint{Addr: addr, Tracepoint: true, TraceReturn: true, LoadArgs: &terminal.ShortLoadConfig})
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return 1
+				}
+			}
 		}
 		cmds := terminal.DebugCommands(client)
 		t := terminal.New(client, nil)
+		t.TraceJSONL = traceOutputFormat == "jsonl"
 		defer t.Close()
 		err = cmds.Call("continue", t)
 		if err != nil {
@@ -485,6 +596,9 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	if Headless && (InitFile != "") {
 		fmt.Fprint(os.Stderr, "Warning: init file ignored\n")
 	}
+	if Headless && (InitSession != "") {
+		fmt.Fprint(os.Stderr, "Warning: init session ignored\n")
+	}
 
 	var server interface {
 		Run() error
@@ -505,7 +619,11 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 			WorkingDir:  WorkingDir,
 			Backend:     Backend,
 			CoreFile:    coreFile,
+			FollowExec:  FollowExec,
+			FollowFork:  FollowFork,
 
+			PrettyPrinters: prettyPrinters(conf),
+			Displays:       displays(conf),
 			DisconnectChan: disconnectChan,
 		}, logflags.Debugger())
 	default:
@@ -553,6 +671,7 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 		}
 		term := terminal.New(client, conf)
 		term.InitFile = InitFile
+		term.InitSession = InitSession
 		status, err = term.Run()
 	}
 
@@ -563,6 +682,36 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	return status
 }
 
+// displays converts the display list of the configuration file.
+func displays(conf *config.Config) []api.DisplayExpr {
+	if conf == nil {
+		return nil
+	}
+	r := make([]api.DisplayExpr, 0, len(conf.Display))
+	for _, d := range conf.Display {
+		r = append(r, api.DisplayExpr{Expr: d.Expr, MaxStringLen: d.MaxStringLen, MaxArrayValues: d.MaxArrayValues})
+	}
+	return r
+}
+
+// prettyPrinters converts the pretty printers of the configuration file,
+// the ones with an invalid type pattern are skipped.
+func prettyPrinters(conf *config.Config) []proc.PrettyPrinter {
+	if conf == nil {
+		return nil
+	}
+	r := make([]proc.PrettyPrinter, 0, len(conf.PrettyPrinters))
+	for _, pp := range conf.PrettyPrinters {
+		typ, err := regexp.Compile(pp.Type)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: invalid type pattern %q in pretty printer: %v\n", pp.Type, err)
+			continue
+		}
+		r = append(r, proc.PrettyPrinter{Type: typ, Summary: pp.Summary, Children: pp.Children})
+	}
+	return r
+}
+
 func optflags(args []string) []string {
 	// after go1.9 building with -gcflags='-N -l' and -a simultaneously works.
 	// after go1.10 specifying -a is unnecessary because of the new caching strategy, but we should pass -gcflags=all=-N -l to have it applied to all packages
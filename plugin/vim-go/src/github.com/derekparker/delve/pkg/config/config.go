@@ -26,6 +26,34 @@ type SubstitutePathRule struct {
 // Slice of source code path substitution rules.
 type SubstitutePathRules []SubstitutePathRule
 
+// DisplayExpr is an expression printed by the display command every time
+// the target stops.
+type DisplayExpr struct {
+	Expr string `yaml:"expr"`
+	// MaxStringLen and MaxArrayValues, if set, are used instead of the
+	// values of the corresponding configuration options when loading the
+	// value of Expr.
+	MaxStringLen   *int `yaml:"max-string-len,omitempty"`
+	MaxArrayValues *int `yaml:"max-array-values,omitempty"`
+}
+
+// PrettyPrinter describes how the values of the types matching Type are
+// printed. Summary and Children are evaluated with the fields of the value
+// in scope, as if they were local variables.
+type PrettyPrinter struct {
+	// Type is a regular expression matched against the name of the type,
+	// for example `^main\.Ring$`.
+	Type string `yaml:"type"`
+	// Summary replaces the value when it is printed, the expressions
+	// enclosed in curly braces are replaced by their values, for example
+	// "len={tail-head}". Braces can be nested, braces inside string and
+	// character literals are ignored.
+	Summary string `yaml:"summary,omitempty"`
+	// Children is an expression whose elements, or fields, are shown instead
+	// of the fields of the value, for example "buf[head:tail]".
+	Children string `yaml:"children,omitempty"`
+}
+
 // Config defines all configuration options available to be set through the config file.
 type Config struct {
 	// Commands aliases.
@@ -43,6 +71,13 @@ type Config struct {
 	// If ShowLocationExpr is true whatis will print the DWARF location
 	// expression for its argument.
 	ShowLocationExpr bool `yaml:"show-location-expr"`
+
+	// Display lists the expressions printed every time the target stops.
+	Display []DisplayExpr `yaml:"display"`
+
+	// PrettyPrinters lists the pretty printers used to print the values of
+	// user defined types.
+	PrettyPrinters []PrettyPrinter `yaml:"pretty-printers"`
 }
 
 // LoadConfig attempts to populate a Config object from the config.yml file.
@@ -149,6 +184,16 @@ substitute-path:
 
 # Uncomment the following line to make the whatis command also print the DWARF location expression of its argument.
 # show-location-expr: true
+
+# Expressions evaluated and printed every time the program stops, see the display command.
+display:
+  # - {expr: "x"}
+  # - {expr: "buf", max-string-len: 256}
+
+# Pretty printers for user defined types, the fields of the value can be used
+# in summary and children. Use print -raw to see the value without them.
+pretty-printers:
+  # - {type: "^main\\.Ring$", summary: "len={tail-head}", children: "buf[head:tail]"}
 `)
 	return err
 }
@@ -52,7 +52,10 @@ type BinaryInfo struct {
 
 	loadModuleDataOnce sync.Once
 	moduleData         []moduleData
-	nameOfRuntimeType  map[uintptr]nameOfRuntimeTypeEntry
+
+	loadWaitReasonsOnce sync.Once
+	waitReasons         []string
+	nameOfRuntimeType   map[uintptr]nameOfRuntimeTypeEntry
 
 	// consts[off] lists all the constants with the type defined at offset off.
 	consts constantsMap
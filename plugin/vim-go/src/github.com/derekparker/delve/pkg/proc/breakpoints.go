@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"go/ast"
 	"go/constant"
+	"go/token"
 	"reflect"
 )
 
@@ -21,6 +22,7 @@ type Breakpoint struct {
 	OriginalData []byte // If software breakpoint, the data we replace with breakpoint instruction.
 	Name         string // User defined name of the breakpoint
 	ID           int    // Monotonically increasing ID.
+	LocExpr      string // Location expression the breakpoint was set from, if any.
 
 	// Kind describes whether this is an internal breakpoint (for next'ing or
 	// stepping).
@@ -31,6 +33,7 @@ type Breakpoint struct {
 
 	// Breakpoint information
 	Tracepoint    bool     // Tracepoint flag
+	TraceReturn   bool     // Tracepoint on a return instruction, its arguments are the return values
 	Goroutine     bool     // Retrieve goroutine information
 	Stacktrace    int      // Number of stack frames to retrieve
 	Variables     []string // Variables to evaluate
@@ -51,8 +54,94 @@ type Breakpoint struct {
 	DeferReturns []uint64
 	// Cond: if not nil the breakpoint will be triggered only if evaluating Cond returns true
 	Cond ast.Expr
+	// HitCond: if not nil the breakpoint will be triggered only if the hit
+	// count, incremented every time Cond is true, satisfies HitCond.
+	HitCond *HitCondition
+	// LogMessage: if not empty the breakpoint is a logpoint, LogMessage is a
+	// format string interpolating the expressions enclosed in curly braces,
+	// printed every time the breakpoint is hit.
+	LogMessage string
 	// internalCond is the same as Cond but used for the condition of internal breakpoints
 	internalCond ast.Expr
+
+	// Watchpoint information: if WatchType is not zero this is a hardware
+	// watchpoint on the WatchSize bytes at Addr, set using the debug register
+	// HWBreakIndex, instead of a breakpoint on code.
+	WatchExpr    string
+	WatchType    WatchType
+	WatchSize    int
+	HWBreakIndex uint8
+
+	// watchVar describes the watched memory, watchValue is its content when
+	// it was last checked.
+	watchVar   *Variable
+	watchValue []byte
+	// watchStack is the stack frame of a watched stack variable, nil if the
+	// watched memory does not belong to a goroutine stack.
+	watchStack *watchStackFrame
+}
+
+// HitCondition is a condition on the hit count of a breakpoint.
+type HitCondition struct {
+	Op  token.Token // one of token.EQL, NEQ, GTR, LSS, GEQ, LEQ or REM
+	Val uint64
+}
+
+// Check returns true if count satisfies the condition, token.REM is
+// satisfied by the multiples of Val.
+func (hc *HitCondition) Check(count uint64) bool {
+	switch hc.Op {
+	case token.EQL:
+		return count == hc.Val
+	case token.NEQ:
+		return count != hc.Val
+	case token.GTR:
+		return count > hc.Val
+	case token.LSS:
+		return count < hc.Val
+	case token.GEQ:
+		return count >= hc.Val
+	case token.LEQ:
+		return count <= hc.Val
+	case token.REM:
+		return hc.Val != 0 && count%hc.Val == 0
+	}
+	return false
+}
+
+func (hc *HitCondition) String() string {
+	return fmt.Sprintf("%s %d", hc.Op, hc.Val)
+}
+
+// WatchType is the type of memory access a watchpoint stops on.
+type WatchType uint8
+
+const (
+	// WatchRead stops when the watched memory is read.
+	WatchRead WatchType = 1 << iota
+	// WatchWrite stops when the watched memory is written.
+	WatchWrite
+)
+
+// Read returns true if the watchpoint stops on reads.
+func (wtype WatchType) Read() bool {
+	return wtype&WatchRead != 0
+}
+
+// Write returns true if the watchpoint stops on writes.
+func (wtype WatchType) Write() bool {
+	return wtype&WatchWrite != 0
+}
+
+func (wtype WatchType) String() string {
+	s := ""
+	if wtype.Read() {
+		s += "r"
+	}
+	if wtype.Write() {
+		s += "w"
+	}
+	return s
 }
 
 // Breakpoint Kind determines the behavior of delve when the
@@ -77,6 +166,9 @@ const (
 )
 
 func (bp *Breakpoint) String() string {
+	if bp.WatchType != 0 {
+		return fmt.Sprintf("Watchpoint %d at %#v %s (%s) (%d)", bp.ID, bp.Addr, bp.WatchExpr, bp.WatchType, bp.TotalHitCount)
+	}
 	return fmt.Sprintf("Breakpoint %d at %#v %s:%d (%d)", bp.ID, bp.Addr, bp.File, bp.Line, bp.TotalHitCount)
 }
 
@@ -102,9 +194,40 @@ func (iae InvalidAddressError) Error() string {
 	return fmt.Sprintf("Invalid address %#v\n", iae.Address)
 }
 
-// CheckCondition evaluates bp's condition on thread.
+// CheckCondition evaluates bp's condition on thread and, if it is
+// satisfied, increments the hit counts of bp and checks its hit condition.
 func (bp *Breakpoint) CheckCondition(thread Thread) BreakpointState {
+	bpstate := bp.checkCondition(thread)
+	if !bpstate.Active {
+		return bpstate
+	}
+	if g, err := GetG(thread); err == nil {
+		bp.HitCount[g.ID]++
+	}
+	bp.TotalHitCount++
+	if !bpstate.Internal && bp.HitCond != nil {
+		bpstate.Active = bp.HitCond.Check(bp.TotalHitCount)
+	}
+	return bpstate
+}
+
+func (bp *Breakpoint) checkCondition(thread Thread) BreakpointState {
 	bpstate := BreakpointState{Breakpoint: bp, Active: false, Internal: false, CondError: nil}
+	if bp.WatchType != 0 {
+		if bp.watchStack != nil {
+			inScope, moved := bp.watchStack.inScope(thread)
+			if !inScope {
+				bpstate.WatchOutOfScope = true
+				return bpstate
+			}
+			if moved {
+				// Access to the old stack, the watchpoint is moved to the
+				// new one by clearWatchpointsOutOfScope.
+				return bpstate
+			}
+		}
+		bpstate.WatchOldValue, bpstate.WatchNewValue = bp.watchValues(thread)
+	}
 	if bp.Cond == nil && bp.internalCond == nil {
 		bpstate.Active = true
 		bpstate.Internal = bp.Kind != UserBreakpoint
@@ -192,6 +315,10 @@ func (nbp NoBreakpointError) Error() string {
 type BreakpointMap struct {
 	M map[uint64]*Breakpoint
 
+	// WatchOutOfScope lists the watchpoints on stack variables that were
+	// removed during the last call to Continue because their frame returned.
+	WatchOutOfScope []*Breakpoint
+
 	breakpointIDCounter         int
 	internalBreakpointIDCounter int
 }
@@ -208,7 +335,16 @@ func (bpmap *BreakpointMap) ResetBreakpointIDCounter() {
 	bpmap.breakpointIDCounter = 0
 }
 
+// ReserveBreakpointID ensures that the breakpoints created by Set from now
+// on will have IDs greater than id.
+func (bpmap *BreakpointMap) ReserveBreakpointID(id int) {
+	if id > bpmap.breakpointIDCounter {
+		bpmap.breakpointIDCounter = id
+	}
+}
+
 type writeBreakpointFn func(addr uint64) (file string, line int, fn *Function, originalData []byte, err error)
+type writeWatchpointFn func(*Breakpoint) error
 type clearBreakpointFn func(*Breakpoint) error
 
 // Set creates a breakpoint at addr calling writeBreakpoint. Do not call this
@@ -271,6 +407,53 @@ func (bpmap *BreakpointMap) SetWithID(id int, addr uint64, writeBreakpoint write
 	return bp, err
 }
 
+// MaxHardwareBreakpoints is the number of debug address registers that can
+// hold a watchpoint, DR0 to DR3 on x86.
+const MaxHardwareBreakpoints = 4
+
+// SetWatchpoint creates a watchpoint on size bytes at addr, assigning it
+// the first free debug register and calling writeWatchpoint. Do not call
+// this function, call proc.Watch instead, this function exists to
+// implement proc.Process.SetWatchpoint.
+func (bpmap *BreakpointMap) SetWatchpoint(addr uint64, size int, wtype WatchType, cond ast.Expr, writeWatchpoint writeWatchpointFn) (*Breakpoint, error) {
+	if bp, ok := bpmap.M[addr]; ok {
+		return bp, BreakpointExistsError{bp.File, bp.Line, bp.Addr}
+	}
+
+	used := map[uint8]bool{}
+	for _, bp := range bpmap.M {
+		if bp.WatchType != 0 {
+			used[bp.HWBreakIndex] = true
+		}
+	}
+	var idx uint8
+	for used[idx] {
+		idx++
+	}
+	if idx >= MaxHardwareBreakpoints {
+		return nil, fmt.Errorf("all %d hardware debug registers are in use", MaxHardwareBreakpoints)
+	}
+
+	newBreakpoint := &Breakpoint{
+		Addr:         addr,
+		Kind:         UserBreakpoint,
+		Cond:         cond,
+		HitCount:     map[int]uint64{},
+		WatchType:    wtype,
+		WatchSize:    size,
+		HWBreakIndex: idx,
+	}
+	if err := writeWatchpoint(newBreakpoint); err != nil {
+		return nil, err
+	}
+
+	bpmap.breakpointIDCounter++
+	newBreakpoint.ID = bpmap.breakpointIDCounter
+	bpmap.M[addr] = newBreakpoint
+
+	return newBreakpoint, nil
+}
+
 // Clear clears the breakpoint at addr.
 // Do not call this function call proc.Process.ClearBreakpoint instead.
 func (bpmap *BreakpointMap) Clear(addr uint64, clearBreakpoint clearBreakpointFn) (*Breakpoint, error) {
@@ -335,6 +518,12 @@ type BreakpointState struct {
 	// CondError contains any error encountered while evaluating the
 	// breakpoint's condition.
 	CondError error
+	// WatchOldValue and WatchNewValue are the values of the watched memory
+	// before and after the access that triggered a watchpoint.
+	WatchOldValue, WatchNewValue *Variable
+	// WatchOutOfScope is true if the watchpoint was triggered after the
+	// frame of the watched stack variable returned.
+	WatchOutOfScope bool
 }
 
 func (bpstate *BreakpointState) Clear() {
@@ -342,6 +531,9 @@ func (bpstate *BreakpointState) Clear() {
 	bpstate.Active = false
 	bpstate.Internal = false
 	bpstate.CondError = nil
+	bpstate.WatchOldValue = nil
+	bpstate.WatchNewValue = nil
+	bpstate.WatchOutOfScope = false
 }
 
 func (bpstate *BreakpointState) String() string {
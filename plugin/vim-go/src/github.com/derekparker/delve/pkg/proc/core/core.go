@@ -97,7 +97,7 @@ func (r *SplicedMemory) Add(reader proc.MemoryReader, off, length uintptr) {
 func (r *SplicedMemory) ReadMemory(buf []byte, addr uintptr) (n int, err error) {
 	started := false
 	for _, entry := range r.readers {
-		if entry.offset+entry.length < addr {
+		if entry.offset+entry.length <= addr {
 			if !started {
 				continue
 			}
@@ -200,6 +200,7 @@ func (p *Process) BinInfo() *proc.BinaryInfo {
 func (p *Process) Recorded() (bool, string)                { return true, "" }
 func (p *Process) Restart(string) error                    { return ErrContinueCore }
 func (p *Process) Direction(proc.Direction) error          { return ErrContinueCore }
+func (p *Process) GetDirection() proc.Direction            { return proc.Forward }
 func (p *Process) When() (string, error)                   { return "", nil }
 func (p *Process) Checkpoint(string) (int, error)          { return -1, ErrContinueCore }
 func (p *Process) Checkpoints() ([]proc.Checkpoint, error) { return nil, nil }
@@ -317,6 +318,10 @@ func (p *Process) SetBreakpoint(addr uint64, kind proc.BreakpointKind, cond ast.
 	return nil, ErrWriteCore
 }
 
+func (p *Process) SetWatchpoint(addr uint64, size int, wtype proc.WatchType, cond ast.Expr) (*proc.Breakpoint, error) {
+	return nil, ErrWriteCore
+}
+
 func (p *Process) SwitchGoroutine(gid int) error {
 	g, err := proc.FindGoroutine(p, gid)
 	if err != nil {
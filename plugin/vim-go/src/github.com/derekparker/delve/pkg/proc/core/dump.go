@@ -0,0 +1,294 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+
+	"github.com/derekparker/delve/pkg/proc"
+)
+
+var (
+	// ErrDumpNotSupported is returned by Dump when the backend can not list
+	// the memory regions of the target process.
+	ErrDumpNotSupported = errors.New("core dumps are not supported by this backend")
+	// ErrDumpCanceled is returned by Dump when the progress function asks to
+	// stop.
+	ErrDumpCanceled = errors.New("core dump canceled")
+)
+
+const (
+	dumpPageSize  = 0x1000
+	dumpChunkSize = 1 << 20
+
+	elfHeaderSize     = 64
+	elfProgHeaderSize = 56
+)
+
+// DumpProgress is the progress of a core dump written by Dump.
+type DumpProgress struct {
+	ThreadsDone, ThreadsTotal int
+	MemDone, MemTotal         uint64
+}
+
+// xstateRegisters is implemented by the registers of the native backend,
+// it returns the XSAVE area the floating point registers were read from.
+type xstateRegisters interface {
+	Xstate() *proc.LinuxX86Xstate
+}
+
+// Dump writes an ELF core file of the stopped process p to out, in the
+// same format used by the linux kernel and read by OpenCore.
+// Progress is called after each thread and each chunk of memory is written,
+// if it returns false the dump is stopped and ErrDumpCanceled is returned.
+func Dump(p proc.Process, out io.Writer, progress func(DumpProgress) bool) error {
+	if p.Exited() {
+		return proc.ProcessExitedError{Pid: p.Pid()}
+	}
+	bi := p.BinInfo()
+	if bi.GOOS != "linux" || bi.Arch.PtrSize() != 8 {
+		return ErrDumpNotSupported
+	}
+	mapper, ok := p.(proc.MemoryMapper)
+	if !ok {
+		return ErrDumpNotSupported
+	}
+	mmap, err := mapper.MemoryMap()
+	if err != nil {
+		return err
+	}
+	mem := p.CurrentThread()
+
+	// The current thread goes first, like the thread that received the
+	// fatal signal in core files written by the kernel.
+	threads := []proc.Thread{mem}
+	for _, th := range p.ThreadList() {
+		if th.ThreadID() != mem.ThreadID() {
+			threads = append(threads, th)
+		}
+	}
+
+	var state DumpProgress
+	state.ThreadsTotal = len(threads)
+	exeFilename := executableFilename(bi, mmap)
+	for _, region := range mmap {
+		if dumpRegionContents(region, exeFilename) {
+			state.MemTotal += region.Size
+		}
+	}
+
+	var notes bytes.Buffer
+	psinfo := LinuxPrPsInfo{Pid: int32(p.Pid())}
+	copy(psinfo.Fname[:len(psinfo.Fname)-1], filepath.Base(exeFilename))
+	copy(psinfo.Args[:len(psinfo.Args)-1], exeFilename)
+	writeNote(&notes, elf.NT_PRPSINFO, "CORE", encodeDesc(&psinfo))
+
+	for _, th := range threads {
+		regs, err := th.Registers(true)
+		if err != nil {
+			return err
+		}
+		prstatus := LinuxPrStatus{Pid: int32(th.ThreadID()), Reg: linuxCoreRegisters(regs)}
+		var xstate *proc.LinuxX86Xstate
+		if xregs, ok := regs.(xstateRegisters); ok {
+			xstate = xregs.Xstate()
+		}
+		if xstate != nil {
+			prstatus.Fpvalid = 1
+		}
+		// struct elf_prstatus has 4 bytes of trailing padding on amd64.
+		writeNote(&notes, elf.NT_PRSTATUS, "CORE", append(encodeDesc(&prstatus), 0, 0, 0, 0))
+		if xstate != nil {
+			writeNote(&notes, NT_X86_XSTATE, "LINUX", xstate.Encode())
+		}
+		state.ThreadsDone++
+		if !progress(state) {
+			return ErrDumpCanceled
+		}
+	}
+
+	writeNote(&notes, NT_FILE, "CORE", encodeNTFile(mmap))
+
+	// Layout: ELF header, program headers, notes and, starting at the first
+	// page boundary after them, the contents of each PT_LOAD segment.
+	phnum := 1 + len(mmap)
+	notesOff := uint64(elfHeaderSize + elfProgHeaderSize*phnum)
+	off := alignUp(notesOff+uint64(notes.Len()), dumpPageSize)
+	dataOff := off
+
+	progs := make([]elf.Prog64, 0, phnum)
+	progs = append(progs, elf.Prog64{
+		Type:   uint32(elf.PT_NOTE),
+		Off:    notesOff,
+		Filesz: uint64(notes.Len()),
+		Align:  4,
+	})
+	for _, region := range mmap {
+		prog := elf.Prog64{
+			Type:  uint32(elf.PT_LOAD),
+			Flags: progFlags(region),
+			Off:   off,
+			Vaddr: region.Addr,
+			Memsz: region.Size,
+			Align: dumpPageSize,
+		}
+		if dumpRegionContents(region, exeFilename) {
+			prog.Filesz = region.Size
+		}
+		off += prog.Filesz
+		progs = append(progs, prog)
+	}
+
+	hdr := elf.Header64{
+		Type:      uint16(elf.ET_CORE),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Phoff:     elfHeaderSize,
+		Ehsize:    elfHeaderSize,
+		Phentsize: elfProgHeaderSize,
+		Phnum:     uint16(phnum),
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	hdr.Ident[elf.EI_OSABI] = byte(elf.ELFOSABI_NONE)
+
+	w := bufio.NewWriter(out)
+	binary.Write(w, binary.LittleEndian, &hdr)
+	binary.Write(w, binary.LittleEndian, progs)
+	w.Write(notes.Bytes())
+	w.Write(make([]byte, dataOff-notesOff-uint64(notes.Len())))
+
+	buf := make([]byte, dumpChunkSize)
+	for i, region := range mmap {
+		for done := uint64(0); done < progs[i+1].Filesz; {
+			chunk := buf
+			if rem := progs[i+1].Filesz - done; rem < uint64(len(chunk)) {
+				chunk = chunk[:rem]
+			}
+			// Regions that can not be read, like [vvar], are written as zeroes
+			// to keep the offsets computed above valid.
+			if _, err := mem.ReadMemory(chunk, uintptr(region.Addr+done)); err != nil {
+				for j := range chunk {
+					chunk[j] = 0
+				}
+			}
+			if _, err := w.Write(chunk); err != nil {
+				return err
+			}
+			done += uint64(len(chunk))
+			state.MemDone += uint64(len(chunk))
+			if !progress(state) {
+				return ErrDumpCanceled
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// executableFilename returns the name of the file mapped at the address of
+// the first function of the executable.
+func executableFilename(bi *proc.BinaryInfo, mmap []proc.MemoryMapEntry) string {
+	if len(bi.Functions) == 0 {
+		return ""
+	}
+	entry := bi.Functions[0].Entry
+	for _, region := range mmap {
+		if entry >= region.Addr && entry < region.Addr+region.Size {
+			return region.Filename
+		}
+	}
+	return ""
+}
+
+// dumpRegionContents returns true if the contents of region should be
+// saved in the core file. Read-only mappings of the executable are left
+// out, OpenCore reads them from the executable through the NT_FILE note,
+// which also keeps the breakpoints set in the text out of the dump.
+func dumpRegionContents(region proc.MemoryMapEntry, exeFilename string) bool {
+	if !region.Read {
+		return false
+	}
+	return region.Write || exeFilename == "" || region.Filename != exeFilename
+}
+
+func progFlags(region proc.MemoryMapEntry) uint32 {
+	var flags elf.ProgFlag
+	if region.Read {
+		flags |= elf.PF_R
+	}
+	if region.Write {
+		flags |= elf.PF_W
+	}
+	if region.Exec {
+		flags |= elf.PF_X
+	}
+	return uint32(flags)
+}
+
+// linuxCoreRegisters converts regs to the format of NT_PRSTATUS, matching
+// registers by name.
+func linuxCoreRegisters(regs proc.Registers) LinuxCoreRegisters {
+	var out LinuxCoreRegisters
+	v := reflect.ValueOf(&out).Elem()
+	for _, reg := range regs.Slice() {
+		if field := v.FieldByName(reg.Name); field.IsValid() && len(reg.Bytes) >= 8 {
+			field.SetUint(binary.LittleEndian.Uint64(reg.Bytes))
+		}
+	}
+	return out
+}
+
+// encodeNTFile encodes the file backed regions of mmap as the descriptor
+// of a NT_FILE note.
+func encodeNTFile(mmap []proc.MemoryMapEntry) []byte {
+	var entries []LinuxNTFileEntry
+	var names bytes.Buffer
+	for _, region := range mmap {
+		if len(region.Filename) == 0 || region.Filename[0] != '/' {
+			continue
+		}
+		entries = append(entries, LinuxNTFileEntry{
+			Start:   region.Addr,
+			End:     region.Addr + region.Size,
+			FileOfs: region.Offset / dumpPageSize,
+		})
+		names.WriteString(region.Filename)
+		names.WriteByte(0)
+	}
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, &LinuxNTFileHdr{Count: uint64(len(entries)), PageSize: dumpPageSize})
+	binary.Write(&buf, binary.LittleEndian, entries)
+	buf.Write(names.Bytes())
+	return buf.Bytes()
+}
+
+func encodeDesc(desc interface{}) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, desc)
+	return buf.Bytes()
+}
+
+// writeNote writes a note in the format read by readNote.
+func writeNote(w *bytes.Buffer, typ elf.NType, name string, desc []byte) {
+	binary.Write(w, binary.LittleEndian, &ELFNotesHdr{
+		Namesz: uint32(len(name) + 1),
+		Descsz: uint32(len(desc)),
+		Type:   uint32(typ),
+	})
+	w.WriteString(name)
+	w.WriteByte(0)
+	w.Write(make([]byte, alignUp(uint64(w.Len()), 4)-uint64(w.Len())))
+	w.Write(desc)
+	w.Write(make([]byte, alignUp(uint64(w.Len()), 4)-uint64(w.Len())))
+}
+
+func alignUp(x, align uint64) uint64 {
+	return (x + align - 1) &^ (align - 1)
+}
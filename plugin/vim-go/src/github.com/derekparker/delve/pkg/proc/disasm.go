@@ -1,6 +1,9 @@
 package proc
 
-import "sort"
+import (
+	"fmt"
+	"sort"
+)
 
 type AsmInstruction struct {
 	Loc        Location
@@ -43,6 +46,30 @@ func Disassemble(dbp Process, g *G, startPC, endPC uint64) ([]AsmInstruction, er
 	return disassemble(mem, regs, dbp.Breakpoints(), dbp.BinInfo(), startPC, endPC, false)
 }
 
+// FunctionReturnLocations returns the addresses of all the RET
+// instructions in function fnName, a breakpoint on each of them stops the
+// process after the function has set its return values.
+func FunctionReturnLocations(p Process, fnName string) ([]uint64, error) {
+	if p.Exited() {
+		return nil, &ProcessExitedError{Pid: p.Pid()}
+	}
+	fn, ok := p.BinInfo().LookupFunc[fnName]
+	if !ok {
+		return nil, fmt.Errorf("could not find function %s", fnName)
+	}
+	text, err := disassemble(p.CurrentThread(), nil, p.Breakpoints(), p.BinInfo(), fn.Entry, fn.End, false)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []uint64
+	for i := range text {
+		if text[i].Inst != nil && text[i].IsRet() {
+			addrs = append(addrs, text[i].Loc.PC)
+		}
+	}
+	return addrs, nil
+}
+
 func disassemble(memrw MemoryReadWriter, regs Registers, breakpoints *BreakpointMap, bi *BinaryInfo, startPC, endPC uint64, singleInstr bool) ([]AsmInstruction, error) {
 	mem := make([]byte, int(endPC-startPC))
 	_, err := memrw.ReadMemory(mem, uintptr(startPC))
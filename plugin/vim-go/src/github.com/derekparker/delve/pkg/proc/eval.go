@@ -223,7 +223,7 @@ func (scope *EvalScope) evalAST(t ast.Expr) (*Variable, error) {
 		return newConstant(constant.MakeFromLiteral(node.Value, node.Kind, 0), scope.Mem), nil
 
 	default:
-		return nil, fmt.Errorf("expression %T not implemented", t)
+		return nil, fmt.Errorf("expression \"%s\" (%T) not implemented", exprToString(t), t)
 
 	}
 }
@@ -360,10 +360,30 @@ func convertInt(n uint64, signed bool, size int64) uint64 {
 	return uint64(binary.BigEndian.Uint64(buf))
 }
 
+// supportedBuiltins maps the name of each supported builtin function to
+// its implementation.
+var supportedBuiltins = map[string]func([]*Variable, []ast.Expr) (*Variable, error){
+	"cap":     capBuiltin,
+	"len":     lenBuiltin,
+	"complex": complexBuiltin,
+	"imag":    imagBuiltin,
+	"real":    realBuiltin,
+	"min": func(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+		return minmaxBuiltin("min", token.LSS, args, nodeargs)
+	},
+	"max": func(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+		return minmaxBuiltin("max", token.GTR, args, nodeargs)
+	},
+}
+
 func (scope *EvalScope) evalBuiltinCall(node *ast.CallExpr) (*Variable, error) {
 	fnnode, ok := node.Fun.(*ast.Ident)
 	if !ok {
-		return nil, fmt.Errorf("function calls are not supported")
+		return nil, fmt.Errorf("function calls are not supported: %s", exprToString(node))
+	}
+	builtin, ok := supportedBuiltins[fnnode.Name]
+	if !ok {
+		return nil, fmt.Errorf("function calls are not supported: %s", exprToString(node))
 	}
 
 	args := make([]*Variable, len(node.Args))
@@ -376,20 +396,7 @@ func (scope *EvalScope) evalBuiltinCall(node *ast.CallExpr) (*Variable, error) {
 		args[i] = v
 	}
 
-	switch fnnode.Name {
-	case "cap":
-		return capBuiltin(args, node.Args)
-	case "len":
-		return lenBuiltin(args, node.Args)
-	case "complex":
-		return complexBuiltin(args, node.Args)
-	case "imag":
-		return imagBuiltin(args, node.Args)
-	case "real":
-		return realBuiltin(args, node.Args)
-	}
-
-	return nil, fmt.Errorf("function calls are not supported")
+	return builtin(args, node.Args)
 }
 
 func capBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
@@ -554,6 +561,56 @@ func realBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 	return newConstant(constant.Real(arg.Value), arg.mem), nil
 }
 
+// minmaxBuiltin implements the min and max builtins, op is the operator
+// used to decide if an argument replaces the result.
+func minmaxBuiltin(name string, op token.Token, args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("not enough arguments to %s", name)
+	}
+
+	var r, typed *Variable
+	for i, arg := range args {
+		arg.loadValue(loadFullValue)
+		if arg.Unreadable != nil {
+			return nil, arg.Unreadable
+		}
+		if arg.Value == nil || (arg.Value.Kind() != constant.Int && arg.Value.Kind() != constant.Float && arg.Value.Kind() != constant.String) {
+			return nil, fmt.Errorf("invalid argument %s (type %s) to %s", exprToString(nodeargs[i]), arg.TypeString(), name)
+		}
+		if arg.FloatSpecial != 0 {
+			return nil, OperationOnSpecialFloatError
+		}
+		if i == 0 {
+			r, typed = arg, arg
+			continue
+		}
+		if (arg.Value.Kind() == constant.String) != (r.Value.Kind() == constant.String) {
+			return nil, fmt.Errorf("invalid argument %s (type %s) to %s: mismatched types", exprToString(nodeargs[i]), arg.TypeString(), name)
+		}
+		if _, err := negotiateType(op, typed, arg); err != nil {
+			return nil, fmt.Errorf("invalid argument %s to %s: %v", exprToString(nodeargs[i]), name, err)
+		}
+		if typed.DwarfType == nil {
+			typed = arg
+		}
+		replace, err := compareOp(op, arg, r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument %s to %s: %v", exprToString(nodeargs[i]), name, err)
+		}
+		if replace {
+			r = arg
+		}
+	}
+
+	if typed.DwarfType == nil {
+		return newConstant(r.Value, r.mem), nil
+	}
+	v := typed.newVariable("", 0, typed.DwarfType, typed.mem)
+	v.Value = r.Value
+	v.Len = r.Len
+	return v, nil
+}
+
 // Evaluates identifier expressions
 func (scope *EvalScope) evalIdent(node *ast.Ident) (*Variable, error) {
 	switch node.Name {
@@ -563,6 +620,10 @@ func (scope *EvalScope) evalIdent(node *ast.Ident) (*Variable, error) {
 		return nilVariable, nil
 	}
 
+	if scope.fields != nil {
+		return scope.fields.structMember(node.Name)
+	}
+
 	vars, err := scope.Locals()
 	if err != nil {
 		return nil, err
@@ -663,7 +724,7 @@ func (scope *EvalScope) evalIndex(node *ast.IndexExpr) (*Variable, error) {
 		}
 		n, err := idxev.asInt()
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid index %s: %v", exprToString(node.Index), err)
 		}
 		return xev.sliceAccess(int(n))
 
@@ -689,6 +750,18 @@ func (scope *EvalScope) evalReslice(node *ast.SliceExpr) (*Variable, error) {
 		return nil, xev.Unreadable
 	}
 
+	if xev.Kind == reflect.Ptr && xev != nilVariable {
+		// slicing a pointer to array slices the array
+		if ptyp, ok := xev.RealType.(*godwarf.PtrType); ok {
+			if _, isarr := resolveTypedef(ptyp.Type).(*godwarf.ArrayType); isarr {
+				xev = xev.maybeDereference()
+				if xev.Unreadable != nil {
+					return nil, xev.Unreadable
+				}
+			}
+		}
+	}
+
 	var low, high int64
 
 	if node.Low != nil {
@@ -716,7 +789,14 @@ func (scope *EvalScope) evalReslice(node *ast.SliceExpr) (*Variable, error) {
 	}
 
 	switch xev.Kind {
-	case reflect.Slice, reflect.Array, reflect.String:
+	case reflect.String:
+		if xev.Base == 0 && xev.Value != nil {
+			// string constants and results of concatenations are not stored in
+			// the target's memory
+			return xev.resliceConstant(low, high)
+		}
+		fallthrough
+	case reflect.Slice, reflect.Array:
 		if xev.Base == 0 {
 			return nil, fmt.Errorf("can not slice \"%s\"", exprToString(node.X))
 		}
@@ -876,6 +956,9 @@ func negotiateType(op token.Token, xv, yv *Variable) (godwarf.Type, error) {
 	}
 
 	if xv.DwarfType == nil && yv.DwarfType == nil {
+		if xv.Value != nil && yv.Value != nil && constantClass(xv.Value) != constantClass(yv.Value) {
+			return nil, fmt.Errorf("mismatched types \"%s\" and \"%s\"", xv.Kind, yv.Kind)
+		}
 		return nil, nil
 	}
 
@@ -899,6 +982,16 @@ func negotiateType(op token.Token, xv, yv *Variable) (godwarf.Type, error) {
 	panic("unreachable")
 }
 
+// constantClass returns the kind of val, with all numeric kinds
+// considered the same.
+func constantClass(val constant.Value) constant.Kind {
+	switch val.Kind() {
+	case constant.Int, constant.Float, constant.Complex:
+		return constant.Int
+	}
+	return val.Kind()
+}
+
 func negotiateTypeNil(op token.Token, v *Variable) error {
 	if op != token.EQL && op != token.NEQ {
 		return fmt.Errorf("operator %s can not be applied to \"nil\"", op.String())
@@ -951,9 +1044,17 @@ func (scope *EvalScope) evalBinary(node *ast.BinaryExpr) (*Variable, error) {
 		return nil, OperationOnSpecialFloatError
 	}
 
+	if iv, v := interfaceAndValue(xv, yv); iv != nil && (node.Op == token.EQL || node.Op == token.NEQ) {
+		eql, err := iv.dynamicValueEqual(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid operation: %s (%v)", exprToString(node), err)
+		}
+		return newConstant(constant.MakeBool(eql == (node.Op == token.EQL)), xv.mem), nil
+	}
+
 	typ, err := negotiateType(node.Op, xv, yv)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid operation: %s (%v)", exprToString(node), err)
 	}
 
 	op := node.Op
@@ -970,7 +1071,7 @@ func (scope *EvalScope) evalBinary(node *ast.BinaryExpr) (*Variable, error) {
 	case token.EQL, token.LSS, token.GTR, token.NEQ, token.LEQ, token.GEQ:
 		v, err := compareOp(op, xv, yv)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid operation: %s (%v)", exprToString(node), err)
 		}
 		return newConstant(constant.MakeBool(v), xv.mem), nil
 
@@ -983,6 +1084,15 @@ func (scope *EvalScope) evalBinary(node *ast.BinaryExpr) (*Variable, error) {
 			return nil, fmt.Errorf("operator %s can not be applied to \"%s\"", node.Op.String(), exprToString(node.Y))
 		}
 
+		if op == token.ADD && xv.Kind == reflect.String {
+			if int64(len(constant.StringVal(xv.Value))) != xv.Len {
+				return nil, fmt.Errorf("string \"%s\" too long for concatenation", exprToString(node.X))
+			}
+			if int64(len(constant.StringVal(yv.Value))) != yv.Len {
+				return nil, fmt.Errorf("string \"%s\" too long for concatenation", exprToString(node.Y))
+			}
+		}
+
 		rc, err := constantBinaryOp(op, xv.Value, yv.Value)
 		if err != nil {
 			return nil, err
@@ -1087,6 +1197,70 @@ func compareOp(op token.Token, xv *Variable, yv *Variable) (bool, error) {
 	return eql, err
 }
 
+// interfaceAndValue returns the operand that is an interface and the one
+// that is not, if xv and yv are one of each.
+func interfaceAndValue(xv, yv *Variable) (iv, v *Variable) {
+	if xv == nilVariable || yv == nilVariable {
+		return nil, nil
+	}
+	switch {
+	case xv.Kind == reflect.Interface && yv.Kind != reflect.Interface:
+		return xv, yv
+	case xv.Kind != reflect.Interface && yv.Kind == reflect.Interface:
+		return yv, xv
+	}
+	return nil, nil
+}
+
+// dynamicValueEqual returns true if the dynamic type of the interface iv is
+// the type of v and the dynamic value of iv is equal to v. Constants are
+// compared as if they had their default type.
+func (iv *Variable) dynamicValueEqual(v *Variable) (bool, error) {
+	if len(iv.Children) == 0 {
+		return false, fmt.Errorf("can not compare unloaded interface")
+	}
+	data := &iv.Children[0]
+	if data.Kind == reflect.Invalid || data.DwarfType == nil {
+		// nil interface
+		return false, nil
+	}
+	if data.Unreadable != nil {
+		return false, data.Unreadable
+	}
+	if data.DwarfType.String() != v.typeOrDefault() {
+		return false, nil
+	}
+	switch data.Kind {
+	case reflect.Slice, reflect.Map, reflect.Func:
+		return false, fmt.Errorf("can not compare %s variables", data.Kind.String())
+	}
+	return compareOp(token.EQL, data, v)
+}
+
+// typeOrDefault returns the name of the type of v, for untyped constants
+// it returns the name of their default type.
+func (v *Variable) typeOrDefault() string {
+	if v.DwarfType != nil {
+		return v.DwarfType.String()
+	}
+	if v.Value == nil {
+		return ""
+	}
+	switch v.Value.Kind() {
+	case constant.Bool:
+		return "bool"
+	case constant.String:
+		return "string"
+	case constant.Int:
+		return "int"
+	case constant.Float:
+		return "float64"
+	case constant.Complex:
+		return "complex128"
+	}
+	return ""
+}
+
 func (v *Variable) isNil() bool {
 	switch v.Kind {
 	case reflect.Ptr:
@@ -1233,6 +1407,17 @@ func (v *Variable) mapAccess(idx *Variable) (*Variable, error) {
 		if key.Unreadable != nil {
 			return nil, fmt.Errorf("can not access unreadable map: %v", key.Unreadable)
 		}
+		if key.Kind == reflect.Interface && idx.Kind != reflect.Interface {
+			// maps with interface keys are indexed with concrete values
+			eql, err := key.dynamicValueEqual(idx)
+			if err != nil {
+				return nil, err
+			}
+			if eql {
+				return it.value(), nil
+			}
+			continue
+		}
 		if first {
 			first = false
 			if err := idx.isType(key.DwarfType, key.Kind); err != nil {
@@ -1255,7 +1440,7 @@ func (v *Variable) mapAccess(idx *Variable) (*Variable, error) {
 }
 
 func (v *Variable) reslice(low int64, high int64) (*Variable, error) {
-	if low < 0 || low >= v.Len || high < 0 || high > v.Len {
+	if low < 0 || low > v.Len || high < 0 || high > v.Len {
 		return nil, fmt.Errorf("index out of bounds")
 	}
 
@@ -1286,6 +1471,19 @@ func (v *Variable) reslice(low int64, high int64) (*Variable, error) {
 	return r, nil
 }
 
+// resliceConstant slices a string that is not stored in the target's
+// memory.
+func (v *Variable) resliceConstant(low int64, high int64) (*Variable, error) {
+	s := constant.StringVal(v.Value)
+	if low < 0 || low > int64(len(s)) || high < low || high > int64(len(s)) {
+		return nil, fmt.Errorf("index out of bounds")
+	}
+	r := v.clone()
+	r.Value = constant.MakeString(s[low:high])
+	r.Len = high - low
+	return r, nil
+}
+
 func fakeSliceType(fieldType godwarf.Type) godwarf.Type {
 	return &godwarf.SliceType{
 		StructType: godwarf.StructType{
@@ -0,0 +1,785 @@
+package proc
+
+import (
+	"debug/dwarf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/derekparker/delve/pkg/dwarf/godwarf"
+	"github.com/derekparker/delve/pkg/dwarf/op"
+	"github.com/derekparker/delve/pkg/dwarf/reader"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// This file implements the function call injection introduced in go1.11.
+//
+// The protocol is described in $GOROOT/src/runtime/asm_amd64.s in the
+// comments for function runtime·debugCallV1.
+//
+// There are two main entry points here. The first one is CallFunction
+// which evaluates a function call expression, sets up the function call
+// on the selected goroutine and resumes execution of the process.
+//
+// The second one is funcCallStep which is called by Continue every time
+// the thread executing the call stops on one of the breakpoint
+// instructions of runtime·debugCallV1, and drives the protocol forward.
+//
+// Stack growth and panics in the called function are handled by the
+// runtime: runtime·debugCallV1 allocates the argument frame on its own
+// stack, growing the goroutine stack if necessary, and recovers panics
+// reporting them back to the debugger.
+
+const (
+	debugCallFunctionNamePrefix1 = "debugCall"
+	debugCallFunctionNamePrefix2 = "runtime.debugCall"
+	debugCallFunctionName        = "runtime.debugCallV1"
+)
+
+// Values of RAX when runtime·debugCallV1 stops on a breakpoint
+// instruction.
+const (
+	debugCallAXPrecheckFailed   = 8
+	debugCallAXCompleteCall     = 0
+	debugCallAXReadReturn       = 1
+	debugCallAXReadPanic        = 2
+	debugCallAXRestoreRegisters = 16
+)
+
+var (
+	errFuncCallUnsupported        = errors.New("function calls not supported by this version of Go, go1.11 or later is required")
+	errFuncCallUnsupportedBackend = errors.New("backend does not support function calls")
+	errFuncCallInProgress         = errors.New("cannot call function while another function call is already in progress")
+	errFuncCallNextInProgress     = errors.New("cannot call function while a next or step is in progress")
+	errNotACallExpr               = errors.New("not a function call")
+	errNoGoroutine                = errors.New("no goroutine selected")
+	errGoroutineNotRunning        = errors.New("selected goroutine not running")
+	errNotEnoughStack             = errors.New("not enough stack space")
+	errTooManyArguments           = errors.New("too many arguments")
+	errNotEnoughArguments         = errors.New("not enough arguments")
+)
+
+// If the argument of CallFunction implements FunctionCaller CallFunction
+// will store the state of the call in progress in the structure returned
+// by FunctionCallState.
+type FunctionCaller interface {
+	FunctionCallState() *FunctionCallState
+}
+
+// funcCallState returns the state of the function call injected in dbp, or
+// nil if dbp does not support function calls.
+func funcCallState(dbp Process) *FunctionCallState {
+	if caller, ok := dbp.(FunctionCaller); ok {
+		return caller.FunctionCallState()
+	}
+	return nil
+}
+
+// callInjectionThread is implemented by threads that can execute injected
+// function calls.
+type callInjectionThread interface {
+	Thread
+	SetPC(uint64) error
+	SetSP(uint64) error
+	SetDX(uint64) error
+	// RestoreRegisters restores all registers of the thread to the values
+	// saved in regs.
+	RestoreRegisters(regs Registers) error
+}
+
+// FunctionCallState is the state of an injected function call.
+type FunctionCallState struct {
+	// inProgress is true if a function call is in progress
+	inProgress bool
+	// threadID is the ID of the thread executing the call
+	threadID int
+	// finished is true if the function call terminated
+	finished bool
+	// savedRegs contains the saved registers
+	savedRegs Registers
+	// expr contains the expression being evaluated
+	expr string
+	// builtin is the call to a builtin function wrapping the function call,
+	// or nil. It is applied to the return value of fn when the call returns.
+	builtin *ast.CallExpr
+	// fn is the function that is being called
+	fn *Function
+	// closureAddr is the address of the closure being called, zero if fn is
+	// not a closure
+	closureAddr uint64
+	// argmem contains the argument frame of this function call
+	argmem []byte
+	// retLoadCfg is the load configuration used to load return values
+	retLoadCfg *LoadConfig
+	// err contains a saved error
+	err error
+	// retvars contains the return variables of the function call
+	retvars []*Variable
+	// panicvar contains the panic variable if a panic happened
+	panicvar *Variable
+}
+
+// ReturnValues returns the return values of the last injected function
+// call, or the value it panicked with.
+func (fncall *FunctionCallState) ReturnValues() []*Variable {
+	if fncall.panicvar != nil {
+		return []*Variable{fncall.panicvar}
+	}
+	return fncall.retvars
+}
+
+// InProgress returns true if an injected function call has not completed
+// yet, for example because it stopped at a breakpoint.
+func (fncall *FunctionCallState) InProgress() bool {
+	return fncall.inProgress
+}
+
+// Err returns the error that terminated the last injected function call.
+func (fncall *FunctionCallState) Err() error {
+	return fncall.err
+}
+
+// CallFunction calls the function described by expr on the selected
+// goroutine of p and waits for it to return, or stop on a breakpoint.
+// The return values of the function are loaded with retLoadCfg, if it is
+// not nil, and can be retrieved with FunctionCallState.ReturnValues.
+func CallFunction(p Process, expr string, retLoadCfg *LoadConfig) error {
+	fncall := funcCallState(p)
+	if fncall == nil {
+		return errFuncCallUnsupportedBackend
+	}
+	if fncall.inProgress {
+		return errFuncCallInProgress
+	}
+	if p.Breakpoints().HasInternalBreakpoints() {
+		return errFuncCallNextInProgress
+	}
+
+	*fncall = FunctionCallState{}
+
+	bi := p.BinInfo()
+	dbgcallfn := bi.LookupFunc[debugCallFunctionName]
+	if dbgcallfn == nil {
+		return errFuncCallUnsupported
+	}
+
+	// check that the selected goroutine is running
+	g := p.SelectedGoroutine()
+	if g == nil {
+		return errNoGoroutine
+	}
+	if g.Status != Grunning || g.Thread == nil {
+		return errGoroutineNotRunning
+	}
+	thread, ok := g.Thread.(callInjectionThread)
+	if !ok {
+		return errFuncCallUnsupportedBackend
+	}
+
+	// check that there are at least 256 bytes free on the stack
+	regs, err := thread.Registers(true)
+	if err != nil {
+		return err
+	}
+	if regs.SP()-256 <= g.stacklo {
+		return errNotEnoughStack
+	}
+	if _, err := regs.Get(int(x86asm.RAX)); err != nil {
+		return errFuncCallUnsupportedBackend
+	}
+
+	scope, err := GoroutineScope(thread)
+	if err != nil {
+		return err
+	}
+	builtin, fn, closureAddr, argvars, err := funcCallEvalExpr(scope, expr)
+	if err != nil {
+		return err
+	}
+
+	argmem, err := funcCallArgFrame(fn, argvars, bi)
+	if err != nil {
+		return err
+	}
+
+	if err := callOP(bi, thread, regs, dbgcallfn.Entry); err != nil {
+		return err
+	}
+	// write the desired argument frame size at SP-(2*pointer_size) (the extra pointer is the saved PC)
+	if err := writePointer(bi, thread, regs.SP()-3*uint64(bi.Arch.PtrSize()), uint64(len(argmem))); err != nil {
+		return err
+	}
+
+	fncall.inProgress = true
+	fncall.threadID = thread.ThreadID()
+	fncall.savedRegs = regs
+	fncall.expr = expr
+	fncall.builtin = builtin
+	fncall.fn = fn
+	fncall.closureAddr = closureAddr
+	fncall.argmem = argmem
+	fncall.retLoadCfg = retLoadCfg
+
+	if err := Continue(p); err != nil {
+		return err
+	}
+	if fncall.finished {
+		return fncall.err
+	}
+	return nil
+}
+
+// writePointer writes val as an architecture pointer at addr in mem.
+func writePointer(bi *BinaryInfo, mem MemoryReadWriter, addr, val uint64) error {
+	ptrbuf := make([]byte, bi.Arch.PtrSize())
+
+	// TODO: use target architecture endianness instead of LittleEndian
+	switch len(ptrbuf) {
+	case 4:
+		binary.LittleEndian.PutUint32(ptrbuf, uint32(val))
+	case 8:
+		binary.LittleEndian.PutUint64(ptrbuf, val)
+	default:
+		panic(fmt.Errorf("unsupported pointer size %d", len(ptrbuf)))
+	}
+	_, err := mem.WriteMemory(uintptr(addr), ptrbuf)
+	return err
+}
+
+// callOP simulates a call instruction on the given thread:
+// * pushes the current value of PC on the stack (adjusting SP)
+// * changes the value of PC to callAddr
+// Note: regs are NOT updated!
+func callOP(bi *BinaryInfo, thread callInjectionThread, regs Registers, callAddr uint64) error {
+	sp := regs.SP()
+	// push PC on the stack
+	sp -= uint64(bi.Arch.PtrSize())
+	if err := thread.SetSP(sp); err != nil {
+		return err
+	}
+	if err := writePointer(bi, thread, sp, regs.PC()); err != nil {
+		return err
+	}
+	return thread.SetPC(callAddr)
+}
+
+// funcCallEvalExpr evaluates expr, which must be a function call, returns
+// the function being called, the address of its closure (if any) and its
+// arguments. For method calls the receiver is the first argument.
+// The function call can be wrapped in a call to a builtin function with a
+// single argument, for example len(f()), which is also returned.
+func funcCallEvalExpr(scope *EvalScope, expr string) (builtin *ast.CallExpr, fn *Function, closureAddr uint64, argvars []*Variable, err error) {
+	t, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, nil, 0, nil, err
+	}
+	callexpr, iscall := removeParen(t).(*ast.CallExpr)
+	if !iscall {
+		return nil, nil, 0, nil, errNotACallExpr
+	}
+	if inner := scope.funcCallBuiltinArg(callexpr); inner != nil {
+		builtin, callexpr = callexpr, inner
+	}
+
+	fn, closureAddr, recv, err := scope.funcCallEvalFun(removeParen(callexpr.Fun))
+	if err != nil {
+		return nil, nil, 0, nil, err
+	}
+	if recv != nil {
+		argvars = append(argvars, recv)
+	}
+
+	for i := range callexpr.Args {
+		argv, err := scope.evalAST(callexpr.Args[i])
+		if err != nil {
+			return nil, nil, 0, nil, err
+		}
+		argv.Name = exprToString(callexpr.Args[i])
+		argv.loadValue(loadSingleValue)
+		if argv.Unreadable != nil {
+			return nil, nil, 0, nil, fmt.Errorf("could not evaluate argument %s: %v", argv.Name, argv.Unreadable)
+		}
+		argvars = append(argvars, argv)
+	}
+
+	return builtin, fn, closureAddr, argvars, nil
+}
+
+// funcCallBuiltinArg returns the function call passed as the only argument
+// of callexpr, if callexpr is a call to a builtin function, nil otherwise.
+func (scope *EvalScope) funcCallBuiltinArg(callexpr *ast.CallExpr) *ast.CallExpr {
+	fnnode, ok := callexpr.Fun.(*ast.Ident)
+	if !ok || supportedBuiltins[fnnode.Name] == nil || len(callexpr.Args) != 1 {
+		return nil
+	}
+	// local variables, package variables and functions shadow builtins
+	if _, err := scope.evalIdent(fnnode); err == nil {
+		return nil
+	}
+	if scope.findFunction(fnnode.Name) != nil {
+		return nil
+	}
+	inner, _ := removeParen(callexpr.Args[0]).(*ast.CallExpr)
+	return inner
+}
+
+// funcCallEvalFun resolves the function called by a call expression.
+// It returns the function, the address of the closure (if the function is
+// a function value) and, for method calls, the receiver.
+func (scope *EvalScope) funcCallEvalFun(node ast.Expr) (fn *Function, closureAddr uint64, recv *Variable, err error) {
+	switch node := node.(type) {
+	case *ast.Ident:
+		// local variables and package variables shadow functions
+		if _, err := scope.evalIdent(node); err != nil {
+			if fn := scope.findFunction(node.Name); fn != nil {
+				return fn, 0, nil, nil
+			}
+		}
+	case *ast.SelectorExpr:
+		if maybePkg, ok := node.X.(*ast.Ident); ok {
+			if fn := scope.findFunction(maybePkg.Name + "." + node.Sel.Name); fn != nil {
+				return fn, 0, nil, nil
+			}
+		}
+		xv, err := scope.evalAST(node.X)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if fn, recv, err := scope.findMethod(xv, node.Sel.Name); fn != nil || err != nil {
+			return fn, 0, recv, err
+		}
+	}
+
+	fnvar, err := scope.evalAST(node)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if fnvar.Kind != reflect.Func {
+		return nil, 0, nil, fmt.Errorf("expression %q is not a function", exprToString(node))
+	}
+	fnvar.loadValue(loadSingleValue)
+	if fnvar.Unreadable != nil {
+		return nil, 0, nil, fnvar.Unreadable
+	}
+	if fnvar.Base == 0 {
+		return nil, 0, nil, errors.New("nil pointer dereference")
+	}
+	fn = scope.BinInfo.PCToFunc(uint64(fnvar.Base))
+	if fn == nil {
+		return nil, 0, nil, fmt.Errorf("could not find function for %#x", fnvar.Base)
+	}
+	closure, err := readUintRaw(fnvar.mem, fnvar.Addr, int64(scope.BinInfo.Arch.PtrSize()))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return fn, closure, nil, nil
+}
+
+// findFunction returns the function called name, which can be qualified
+// with the last element of its package path. Unqualified names are looked
+// up in the package of the current function.
+func (scope *EvalScope) findFunction(name string) *Function {
+	if !strings.Contains(name, ".") {
+		if scope.Fn == nil {
+			return nil
+		}
+		name = scope.Fn.PackageName() + "." + name
+	}
+	if fn := scope.BinInfo.LookupFunc[name]; fn != nil {
+		return fn
+	}
+	for i := range scope.BinInfo.Functions {
+		if fn := &scope.BinInfo.Functions[i]; strings.HasSuffix(fn.Name, "/"+name) {
+			return fn
+		}
+	}
+	return nil
+}
+
+// findMethod returns the method called name of the value xv and the
+// receiver to pass to it. It returns a nil function if xv does not have
+// such a method.
+func (scope *EvalScope) findMethod(xv *Variable, name string) (*Function, *Variable, error) {
+	if xv.DwarfType == nil {
+		return nil, nil, nil
+	}
+	if xv.Kind == reflect.Interface {
+		xv.loadValue(loadSingleValue)
+		if xv.Unreadable != nil {
+			return nil, nil, xv.Unreadable
+		}
+		if len(xv.Children) == 0 || xv.Children[0].Addr == 0 {
+			return nil, nil, fmt.Errorf("method %s called on nil interface", name)
+		}
+		xv = &xv.Children[0]
+	}
+
+	typename := xv.DwarfType.Common().Name
+	ptrtypename := ""
+	if strings.HasPrefix(typename, "*") {
+		typename, ptrtypename = typename[1:], typename
+	} else {
+		ptrtypename = "*" + typename
+	}
+	pkgpath, tname := typename, ""
+	if dot := strings.LastIndex(typename, "."); dot >= 0 {
+		pkgpath, tname = typename[:dot], typename[dot+1:]
+	}
+
+	if fn := scope.BinInfo.LookupFunc[typename+"."+name]; fn != nil {
+		// value receiver
+		if xv.Kind == reflect.Ptr {
+			xv = xv.maybeDereference()
+			if xv.Addr == 0 {
+				return nil, nil, fmt.Errorf("method %s called on nil pointer", name)
+			}
+		}
+		return fn, xv, nil
+	}
+	if fn := scope.BinInfo.LookupFunc[pkgpath+".(*"+tname+")."+name]; fn != nil {
+		// pointer receiver
+		if xv.DwarfType.Common().Name == ptrtypename {
+			return fn, xv, nil
+		}
+		if xv.Addr == 0 || xv.Addr == fakeAddress {
+			return nil, nil, fmt.Errorf("method %s has a pointer receiver but %s is not addressable", name, xv.Name)
+		}
+		recv := xv.pointerToVariable()
+		return fn, recv, nil
+	}
+	return nil, nil, nil
+}
+
+// pointerToVariable returns a variable containing the address of v.
+func (v *Variable) pointerToVariable() *Variable {
+	typ := pointerTo(v.DwarfType, v.bi.Arch)
+	r := &Variable{Name: "&(" + v.Name + ")", DwarfType: typ, RealType: typ, Kind: reflect.Ptr, Children: []Variable{*v}, bi: v.bi, mem: v.mem, loaded: true}
+	return r
+}
+
+// funcCallArg is a formal argument of a function.
+type funcCallArg struct {
+	name  string
+	typ   godwarf.Type
+	off   int64
+	isret bool
+}
+
+// funcCallArgs returns the formal arguments of fn, sorted by their offset
+// in the argument frame, and the size of the argument frame.
+func funcCallArgs(fn *Function, bi *BinaryInfo, includeRet bool) (argFrameSize int64, formalArgs []funcCallArg, err error) {
+	const CFA = 0x1000
+	vrdr := reader.Variables(bi.dwarf, fn.offset, fn.Entry, int(^uint(0)>>1), false)
+	scope := globalScope(bi, nil)
+	scope.PC = fn.Entry
+
+	// typechecks arguments, calculates argument frame size
+	for vrdr.Next() {
+		e := vrdr.Entry()
+		if e.Tag != dwarf.TagFormalParameter {
+			continue
+		}
+		entry, _ := reader.LoadAbstractOrigin(e, bi.dwarfReader)
+		argname, _ := entry.Val(dwarf.AttrName).(string)
+		typoff, ok := entry.Val(dwarf.AttrType).(dwarf.Offset)
+		if !ok {
+			return 0, nil, fmt.Errorf("could not read type of argument %s", argname)
+		}
+		typ, err := scope.Type(typoff)
+		if err != nil {
+			return 0, nil, err
+		}
+		off, pieces, _, err := bi.Location(entry, dwarf.AttrLocation, fn.Entry, op.DwarfRegisters{CFA: CFA, FrameBase: CFA})
+		if err != nil || pieces != nil {
+			return 0, nil, fmt.Errorf("unsupported location expression for argument %s: %v", argname, err)
+		}
+
+		off -= CFA
+
+		if end := off + typ.Size(); end > argFrameSize {
+			argFrameSize = end
+		}
+
+		if isret, _ := entry.Val(dwarf.AttrVarParam).(bool); !isret || includeRet {
+			formalArgs = append(formalArgs, funcCallArg{name: argname, typ: typ, off: off, isret: isret})
+		}
+	}
+	if err := vrdr.Err(); err != nil {
+		return 0, nil, fmt.Errorf("DWARF read error: %v", err)
+	}
+
+	sort.Slice(formalArgs, func(i, j int) bool {
+		return formalArgs[i].off < formalArgs[j].off
+	})
+
+	return argFrameSize, formalArgs, nil
+}
+
+// funcCallArgFrame returns the argument frame of a call to fn with the
+// given actual arguments.
+func funcCallArgFrame(fn *Function, actualArgs []*Variable, bi *BinaryInfo) ([]byte, error) {
+	argFrameSize, formalArgs, err := funcCallArgs(fn, bi, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(actualArgs) > len(formalArgs) {
+		return nil, errTooManyArguments
+	}
+	if len(actualArgs) < len(formalArgs) {
+		return nil, errNotEnoughArguments
+	}
+
+	argmem := &argFrameMemory{make([]byte, argFrameSize)}
+	for i := range formalArgs {
+		formalArg := &formalArgs[i]
+		actualArg := actualArgs[i]
+
+		formalArgVar := newVariable(formalArg.name, uintptr(fakeAddress+formalArg.off), formalArg.typ, bi, argmem)
+		if err := formalArgVar.setArgument(actualArg); err != nil {
+			return nil, fmt.Errorf("could not pass %s as argument %s of %s: %v", actualArg.Name, formalArg.name, fn.Name, err)
+		}
+	}
+
+	return argmem.data, nil
+}
+
+// setArgument copies the value of the actual argument y into v.
+// Pointers are copied as they are, it is up to the user not to pass
+// pointers to stack variables to functions that retain them.
+func (v *Variable) setArgument(y *Variable) error {
+	switch {
+	case y == nilVariable:
+		if err := y.isType(v.RealType, v.Kind); err != nil {
+			return err
+		}
+		// the argument frame is zeroed
+		return nil
+
+	case y.DwarfType == nil || y.Addr == 0:
+		// constant expression or pointer built by the debugger
+		if y.Kind == reflect.Ptr && len(y.Children) == 1 {
+			if y.RealType.String() != v.RealType.String() {
+				return fmt.Errorf("mismatched types %s and %s", y.TypeString(), v.TypeString())
+			}
+		} else if err := y.isType(v.RealType, v.Kind); err != nil {
+			return err
+		}
+		return v.setValue(y)
+	}
+
+	if y.RealType.String() != v.RealType.String() {
+		return fmt.Errorf("mismatched types %s and %s", y.TypeString(), v.TypeString())
+	}
+	buf := make([]byte, v.RealType.Size())
+	if _, err := y.mem.ReadMemory(buf, y.Addr); err != nil {
+		return err
+	}
+	_, err := v.mem.WriteMemory(v.Addr, buf)
+	return err
+}
+
+// argFrameMemory is the memory of an argument frame prepared by the
+// debugger, it starts at fakeAddress.
+type argFrameMemory struct {
+	data []byte
+}
+
+func (mem *argFrameMemory) ReadMemory(data []byte, addr uintptr) (int, error) {
+	addr -= fakeAddress
+	if addr >= uintptr(len(mem.data)) || addr+uintptr(len(data)) > uintptr(len(mem.data)) {
+		return 0, errors.New("read out of bounds")
+	}
+	copy(data, mem.data[addr:])
+	return len(data), nil
+}
+
+func (mem *argFrameMemory) WriteMemory(addr uintptr, data []byte) (int, error) {
+	addr -= fakeAddress
+	if addr >= uintptr(len(mem.data)) || addr+uintptr(len(data)) > uintptr(len(mem.data)) {
+		return 0, errors.New("write out of bounds")
+	}
+	copy(mem.data[addr:], data)
+	return len(data), nil
+}
+
+// onDebugCall returns true if thread is stopped inside
+// runtime·debugCallV1.
+func onDebugCall(thread Thread) bool {
+	loc, err := thread.Location()
+	if err != nil || loc.Fn == nil {
+		return false
+	}
+	return strings.HasPrefix(loc.Fn.Name, debugCallFunctionNamePrefix1) || strings.HasPrefix(loc.Fn.Name, debugCallFunctionNamePrefix2)
+}
+
+// funcCallStep executes the step of the function call protocol requested
+// by runtime·debugCallV1 through the value of RAX. It returns true when
+// the function call is finished.
+func funcCallStep(p Process, fncall *FunctionCallState, t Thread) bool {
+	bi := p.BinInfo()
+	thread := t.(callInjectionThread)
+
+	regs, err := thread.Registers(false)
+	if err != nil {
+		fncall.err = err
+		return fncall.abort(thread)
+	}
+
+	rax, _ := regs.Get(int(x86asm.RAX))
+
+	switch rax {
+	case debugCallAXPrecheckFailed:
+		// get error from top of the stack and return it to user
+		errvar, err := readTopstackVariable(bi, thread, regs, "string", loadFullValue)
+		if err != nil {
+			fncall.err = fmt.Errorf("could not get precheck error reason: %v", err)
+		} else {
+			fncall.err = fmt.Errorf("%v", constant.StringVal(errvar.Value))
+		}
+
+	case debugCallAXCompleteCall:
+		// write arguments to the stack, call final function
+		if _, err := thread.WriteMemory(uintptr(regs.SP()), fncall.argmem); err != nil {
+			fncall.err = fmt.Errorf("could not write arguments: %v", err)
+			return fncall.abort(thread)
+		}
+		if fncall.closureAddr != 0 {
+			// When calling a function pointer we must set the DX register to the
+			// address of the function pointer itself.
+			if err := thread.SetDX(fncall.closureAddr); err != nil {
+				fncall.err = fmt.Errorf("could not set closure register: %v", err)
+				return fncall.abort(thread)
+			}
+		}
+		if err := callOP(bi, thread, regs, fncall.fn.Entry); err != nil {
+			fncall.err = fmt.Errorf("could not call function: %v", err)
+			return fncall.abort(thread)
+		}
+
+	case debugCallAXRestoreRegisters:
+		// runtime requests that we restore the registers (all except pc and sp),
+		// this is also the last step of the function call protocol.
+		fncall.finished = true
+		fncall.inProgress = false
+		pc, sp := regs.PC(), regs.SP()
+		if err := thread.RestoreRegisters(fncall.savedRegs); err != nil {
+			fncall.err = fmt.Errorf("could not restore registers: %v", err)
+		}
+		if err := thread.SetPC(pc); err != nil {
+			fncall.err = fmt.Errorf("could not restore PC: %v", err)
+		}
+		if err := thread.SetSP(sp); err != nil {
+			fncall.err = fmt.Errorf("could not restore SP: %v", err)
+		}
+		if err := stepInstructionOut(thread); err != nil {
+			fncall.err = fmt.Errorf("could not step out of %s: %v", debugCallFunctionName, err)
+		}
+		return true
+
+	case debugCallAXReadReturn:
+		// read return arguments from stack
+		if fncall.retLoadCfg == nil || fncall.panicvar != nil {
+			break
+		}
+		_, formalArgs, err := funcCallArgs(fncall.fn, bi, true)
+		if err != nil {
+			fncall.err = fmt.Errorf("could not get return values: %v", err)
+			break
+		}
+		for _, arg := range formalArgs {
+			if !arg.isret {
+				continue
+			}
+			v := newVariable(arg.name, uintptr(int64(regs.SP())+arg.off), arg.typ, bi, thread)
+			v.Flags |= VariableReturnArgument
+			fncall.retvars = append(fncall.retvars, v)
+		}
+		loadValues(fncall.retvars, *fncall.retLoadCfg)
+		if fncall.builtin != nil {
+			fncall.applyBuiltin()
+		}
+
+	case debugCallAXReadPanic:
+		// read panic value from stack
+		if fncall.retLoadCfg == nil {
+			break
+		}
+		fncall.panicvar, err = readTopstackVariable(bi, thread, regs, "interface {}", *fncall.retLoadCfg)
+		if err != nil {
+			fncall.err = fmt.Errorf("could not get panic: %v", err)
+			break
+		}
+		fncall.panicvar.Name = "~panic"
+
+	default:
+		// Got an unknown AX value, this is probably bad but the safest thing
+		// possible is to ignore it and hope it didn't matter.
+	}
+
+	return false
+}
+
+// applyBuiltin replaces the return values of the function call with the
+// result of the builtin function wrapping it.
+func (fncall *FunctionCallState) applyBuiltin() {
+	if len(fncall.retvars) != 1 {
+		fncall.err = fmt.Errorf("%s: %d-valued %s used as argument of %s", fncall.expr, len(fncall.retvars), exprToString(fncall.builtin.Args[0]), exprToString(fncall.builtin.Fun))
+		fncall.retvars = nil
+		return
+	}
+	v, err := supportedBuiltins[fncall.builtin.Fun.(*ast.Ident).Name](fncall.retvars, fncall.builtin.Args)
+	if err != nil {
+		fncall.err = err
+		fncall.retvars = nil
+		return
+	}
+	v.Name = fncall.expr
+	v.Flags |= VariableReturnArgument
+	fncall.retvars = []*Variable{v}
+}
+
+// abort restores the registers saved before the function call was
+// started, after a failure that prevents the call protocol from
+// continuing.
+func (fncall *FunctionCallState) abort(thread callInjectionThread) bool {
+	fncall.finished = true
+	fncall.inProgress = false
+	if err := thread.RestoreRegisters(fncall.savedRegs); err != nil {
+		fncall.err = fmt.Errorf("%v, additionally the registers could not be restored: %v", fncall.err, err)
+	}
+	return true
+}
+
+// readTopstackVariable reads a variable of type typename stored at the top
+// of the stack of thread.
+func readTopstackVariable(bi *BinaryInfo, thread Thread, regs Registers, typename string, loadCfg LoadConfig) (*Variable, error) {
+	typ, err := bi.findType(typename)
+	if err != nil {
+		return nil, err
+	}
+	v := newVariable("", uintptr(regs.SP()), typ, bi, thread)
+	v.loadValue(loadCfg)
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	return v, nil
+}
+
+// stepInstructionOut single-steps thread until it leaves
+// runtime·debugCallV1.
+func stepInstructionOut(thread Thread) error {
+	for {
+		if err := thread.StepInstruction(); err != nil {
+			return err
+		}
+		if !onDebugCall(thread) {
+			return nil
+		}
+	}
+}
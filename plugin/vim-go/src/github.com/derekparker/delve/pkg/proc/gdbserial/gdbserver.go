@@ -926,6 +926,10 @@ func (p *Process) Direction(dir proc.Direction) error {
 	return nil
 }
 
+func (p *Process) GetDirection() proc.Direction {
+	return p.conn.direction
+}
+
 func (p *Process) Breakpoints() *proc.BreakpointMap {
 	return &p.breakpoints
 }
@@ -959,6 +963,10 @@ func (p *Process) SetBreakpoint(addr uint64, kind proc.BreakpointKind, cond ast.
 	return p.breakpoints.Set(addr, kind, cond, p.writeBreakpoint)
 }
 
+func (p *Process) SetWatchpoint(addr uint64, size int, wtype proc.WatchType, cond ast.Expr) (*proc.Breakpoint, error) {
+	return nil, proc.WatchpointsNotSupportedErr
+}
+
 func (p *Process) ClearBreakpoint(addr uint64) (*proc.Breakpoint, error) {
 	if p.exited {
 		return nil, &proc.ProcessExitedError{Pid: p.conn.pid}
@@ -1456,12 +1464,6 @@ func (thread *Thread) SetCurrentBreakpoint() error {
 			}
 		}
 		thread.CurrentBreakpoint = bp.CheckCondition(thread)
-		if thread.CurrentBreakpoint.Breakpoint != nil && thread.CurrentBreakpoint.Active {
-			if g, err := proc.GetG(thread); err == nil {
-				thread.CurrentBreakpoint.HitCount[g.ID]++
-			}
-			thread.CurrentBreakpoint.TotalHitCount++
-		}
 	}
 	return nil
 }
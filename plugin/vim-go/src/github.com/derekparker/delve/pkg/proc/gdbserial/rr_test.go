@@ -246,3 +246,62 @@ func TestCheckpoints(t *testing.T) {
 		}
 	})
 }
+
+func reverse(p *gdbserial.Process, t *testing.T, fn func(proc.Process) error, s string) {
+	assertNoError(p.Direction(proc.Backward), t, "Switching to backward direction")
+	assertNoError(fn(p), t, s)
+	assertNoError(p.ClearInternalBreakpoints(), t, "ClearInternalBreakpoints")
+	assertNoError(p.Direction(proc.Forward), t, "Switching to forward direction")
+}
+
+func assertFunctionLine(p *gdbserial.Process, t *testing.T, fnname string, line int) {
+	_, loc := getPosition(p, t)
+	if loc.Fn == nil || loc.Fn.Name != fnname || loc.Line != line {
+		_, file, callerLine, _ := runtime.Caller(1)
+		t.Fatalf("%s:%d: stopped at %s:%d %#x (expected %s:%d)", filepath.Base(file), callerLine, loc.File, loc.Line, loc.PC, fnname, line)
+	}
+}
+
+func TestReverseNext(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestRecording("testnextprog", t, func(p *gdbserial.Process, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture.Source, 40)
+		assertNoError(proc.Continue(p), t, "Continue")
+		assertFunctionLine(p, t, "main.main", 40)
+		_, err := p.ClearBreakpoint(bp.Addr)
+		assertNoError(err, t, "ClearBreakpoint")
+
+		reverse(p, t, proc.Next, "reverse Next")
+		assertFunctionLine(p, t, "main.main", 39)
+		reverse(p, t, proc.Next, "reverse Next")
+		assertFunctionLine(p, t, "main.main", 38)
+
+		assertNoError(proc.Next(p), t, "Next")
+		assertFunctionLine(p, t, "main.main", 39)
+	})
+}
+
+func TestReverseStepAndStepOut(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestRecording("testnextprog", t, func(p *gdbserial.Process, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture.Source, 40)
+		assertNoError(proc.Continue(p), t, "Continue")
+		_, err := p.ClearBreakpoint(bp.Addr)
+		assertNoError(err, t, "ClearBreakpoint")
+
+		// stepping backwards from the line after a function call enters the
+		// called function at its last instruction.
+		reverse(p, t, proc.Step, "reverse Step")
+		assertFunctionLine(p, t, "main.testnext", 35)
+
+		reverse(p, t, proc.StepOut, "reverse StepOut")
+		assertFunctionLine(p, t, "main.main", 39)
+
+		_, loc0 := getPosition(p, t)
+		reverse(p, t, func(p proc.Process) error { return p.StepInstruction() }, "reverse StepInstruction")
+		_, loc1 := getPosition(p, t)
+		if loc1.PC >= loc0.PC {
+			t.Fatalf("reverse StepInstruction did not go backwards: %#x -> %#x", loc0.PC, loc1.PC)
+		}
+	})
+}
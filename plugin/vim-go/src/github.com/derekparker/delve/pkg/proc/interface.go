@@ -30,6 +30,8 @@ type RecordingManipulation interface {
 	Restart(pos string) error
 	// Direction changes execution direction.
 	Direction(Direction) error
+	// GetDirection returns the current direction of execution.
+	GetDirection() Direction
 	// When returns current recording position.
 	When() (string, error)
 	// Checkpoint sets a checkpoint at the current position.
@@ -97,6 +99,26 @@ type ProcessManipulation interface {
 type BreakpointManipulation interface {
 	Breakpoints() *BreakpointMap
 	SetBreakpoint(addr uint64, kind BreakpointKind, cond ast.Expr) (*Breakpoint, error)
+	// SetWatchpoint sets a hardware watchpoint on size bytes at addr, use
+	// Watch to set a watchpoint on an expression.
+	SetWatchpoint(addr uint64, size int, wtype WatchType, cond ast.Expr) (*Breakpoint, error)
 	ClearBreakpoint(addr uint64) (*Breakpoint, error)
 	ClearInternalBreakpoints() error
 }
+
+// TargetGroup is implemented by processes that debug more than one target
+// at the same time, for example a program and the child processes it
+// creates. All the targets are resumed and stopped together, the methods of
+// Process act on the current target, which is the one that stopped last or
+// the one selected with SwitchTarget.
+type TargetGroup interface {
+	// Targets returns the targets of the group.
+	Targets() []Process
+	// SwitchTarget makes the target with the given pid the current target.
+	SwitchTarget(pid int) error
+	// SyncBreakpoint makes the breakpoints with the same ID as bp on the
+	// other targets match bp, a user breakpoint of the current target: they
+	// are created on the targets whose executable contains the location of
+	// bp and updated or removed (if bp was cleared) on the others.
+	SyncBreakpoint(bp *Breakpoint) error
+}
@@ -22,6 +22,25 @@ type MemoryReadWriter interface {
 	WriteMemory(addr uintptr, data []byte) (written int, err error)
 }
 
+// MemoryMapEntry is a region of the address space of the target process.
+type MemoryMapEntry struct {
+	Addr uint64
+	Size uint64
+
+	Read, Write, Exec bool
+
+	// Filename is the name of the file mapped in this region, if any, and
+	// Offset is the offset of the region in that file.
+	Filename string
+	Offset   uint64
+}
+
+// MemoryMapper is implemented by the processes that can list the memory
+// regions they have mapped, which is needed to write a core dump of them.
+type MemoryMapper interface {
+	MemoryMap() ([]MemoryMapEntry, error)
+}
+
 type memCache struct {
 	loaded    bool
 	cacheAddr uintptr
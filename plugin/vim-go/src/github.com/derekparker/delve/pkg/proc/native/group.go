@@ -0,0 +1,294 @@
+package native
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+
+	"github.com/derekparker/delve/pkg/proc"
+)
+
+// FollowMode describes which child processes of a target are debugged
+// together with it.
+type FollowMode uint8
+
+const (
+	// FollowExec debugs the programs executed by the target and by its
+	// child processes.
+	FollowExec FollowMode = 1 << iota
+	// FollowFork debugs the child processes created by the target with
+	// fork, before they execute a new program.
+	FollowFork
+)
+
+// ErrFollowNotSupported is returned by NewTargetGroup on the operating
+// systems where following child processes is not supported.
+var ErrFollowNotSupported = errors.New("following child processes is only supported on linux")
+
+// TargetGroup is a process and the child processes it creates, debugged
+// together. See proc.TargetGroup.
+//
+// The embedded Process is the current target, all the methods of
+// proc.Process except ContinueOnce and Detach act on it.
+type TargetGroup struct {
+	*Process
+
+	procs []*Process
+	mode  FollowMode
+
+	// pending are the child processes that are traced, because they could
+	// execute a new program, but are not targets yet.
+	pending map[int]bool
+	// earlyStops are the new processes whose initial stop was received
+	// before the event of their parent that created them.
+	earlyStops map[int]bool
+}
+
+// NewTargetGroup starts following the child processes created by p,
+// according to mode.
+func NewTargetGroup(p *Process, mode FollowMode) (*TargetGroup, error) {
+	g := &TargetGroup{
+		Process:    p,
+		procs:      []*Process{p},
+		mode:       mode,
+		pending:    make(map[int]bool),
+		earlyStops: make(map[int]bool),
+	}
+	p.group = g
+	if err := p.followChildren(); err != nil {
+		p.group = nil
+		return nil, err
+	}
+	return g, nil
+}
+
+// Targets returns the processes of the group.
+func (g *TargetGroup) Targets() []proc.Process {
+	r := make([]proc.Process, 0, len(g.procs))
+	for _, p := range g.procs {
+		r = append(r, p)
+	}
+	return r
+}
+
+// SwitchTarget makes the process with the given pid the current target.
+func (g *TargetGroup) SwitchTarget(pid int) error {
+	for _, p := range g.procs {
+		if p.pid == pid {
+			g.Process = p
+			return nil
+		}
+	}
+	return fmt.Errorf("target %d does not exist", pid)
+}
+
+// ContinueOnce resumes all the processes of the group and waits for one of
+// them to stop, which becomes the current target.
+func (g *TargetGroup) ContinueOnce() (proc.Thread, error) {
+	if g.exited {
+		return nil, &proc.ProcessExitedError{Pid: g.Pid()}
+	}
+
+	for _, p := range g.procs {
+		if err := p.resume(); err != nil {
+			return nil, err
+		}
+		p.allGCache = nil
+		for _, th := range p.threads {
+			th.CurrentBreakpoint.Clear()
+		}
+	}
+
+	if g.resumeChan != nil {
+		close(g.resumeChan)
+		g.resumeChan = nil
+	}
+
+	prev := g.Process
+	trapthread, err := g.trapWait(-1)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range append([]*Process(nil), g.procs...) {
+		if p.exited {
+			continue
+		}
+		if err := p.stop(trapthread); err != nil {
+			return nil, err
+		}
+	}
+	g.Process = trapthread.dbp
+	if prev != g.Process && !prev.exited {
+		// next and step only apply to the target they were started on.
+		if err := prev.ClearInternalBreakpoints(); err != nil {
+			return nil, err
+		}
+	}
+	return trapthread, nil
+}
+
+// Detach detaches from all the processes of the group, see
+// Process.Detach.
+func (g *TargetGroup) Detach(kill bool) error {
+	g.detachPending()
+	if len(g.procs) == 0 {
+		return nil
+	}
+	// The goroutine executing the ptrace requests of the group is stopped
+	// when the last process is killed, the first one is detached last.
+	// Killed processes remove themselves from g.procs, see postExit.
+	children := append([]*Process(nil), g.procs[1:]...)
+	for i := len(children) - 1; i >= 0; i-- {
+		p := children[i]
+		if err := p.Detach(kill); err != nil {
+			return err
+		}
+		if !p.exited {
+			p.exited = true
+			g.removeTarget(p)
+		}
+	}
+	g.Process = g.procs[0]
+	return g.Process.Detach(kill)
+}
+
+// SyncBreakpoint copies bp, a user breakpoint of the current target, to
+// the other processes of the group. See proc.TargetGroup.
+func (g *TargetGroup) SyncBreakpoint(bp *proc.Breakpoint) error {
+	if bp.WatchType != 0 {
+		// watchpoints are on the memory of a single process
+		return nil
+	}
+	cleared := !bp.IsUser() || g.breakpoints.M[bp.Addr] != bp
+	for _, p := range g.procs {
+		if p == g.Process {
+			continue
+		}
+		p.breakpoints.ReserveBreakpointID(bp.ID)
+		var pbp *proc.Breakpoint
+		for _, obp := range p.breakpoints.M {
+			if obp.IsUser() && obp.ID == bp.ID {
+				pbp = obp
+				break
+			}
+		}
+		if cleared {
+			if pbp != nil {
+				if _, err := p.ClearBreakpoint(pbp.Addr); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if pbp == nil {
+			if err := p.setBreakpointLike(bp); err != nil {
+				return err
+			}
+			continue
+		}
+		copyBreakpointInfo(pbp, bp)
+	}
+	return nil
+}
+
+// setBreakpointLike sets a breakpoint with the same ID and properties as
+// bp, a breakpoint of another process, if the executable of dbp contains
+// its location.
+func (dbp *Process) setBreakpointLike(bp *proc.Breakpoint) error {
+	addr := bp.Addr
+	if f, l, fn := dbp.bi.PCToLine(addr); fn == nil || fn.Name != bp.FunctionName || f != bp.File || l != bp.Line {
+		var err error
+		addr, err = proc.FindFileLocation(dbp, bp.File, bp.Line)
+		if err != nil {
+			return nil
+		}
+	}
+	nbp, err := dbp.breakpoints.SetWithID(bp.ID, addr, dbp.writeBreakpoint)
+	if err != nil {
+		if _, exists := err.(proc.BreakpointExistsError); exists {
+			return nil
+		}
+		return err
+	}
+	dbp.breakpoints.ReserveBreakpointID(bp.ID)
+	copyBreakpointInfo(nbp, bp)
+	return nil
+}
+
+// userBreakpoints returns the user breakpoints of all the processes of the
+// group, one for each ID.
+func (g *TargetGroup) userBreakpoints() []*proc.Breakpoint {
+	seen := make(map[int]bool)
+	r := []*proc.Breakpoint{}
+	for _, p := range g.procs {
+		for _, bp := range p.breakpoints.M {
+			if !bp.IsUser() || bp.WatchType != 0 || bp.ID <= 0 || seen[bp.ID] {
+				continue
+			}
+			seen[bp.ID] = true
+			r = append(r, bp)
+		}
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].ID < r[j].ID })
+	return r
+}
+
+// reserveBreakpointIDs makes sure that the breakpoints created on p don't
+// reuse the ID of a user breakpoint of the group.
+func (g *TargetGroup) reserveBreakpointIDs(p *Process) {
+	if bps := g.userBreakpoints(); len(bps) > 0 {
+		p.breakpoints.ReserveBreakpointID(bps[len(bps)-1].ID)
+	}
+}
+
+// newProcess returns a new process of the group, its ptrace requests are
+// executed by the same thread as the other processes of the group.
+func (g *TargetGroup) newProcess(pid int) *Process {
+	return &Process{
+		pid:            pid,
+		threads:        make(map[int]*Thread),
+		breakpoints:    proc.NewBreakpointMap(),
+		os:             new(OSProcessDetails),
+		ptraceChan:     g.ptraceChan,
+		ptraceDoneChan: g.ptraceDoneChan,
+		bi:             proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH),
+		group:          g,
+	}
+}
+
+// removeTarget removes p, which exited, from the group. Returns true if
+// it was the last process of the group.
+func (g *TargetGroup) removeTarget(p *Process) bool {
+	for i := range g.procs {
+		if g.procs[i] == p {
+			g.procs = append(g.procs[:i], g.procs[i+1:]...)
+			break
+		}
+	}
+	if len(g.procs) == 0 {
+		return true
+	}
+	p.threads = make(map[int]*Thread)
+	if g.Process == p {
+		g.Process = g.procs[0]
+	}
+	return false
+}
+
+// copyBreakpointInfo copies the properties set by the user of src to dst,
+// a breakpoint of a different process.
+func copyBreakpointInfo(dst, src *proc.Breakpoint) {
+	dst.Name = src.Name
+	dst.LocExpr = src.LocExpr
+	dst.Tracepoint = src.Tracepoint
+	dst.TraceReturn = src.TraceReturn
+	dst.Goroutine = src.Goroutine
+	dst.Stacktrace = src.Stacktrace
+	dst.Variables = src.Variables
+	dst.LoadArgs = src.LoadArgs
+	dst.LoadLocals = src.LoadLocals
+	dst.Cond = src.Cond
+	dst.HitCond = src.HitCond
+	dst.LogMessage = src.LogMessage
+}
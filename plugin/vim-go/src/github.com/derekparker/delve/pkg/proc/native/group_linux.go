@@ -0,0 +1,311 @@
+package native
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+
+	sys "golang.org/x/sys/unix"
+
+	"github.com/derekparker/delve/pkg/proc"
+)
+
+// ptraceOptions returns the ptrace options set on the threads of dbp.
+func (dbp *Process) ptraceOptions() int {
+	if dbp.group == nil {
+		return sys.PTRACE_O_TRACECLONE
+	}
+	return sys.PTRACE_O_TRACECLONE | sys.PTRACE_O_TRACEFORK | sys.PTRACE_O_TRACEVFORK | sys.PTRACE_O_TRACEEXEC
+}
+
+// followChildren sets the ptrace options needed to follow the child
+// processes on all the threads of dbp.
+func (dbp *Process) followChildren() error {
+	for _, th := range dbp.threads {
+		var err error
+		dbp.execPtraceFunc(func() { err = sys.PtraceSetOptions(th.ID, dbp.ptraceOptions()) })
+		if err != nil {
+			return fmt.Errorf("could not set options for thread %d %s", th.ID, err)
+		}
+	}
+	return nil
+}
+
+// detachPending detaches from the child processes that are not targets.
+// Pending processes that are running can not be detached, they will be
+// detached by the kernel when the debugger exits.
+func (g *TargetGroup) detachPending() {
+	for pid := range g.pending {
+		g.execPtraceFunc(func() { PtraceDetach(pid, 0) })
+		delete(g.pending, pid)
+	}
+}
+
+// findThread returns the thread with the given id and the target it
+// belongs to.
+func (g *TargetGroup) findThread(tid int) (*Process, *Thread, bool) {
+	for _, p := range g.procs {
+		if th, ok := p.threads[tid]; ok {
+			return p, th, true
+		}
+	}
+	return nil, nil, false
+}
+
+// untrackedEvent handles a wait status of a thread that doesn't belong to
+// any target: either a pending process or a new process whose parent event
+// hasn't been received yet.
+func (g *TargetGroup) untrackedEvent(wpid int, status *sys.WaitStatus, halt bool) error {
+	if !g.pending[wpid] {
+		if status.Stopped() {
+			g.earlyStops[wpid] = true
+		}
+		return nil
+	}
+	if status.Exited() || status.Signaled() {
+		delete(g.pending, wpid)
+		return nil
+	}
+	if !status.Stopped() {
+		return nil
+	}
+	var err error
+	switch {
+	case status.StopSignal() == sys.SIGTRAP && status.TrapCause() == sys.PTRACE_EVENT_EXEC:
+		delete(g.pending, wpid)
+		return g.addTarget(wpid, halt)
+	case status.StopSignal() == sys.SIGTRAP && status.TrapCause() == 0:
+		// breakpoint hit by a child created with vfork, which shares the
+		// memory of its parent: the signal would kill it.
+		if err = g.stepOverBreakpoint(wpid); err == nil {
+			g.execPtraceFunc(func() { err = PtraceCont(wpid, 0) })
+		}
+	case status.StopSignal() == sys.SIGTRAP && status.TrapCause() != -1:
+		// fork, vfork or clone: the new task is traced automatically and
+		// could execute a new program, too.
+		var child uint
+		g.execPtraceFunc(func() { child, err = sys.PtraceGetEventMsg(wpid) })
+		if err == nil {
+			g.pending[int(child)] = true
+			if g.earlyStops[int(child)] {
+				delete(g.earlyStops, int(child))
+				g.execPtraceFunc(func() { err = PtraceCont(int(child), 0) })
+			}
+		}
+		g.execPtraceFunc(func() { err = PtraceCont(wpid, 0) })
+	case status.StopSignal() == sys.SIGSTOP:
+		// initial stop of a new task
+		g.execPtraceFunc(func() { err = PtraceCont(wpid, 0) })
+	default:
+		g.execPtraceFunc(func() { err = PtraceCont(wpid, int(status.StopSignal())) })
+	}
+	if err == sys.ESRCH {
+		delete(g.pending, wpid)
+		return nil
+	}
+	return err
+}
+
+// stepOverBreakpoint moves wpid, a pending process stopped by a SIGTRAP,
+// past the breakpoint of a target it stopped at, if any. The breakpoint is
+// removed while wpid executes the original instruction: the parent of a
+// vfork child does not run until the child executes a new program or exits.
+func (g *TargetGroup) stepOverBreakpoint(wpid int) error {
+	for _, p := range g.procs {
+		th := &Thread{ID: wpid, dbp: p, os: new(OSSpecificDetails)}
+		pc, err := th.PC()
+		if err != nil {
+			return err
+		}
+		bp := p.breakpoints.M[pc-uint64(p.bi.Arch.BreakpointSize())]
+		if bp == nil || bp.WatchType != 0 {
+			continue
+		}
+		instr := make([]byte, p.bi.Arch.BreakpointSize())
+		if _, err := th.ReadMemory(instr, uintptr(bp.Addr)); err != nil || !bytes.Equal(instr, p.bi.Arch.BreakpointInstruction()) {
+			continue
+		}
+		if err := th.SetPC(bp.Addr); err != nil {
+			return err
+		}
+		if _, err := th.WriteMemory(uintptr(bp.Addr), bp.OriginalData); err != nil {
+			return err
+		}
+		err = th.singleStep()
+		if werr := p.writeSoftwareBreakpoint(th, bp.Addr); err == nil {
+			err = werr
+		}
+		return err
+	}
+	return nil
+}
+
+// waitNewChild waits for the initial stop of a new child process.
+func (g *TargetGroup) waitNewChild(pid int) error {
+	if g.earlyStops[pid] {
+		delete(g.earlyStops, pid)
+		return nil
+	}
+	_, _, err := g.waitFast(pid)
+	return err
+}
+
+// forked handles a fork or vfork event of th, a thread of p.
+func (g *TargetGroup) forked(p *Process, th *Thread, vfork, halt bool) error {
+	var child uint
+	var err error
+	p.execPtraceFunc(func() { child, err = sys.PtraceGetEventMsg(th.ID) })
+	if err != nil {
+		return fmt.Errorf("could not get child of %d: %v", th.ID, err)
+	}
+	if err := g.waitNewChild(int(child)); err != nil {
+		return fmt.Errorf("error waiting for child %d: %v", child, err)
+	}
+
+	if !vfork && g.mode&FollowFork != 0 {
+		if err := g.addForkedTarget(p, int(child), halt); err != nil {
+			return err
+		}
+	} else {
+		if !vfork {
+			// the child has a copy of the memory of the parent, breakpoints
+			// included. After vfork the memory is shared instead and the
+			// breakpoints must stay.
+			tmp := &Thread{ID: int(child), dbp: p, os: new(OSSpecificDetails)}
+			for _, bp := range p.breakpoints.M {
+				if bp.WatchType == 0 {
+					tmp.WriteMemory(uintptr(bp.Addr), bp.OriginalData)
+				}
+			}
+		}
+		if g.mode&FollowExec != 0 {
+			g.pending[int(child)] = true
+			p.execPtraceFunc(func() { err = PtraceCont(int(child), 0) })
+		} else {
+			p.execPtraceFunc(func() { err = PtraceDetach(int(child), 0) })
+		}
+		if err != nil && err != sys.ESRCH {
+			return fmt.Errorf("could not resume child %d: %v", child, err)
+		}
+	}
+
+	if halt {
+		th.os.running = false
+		return nil
+	}
+	if err := th.Continue(); err != nil && err != sys.ESRCH {
+		return fmt.Errorf("could not continue thread %d %s", th.ID, err)
+	}
+	return nil
+}
+
+// addForkedTarget makes pid, a child process of parent that was just
+// created by fork, a target.
+func (g *TargetGroup) addForkedTarget(parent *Process, pid int, halt bool) error {
+	p := g.newProcess(pid)
+	p.childProcess = parent.childProcess
+	if err := p.LoadInformation(""); err != nil {
+		return err
+	}
+	if err := p.updateThreadList(); err != nil {
+		return err
+	}
+	p.selectedGoroutine, _ = proc.GetG(p.currentThread)
+
+	// The breakpoints of the parent are already written in the memory of the
+	// child, user breakpoints are kept, internal breakpoints are removed.
+	for _, bp := range parent.breakpoints.M {
+		if bp.WatchType != 0 {
+			continue
+		}
+		if !bp.IsUser() {
+			p.currentThread.WriteMemory(uintptr(bp.Addr), bp.OriginalData)
+			continue
+		}
+		originalData := bp.OriginalData
+		nbp, err := p.breakpoints.SetWithID(bp.ID, bp.Addr, func(addr uint64) (string, int, *proc.Function, []byte, error) {
+			f, l, fn := p.bi.PCToLine(addr)
+			return f, l, fn, originalData, nil
+		})
+		if err != nil {
+			return err
+		}
+		copyBreakpointInfo(nbp, bp)
+	}
+	g.reserveBreakpointIDs(p)
+
+	g.procs = append(g.procs, p)
+	if halt {
+		return nil
+	}
+	return p.currentThread.resume()
+}
+
+// addTarget makes pid, a pending process that just executed a new program,
+// a target.
+func (g *TargetGroup) addTarget(pid int, halt bool) error {
+	var err error
+	if g.mode&FollowExec == 0 {
+		g.execPtraceFunc(func() { err = PtraceDetach(pid, 0) })
+		return err
+	}
+	p := g.newProcess(pid)
+	if _, err := initializeDebugProcess(p, ""); err != nil {
+		p.bi.Close()
+		g.execPtraceFunc(func() { err = PtraceDetach(pid, 0) })
+		return err
+	}
+	bps := g.userBreakpoints()
+	g.procs = append(g.procs, p)
+	return g.startTarget(p, bps, halt)
+}
+
+// execed handles an exec event of p: the executable of p is loaded again
+// and the user breakpoints are set on the new program.
+func (g *TargetGroup) execed(p *Process, halt bool) error {
+	if g.mode&FollowExec == 0 {
+		var err error
+		p.execPtraceFunc(func() { err = PtraceDetach(p.pid, 0) })
+		if err != nil && err != sys.ESRCH {
+			return err
+		}
+		p.postExit()
+		if len(g.procs) == 0 {
+			return proc.ProcessExitedError{Pid: p.pid}
+		}
+		return nil
+	}
+
+	bps := g.userBreakpoints()
+	p.bi.Close()
+	p.bi = proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
+	p.threads = make(map[int]*Thread)
+	p.currentThread = nil
+	p.selectedGoroutine = nil
+	p.allGCache = nil
+	p.breakpoints = proc.NewBreakpointMap()
+	if _, err := initializeDebugProcess(p, ""); err != nil {
+		p.execPtraceFunc(func() { PtraceDetach(p.pid, 0) })
+		p.postExit()
+		if len(g.procs) == 0 {
+			return proc.ProcessExitedError{Pid: p.pid}
+		}
+		return nil
+	}
+	return g.startTarget(p, bps, halt)
+}
+
+// startTarget sets bps on p, a target that just executed a new program,
+// and resumes it unless halt is set.
+func (g *TargetGroup) startTarget(p *Process, bps []*proc.Breakpoint, halt bool) error {
+	for _, bp := range bps {
+		if err := p.setBreakpointLike(bp); err != nil {
+			return err
+		}
+	}
+	g.reserveBreakpointIDs(p)
+	if halt {
+		return nil
+	}
+	return p.currentThread.resume()
+}
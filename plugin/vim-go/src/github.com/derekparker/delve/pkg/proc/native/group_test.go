@@ -0,0 +1,67 @@
+package native
+
+import (
+	"testing"
+
+	"github.com/derekparker/delve/pkg/proc"
+)
+
+func fakeWriteBreakpoint(addr uint64) (string, int, *proc.Function, []byte, error) {
+	return "main.go", 10, &proc.Function{Name: "main.f"}, []byte{0x90}, nil
+}
+
+func newFakeGroup() (*TargetGroup, *Process, *Process) {
+	p1 := &Process{breakpoints: proc.NewBreakpointMap()}
+	p2 := &Process{breakpoints: proc.NewBreakpointMap()}
+	return &TargetGroup{Process: p1, procs: []*Process{p1, p2}}, p1, p2
+}
+
+func TestSyncBreakpointCopiesInfo(t *testing.T) {
+	g, p1, p2 := newFakeGroup()
+	bp1, err := p1.breakpoints.SetWithID(3, 0x1000, fakeWriteBreakpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bp2, err := p2.breakpoints.SetWithID(3, 0x1000, fakeWriteBreakpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bp1.Name = "traced"
+	bp1.Tracepoint = true
+	bp1.LogMessage = "hit"
+	if err := g.SyncBreakpoint(bp1); err != nil {
+		t.Fatalf("SyncBreakpoint: %v", err)
+	}
+	if bp2.Name != "traced" || !bp2.Tracepoint || bp2.LogMessage != "hit" {
+		t.Fatalf("breakpoint info not copied: %#v", bp2)
+	}
+	// the ID of bp1 is not reused by the other processes
+	bp, err := p2.breakpoints.Set(0x2000, proc.UserBreakpoint, nil, fakeWriteBreakpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bp.ID != 4 {
+		t.Fatalf("wrong ID of new breakpoint: %d", bp.ID)
+	}
+}
+
+func TestSyncBreakpointSkipsWatchpoints(t *testing.T) {
+	g, p1, p2 := newFakeGroup()
+	wp, err := p1.breakpoints.SetWatchpoint(0x1000, 8, proc.WatchWrite, nil, func(*proc.Breakpoint) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SyncBreakpoint(wp); err != nil {
+		t.Fatalf("SyncBreakpoint: %v", err)
+	}
+	if len(p2.breakpoints.M) != 0 {
+		t.Fatalf("watchpoint copied to another process: %#v", p2.breakpoints.M)
+	}
+	bp, err := p2.breakpoints.Set(0x2000, proc.UserBreakpoint, nil, fakeWriteBreakpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bp.ID != 1 {
+		t.Fatalf("wrong ID of new breakpoint: %d", bp.ID)
+	}
+}
@@ -30,6 +30,7 @@ type Process struct {
 	selectedGoroutine *proc.G
 
 	allGCache           []*proc.G
+	fncallState         proc.FunctionCallState
 	os                  *OSProcessDetails
 	firstStart          bool
 	stopMu              sync.Mutex
@@ -39,6 +40,10 @@ type Process struct {
 	ptraceDoneChan      chan interface{}
 	childProcess        bool // this process was launched, not attached to
 	manualStopRequested bool
+
+	// group is the group this process belongs to, if its child processes
+	// are being followed.
+	group *TargetGroup
 }
 
 // New returns an initialized Process struct. Before returning,
@@ -67,6 +72,7 @@ func (dbp *Process) BinInfo() *proc.BinaryInfo {
 func (dbp *Process) Recorded() (bool, string)                { return false, "" }
 func (dbp *Process) Restart(string) error                    { return proc.NotRecordedErr }
 func (dbp *Process) Direction(proc.Direction) error          { return proc.NotRecordedErr }
+func (dbp *Process) GetDirection() proc.Direction            { return proc.Forward }
 func (dbp *Process) When() (string, error)                   { return "", nil }
 func (dbp *Process) Checkpoint(string) (int, error)          { return -1, proc.NotRecordedErr }
 func (dbp *Process) Checkpoints() ([]proc.Checkpoint, error) { return nil, proc.NotRecordedErr }
@@ -213,12 +219,43 @@ func (dbp *Process) SetBreakpoint(addr uint64, kind proc.BreakpointKind, cond as
 	return dbp.breakpoints.Set(addr, kind, cond, dbp.writeBreakpoint)
 }
 
+// SetWatchpoint sets a hardware watchpoint on size bytes at addr, on all
+// threads.
+func (dbp *Process) SetWatchpoint(addr uint64, size int, wtype proc.WatchType, cond ast.Expr) (*proc.Breakpoint, error) {
+	if dbp.exited {
+		return nil, &proc.ProcessExitedError{Pid: dbp.Pid()}
+	}
+	return dbp.breakpoints.SetWatchpoint(addr, size, wtype, cond, dbp.writeWatchpoint)
+}
+
+func (dbp *Process) writeWatchpoint(bp *proc.Breakpoint) error {
+	for _, thread := range dbp.threads {
+		if err := thread.writeHardwareBreakpoint(bp); err != nil {
+			dbp.clearBreakpoint(bp)
+			return err
+		}
+	}
+	return nil
+}
+
 // ClearBreakpoint clears the breakpoint at addr.
 func (dbp *Process) ClearBreakpoint(addr uint64) (*proc.Breakpoint, error) {
 	if dbp.exited {
 		return nil, &proc.ProcessExitedError{Pid: dbp.Pid()}
 	}
-	return dbp.breakpoints.Clear(addr, dbp.currentThread.ClearBreakpoint)
+	return dbp.breakpoints.Clear(addr, dbp.clearBreakpoint)
+}
+
+func (dbp *Process) clearBreakpoint(bp *proc.Breakpoint) error {
+	if bp.WatchType == 0 {
+		return dbp.currentThread.ClearBreakpoint(bp)
+	}
+	for _, thread := range dbp.threads {
+		if err := thread.clearHardwareBreakpoint(bp); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (dbp *Process) ContinueOnce() (proc.Thread, error) {
@@ -322,11 +359,11 @@ func (dbp *Process) SwitchGoroutine(gid int) error {
 // FindBreakpoint finds the breakpoint for the given pc.
 func (dbp *Process) FindBreakpoint(pc uint64) (*proc.Breakpoint, bool) {
 	// Check to see if address is past the breakpoint, (i.e. breakpoint was hit).
-	if bp, ok := dbp.breakpoints.M[pc-uint64(dbp.bi.Arch.BreakpointSize())]; ok {
+	if bp, ok := dbp.breakpoints.M[pc-uint64(dbp.bi.Arch.BreakpointSize())]; ok && bp.WatchType == 0 {
 		return bp, true
 	}
 	// Directly use addr to lookup breakpoint.
-	if bp, ok := dbp.breakpoints.M[pc]; ok {
+	if bp, ok := dbp.breakpoints.M[pc]; ok && bp.WatchType == 0 {
 		return bp, true
 	}
 	return nil, false
@@ -394,8 +431,11 @@ func (dbp *Process) execPtraceFunc(fn func()) {
 
 func (dbp *Process) postExit() {
 	dbp.exited = true
-	close(dbp.ptraceChan)
-	close(dbp.ptraceDoneChan)
+	// the ptrace goroutine is shared by all the processes of a group
+	if dbp.group == nil || dbp.group.removeTarget(dbp) {
+		close(dbp.ptraceChan)
+		close(dbp.ptraceDoneChan)
+	}
 	dbp.bi.Close()
 }
 
@@ -407,3 +447,7 @@ func (dbp *Process) writeSoftwareBreakpoint(thread *Thread, addr uint64) error {
 func (dbp *Process) AllGCache() *[]*proc.G {
 	return &dbp.allGCache
 }
+
+func (dbp *Process) FunctionCallState() *proc.FunctionCallState {
+	return &dbp.fncallState
+}
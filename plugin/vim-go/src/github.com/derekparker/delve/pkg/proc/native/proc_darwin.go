@@ -461,3 +461,10 @@ func (dbp *Process) stop(trapthread *Thread) (err error) {
 func (dbp *Process) detach(kill bool) error {
 	return PtraceDetach(dbp.pid, 0)
 }
+
+func (dbp *Process) followChildren() error {
+	return ErrFollowNotSupported
+}
+
+func (g *TargetGroup) detachPending() {
+}
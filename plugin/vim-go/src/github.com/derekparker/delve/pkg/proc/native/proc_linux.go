@@ -106,7 +106,13 @@ func (dbp *Process) kill() (err error) {
 	if !dbp.threads[dbp.pid].Stopped() {
 		return errors.New("process must be stopped in order to kill it")
 	}
-	if err = sys.Kill(-dbp.pid, sys.SIGKILL); err != nil {
+	// The processes forked by the launched process belong to its process
+	// group, only the launched process leads it.
+	pid := -dbp.pid
+	if pgid, _ := sys.Getpgid(dbp.pid); pgid != dbp.pid {
+		pid = dbp.pid
+	}
+	if err = sys.Kill(pid, sys.SIGKILL); err != nil {
 		return errors.New("could not deliver signal " + err.Error())
 	}
 	if _, _, err = dbp.wait(dbp.pid, 0); err != nil {
@@ -146,12 +152,12 @@ func (dbp *Process) addThread(tid int, attach bool) (*Thread, error) {
 		}
 	}
 
-	dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, syscall.PTRACE_O_TRACECLONE) })
+	dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, dbp.ptraceOptions()) })
 	if err == syscall.ESRCH {
 		if _, _, err = dbp.waitFast(tid); err != nil {
 			return nil, fmt.Errorf("error while waiting after adding thread: %d %s", tid, err)
 		}
-		dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, syscall.PTRACE_O_TRACECLONE) })
+		dbp.execPtraceFunc(func() { err = syscall.PtraceSetOptions(tid, dbp.ptraceOptions()) })
 		if err == syscall.ESRCH {
 			return nil, err
 		}
@@ -165,6 +171,14 @@ func (dbp *Process) addThread(tid int, attach bool) (*Thread, error) {
 		dbp: dbp,
 		os:  new(OSSpecificDetails),
 	}
+	// Debug registers are not inherited by new threads.
+	for _, bp := range dbp.breakpoints.M {
+		if bp.WatchType != 0 {
+			if err := dbp.threads[tid].writeHardwareBreakpoint(bp); err != nil {
+				return nil, err
+			}
+		}
+	}
 	if dbp.currentThread == nil {
 		dbp.SwitchThread(tid)
 	}
@@ -206,23 +220,41 @@ func (dbp *Process) trapWaitInternal(pid int, halt bool) (*Thread, error) {
 		if wpid == 0 {
 			continue
 		}
+		// p is the process wpid belongs to, when following child processes
+		// it can be any process of the group.
+		p := dbp
 		th, ok := dbp.threads[wpid]
+		if !ok && dbp.group != nil {
+			p, th, ok = dbp.group.findThread(wpid)
+			if !ok {
+				if err := dbp.group.untrackedEvent(wpid, status, halt); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
 		if ok {
 			th.Status = (*WaitStatus)(status)
 		}
 		if status.Exited() {
-			if wpid == dbp.pid {
-				dbp.postExit()
-				return nil, proc.ProcessExitedError{Pid: wpid, Status: status.ExitStatus()}
+			if wpid == p.pid {
+				p.postExit()
+				if p.group == nil || len(p.group.procs) == 0 {
+					return nil, proc.ProcessExitedError{Pid: wpid, Status: status.ExitStatus()}
+				}
+				if halt {
+					return nil, nil
+				}
+				continue
 			}
-			delete(dbp.threads, wpid)
+			delete(p.threads, wpid)
 			continue
 		}
 		if status.StopSignal() == sys.SIGTRAP && status.TrapCause() == sys.PTRACE_EVENT_CLONE {
 			// A traced thread has cloned a new thread, grab the pid and
 			// add it to our list of traced threads.
 			var cloned uint
-			dbp.execPtraceFunc(func() { cloned, err = sys.PtraceGetEventMsg(wpid) })
+			p.execPtraceFunc(func() { cloned, err = sys.PtraceGetEventMsg(wpid) })
 			if err != nil {
 				if err == sys.ESRCH {
 					// thread died while we were adding it
@@ -230,35 +262,55 @@ func (dbp *Process) trapWaitInternal(pid int, halt bool) (*Thread, error) {
 				}
 				return nil, fmt.Errorf("could not get event message: %s", err)
 			}
-			th, err = dbp.addThread(int(cloned), false)
+			th, err = p.addThread(int(cloned), false)
 			if err != nil {
 				if err == sys.ESRCH {
 					// thread died while we were adding it
-					delete(dbp.threads, int(cloned))
+					delete(p.threads, int(cloned))
 					continue
 				}
 				return nil, err
 			}
 			if halt {
 				th.os.running = false
-				dbp.threads[int(wpid)].os.running = false
+				p.threads[int(wpid)].os.running = false
 				return nil, nil
 			}
 			if err = th.Continue(); err != nil {
 				if err == sys.ESRCH {
 					// thread died while we were adding it
-					delete(dbp.threads, th.ID)
+					delete(p.threads, th.ID)
 					continue
 				}
 				return nil, fmt.Errorf("could not continue new thread %d %s", cloned, err)
 			}
-			if err = dbp.threads[int(wpid)].Continue(); err != nil {
+			if err = p.threads[int(wpid)].Continue(); err != nil {
 				if err != sys.ESRCH {
 					return nil, fmt.Errorf("could not continue existing thread %d %s", wpid, err)
 				}
 			}
 			continue
 		}
+		if p.group != nil && status.StopSignal() == sys.SIGTRAP {
+			switch status.TrapCause() {
+			case sys.PTRACE_EVENT_FORK, sys.PTRACE_EVENT_VFORK:
+				if err := p.group.forked(p, th, status.TrapCause() == sys.PTRACE_EVENT_VFORK, halt); err != nil {
+					return nil, err
+				}
+				if halt {
+					return nil, nil
+				}
+				continue
+			case sys.PTRACE_EVENT_EXEC:
+				if err := p.group.execed(p, halt); err != nil {
+					return nil, err
+				}
+				if halt {
+					return nil, nil
+				}
+				continue
+			}
+		}
 		if th == nil {
 			// Sometimes we get an unknown thread, ignore it?
 			continue
@@ -271,7 +323,7 @@ func (dbp *Process) trapWaitInternal(pid int, halt bool) (*Thread, error) {
 			// TODO(dp) alert user about unexpected signals here.
 			if err := th.resumeWithSig(int(status.StopSignal())); err != nil {
 				if err == sys.ESRCH {
-					return nil, proc.ProcessExitedError{Pid: dbp.pid}
+					return nil, proc.ProcessExitedError{Pid: p.pid}
 				}
 				return nil, err
 			}
@@ -310,6 +362,50 @@ func (dbp *Process) loadProcessInformation(wg *sync.WaitGroup) {
 	dbp.os.comm = strings.Replace(string(comm), "%", "%%", -1)
 }
 
+// MemoryMap returns the memory regions of the process, as listed in
+// /proc/pid/maps.
+func (dbp *Process) MemoryMap() ([]proc.MemoryMapEntry, error) {
+	if dbp.exited {
+		return nil, proc.ProcessExitedError{Pid: dbp.pid}
+	}
+	maps, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/maps", dbp.pid))
+	if err != nil {
+		return nil, err
+	}
+	var r []proc.MemoryMapEntry
+	for _, line := range strings.Split(string(maps), "\n") {
+		// Each line is: address perms offset dev inode pathname, pathname can
+		// contain spaces and is missing for anonymous mappings.
+		fields := strings.SplitN(line, " ", 6)
+		if len(fields) < 5 {
+			continue
+		}
+		addrs := strings.SplitN(fields[0], "-", 2)
+		if len(addrs) != 2 || len(fields[1]) < 3 {
+			return nil, fmt.Errorf("malformed line in /proc/%d/maps: %q", dbp.pid, line)
+		}
+		start, err1 := strconv.ParseUint(addrs[0], 16, 64)
+		end, err2 := strconv.ParseUint(addrs[1], 16, 64)
+		offset, err3 := strconv.ParseUint(fields[2], 16, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("malformed line in /proc/%d/maps: %q", dbp.pid, line)
+		}
+		entry := proc.MemoryMapEntry{
+			Addr:   start,
+			Size:   end - start,
+			Read:   fields[1][0] == 'r',
+			Write:  fields[1][1] == 'w',
+			Exec:   fields[1][2] == 'x',
+			Offset: offset,
+		}
+		if len(fields) == 6 {
+			entry.Filename = strings.TrimSpace(fields[5])
+		}
+		r = append(r, entry)
+	}
+	return r, nil
+}
+
 func status(pid int, comm string) rune {
 	f, err := os.Open(fmt.Sprintf("/proc/%d/stat", pid))
 	if err != nil {
@@ -384,9 +480,12 @@ func (dbp *Process) exitGuard(err error) error {
 func (dbp *Process) resume() error {
 	// all threads stopped over a breakpoint are made to step over it
 	for _, thread := range dbp.threads {
-		if thread.CurrentBreakpoint.Breakpoint != nil {
-			if err := thread.StepInstruction(); err != nil {
-				return err
+		if bp := thread.CurrentBreakpoint.Breakpoint; bp != nil {
+			// Watchpoints stop after the instruction that accessed memory.
+			if bp.WatchType == 0 {
+				if err := thread.StepInstruction(); err != nil {
+					return err
+				}
 			}
 			thread.CurrentBreakpoint.Clear()
 		}
@@ -414,7 +513,7 @@ func (dbp *Process) stop(trapthread *Thread) (err error) {
 	}
 
 	// wait for all threads to stop
-	for {
+	for !dbp.exited {
 		allstopped := true
 		for _, th := range dbp.threads {
 			if th.os.running {
@@ -488,3 +488,10 @@ func killProcess(pid int) error {
 
 	return p.Kill()
 }
+
+func (dbp *Process) followChildren() error {
+	return ErrFollowNotSupported
+}
+
+func (g *TargetGroup) detachPending() {
+}
@@ -52,6 +52,15 @@ func PtracePeekUser(tid int, off uintptr) (uintptr, error) {
 	return val, nil
 }
 
+// PtraceSetFpRegs executes ptrace PTRACE_SETFPREGS.
+func PtraceSetFpRegs(tid int, fpregs *proc.PtraceFpRegs) error {
+	_, _, err := syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_SETFPREGS, uintptr(tid), uintptr(0), uintptr(unsafe.Pointer(fpregs)), 0, 0)
+	if err != syscall.Errno(0) {
+		return err
+	}
+	return nil
+}
+
 // PtraceGetRegset returns floating point registers of the specified thread
 // using PTRACE.
 // See amd64_linux_fetch_inferior_registers in gdb/amd64-linux-nat.c.html
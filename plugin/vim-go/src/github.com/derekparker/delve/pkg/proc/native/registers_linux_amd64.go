@@ -11,8 +11,9 @@ import (
 
 // Regs is a wrapper for sys.PtraceRegs.
 type Regs struct {
-	regs   *sys.PtraceRegs
-	fpregs []proc.Register
+	regs     *sys.PtraceRegs
+	fpregs   []proc.Register
+	fpregset *proc.LinuxX86Xstate
 }
 
 func (r *Regs) Slice() []proc.Register {
@@ -89,6 +90,12 @@ func (r *Regs) GAddr() (uint64, bool) {
 	return 0, false
 }
 
+// Xstate returns the XSAVE area the floating point registers were read
+// from, or nil if they were not requested.
+func (r *Regs) Xstate() *proc.LinuxX86Xstate {
+	return r.fpregset
+}
+
 // SetPC sets RIP to the value specified by 'pc'.
 func (r *Regs) SetPC(t proc.Thread, pc uint64) (err error) {
 	thread := t.(*Thread)
@@ -263,9 +270,9 @@ func registers(thread *Thread, floatingPoint bool) (proc.Registers, error) {
 	if err != nil {
 		return nil, err
 	}
-	r := &Regs{&regs, nil}
+	r := &Regs{&regs, nil, nil}
 	if floatingPoint {
-		r.fpregs, err = thread.fpRegisters()
+		r.fpregs, r.fpregset, err = thread.fpRegisters()
 		if err != nil {
 			return nil, err
 		}
@@ -283,9 +290,9 @@ const (
 	_XSAVE_SSE_REGION_LEN        = 416
 )
 
-func (thread *Thread) fpRegisters() (regs []proc.Register, err error) {
-	var fpregs proc.LinuxX86Xstate
-	thread.dbp.execPtraceFunc(func() { fpregs, err = PtraceGetRegset(thread.ID) })
+func (thread *Thread) fpRegisters() (regs []proc.Register, fpregs *proc.LinuxX86Xstate, err error) {
+	fpregs = new(proc.LinuxX86Xstate)
+	thread.dbp.execPtraceFunc(func() { *fpregs, err = PtraceGetRegset(thread.ID) })
 	regs = fpregs.Decode()
 	if err != nil {
 		err = fmt.Errorf("could not get floating point registers: %v", err.Error())
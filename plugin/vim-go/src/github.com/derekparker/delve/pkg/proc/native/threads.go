@@ -114,22 +114,25 @@ func (thread *Thread) SetPC(pc uint64) error {
 // thread is stopped at as CurrentBreakpoint on the thread struct.
 func (thread *Thread) SetCurrentBreakpoint() error {
 	thread.CurrentBreakpoint.Clear()
-	pc, err := thread.PC()
+	bp, err := thread.findHardwareBreakpoint()
 	if err != nil {
 		return err
 	}
-	if bp, ok := thread.dbp.FindBreakpoint(pc); ok {
-		if err = thread.SetPC(bp.Addr); err != nil {
+	if bp == nil {
+		pc, err := thread.PC()
+		if err != nil {
 			return err
 		}
-		thread.CurrentBreakpoint = bp.CheckCondition(thread)
-		if thread.CurrentBreakpoint.Breakpoint != nil && thread.CurrentBreakpoint.Active {
-			if g, err := proc.GetG(thread); err == nil {
-				thread.CurrentBreakpoint.HitCount[g.ID]++
+		var ok bool
+		if bp, ok = thread.dbp.FindBreakpoint(pc); ok {
+			if err = thread.SetPC(bp.Addr); err != nil {
+				return err
 			}
-			thread.CurrentBreakpoint.TotalHitCount++
 		}
 	}
+	if bp != nil {
+		thread.CurrentBreakpoint = bp.CheckCondition(thread)
+	}
 	return nil
 }
 
@@ -144,3 +144,15 @@ func (t *Thread) ReadMemory(buf []byte, addr uintptr) (int, error) {
 	}
 	return len(buf), nil
 }
+
+func (t *Thread) writeHardwareBreakpoint(bp *proc.Breakpoint) error {
+	return proc.WatchpointsNotSupportedErr
+}
+
+func (t *Thread) clearHardwareBreakpoint(bp *proc.Breakpoint) error {
+	return nil
+}
+
+func (t *Thread) findHardwareBreakpoint() (*proc.Breakpoint, error) {
+	return nil, nil
+}
@@ -86,7 +86,7 @@ func (t *Thread) saveRegisters() (proc.Registers, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not save register contents")
 	}
-	return &Regs{&t.os.registers, nil}, nil
+	return &Regs{&t.os.registers, nil, nil}, nil
 }
 
 func (t *Thread) restoreRegisters() (err error) {
@@ -94,6 +94,47 @@ func (t *Thread) restoreRegisters() (err error) {
 	return
 }
 
+// SetSP sets the value of RSP.
+func (t *Thread) SetSP(sp uint64) (err error) {
+	var regs sys.PtraceRegs
+	t.dbp.execPtraceFunc(func() {
+		if err = sys.PtraceGetRegs(t.ID, &regs); err != nil {
+			return
+		}
+		regs.Rsp = sp
+		err = sys.PtraceSetRegs(t.ID, &regs)
+	})
+	return
+}
+
+// SetDX sets the value of RDX, the closure context register.
+func (t *Thread) SetDX(dx uint64) (err error) {
+	var regs sys.PtraceRegs
+	t.dbp.execPtraceFunc(func() {
+		if err = sys.PtraceGetRegs(t.ID, &regs); err != nil {
+			return
+		}
+		regs.Rdx = dx
+		err = sys.PtraceSetRegs(t.ID, &regs)
+	})
+	return
+}
+
+// RestoreRegisters restores the general purpose registers and, if they
+// were read, the floating point registers saved in savedRegs.
+func (t *Thread) RestoreRegisters(savedRegs proc.Registers) (err error) {
+	sr := savedRegs.(*Regs)
+	t.dbp.execPtraceFunc(func() {
+		if err = sys.PtraceSetRegs(t.ID, sr.regs); err != nil {
+			return
+		}
+		if sr.fpregset != nil {
+			err = PtraceSetFpRegs(t.ID, &sr.fpregset.PtraceFpRegs)
+		}
+	})
+	return
+}
+
 func (t *Thread) WriteMemory(addr uintptr, data []byte) (written int, err error) {
 	if t.dbp.exited {
 		return 0, proc.ProcessExitedError{Pid: t.dbp.pid}
@@ -115,3 +156,103 @@ func (t *Thread) ReadMemory(data []byte, addr uintptr) (n int, err error) {
 	t.dbp.execPtraceFunc(func() { _, err = sys.PtracePeekData(t.ID, addr, data) })
 	return
 }
+
+// debugRegOffset is the offset of u_debugreg in struct user, see
+// sys/user.h.
+const debugRegOffset = 848
+
+func (t *Thread) peekDebugReg(i int) (val uintptr, err error) {
+	t.dbp.execPtraceFunc(func() { val, err = PtracePeekUser(t.ID, debugRegOffset+uintptr(i)*8) })
+	return
+}
+
+func (t *Thread) pokeDebugReg(i int, val uintptr) (err error) {
+	t.dbp.execPtraceFunc(func() { err = PtracePokeUser(t.ID, debugRegOffset+uintptr(i)*8, val) })
+	return
+}
+
+// writeHardwareBreakpoint sets the debug address register of bp to its
+// address and enables it in DR7.
+// See Section 17.2 of Intel® 64 and IA-32 Architectures Software
+// Developer’s Manual, Volume 3B.
+func (t *Thread) writeHardwareBreakpoint(bp *proc.Breakpoint) error {
+	idx := uint(bp.HWBreakIndex)
+	if idx >= proc.MaxHardwareBreakpoints {
+		return fmt.Errorf("all %d hardware debug registers are in use", proc.MaxHardwareBreakpoints)
+	}
+
+	// x86 can not stop on reads only, read watchpoints also stop on writes.
+	var rw uintptr = 0x1
+	if bp.WatchType.Read() {
+		rw = 0x3
+	}
+	var size uintptr
+	switch bp.WatchSize {
+	case 1:
+		size = 0x0
+	case 2:
+		size = 0x1
+	case 4:
+		size = 0x3
+	case 8:
+		size = 0x2
+	default:
+		return fmt.Errorf("invalid watchpoint size %d", bp.WatchSize)
+	}
+
+	dr7, err := t.peekDebugReg(7)
+	if err != nil {
+		return err
+	}
+	if err := t.pokeDebugReg(int(idx), uintptr(bp.Addr)); err != nil {
+		return err
+	}
+	dr7 &^= 0x3<<(2*idx) | 0xf<<(16+4*idx)
+	dr7 |= 0x1<<(2*idx) | (rw|size<<2)<<(16+4*idx)
+	return t.pokeDebugReg(7, dr7)
+}
+
+// clearHardwareBreakpoint disables the debug address register of bp.
+func (t *Thread) clearHardwareBreakpoint(bp *proc.Breakpoint) error {
+	idx := uint(bp.HWBreakIndex)
+	dr7, err := t.peekDebugReg(7)
+	if err != nil {
+		return err
+	}
+	dr7 &^= 0x3<<(2*idx) | 0xf<<(16+4*idx)
+	if err := t.pokeDebugReg(7, dr7); err != nil {
+		return err
+	}
+	return t.pokeDebugReg(int(idx), 0)
+}
+
+// findHardwareBreakpoint returns the watchpoint that stopped this thread, if
+// any, and resets the debug status register DR6.
+func (t *Thread) findHardwareBreakpoint() (*proc.Breakpoint, error) {
+	hasWatchpoints := false
+	for _, bp := range t.dbp.breakpoints.M {
+		if bp.WatchType != 0 {
+			hasWatchpoints = true
+			break
+		}
+	}
+	if !hasWatchpoints {
+		return nil, nil
+	}
+	dr6, err := t.peekDebugReg(6)
+	if err != nil {
+		return nil, err
+	}
+	if dr6&0xf == 0 {
+		return nil, nil
+	}
+	if err := t.pokeDebugReg(6, 0); err != nil {
+		return nil, err
+	}
+	for _, bp := range t.dbp.breakpoints.M {
+		if bp.WatchType != 0 && dr6&(1<<bp.HWBreakIndex) != 0 {
+			return bp, nil
+		}
+	}
+	return nil, nil
+}
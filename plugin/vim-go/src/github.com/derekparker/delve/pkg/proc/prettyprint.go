@@ -0,0 +1,153 @@
+package proc
+
+import (
+	"bytes"
+	"fmt"
+	"go/constant"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// PrettyPrinter describes how the values of the types whose name matches
+// Type are shown.
+// Summary and Children are evaluated with the fields of the value in scope,
+// as if they were local variables.
+type PrettyPrinter struct {
+	Type *regexp.Regexp
+	// Summary is a string where the expressions enclosed in curly braces are
+	// replaced by their values, for example "len={tail-head}". Braces can
+	// be nested, braces inside string and character literals are ignored.
+	Summary string
+	// Children is an expression whose value replaces the children of the
+	// value being printed, for example "buf[head:tail]".
+	Children string
+}
+
+// PrettyPrint applies printers to vars and to their children, recursively,
+// replacing the values of the matching types with their pretty printed
+// view.
+func PrettyPrint(vars []*Variable, printers []PrettyPrinter, cfg LoadConfig) {
+	if len(printers) == 0 {
+		return
+	}
+	for _, v := range vars {
+		v.prettyPrint(printers, 0, cfg)
+	}
+}
+
+func (v *Variable) prettyPrint(printers []PrettyPrinter, depth int, cfg LoadConfig) {
+	if v.Unreadable != nil || v.OnlyAddr || depth > cfg.MaxVariableRecurse+1 {
+		return
+	}
+	if v.Kind != reflect.Ptr && v.Flags&VariablePretty == 0 {
+		for i := range printers {
+			if printers[i].Type.MatchString(v.TypeString()) {
+				v.applyPrettyPrinter(&printers[i], cfg)
+				break
+			}
+		}
+	}
+	for i := range v.Children {
+		v.Children[i].prettyPrint(printers, depth+1, cfg)
+	}
+}
+
+// applyPrettyPrinter sets the summary of v and replaces its children with
+// the view described by printer, the type and value of v are not changed.
+// If evaluating printer fails the error is reported in the summary and the
+// raw value is left untouched.
+func (v *Variable) applyPrettyPrinter(printer *PrettyPrinter, cfg LoadConfig) {
+	scope := &EvalScope{Mem: v.mem, BinInfo: v.bi, fields: v}
+
+	summary, err := scope.evalSummary(printer.Summary)
+	if err != nil {
+		v.Summary = fmt.Sprintf("(pretty printer error: %v)", err)
+		return
+	}
+
+	if printer.Children != "" {
+		cv, err := scope.EvalExpression(printer.Children, cfg)
+		if err != nil {
+			v.Summary = fmt.Sprintf("(pretty printer error: %v)", err)
+			return
+		}
+		cv.Name = printer.Children
+		v.Children = []Variable{*cv}
+		v.Flags |= VariablePrettyChildren
+	}
+
+	v.Summary = summary
+	v.Flags |= VariablePretty
+}
+
+// evalSummary replaces the expressions enclosed in curly braces in summary
+// with their values.
+func (scope *EvalScope) evalSummary(summary string) (string, error) {
+	var buf bytes.Buffer
+	for {
+		start := strings.Index(summary, "{")
+		if start < 0 {
+			buf.WriteString(summary)
+			return buf.String(), nil
+		}
+		end := matchingBrace(summary[start:])
+		if end < 0 {
+			return "", fmt.Errorf("unterminated expression in summary %q", summary)
+		}
+		end += start
+		buf.WriteString(summary[:start])
+		ev, err := scope.EvalExpression(summary[start+1:end], loadSingleValue)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(ev.summaryString())
+		summary = summary[end+1:]
+	}
+}
+
+// matchingBrace returns the index of the brace closing the one at the start
+// of s, skipping nested braces and string and character literals, or -1 if
+// there is none.
+func matchingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '"', '\'', '`':
+			quote := s[i]
+			for i++; i < len(s) && s[i] != quote; i++ {
+				if s[i] == '\\' && quote != '`' {
+					i++
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// summaryString returns a short description of v suitable for a summary.
+func (v *Variable) summaryString() string {
+	switch {
+	case v.Unreadable != nil:
+		return fmt.Sprintf("(unreadable %v)", v.Unreadable)
+	case v.Value == nil:
+		switch v.Kind {
+		case reflect.Array, reflect.Slice, reflect.Map, reflect.Chan:
+			return fmt.Sprintf("%s len: %d", v.TypeString(), v.Len)
+		}
+		return v.TypeString()
+	case v.Kind == reflect.String:
+		return constant.StringVal(v.Value)
+	}
+	if descr := v.ConstDescr(); descr != "" {
+		return descr
+	}
+	return v.Value.String()
+}
@@ -63,7 +63,8 @@ func FindFunctionLocation(p Process, funcName string, firstLine bool, lineOffset
 	return origfn.Entry, nil
 }
 
-// Next continues execution until the next source line.
+// Next continues execution until the next source line, or the previous
+// one if the process is running backwards.
 func Next(dbp Process) (err error) {
 	if dbp.Exited() {
 		return &ProcessExitedError{Pid: dbp.Pid()}
@@ -88,6 +89,7 @@ func Continue(dbp Process) error {
 		return &ProcessExitedError{Pid: dbp.Pid()}
 	}
 	dbp.CheckAndClearManualStopRequest()
+	dbp.Breakpoints().WatchOutOfScope = nil
 	defer func() {
 		// Make sure we clear internal breakpoints if we simultaneously receive a
 		// manual stop request and hit a breakpoint.
@@ -104,6 +106,9 @@ func Continue(dbp Process) error {
 		if err != nil {
 			return err
 		}
+		if err := clearWatchpointsOutOfScope(dbp); err != nil {
+			return err
+		}
 
 		threads := dbp.ThreadList()
 
@@ -114,6 +119,17 @@ func Continue(dbp Process) error {
 		curthread := dbp.CurrentThread()
 		curbp := curthread.Breakpoint()
 
+		if fncall := funcCallState(dbp); fncall != nil && fncall.inProgress && trapthread.ThreadID() == fncall.threadID && onDebugCall(trapthread) {
+			// The thread executing an injected function call stopped on one of the
+			// breakpoint instructions of runtime.debugCallV1.
+			if funcCallStep(dbp, fncall, trapthread) {
+				return conditionErrors(threads)
+			}
+			if curthread.ThreadID() == trapthread.ThreadID() {
+				continue
+			}
+		}
+
 		switch {
 		case curbp.Breakpoint == nil:
 			// runtime.Breakpoint or manual stop
@@ -142,6 +158,15 @@ func Continue(dbp Process) error {
 				if err := conditionErrors(threads); err != nil {
 					return err
 				}
+				if dbp.GetDirection() == Backward {
+					// we stopped right after a CALL instruction, stepping
+					// backwards once more takes us to the RET instruction of the
+					// called function.
+					if err := dbp.ClearInternalBreakpoints(); err != nil {
+						return err
+					}
+					return dbp.StepInstruction()
+				}
 				regs, err := curthread.Registers(false)
 				if err != nil {
 					return err
@@ -220,7 +245,8 @@ func pickCurrentThread(dbp Process, trapthread Thread, threads []Thread) error {
 }
 
 // Step will continue until another source line is reached.
-// Will step into functions.
+// Will step into functions, when running backwards functions are entered
+// at their RET instruction.
 func Step(dbp Process) (err error) {
 	if dbp.Exited() {
 		return &ProcessExitedError{Pid: dbp.Pid()}
@@ -229,6 +255,16 @@ func Step(dbp Process) (err error) {
 		return fmt.Errorf("next while nexting")
 	}
 
+	if dbp.GetDirection() == Backward {
+		afterCall, err := afterCallInstruction(dbp)
+		if err != nil {
+			return err
+		}
+		if afterCall {
+			return dbp.StepInstruction()
+		}
+	}
+
 	if err = next(dbp, true, false); err != nil {
 		switch err.(type) {
 		case ThreadBlockedError: // Noop
@@ -283,7 +319,9 @@ func andFrameoffCondition(cond ast.Expr, frameoff int64) ast.Expr {
 }
 
 // StepOut will continue until the current goroutine exits the
-// function currently being executed or a deferred function is executed
+// function currently being executed or a deferred function is executed.
+// When running backwards it stops at the CALL instruction that called the
+// current function.
 func StepOut(dbp Process) error {
 	if dbp.Exited() {
 		return &ProcessExitedError{Pid: dbp.Pid()}
@@ -315,6 +353,17 @@ func StepOut(dbp Process) error {
 	sameGCond := SameGoroutineCondition(selg)
 	retFrameCond := andFrameoffCondition(sameGCond, retframe.FrameOffset())
 
+	if dbp.GetDirection() == Backward {
+		if topframe.Ret == 0 {
+			return errors.New("nothing to stepout to")
+		}
+		if err := setStepOutReverseBreakpoint(dbp, topframe, retframe, retFrameCond); err != nil {
+			return err
+		}
+		success = true
+		return Continue(dbp)
+	}
+
 	var deferpc uint64 = 0
 	if filepath.Ext(topframe.Current.File) == ".go" {
 		if selg != nil {
@@ -479,7 +528,8 @@ func FindGoroutine(dbp Process, gid int) (*G, error) {
 
 // ConvertEvalScope returns a new EvalScope in the context of the
 // specified goroutine ID and stack frame.
-func ConvertEvalScope(dbp Process, gid, frame int) (*EvalScope, error) {
+// If deferCall is > 0 the eval scope will be relative to the specified deferred call.
+func ConvertEvalScope(dbp Process, gid, frame, deferCall int) (*EvalScope, error) {
 	if dbp.Exited() {
 		return nil, &ProcessExitedError{Pid: dbp.Pid()}
 	}
@@ -499,7 +549,12 @@ func ConvertEvalScope(dbp Process, gid, frame int) (*EvalScope, error) {
 		thread = g.Thread
 	}
 
-	locs, err := g.Stacktrace(frame + 1)
+	var locs []Stackframe
+	if deferCall > 0 {
+		locs, err = g.StacktraceDefers(frame + 1)
+	} else {
+		locs, err = g.Stacktrace(frame + 1)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -508,6 +563,13 @@ func ConvertEvalScope(dbp Process, gid, frame int) (*EvalScope, error) {
 		return nil, fmt.Errorf("Frame %d does not exist in goroutine %d", frame, gid)
 	}
 
+	if deferCall > 0 {
+		if deferCall-1 >= len(locs[frame].Defers) {
+			return nil, fmt.Errorf("Frame %d only has %d deferred calls", frame, len(locs[frame].Defers))
+		}
+		return locs[frame].Defers[deferCall-1].EvalScope(dbp.BinInfo(), thread, g, locs[frame])
+	}
+
 	return FrameToScope(dbp.BinInfo(), thread, g, locs[frame:]...), nil
 }
 
@@ -12,3 +12,65 @@ func TestIssue554(t *testing.T) {
 		t.Fatalf("should be false")
 	}
 }
+
+func TestSetWatchpointRegisterLimit(t *testing.T) {
+	bpmap := NewBreakpointMap()
+	writeWatchpoint := func(*Breakpoint) error { return nil }
+	for i := 0; i < MaxHardwareBreakpoints; i++ {
+		bp, err := bpmap.SetWatchpoint(uint64(0x1000+8*i), 8, WatchWrite, nil, writeWatchpoint)
+		if err != nil {
+			t.Fatalf("SetWatchpoint %d: %v", i, err)
+		}
+		if bp.HWBreakIndex != uint8(i) {
+			t.Fatalf("watchpoint %d uses debug register %d", i, bp.HWBreakIndex)
+		}
+	}
+	if _, err := bpmap.SetWatchpoint(0x2000, 8, WatchWrite, nil, writeWatchpoint); err == nil {
+		t.Fatalf("watchpoint set with all debug registers in use")
+	}
+}
+
+func TestSummaryMatchingBrace(t *testing.T) {
+	testcases := []struct {
+		summary string
+		end     int
+	}{
+		{"{tail-head} items", 10},
+		{"{m[T{1}]}", 8},
+		{`{m["}"]}`, 7},
+		{`{m['}']}`, 7},
+		{`{s == "\"}"}`, 11},
+		{"{m[`}`]}", 7},
+		{"{unterminated", -1},
+		{`{m["}]}`, -1},
+	}
+	for _, tc := range testcases {
+		if end := matchingBrace(tc.summary); end != tc.end {
+			t.Errorf("%s: expected %d got %d", tc.summary, tc.end, end)
+		}
+	}
+}
+
+func TestReserveBreakpointID(t *testing.T) {
+	bpmap := NewBreakpointMap()
+	writeBreakpoint := func(addr uint64) (string, int, *Function, []byte, error) {
+		return "main.go", 1, &Function{Name: "main.main"}, nil, nil
+	}
+	bpmap.ReserveBreakpointID(5)
+	bp, err := bpmap.Set(0x1000, UserBreakpoint, nil, writeBreakpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bp.ID != 6 {
+		t.Fatalf("expected ID 6 after reserving 5, got %d", bp.ID)
+	}
+	// reserving a lower ID does not cause reuse
+	bpmap.ReserveBreakpointID(2)
+	bp, err = bpmap.Set(0x2000, UserBreakpoint, nil, writeBreakpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bp.ID != 7 {
+		t.Fatalf("expected ID 7, got %d", bp.ID)
+	}
+}
@@ -2,6 +2,7 @@ package proc_test
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"go/ast"
@@ -22,9 +23,11 @@ import (
 	"github.com/derekparker/delve/pkg/dwarf/frame"
 	"github.com/derekparker/delve/pkg/goversion"
 	"github.com/derekparker/delve/pkg/proc"
+	"github.com/derekparker/delve/pkg/proc/core"
 	"github.com/derekparker/delve/pkg/proc/gdbserial"
 	"github.com/derekparker/delve/pkg/proc/native"
 	protest "github.com/derekparker/delve/pkg/proc/test"
+	"github.com/derekparker/delve/service/api"
 )
 
 var normalLoadConfig = proc.LoadConfig{true, 1, 64, 64, -1}
@@ -1253,7 +1256,7 @@ func TestFrameEvaluation(t *testing.T) {
 				continue
 			}
 
-			scope, err := proc.ConvertEvalScope(p, g.ID, frame)
+			scope, err := proc.ConvertEvalScope(p, g.ID, frame, 0)
 			assertNoError(err, t, "ConvertEvalScope()")
 			t.Logf("scope = %v", scope)
 			v, err := scope.EvalVariable("i", normalLoadConfig)
@@ -1278,7 +1281,7 @@ func TestFrameEvaluation(t *testing.T) {
 		assertNoError(err, t, "GetG()")
 
 		for i := 0; i <= 3; i++ {
-			scope, err := proc.ConvertEvalScope(p, g.ID, i+1)
+			scope, err := proc.ConvertEvalScope(p, g.ID, i+1, 0)
 			assertNoError(err, t, fmt.Sprintf("ConvertEvalScope() on frame %d", i+1))
 			v, err := scope.EvalVariable("n", normalLoadConfig)
 			assertNoError(err, t, fmt.Sprintf("EvalVariable() on frame %d", i+1))
@@ -3697,3 +3700,343 @@ func TestIssue951(t *testing.T) {
 		}
 	})
 }
+
+func assertWatchValues(p proc.Process, t *testing.T, oldval, newval int64) {
+	bpstate := p.CurrentThread().Breakpoint()
+	if bpstate.Breakpoint == nil || bpstate.WatchType == 0 {
+		t.Fatalf("not stopped at a watchpoint: %v", bpstate.Breakpoint)
+	}
+	if bpstate.WatchOldValue == nil || bpstate.WatchNewValue == nil {
+		t.Fatalf("watchpoint values not loaded")
+	}
+	o, _ := constant.Int64Val(bpstate.WatchOldValue.Value)
+	n, _ := constant.Int64Val(bpstate.WatchNewValue.Value)
+	if o != oldval || n != newval {
+		t.Fatalf("wrong watchpoint values: %d -> %d, expected %d -> %d", o, n, oldval, newval)
+	}
+}
+
+func TestWatchpointsBasic(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("watchpoints are only supported on linux/amd64")
+	}
+	withTestProcess("databpeasy", t, func(p proc.Process, fixture protest.Fixture) {
+		_, err := setFunctionBreakpoint(p, "main.main")
+		assertNoError(err, t, "SetBreakpoint()")
+		assertNoError(proc.Continue(p), t, "Continue 0")
+		assertLineNumber(p, t, 11, "Continue 0")
+
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+		_, err = proc.Watch(p, scope, "globalvar1", proc.WatchWrite, nil)
+		assertNoError(err, t, "Watch(globalvar1)")
+
+		assertNoError(proc.Continue(p), t, "Continue 1")
+		assertLineNumber(p, t, 14, "Continue 1")
+		assertWatchValues(p, t, 0, 2)
+
+		assertNoError(proc.Continue(p), t, "Continue 2")
+		assertLineNumber(p, t, 16, "Continue 2")
+		assertWatchValues(p, t, 2, 4)
+	})
+}
+
+func TestWatchpointsStackOutOfScope(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("watchpoints are only supported on linux/amd64")
+	}
+	withTestProcess("databpeasy", t, func(p proc.Process, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture, 23)
+		setFileBreakpoint(p, t, fixture, 18)
+		assertNoError(proc.Continue(p), t, "Continue 0")
+		assertLineNumber(p, t, 23, "Continue 0")
+		_, err := p.ClearBreakpoint(bp.Addr)
+		assertNoError(err, t, "ClearBreakpoint()")
+
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+		wp, err := proc.Watch(p, scope, "n", proc.WatchWrite, nil)
+		assertNoError(err, t, "Watch(n)")
+
+		for i, oldval := range []int64{0, 1} {
+			assertNoError(proc.Continue(p), t, "Continue")
+			assertLineNumber(p, t, 23, fmt.Sprintf("Continue %d", i+1))
+			assertWatchValues(p, t, oldval, oldval+int64(i)+1)
+		}
+
+		assertNoError(proc.Continue(p), t, "Continue 3")
+		assertLineNumber(p, t, 18, "Continue 3")
+		if oos := p.Breakpoints().WatchOutOfScope; len(oos) != 1 || oos[0] != wp {
+			t.Fatalf("watchpoint not reported out of scope: %v", oos)
+		}
+		if _, ok := p.Breakpoints().M[wp.Addr]; ok {
+			t.Fatalf("watchpoint not cleared")
+		}
+	})
+}
+
+func TestWatchpointsStackGrowth(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("watchpoints are only supported on linux/amd64")
+	}
+	withTestProcess("databpstack", t, func(p proc.Process, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture, 10)
+		assertNoError(proc.Continue(p), t, "Continue 0")
+		assertLineNumber(p, t, 10, "Continue 0")
+		_, err := p.ClearBreakpoint(bp.Addr)
+		assertNoError(err, t, "ClearBreakpoint()")
+
+		scope, err := proc.GoroutineScope(p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope")
+		wp, err := proc.Watch(p, scope, "w", proc.WatchWrite, nil)
+		assertNoError(err, t, "Watch(w)")
+		addr := wp.Addr
+
+		// grow moves the stack of the goroutine, the watchpoint must follow w.
+		setFileBreakpoint(p, t, fixture, 11)
+		assertNoError(proc.Continue(p), t, "Continue 1")
+		assertLineNumber(p, t, 11, "Continue 1")
+		if oos := p.Breakpoints().WatchOutOfScope; len(oos) != 0 {
+			t.Fatalf("watchpoint reported out of scope after the stack grew: %v", oos)
+		}
+		var moved *proc.Breakpoint
+		for _, bp := range p.Breakpoints().M {
+			if bp.WatchType != 0 {
+				moved = bp
+			}
+		}
+		if moved == nil || moved.Addr == addr || moved.ID != wp.ID {
+			t.Fatalf("watchpoint not moved with the stack: %v", moved)
+		}
+
+		assertNoError(proc.Continue(p), t, "Continue 2")
+		assertWatchValues(p, t, 0, 1)
+	})
+}
+
+func TestCallFunction(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("function calls are only supported on linux/amd64")
+	}
+	if ver, _ := goversion.Parse(runtime.Version()); ver.Major >= 0 && !ver.AfterOrEqual(goversion.GoVersion{1, 11, -1, 0, 0, ""}) {
+		t.Skip("function calls need runtime.debugCallV1, available since go1.11")
+	}
+
+	var testcases = []struct {
+		expr string   // call expression to evaluate
+		outs []string // list of return parameters in this format: <param name>:<param type>:<param value>
+		err  error    // if not nil should return an error
+	}{
+		{"call1(one, two)", []string{":int:3"}, nil},
+		{"call1(one+two, 4)", []string{":int:7"}, nil},
+		{"callpanic()", []string{`~panic:interface {}:interface {}(string) "callpanic panicked"`}, nil},
+		{`stringsJoin(nil, "")`, []string{`:string:""`}, nil},
+		{`stringsJoin(stringslice, comma)`, []string{`:string:"one,two,three"`}, nil},
+		{`stringsJoin(intslice, comma)`, nil, errors.New("could not pass intslice as argument v of main.stringsJoin: mismatched types []int and []string")},
+		{`a.VRcvrable(2)`, []string{`:string:"3 + 2 = 5"`}, nil},
+		{`a.PRcvrable(2)`, []string{`:string:"3 - 2 = 1"`}, nil},
+		{`pa.VRcvrable(2)`, []string{`:string:"6 + 2 = 8"`}, nil},
+		{`pa.PRcvrable(2)`, []string{`:string:"6 - 2 = 4"`}, nil},
+		{"call1(one)", nil, errors.New("not enough arguments")},
+		{"call1(one, two, two)", nil, errors.New("too many arguments")},
+		{`len(stringsJoin(stringslice, comma))`, []string{`len(stringsJoin(stringslice, comma))::13`}, nil},
+		{`len(call0(one, two))`, nil, errors.New("len(call0(one, two)): 0-valued call0(one, two) used as argument of len")},
+	}
+
+	withTestProcess("fncall", t, func(p proc.Process, fixture protest.Fixture) {
+		setFileBreakpoint(p, t, fixture, 64)
+		assertNoError(proc.Continue(p), t, "Continue()")
+		for _, tc := range testcases {
+			err := proc.CallFunction(p, tc.expr, &normalLoadConfig)
+			if tc.err != nil {
+				if err == nil {
+					t.Fatalf("call %q: expected error %q, got no error", tc.expr, tc.err.Error())
+				}
+				if err.Error() != tc.err.Error() {
+					t.Fatalf("call %q: expected error %q, got %q", tc.expr, tc.err.Error(), err.Error())
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("call %q: error %q", tc.expr, err.Error())
+			}
+
+			retvals := p.(proc.FunctionCaller).FunctionCallState().ReturnValues()
+			if len(retvals) != len(tc.outs) {
+				t.Fatalf("call %q: wrong number of return parameters", tc.expr)
+			}
+			for i := range retvals {
+				flds := strings.SplitN(tc.outs[i], ":", 3)
+				tgtName, tgtType, tgtValue := flds[0], flds[1], flds[2]
+				cv := api.ConvertVar(retvals[i])
+				if cv.Name != tgtName {
+					t.Fatalf("call %q output parameter %d: expected name %q, got %q", tc.expr, i, tgtName, cv.Name)
+				}
+				if cv.Type != tgtType {
+					t.Fatalf("call %q output parameter %d: expected type %q, got %q", tc.expr, i, tgtType, cv.Type)
+				}
+				if cvs := cv.SinglelineString(); cvs != tgtValue {
+					t.Fatalf("call %q output parameter %d: expected value %q, got %q", tc.expr, i, tgtValue, cvs)
+				}
+			}
+		}
+
+		// The call does not move the goroutine that was stopped.
+		assertLineNumber(p, t, 64, "after calls")
+	})
+}
+
+func TestDump(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" || testBackend != "native" {
+		t.Skip("core dumps are only supported on linux/amd64 with the native backend")
+	}
+	withTestProcess("testvariables2", t, func(p proc.Process, fixture protest.Fixture) {
+		assertNoError(proc.Continue(p), t, "Continue()")
+
+		fh, err := ioutil.TempFile("", "dump")
+		assertNoError(err, t, "TempFile()")
+		defer os.Remove(fh.Name())
+		assertNoError(core.Dump(p, fh, func(core.DumpProgress) bool { return true }), t, "Dump()")
+		fh.Close()
+
+		c, err := core.OpenCore(fh.Name(), fixture.Path)
+		assertNoError(err, t, "OpenCore()")
+
+		if len(c.ThreadList()) != len(p.ThreadList()) {
+			t.Fatalf("wrong number of threads in core: %d, expected %d", len(c.ThreadList()), len(p.ThreadList()))
+		}
+		th, ok := c.FindThread(p.CurrentThread().ThreadID())
+		if !ok {
+			t.Fatalf("current thread %d not found in core", p.CurrentThread().ThreadID())
+		}
+		assertNoError(c.SwitchThread(th.ThreadID()), t, "SwitchThread()")
+		if pc, cpc := currentPC(p, t), currentPC(c, t); pc != cpc {
+			t.Fatalf("wrong PC in core: %#x, expected %#x", cpc, pc)
+		}
+
+		gs, err := proc.GoroutinesInfo(p)
+		assertNoError(err, t, "GoroutinesInfo()")
+		cgs, err := proc.GoroutinesInfo(c)
+		assertNoError(err, t, "GoroutinesInfo() on core")
+		if len(gs) != len(cgs) {
+			t.Fatalf("wrong number of goroutines in core: %d, expected %d", len(cgs), len(gs))
+		}
+
+		for _, name := range []string{"i1", "f1", "s1", "m1"} {
+			v := api.ConvertVar(evalVariable(p, t, name)).SinglelineString()
+			cv := api.ConvertVar(evalVariable(c, t, name)).SinglelineString()
+			if v != cv {
+				t.Fatalf("wrong value of %s in core: %s, expected %s", name, cv, v)
+			}
+		}
+	})
+}
+
+func TestStacktraceWithDefers(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("deferstack", t, func(p proc.Process, fixture protest.Fixture) {
+		assertNoError(proc.Continue(p), t, "Continue()")
+		g, err := proc.GetG(p.CurrentThread())
+		assertNoError(err, t, "GetG()")
+		frames, err := g.StacktraceDefers(40)
+		assertNoError(err, t, "StacktraceDefers()")
+		logStacktrace(t, frames)
+
+		type deferredCall struct {
+			fn   string
+			a, b int64
+		}
+		expected := map[string][]deferredCall{
+			"main.call3": {{"main.f2", 6, 7}, {"main.f2", 4, 5}},
+			"main.call2": {{"main.f2", 2, 3}},
+			"main.call1": {{"main.f1", 0, 0}, {"main.f2", 1, -1}},
+		}
+
+		for i, frame := range frames {
+			if frame.Call.Fn == nil {
+				continue
+			}
+			tgt, ok := expected[frame.Call.Fn.Name]
+			if !ok {
+				if len(frame.Defers) != 0 {
+					t.Errorf("unexpected deferred calls in frame %d %s", i, frame.Call.Fn.Name)
+				}
+				continue
+			}
+			delete(expected, frame.Call.Fn.Name)
+			if len(frame.Defers) != len(tgt) {
+				t.Fatalf("wrong number of deferred calls in frame %d %s: %d, expected %d", i, frame.Call.Fn.Name, len(frame.Defers), len(tgt))
+			}
+			for j, d := range frame.Defers {
+				assertNoError(d.Unreadable, t, "deferred call unreadable")
+				if fn := p.BinInfo().PCToFunc(d.DeferredPC); fn == nil || fn.Name != tgt[j].fn {
+					t.Fatalf("wrong deferred function in frame %d defer %d: %v", i, j+1, fn)
+				}
+				if tgt[j].fn != "main.f2" {
+					continue
+				}
+				scope, err := proc.ConvertEvalScope(p, g.ID, i, j+1)
+				assertNoError(err, t, "ConvertEvalScope()")
+				for name, val := range map[string]int64{"a": tgt[j].a, "b": tgt[j].b} {
+					v, err := scope.EvalVariable(name, normalLoadConfig)
+					assertNoError(err, t, fmt.Sprintf("EvalVariable(%s) in frame %d defer %d", name, i, j+1))
+					if n, _ := constant.Int64Val(v.Value); n != val {
+						t.Errorf("wrong value of %s in frame %d defer %d: %d, expected %d", name, i, j+1, n, val)
+					}
+				}
+			}
+		}
+		if len(expected) != 0 {
+			t.Fatalf("frames not found: %v", expected)
+		}
+
+		if _, err := proc.ConvertEvalScope(p, g.ID, 0, 1); err == nil {
+			t.Fatalf("ConvertEvalScope() succeeded on a frame without deferred calls")
+		}
+	})
+}
+
+func TestFunctionReturnLocations(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("increment", t, func(p proc.Process, fixture protest.Fixture) {
+		addrs, err := proc.FunctionReturnLocations(p, "main.Increment")
+		assertNoError(err, t, "FunctionReturnLocations()")
+		if len(addrs) == 0 {
+			t.Fatal("no return locations for main.Increment")
+		}
+		for _, addr := range addrs {
+			if fn := p.BinInfo().PCToFunc(addr); fn == nil || fn.Name != "main.Increment" {
+				t.Fatalf("return location %#x not in main.Increment: %v", addr, fn)
+			}
+			_, err := p.SetBreakpoint(addr, proc.UserBreakpoint, nil)
+			assertNoError(err, t, "SetBreakpoint()")
+		}
+
+		// Increment(3) calls Increment(1) which calls Increment(0), they
+		// return in reverse order.
+		for _, tgt := range []uint64{1, 2, 4} {
+			assertNoError(proc.Continue(p), t, "Continue()")
+			scope, err := proc.GoroutineScope(p.CurrentThread())
+			assertNoError(err, t, "GoroutineScope()")
+			args, err := scope.FunctionArguments(normalLoadConfig)
+			assertNoError(err, t, "FunctionArguments()")
+			var ret *proc.Variable
+			for _, arg := range args {
+				if arg.Flags&proc.VariableReturnArgument != 0 {
+					ret = arg
+				}
+			}
+			if ret == nil {
+				t.Fatalf("return value not found in %v", args)
+			}
+			if n, _ := constant.Uint64Val(ret.Value); n != tgt {
+				t.Fatalf("wrong return value %v, expected %d", ret.Value, tgt)
+			}
+		}
+
+		_, err = proc.FunctionReturnLocations(p, "main.nonexistent")
+		if err == nil {
+			t.Fatal("return locations of a nonexistent function")
+		}
+	})
+}
@@ -298,6 +298,22 @@ func (xsave *LinuxX86Xstate) Decode() (regs []Register) {
 	return
 }
 
+// Encode encodes xsave as an XSAVE area in standard format, the inverse of
+// LinuxX86XstateRead.
+func (xsave *LinuxX86Xstate) Encode() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, &xsave.PtraceFpRegs)
+	var xsaveheader [_XSAVE_HEADER_LEN]byte
+	xstate_bv := uint64(1<<0 | 1<<1) // x87 and SSE state
+	if xsave.AvxState {
+		xstate_bv |= 1 << 2
+	}
+	binary.LittleEndian.PutUint64(xsaveheader[0:8], xstate_bv)
+	buf.Write(xsaveheader[:])
+	buf.Write(xsave.YmmSpace[:])
+	return buf.Bytes()
+}
+
 const (
 	_XSAVE_HEADER_START          = 512
 	_XSAVE_HEADER_LEN            = 64
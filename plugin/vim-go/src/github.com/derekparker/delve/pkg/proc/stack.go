@@ -4,6 +4,7 @@ import (
 	"debug/dwarf"
 	"errors"
 	"fmt"
+	"go/constant"
 	"strings"
 
 	"github.com/derekparker/delve/pkg/dwarf/frame"
@@ -47,6 +48,10 @@ type Stackframe struct {
 	// Inlined is true if this frame is actually an inlined call.
 	Inlined bool
 
+	// Defers is the list of functions deferred by this stack frame (so far),
+	// most recent first. Only filled by G.StacktraceDefers.
+	Defers []*Defer
+
 	// lastpc is a memory address guaranteed to belong to the last instruction
 	// executed in this stack frame.
 	// For the topmost stack frame this will be the same as Current.PC and
@@ -120,6 +125,17 @@ func (g *G) Stacktrace(depth int) ([]Stackframe, error) {
 	return it.stacktrace(depth)
 }
 
+// StacktraceDefers returns the stack trace for a goroutine, like
+// Stacktrace, with the Defers field of each frame filled in.
+func (g *G) StacktraceDefers(depth int) ([]Stackframe, error) {
+	frames, err := g.Stacktrace(depth)
+	if err != nil {
+		return nil, err
+	}
+	g.readDefers(frames)
+	return frames, nil
+}
+
 // NullAddrError is an error for a null address.
 type NullAddrError struct{}
 
@@ -559,3 +575,139 @@ func (it *stackIterator) readRegisterAt(regnum uint64, addr uint64) (*op.DwarfRe
 	}
 	return op.DwarfRegisterFromBytes(buf), nil
 }
+
+// Defer represents one deferred call.
+type Defer struct {
+	DeferredPC uint64 // Value of field _defer.fn.fn, the deferred function
+	DeferPC    uint64 // PC address of instruction that added this defer
+	SP         uint64 // Value of SP register when this function was deferred (this field gets adjusted when the stack is moved to match the new stack space)
+	link       *Defer // Next deferred function
+	argSz      int64  // Size of the arguments of the deferred call, stored after the _defer header
+
+	variable   *Variable
+	Unreadable error
+}
+
+// errSPDecreased is used when (*Defer).Next detects a corrupted linked
+// list, specifically when after following a link pointer the value of SP
+// decreases rather than increasing or staying the same (the defer list is
+// sorted by SP in increasing order).
+var errSPDecreased = errors.New("corrupted defer list: SP decreased")
+
+// readDefers decorates frames with the functions deferred at each stack
+// frame.
+func (g *G) readDefers(frames []Stackframe) {
+	curdefer := g.Defer()
+	i := 0
+
+	// scan simultaneously frames and the curdefer linked list, assigning
+	// defers to their associated frames.
+	for curdefer != nil && i < len(frames) {
+		if curdefer.Unreadable != nil {
+			// Current defer is unreadable, stick it into the first available frame
+			// (so that it can be reported to the user) and exit
+			frames[i].Defers = append(frames[i].Defers, curdefer)
+			return
+		}
+		if frames[i].Err != nil {
+			return
+		}
+
+		if frames[i].Inlined || frames[i].SystemStack || curdefer.SP >= uint64(frames[i].Regs.CFA) {
+			// frames[i].Regs.CFA is the value that SP had before the function of
+			// frames[i] was called, curdefer.SP >= frames[i].Regs.CFA means that
+			// curdefer was added by a function further down the stack.
+			//
+			// Inlined calls can not defer functions and SystemStack frames live on
+			// a different physical stack and can't be compared with deferred
+			// frames.
+			i++
+		} else {
+			frames[i].Defers = append(frames[i].Defers, curdefer)
+			curdefer = curdefer.Next()
+		}
+	}
+}
+
+func (d *Defer) load() {
+	d.variable.loadValue(LoadConfig{false, 1, 0, 0, -1})
+	if d.variable.Unreadable != nil {
+		d.Unreadable = d.variable.Unreadable
+		return
+	}
+
+	fnvar := d.variable.fieldVariable("fn").maybeDereference()
+	if fnvar.Addr != 0 {
+		fnvar.loadValue(LoadConfig{false, 1, 0, 0, -1})
+		if fnvar.Unreadable == nil {
+			d.DeferredPC, _ = constant.Uint64Val(fnvar.fieldVariable("fn").Value)
+		}
+	}
+
+	d.DeferPC, _ = constant.Uint64Val(d.variable.fieldVariable("pc").Value)
+	d.SP, _ = constant.Uint64Val(d.variable.fieldVariable("sp").Value)
+	d.argSz, _ = constant.Int64Val(d.variable.fieldVariable("siz").Value)
+
+	linkvar := d.variable.fieldVariable("link").maybeDereference()
+	if linkvar.Addr != 0 {
+		d.link = &Defer{variable: linkvar}
+	}
+}
+
+// Next returns the next defer in the linked list.
+func (d *Defer) Next() *Defer {
+	if d.link == nil {
+		return nil
+	}
+	d.link.load()
+	if d.link.SP < d.SP {
+		d.link.Unreadable = errSPDecreased
+	}
+	return d.link
+}
+
+// EvalScope returns an EvalScope relative to the argument frame of this
+// deferred call, frame must be the stack frame that deferred it.
+// The arguments of a deferred call are stored in memory immediately after
+// its _defer header.
+func (d *Defer) EvalScope(bi *BinaryInfo, mem MemoryReadWriter, g *G, frame Stackframe) (*EvalScope, error) {
+	if d.Unreadable != nil {
+		return nil, d.Unreadable
+	}
+	file, line, fn := bi.PCToLine(d.DeferredPC)
+	if fn == nil {
+		return nil, fmt.Errorf("could not find function at %#x", d.DeferredPC)
+	}
+
+	// Since CFA in go is always the address of the first argument, that's
+	// what we use for the value of CFA. For SP we use CFA minus the size of
+	// one pointer, the space occupied by the return address pushed on the
+	// stack by the CALL.
+	regs := frame.Regs
+	regs.Regs = make([]*op.DwarfRegister, len(frame.Regs.Regs))
+	copy(regs.Regs, frame.Regs.Regs)
+	regs.CFA = int64(d.variable.Addr) + d.variable.RealType.Common().ByteSize
+	regs.AddReg(regs.SPRegNum, op.DwarfRegisterFromUint64(uint64(regs.CFA-int64(bi.Arch.PtrSize()))))
+	regs.AddReg(regs.PCRegNum, op.DwarfRegisterFromUint64(d.DeferredPC))
+
+	rdr := bi.dwarf.Reader()
+	rdr.Seek(fn.offset)
+	e, err := rdr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("could not read DWARF function entry: %v", err)
+	}
+	regs.FrameBase, _, _, _ = bi.Location(e, dwarf.AttrFrameBase, d.DeferredPC, regs)
+
+	var gvar *Variable
+	if g != nil {
+		gvar = g.variable
+	}
+	return &EvalScope{
+		Location:    Location{PC: d.DeferredPC, File: file, Line: line, Fn: fn},
+		Regs:        regs,
+		Mem:         cacheMemory(mem, uintptr(regs.CFA), int(d.argSz)),
+		Gvar:        gvar,
+		BinInfo:     bi,
+		frameOffset: frame.FrameOffset(),
+	}, nil
+}
@@ -111,6 +111,17 @@ func (err *NoSourceForPCError) Error() string {
 // for an inlined function call. Everything works the same as normal except
 // when removing instructions belonging to inlined calls we also remove all
 // instructions belonging to the current inlined call.
+//
+// When the process is executing backwards (see RecordingManipulation) the
+// breakpoints are chosen so that the process stops at the start of the
+// previously executed line:
+// - the breakpoint on the return address is replaced by a breakpoint on the
+//   CALL instruction of the caller.
+// - no breakpoints are set on deferred functions, the prologue of the
+//   function or the current instruction.
+// - if stepInto is true a StepBreakpoint is set after every CALL
+//   instruction of the function, once one is reached Continue will step
+//   backwards into the RET instruction of the called function.
 func next(dbp Process, stepInto, inlinedStepOut bool) error {
 	selg := dbp.SelectedGoroutine()
 	curthread := dbp.CurrentThread()
@@ -152,6 +163,8 @@ func next(dbp Process, stepInto, inlinedStepOut bool) error {
 		return err
 	}
 
+	backward := dbp.GetDirection() == Backward
+
 	sameGCond := SameGoroutineCondition(selg)
 	retFrameCond := andFrameoffCondition(sameGCond, retframe.FrameOffset())
 	sameFrameCond := andFrameoffCondition(sameGCond, topframe.FrameOffset())
@@ -172,7 +185,11 @@ func next(dbp Process, stepInto, inlinedStepOut bool) error {
 		}
 	}
 
-	if stepInto {
+	if stepInto && backward {
+		if err := setStepIntoBreakpointsReverse(dbp, text, topframe, sameFrameCond); err != nil {
+			return err
+		}
+	} else if stepInto {
 		for _, instr := range text {
 			if instr.Loc.File != topframe.Current.File || instr.Loc.Line != topframe.Current.Line || !instr.IsCall() {
 				continue
@@ -193,7 +210,7 @@ func next(dbp Process, stepInto, inlinedStepOut bool) error {
 		}
 	}
 
-	if !csource {
+	if !csource && !backward {
 		deferreturns := []uint64{}
 
 		// Find all runtime.deferreturn locations in the function
@@ -248,6 +265,13 @@ func next(dbp Process, stepInto, inlinedStepOut bool) error {
 		}
 	}
 
+	if backward {
+		pcs, err = removePCsForReverse(dbp, pcs, topframe)
+		if err != nil {
+			return err
+		}
+	}
+
 	if !csource {
 		var covered bool
 		for i := range pcs {
@@ -274,7 +298,14 @@ func next(dbp Process, stepInto, inlinedStepOut bool) error {
 		}
 
 	}
-	if !topframe.Inlined {
+	if !topframe.Inlined && backward {
+		// Add a breakpoint on the CALL instruction of the caller, which is
+		// where we end up when running backwards past the entry point of the
+		// current function.
+		if err := setStepOutReverseBreakpoint(dbp, topframe, retframe, retFrameCond); err != nil {
+			return err
+		}
+	} else if !topframe.Inlined {
 		// Add a breakpoint on the return address for the current frame.
 		// For inlined functions there is no need to do this, the set of PCs
 		// returned by the AllPCsBetween call above already cover all instructions
@@ -301,6 +332,107 @@ func next(dbp Process, stepInto, inlinedStepOut bool) error {
 	return nil
 }
 
+// removePCsForReverse removes from pcs the current instruction and the
+// instructions belonging to the prologue of the current function. Running
+// backwards from either of them would stop the process before it reaches
+// the previously executed line.
+func removePCsForReverse(dbp Process, pcs []uint64, topframe Stackframe) ([]uint64, error) {
+	prologueEnd, err := FirstPCAfterPrologue(dbp, topframe.Current.Fn, false)
+	if err != nil {
+		return nil, err
+	}
+	r := pcs[:0]
+	for _, pc := range pcs {
+		if pc == topframe.Current.PC || (pc >= topframe.Current.Fn.Entry && pc < prologueEnd) {
+			continue
+		}
+		r = append(r, pc)
+	}
+	return r, nil
+}
+
+// setStepIntoBreakpointsReverse sets a StepBreakpoint on the instruction
+// following each CALL instruction in text, so that running backwards stops
+// right after the called function returned.
+// See the documentation of next.
+func setStepIntoBreakpointsReverse(dbp Process, text []AsmInstruction, topframe Stackframe, cond ast.Expr) error {
+	bpmap := dbp.Breakpoints()
+	for i, instr := range text {
+		if instr.Loc.File != topframe.Current.File || !canStepIntoReverse(instr) || i+1 >= len(text) {
+			continue
+		}
+		if _, exists := bpmap.M[text[i+1].Loc.PC]; exists {
+			continue
+		}
+		if _, err := dbp.SetBreakpoint(text[i+1].Loc.PC, StepBreakpoint, cond); err != nil {
+			if _, ok := err.(BreakpointExistsError); !ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// canStepIntoReverse returns true if instr is a CALL instruction to a
+// function that step can enter.
+func canStepIntoReverse(instr AsmInstruction) bool {
+	if !instr.IsCall() || instr.DestLoc == nil || instr.DestLoc.Fn == nil {
+		return false
+	}
+	// Skip unexported runtime functions, see setStepIntoBreakpoint
+	fn := instr.DestLoc.Fn
+	return !strings.HasPrefix(fn.Name, "runtime.") || isExportedRuntime(fn.Name)
+}
+
+// afterCallInstruction returns true if the current instruction of the
+// selected goroutine immediately follows a CALL instruction that step can
+// enter. Running backwards from there the next instruction executed is the
+// RET instruction of the called function, a breakpoint on the current
+// instruction would not be hit.
+func afterCallInstruction(dbp Process) (bool, error) {
+	curthread := dbp.CurrentThread()
+	topframe, _, err := topframe(dbp.SelectedGoroutine(), curthread)
+	if err != nil {
+		return false, err
+	}
+	if topframe.Current.Fn == nil {
+		return false, nil
+	}
+	text, err := disassemble(curthread, nil, dbp.Breakpoints(), dbp.BinInfo(), topframe.Current.Fn.Entry, topframe.Current.PC, false)
+	if err != nil || len(text) == 0 {
+		return false, err
+	}
+	instr := text[len(text)-1]
+	return instr.Loc.PC+uint64(len(instr.Bytes)) == topframe.Current.PC && canStepIntoReverse(instr), nil
+}
+
+// setStepOutReverseBreakpoint sets a breakpoint on the CALL instruction
+// that created topframe, the process stops there when it runs backwards
+// past the entry point of topframe.
+func setStepOutReverseBreakpoint(dbp Process, topframe, retframe Stackframe, cond ast.Expr) error {
+	if topframe.Ret == 0 || retframe.Current.Fn == nil {
+		return nil
+	}
+	text, err := disassemble(dbp.CurrentThread(), nil, dbp.Breakpoints(), dbp.BinInfo(), retframe.Current.Fn.Entry, retframe.Current.Fn.End, false)
+	if err != nil {
+		return err
+	}
+	for _, instr := range text {
+		if !instr.IsCall() || instr.Loc.PC+uint64(len(instr.Bytes)) != topframe.Ret {
+			continue
+		}
+		if _, err := dbp.SetBreakpoint(instr.Loc.PC, NextBreakpoint, cond); err != nil {
+			if _, ok := err.(BreakpointExistsError); !ok {
+				return err
+			}
+		}
+		return nil
+	}
+	// Return address could be wrong, if we are unable to find the CALL
+	// instruction it's ok.
+	return nil
+}
+
 // Removes instructions belonging to inlined calls of topframe from pcs.
 // If includeCurrentFn is true it will also remove all instructions
 // belonging to the current function.
@@ -62,6 +62,12 @@ const (
 	VariableArgument
 	// VariableReturnArgument means this variable is a function return value
 	VariableReturnArgument
+	// VariablePretty means this variable was printed by a pretty printer,
+	// see Summary
+	VariablePretty
+	// VariablePrettyChildren means that the children of this variable were
+	// replaced by a single child, the view produced by a pretty printer
+	VariablePrettyChildren
 )
 
 // Variable represents a variable. It contains the address, name,
@@ -103,6 +109,9 @@ type Variable struct {
 	Unreadable error
 
 	LocationExpr string // location expression
+
+	// Summary is set by pretty printers to describe the value.
+	Summary string
 }
 
 type LoadConfig struct {
@@ -147,6 +156,7 @@ type G struct {
 	stkbarVar  *Variable // stkbar field of g struct
 	stkbarPos  int       // stkbarPos field of g struct
 	stackhi    uint64    // value of stack.hi
+	stacklo    uint64    // value of stack.lo
 
 	SystemStack bool // SystemStack is true if this goroutine is currently executing on a system stack.
 
@@ -170,6 +180,11 @@ type EvalScope struct {
 
 	frameOffset int64
 
+	// fields is the variable whose fields are used to resolve identifiers,
+	// instead of local and package variables, when evaluating pretty
+	// printers.
+	fields *Variable
+
 	aordr *dwarf.Reader // extra reader to load DW_AT_abstract_origin entries, do not initialize
 }
 
@@ -423,13 +438,25 @@ func (gvar *Variable) parseG() (*G, error) {
 	gopc, _ := constant.Int64Val(gvar.fieldVariable("gopc").Value)
 	waitReason := ""
 	if wrvar := gvar.fieldVariable("waitreason"); wrvar.Value != nil {
-		waitReason = constant.StringVal(wrvar.Value)
+		switch wrvar.Kind {
+		case reflect.String:
+			waitReason = constant.StringVal(wrvar.Value)
+		default:
+			// Since go1.11 waitreason is an index into runtime.waitReasonStrings.
+			n, _ := constant.Int64Val(wrvar.Value)
+			if waitReasons := loadWaitReasons(gvar.bi, gvar.mem); n > 0 && n < int64(len(waitReasons)) {
+				waitReason = waitReasons[n]
+			}
+		}
 	}
-	var stackhi uint64
+	var stackhi, stacklo uint64
 	if stackVar := gvar.fieldVariable("stack"); stackVar != nil {
 		if stackhiVar := stackVar.fieldVariable("hi"); stackhiVar != nil {
 			stackhi, _ = constant.Uint64Val(stackhiVar.Value)
 		}
+		if stackloVar := stackVar.fieldVariable("lo"); stackloVar != nil {
+			stacklo, _ = constant.Uint64Val(stackloVar.Value)
+		}
 	}
 
 	stkbarVar, _ := gvar.structMember("stkbar")
@@ -454,6 +481,7 @@ func (gvar *Variable) parseG() (*G, error) {
 		stkbarVar:  stkbarVar,
 		stkbarPos:  int(stkbarPos),
 		stackhi:    stackhi,
+		stacklo:    stacklo,
 	}
 	return g, nil
 }
@@ -470,6 +498,28 @@ func (v *Variable) loadFieldNamed(name string) *Variable {
 	return v
 }
 
+// loadWaitReasons reads runtime.waitReasonStrings, the descriptions of the
+// values of the waitreason field of runtime.g.
+func loadWaitReasons(bi *BinaryInfo, mem MemoryReadWriter) []string {
+	bi.loadWaitReasonsOnce.Do(func() {
+		v, err := globalScope(bi, mem).findGlobal("runtime.waitReasonStrings")
+		if err != nil {
+			return
+		}
+		v.loadValue(LoadConfig{false, 0, 64, 128, 0})
+		if v.Unreadable != nil {
+			return
+		}
+		bi.waitReasons = make([]string, len(v.Children))
+		for i := range v.Children {
+			if v.Children[i].Value != nil && v.Children[i].Kind == reflect.String {
+				bi.waitReasons[i] = constant.StringVal(v.Children[i].Value)
+			}
+		}
+	})
+	return bi.waitReasons
+}
+
 func (v *Variable) fieldVariable(name string) *Variable {
 	for i := range v.Children {
 		if child := &v.Children[i]; child.Name == name {
@@ -481,27 +531,25 @@ func (v *Variable) fieldVariable(name string) *Variable {
 
 // PC of entry to top-most deferred function.
 func (g *G) DeferPC() uint64 {
-	if g.variable.Unreadable != nil {
-		return 0
-	}
-	d := g.variable.fieldVariable("_defer").maybeDereference()
-	if d.Addr == 0 {
+	d := g.Defer()
+	if d == nil {
 		return 0
 	}
-	d.loadValue(LoadConfig{false, 1, 64, 0, -1})
-	if d.Unreadable != nil {
-		return 0
-	}
-	fnvar := d.fieldVariable("fn").maybeDereference()
-	if fnvar.Addr == 0 {
-		return 0
+	return d.DeferredPC
+}
+
+// Defer returns the top-most defer of the goroutine.
+func (g *G) Defer() *Defer {
+	if g.variable.Unreadable != nil {
+		return nil
 	}
-	fnvar.loadValue(LoadConfig{false, 1, 64, 0, -1})
-	if fnvar.Unreadable != nil {
-		return 0
+	dvar := g.variable.fieldVariable("_defer").maybeDereference()
+	if dvar.Addr == 0 {
+		return nil
 	}
-	deferPC, _ := constant.Int64Val(fnvar.fieldVariable("fn").Value)
-	return uint64(deferPC)
+	d := &Defer{variable: dvar}
+	d.load()
+	return d
 }
 
 // From $GOROOT/src/runtime/traceback.go:597
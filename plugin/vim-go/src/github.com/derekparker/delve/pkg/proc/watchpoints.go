@@ -0,0 +1,201 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+)
+
+// WatchpointsNotSupportedErr is returned by targets that can not set
+// hardware watchpoints.
+var WatchpointsNotSupportedErr = errors.New("hardware watchpoints are not supported on this target")
+
+// Watch sets a hardware watchpoint on the memory of expr, evaluated in
+// scope, that stops execution when the memory is accessed as specified by
+// wtype.
+// If expr is a variable on the stack of a goroutine the watchpoint is
+// removed when the frame of scope returns.
+func Watch(p Process, scope *EvalScope, expr string, wtype WatchType, cond ast.Expr) (*Breakpoint, error) {
+	if wtype == 0 {
+		return nil, errors.New("watchpoint must stop on reads, writes or both")
+	}
+	v, err := scope.EvalExpression(expr, loadSingleValue)
+	if err != nil {
+		return nil, err
+	}
+	if v.Unreadable != nil {
+		return nil, fmt.Errorf("can not watch %s: %v", expr, v.Unreadable)
+	}
+	if _, isComposite := v.mem.(*compositeMemory); isComposite || v.Addr == 0 || v.Addr == fakeAddress {
+		return nil, fmt.Errorf("can not watch %s: expression is not addressable", expr)
+	}
+	size := v.RealType.Size()
+	switch size {
+	case 1, 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("can not watch %s: size of %s is %d bytes, watchpoints support 1, 2, 4 or 8 bytes", expr, v.TypeString(), size)
+	}
+	if uint64(v.Addr)%uint64(size) != 0 {
+		return nil, fmt.Errorf("can not watch %s: address %#x is not aligned to %d bytes", expr, v.Addr, size)
+	}
+
+	watchVar := newVariable(expr, v.Addr, v.DwarfType, v.bi, p.CurrentThread())
+	watchValue := make([]byte, size)
+	if _, err := watchVar.mem.ReadMemory(watchValue, watchVar.Addr); err != nil {
+		return nil, err
+	}
+
+	var watchStack *watchStackFrame
+	if scope.Gvar != nil {
+		g, err := scope.Gvar.parseG()
+		if err != nil {
+			return nil, err
+		}
+		if uint64(v.Addr) >= g.stacklo && uint64(v.Addr) < g.stackhi {
+			watchStack = &watchStackFrame{goid: g.ID, stackhi: g.stackhi, frameOffset: scope.frameOffset, fn: scope.Fn}
+		}
+	}
+
+	bp, err := p.SetWatchpoint(uint64(v.Addr), int(size), wtype, cond)
+	if err != nil {
+		return nil, err
+	}
+	bp.WatchExpr = expr
+	bp.watchVar = watchVar
+	bp.watchValue = watchValue
+	bp.watchStack = watchStack
+	return bp, nil
+}
+
+// watchValues returns the previous and current value of the memory watched
+// by bp, and remembers the current value for the next time bp is hit.
+func (bp *Breakpoint) watchValues(thread Thread) (oldv, newv *Variable) {
+	value := make([]byte, bp.WatchSize)
+	if _, err := thread.ReadMemory(value, uintptr(bp.Addr)); err != nil {
+		return nil, nil
+	}
+	addr := uintptr(bp.Addr)
+	oldv = newVariable(bp.WatchExpr, addr, bp.watchVar.DwarfType, bp.watchVar.bi, &memCache{true, addr, bp.watchValue, thread})
+	newv = newVariable(bp.WatchExpr, addr, bp.watchVar.DwarfType, bp.watchVar.bi, &memCache{true, addr, value, thread})
+	oldv.loadValue(loadFullValue)
+	newv.loadValue(loadFullValue)
+	bp.watchValue = value
+	return oldv, newv
+}
+
+// watchStackFrame is the stack frame of a watched stack variable.
+type watchStackFrame struct {
+	goid        int
+	stackhi     uint64
+	frameOffset int64
+	fn          *Function
+}
+
+// inScope returns false if the goroutine running on thread is the one that
+// owns the watched frame, and the frame has returned. moved is true if the
+// stack of that goroutine was moved since the watchpoint was set, in which
+// case the watched memory no longer holds the variable.
+// Other goroutines can only access the stack of the watched goroutine while
+// it is parked, in which case its frame is checked when execution stops,
+// see clearWatchpointsOutOfScope.
+func (w *watchStackFrame) inScope(thread Thread) (inScope, moved bool) {
+	g, err := GetG(thread)
+	if err != nil || g == nil || g.ID != w.goid {
+		return true, false
+	}
+	return w.onStack(g), g.stackhi != w.stackhi
+}
+
+// onStack returns true if the watched frame is still on the stack of g.
+// The frame is found by its offset from the top of the stack, which does not
+// change when the stack is moved to grow it.
+func (w *watchStackFrame) onStack(g *G) bool {
+	if g.ID != w.goid {
+		// The goroutine exited and its G struct was reused.
+		return false
+	}
+	it, err := g.stackIterator()
+	if err != nil {
+		return true
+	}
+	for it.Next() {
+		frame := it.Frame()
+		if frame.SystemStack {
+			continue
+		}
+		off := frame.FrameOffset()
+		if off == w.frameOffset && frame.Call.Fn == w.fn {
+			return true
+		}
+		if off > w.frameOffset {
+			break
+		}
+	}
+	return false
+}
+
+// clearWatchpointsOutOfScope removes the watchpoints on stack variables
+// whose frame has returned, and records them in WatchOutOfScope. Watchpoints
+// on variables of a stack that was moved since they were set are moved to
+// the new location of the variable.
+// Since this only happens when execution stops, accesses to a moved variable
+// are missed until the next stop.
+func clearWatchpointsOutOfScope(dbp Process) error {
+	bpmap := dbp.Breakpoints()
+	var gs []*G
+	for _, bp := range bpmap.M {
+		if bp.watchStack == nil {
+			continue
+		}
+		if gs == nil {
+			var err error
+			if gs, err = GoroutinesInfo(dbp); err != nil {
+				return err
+			}
+		}
+		var owner *G
+		for _, g := range gs {
+			if g.ID == bp.watchStack.goid {
+				owner = g
+				break
+			}
+		}
+		if owner != nil && bp.watchStack.onStack(owner) {
+			if owner.stackhi != bp.watchStack.stackhi {
+				if err := moveStackWatchpoint(dbp, bp, owner.stackhi); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if _, err := dbp.ClearBreakpoint(bp.Addr); err != nil {
+			return err
+		}
+		bpmap.WatchOutOfScope = append(bpmap.WatchOutOfScope, bp)
+	}
+	return nil
+}
+
+// moveStackWatchpoint moves bp, a watchpoint on a stack variable, to the new
+// location of the variable after the stack of its goroutine was moved to
+// stackhi. The watchpoint keeps its ID and state.
+func moveStackWatchpoint(dbp Process, bp *Breakpoint, stackhi uint64) error {
+	addr := bp.Addr - bp.watchStack.stackhi + stackhi
+	saved := *bp // ClearBreakpoint resets the kind and condition of bp
+	if _, err := dbp.ClearBreakpoint(bp.Addr); err != nil {
+		return err
+	}
+	nbp, err := dbp.SetWatchpoint(addr, bp.WatchSize, bp.WatchType, saved.Cond)
+	if err != nil {
+		return err
+	}
+	bpmap := dbp.Breakpoints()
+	bpmap.breakpointIDCounter--
+	hwidx := nbp.HWBreakIndex
+	*nbp = saved
+	nbp.Addr = addr
+	nbp.HWBreakIndex = hwidx
+	nbp.watchVar = newVariable(bp.WatchExpr, uintptr(addr), bp.watchVar.DwarfType, bp.watchVar.bi, dbp.CurrentThread())
+	nbp.watchStack = &watchStackFrame{goid: bp.watchStack.goid, stackhi: stackhi, frameOffset: bp.watchStack.frameOffset, fn: bp.watchStack.fn}
+	return nil
+}
@@ -29,8 +29,10 @@ const optimizedFunctionWarning = "Warning: debugging optimized function"
 type cmdPrefix int
 
 const (
-	noPrefix = cmdPrefix(0)
-	onPrefix = cmdPrefix(1 << iota)
+	noPrefix       = cmdPrefix(0)
+	onPrefix       = cmdPrefix(1 << iota)
+	deferredPrefix = cmdPrefix(1 << iota)
+	revPrefix      = cmdPrefix(1 << iota)
 )
 
 type callContext struct {
@@ -40,7 +42,7 @@ type callContext struct {
 }
 
 func (ctx *callContext) scoped() bool {
-	return ctx.Scope.GoroutineID >= 0 || ctx.Scope.Frame > 0
+	return ctx.Scope.GoroutineID >= 0 || ctx.Scope.Frame > 0 || ctx.Scope.DeferredCall > 0
 }
 
 type frameDirection int
@@ -80,8 +82,8 @@ type Commands struct {
 }
 
 var (
-	LongLoadConfig  = api.LoadConfig{true, 1, 64, 64, -1}
-	ShortLoadConfig = api.LoadConfig{false, 0, 64, 0, 3}
+	LongLoadConfig  = api.LoadConfig{true, 1, 64, 64, -1, false}
+	ShortLoadConfig = api.LoadConfig{false, 0, 64, 0, 3, false}
 )
 
 type ByFirstAlias []command
@@ -113,6 +115,26 @@ See also: "help on", "help cond" and "help clear"`},
 
 A tracepoint is a breakpoint that does not stop the execution of the program, instead when the tracepoint is hit a notification is displayed. See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/locspec.md for the syntax of linespec.
 
+See also: "help on", "help cond" and "help clear"`},
+		{aliases: []string{"watch"}, allowedPrefixes: onPrefix, cmdFn: watchpoint, helpMsg: `Set watchpoint.
+
+	[goroutine <n>] [frame <m>] watch [-r|-w|-rw] <expr>
+
+	-r	stops when the memory location is read
+	-w	stops when the memory location is written
+	-rw	stops when the memory location is read or written
+
+The memory location is specified with the same expression language used by 'print', for example:
+
+	watch v
+	watch -r *p
+
+will watch the address of variable 'v' and the memory pointed to by 'p'. If no flag is specified the default is -w. The watched memory must be 1, 2, 4 or 8 bytes long and aligned to its size. On x86 read watchpoints also stop when the memory location is written.
+
+A watchpoint on a local variable is removed automatically when the function that declares it returns.
+
+Watchpoints are only supported on linux/amd64.
+
 See also: "help on", "help cond" and "help clear"`},
 		{aliases: []string{"restart", "r"}, cmdFn: restart, helpMsg: `Restart process.
 
@@ -123,15 +145,36 @@ See also: "help on", "help cond" and "help clear"`},
   checkpoint.  For normal processes restarts the process, optionally changing
   the arguments.  With -noargs, the process starts with an empty commandline.
 `},
-		{aliases: []string{"continue", "c"}, cmdFn: c.cont, helpMsg: "Run until breakpoint or program termination."},
-		{aliases: []string{"step", "s"}, cmdFn: c.step, helpMsg: "Single step through program."},
-		{aliases: []string{"step-instruction", "si"}, cmdFn: c.stepInstruction, helpMsg: "Single step a single cpu instruction."},
-		{aliases: []string{"next", "n"}, cmdFn: c.next, helpMsg: "Step over to next source line."},
-		{aliases: []string{"stepout"}, cmdFn: c.stepout, helpMsg: "Step out of the current function."},
+		{aliases: []string{"continue", "c"}, allowedPrefixes: revPrefix, cmdFn: c.cont, helpMsg: "Run until breakpoint or program termination."},
+		{aliases: []string{"step", "s"}, allowedPrefixes: revPrefix, cmdFn: c.step, helpMsg: "Single step through program."},
+		{aliases: []string{"step-instruction", "si"}, allowedPrefixes: revPrefix, cmdFn: c.stepInstruction, helpMsg: "Single step a single cpu instruction."},
+		{aliases: []string{"next", "n"}, allowedPrefixes: revPrefix, cmdFn: c.next, helpMsg: "Step over to next source line."},
+		{aliases: []string{"stepout"}, allowedPrefixes: revPrefix, cmdFn: c.stepout, helpMsg: "Step out of the current function."},
+		{aliases: []string{"call"}, allowedPrefixes: onPrefix, cmdFn: c.call, helpMsg: `Resumes process, injecting a function call (EXPERIMENTAL!!!)
+
+	[goroutine <n>] call <function call expression>
+
+The function is called on the current goroutine, or on the goroutine specified by the goroutine prefix, and the values it returns are printed when it completes. The goroutine must be running on a thread, for example stopped at a breakpoint. Execution of other goroutines resumes while the function runs.
+
+Current limitations:
+- only linux/amd64 is supported, and the target must be built with go1.11 or later
+- only pointers to heap-allocated objects can be passed as arguments to functions that retain them
+- if a breakpoint is hit inside the called function execution stops there, the call completes on the next continue
+- only a single function call can be evaluated, optionally wrapped in a builtin function with one argument, for example "call len(x.Keys())"; the arguments of the function can only contain calls to builtin functions
+`},
 		{aliases: []string{"threads"}, cmdFn: threads, helpMsg: "Print out info for every traced thread."},
 		{aliases: []string{"thread", "tr"}, cmdFn: thread, helpMsg: `Switch to the specified thread.
 
 	thread <id>`},
+		{aliases: []string{"target"}, cmdFn: target, helpMsg: `Manages the processes being debugged.
+
+	target list
+	target switch <pid>
+
+When the debugger follows child processes (see --follow-exec and --follow-fork) every program executed, or process forked, by the target becomes a new target. Breakpoints are set on all the targets whose executable contains their location.
+
+	target list		Lists the targets, the current one is marked with '*'.
+	target switch <pid>	Makes the target with the given pid the current one, the other commands act on it.`},
 		{aliases: []string{"clear"}, cmdFn: clear, helpMsg: `Deletes breakpoint.
 
 	clear <breakpoint name or id>`},
@@ -142,7 +185,7 @@ See also: "help on", "help cond" and "help clear"`},
 If called with the linespec argument it will delete all the breakpoints matching the linespec. If linespec is omitted all breakpoints are deleted.`},
 		{aliases: []string{"goroutines"}, cmdFn: goroutines, helpMsg: `List program goroutines.
 
-	goroutines [-u (default: user location)|-r (runtime location)|-g (go statement location)]
+	goroutines [-u (default: user location)|-r (runtime location)|-g (go statement location)] [-with <field> <arg>]... [-without <field> <arg>]... [-group <field>]
 
 Print out info for every goroutine. The flag controls what information is shown along with each goroutine:
 
@@ -150,7 +193,23 @@ Print out info for every goroutine. The flag controls what information is shown
 	-r	displays location of topmost stackframe (including frames inside private runtime functions)
 	-g	displays location of go instruction that created the goroutine
 
-If no flag is specified the default is -u.`},
+If no flag is specified the default is -u.
+
+Goroutines can be filtered with -with, which only shows goroutines matching the filter, and -without, which hides them. The fields that can be used in filters are:
+
+	curloc <str>		location of topmost stackframe contains str, in function name or file:line
+	userloc <str>		location of topmost stackframe in user code contains str
+	goloc <str>		location of the go instruction that created the goroutine contains str
+	func <regex>		name of the function of the topmost stackframe in user code matches regex
+	waitreason <str>	reason the goroutine is waiting contains str, for example chan or select
+	status <status>		status of the goroutine is one of running, runnable, waiting, syscall
+
+-group groups goroutines by one of the fields above, or by stack, which groups goroutines with identical stacks starting from the topmost stackframe in user code. Groups are sorted by size and at most 5 goroutines are shown for each group.
+
+For example:
+
+	goroutines -with userloc main.go -without waitreason select
+	goroutines -group stack`},
 		{aliases: []string{"goroutine"}, allowedPrefixes: onPrefix, cmdFn: c.goroutine, helpMsg: `Shows or changes current goroutine
 
 	goroutine
@@ -161,11 +220,13 @@ Called without arguments it will show information about the current goroutine.
 Called with a single argument it will switch to the specified goroutine.
 Called with more arguments it will execute a command on the specified goroutine.`},
 		{aliases: []string{"breakpoints", "bp"}, cmdFn: breakpoints, helpMsg: "Print out info for active breakpoints."},
-		{aliases: []string{"print", "p"}, allowedPrefixes: onPrefix, cmdFn: printVar, helpMsg: `Evaluate an expression.
+		{aliases: []string{"print", "p"}, allowedPrefixes: onPrefix | deferredPrefix, cmdFn: printVar, helpMsg: `Evaluate an expression.
 
-	[goroutine <n>] [frame <m>] print <expression>
+	[goroutine <n>] [frame <m>] [deferred <k>] print [-raw] <expression>
 
-See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/expr.md for a description of supported expressions.`},
+See $GOPATH/src/github.com/derekparker/delve/Documentation/cli/expr.md for a description of supported expressions.
+
+If -raw is specified the pretty printers defined in the configuration file are not used.`},
 		{aliases: []string{"whatis"}, cmdFn: whatisCommand, helpMsg: `Prints type of an expression.
 
 		whatis <expression>.`},
@@ -189,23 +250,23 @@ If regex is specified only the functions matching it will be returned.`},
 	types [<regex>]
 
 If regex is specified only the types matching it will be returned.`},
-		{aliases: []string{"args"}, allowedPrefixes: onPrefix, cmdFn: args, helpMsg: `Print function arguments.
+		{aliases: []string{"args"}, allowedPrefixes: onPrefix | deferredPrefix, cmdFn: args, helpMsg: `Print function arguments.
 
-	[goroutine <n>] [frame <m>] args [-v] [<regex>]
+	[goroutine <n>] [frame <m>] [deferred <k>] args [-v] [-raw] [<regex>]
 
-If regex is specified only function arguments with a name matching it will be returned. If -v is specified more information about each function argument will be shown.`},
-		{aliases: []string{"locals"}, allowedPrefixes: onPrefix, cmdFn: locals, helpMsg: `Print local variables.
+If regex is specified only function arguments with a name matching it will be returned. If -v is specified more information about each function argument will be shown. If -raw is specified the pretty printers are not used.`},
+		{aliases: []string{"locals"}, allowedPrefixes: onPrefix | deferredPrefix, cmdFn: locals, helpMsg: `Print local variables.
 
-	[goroutine <n>] [frame <m>] locals [-v] [<regex>]
+	[goroutine <n>] [frame <m>] [deferred <k>] locals [-v] [-raw] [<regex>]
 
 The name of variables that are shadowed in the current scope will be shown in parenthesis.
 
-If regex is specified only local variables with a name matching it will be returned. If -v is specified more information about each local variable will be shown.`},
+If regex is specified only local variables with a name matching it will be returned. If -v is specified more information about each local variable will be shown. If -raw is specified the pretty printers are not used.`},
 		{aliases: []string{"vars"}, cmdFn: vars, helpMsg: `Print package variables.
 
-	vars [-v] [<regex>]
+	vars [-v] [-raw] [<regex>]
 
-If regex is specified only package variables with a name matching it will be returned. If -v is specified more information about each package variable will be shown.`},
+If regex is specified only package variables with a name matching it will be returned. If -v is specified more information about each package variable will be shown. If -raw is specified the pretty printers are not used.`},
 		{aliases: []string{"regs"}, cmdFn: regs, helpMsg: `Print contents of CPU registers.
 
 	regs [-a]
@@ -219,10 +280,11 @@ Argument -a shows more registers.`},
 Show source around current point or provided linespec.`},
 		{aliases: []string{"stack", "bt"}, allowedPrefixes: onPrefix, cmdFn: stackCommand, helpMsg: `Print stack trace.
 
-	[goroutine <n>] [frame <m>] stack [<depth>] [-full] [-g] [-s] [-offsets]
+	[goroutine <n>] [frame <m>] stack [<depth>] [-full] [-g] [-s] [-offsets] [-defer]
 
 	-full		every stackframe is decorated with the value of its local variables and arguments.
 	-offsets	prints frame offset of each frame
+	-defer		prints deferred function call stack for each frame.
 `},
 		{aliases: []string{"frame"},
 			cmdFn: func(t *Term, ctx callContext, arg string) error {
@@ -235,6 +297,11 @@ Show source around current point or provided linespec.`},
 
 The first form sets frame used by subsequent commands such as "print" or "set".
 The second form runs the command on the given frame.`},
+		{aliases: []string{"deferred"}, cmdFn: c.deferredCommand, helpMsg: `Executes command in the context of a deferred call.
+
+	deferred <n> <command>
+
+Executes the specified command (print, args, locals) in the context of the n-th deferred call in the current frame. Deferred calls are numbered starting from 1, most recent first, as listed by 'stack -defer'.`},
 		{aliases: []string{"up"},
 			cmdFn: func(t *Term, ctx callContext, arg string) error {
 				return c.frameCommand(t, ctx, arg, frameUp)
@@ -266,6 +333,37 @@ If no argument is specified the function being executed in the selected stack fr
 
 	-a <start> <end>	disassembles the specified address range
 	-l <locspec>		disassembles the specified function`},
+		{aliases: []string{"examinemem", "x"}, cmdFn: examineMemoryCmd, helpMsg: `Examine memory.
+
+	[goroutine <n>] [frame <m>] examinemem [-fmt <format>] [-count <count>] [-size <size>] <address>
+
+Prints count units of memory, of size bytes each, starting at address, in the specified format:
+
+	-fmt <format>	one of hex (default), oct, dec, bin or char
+	-count <count>	number of units to print (default 1), -len is an alias of -count
+	-size <size>	size of each unit in bytes, 1 (default), 2, 4 or 8; the char format only supports 1
+
+The address is either a number or an expression evaluating to a pointer, such as &expr, or to an integer. At most 1000 bytes can be printed at once.
+
+For example:
+
+	x -fmt hex -count 20 -size 1 0xc00008af38
+	x -count 4 -size 8 &nums[0]
+	x -fmt char -count 16 unsafe.Pointer(buf)`},
+		{aliases: []string{"dump"}, cmdFn: dump, helpMsg: `Creates a core dump from the current process state.
+
+	dump <output file>
+
+The core dump is written as an ELF core file and can be opened with 'dlv core'. Only supported on linux/amd64 with the native backend.`},
+		{aliases: []string{"display"}, cmdFn: display, helpMsg: `Print value of an expression every time the program stops.
+
+	display -a <expression>
+	display -d <number>
+	display
+
+The '-a' option adds an expression to the list of expressions printed every time the program stops, the '-d' option removes the specified expression from the list. If display is called without arguments it will print the value of all expressions in the list.
+
+Expressions are evaluated in the topmost frame of the current goroutine, with the max-string-len and max-array-values configuration parameters in effect when they were added. The list is kept across restarts and is saved to the configuration file by 'config -save'.`},
 		{aliases: []string{"on"}, cmdFn: c.onCmd, helpMsg: `Executes a command when a breakpoint is hit.
 
 	on <breakpoint name or id> <command>.
@@ -274,8 +372,37 @@ Supported commands: print, stack and goroutine)`},
 		{aliases: []string{"condition", "cond"}, cmdFn: conditionCmd, helpMsg: `Set breakpoint condition.
 
 	condition <breakpoint name or id> <boolean expression>.
+	condition -hitcount <breakpoint name or id> <operator> <argument>
+	condition -clear <breakpoint name or id>
+
+The first form specifies that the breakpoint or tracepoint should break only if the boolean expression is true.
+
+The second form specifies that the breakpoint or tracepoint should break only when its hit count, which counts the hits where the boolean condition is true, satisfies the condition. The operator can be ==, !=, >, <, >=, <= or %, where '% n' is satisfied by the multiples of n. For example:
+
+	condition -hitcount 1 > 100
+	condition -hitcount 1 % 10
 
-Specifies that the breakpoint or tracepoint should break only if the boolean expression is true.`},
+The third form removes both conditions.`},
+		{aliases: []string{"logpoint"}, cmdFn: logpointCmd, helpMsg: `Turns a breakpoint into a logpoint.
+
+	logpoint <breakpoint name or id> <format string>
+	logpoint <breakpoint name or id>
+
+Every time a logpoint is hit the format string is printed, with the expressions enclosed in curly braces replaced by their values, and execution continues. Use '{{' and '}}' to print literal braces. For example:
+
+	logpoint 1 {x.ID} got {len(buf)} bytes
+
+Called without a format string it turns the logpoint back into the breakpoint or tracepoint it was.`},
+		{aliases: []string{"save-session"}, cmdFn: saveSessionCmd, helpMsg: `Saves the current session to a file.
+
+	save-session <file>
+
+The session contains the breakpoints and tracepoints, with their conditions, the commands set with 'on' and their log messages, the path substitution rules and the display expressions. Breakpoints are saved with the location expression they were set from, so that loading the session sets them again on a rebuilt executable. Watchpoints are not saved.`},
+		{aliases: []string{"load-session"}, cmdFn: loadSessionCmd, helpMsg: `Restores a session saved by save-session.
+
+	load-session <file>
+
+Breakpoints whose location can not be found are reported and skipped. A session can also be restored when delve starts with the --init-session flag.`},
 		{aliases: []string{"config"}, cmdFn: configureCmd, helpMsg: `Changes configuration parameters.
 
 	config -list
@@ -307,6 +434,22 @@ Defines <alias> as an alias to <command> or removes an alias.`},
 			cmdFn:   rewind,
 			helpMsg: "Run backwards until breakpoint or program termination.",
 		})
+		c.cmds = append(c.cmds, command{
+			aliases: []string{"rev"},
+			cmdFn:   c.revCommand,
+			helpMsg: `Reverses the execution of the target program for the command specified.
+
+	rev <command>
+
+Currently the following commands can be reversed: next, step, step-instruction, stepout and continue (equivalent to rewind).
+
+	rev next		Steps backwards to the previous source line.
+	rev step		Steps backwards to the previous source line, entering the function calls executed on it at their last instruction.
+	rev stepout		Steps backwards to the call instruction of the current function.
+	rev step-instruction	Steps backwards a single cpu instruction.
+
+A breakpoint hit in the middle of a reverse stepping operation stops it, it can not be resumed.`,
+		})
 		c.cmds = append(c.cmds, command{
 			aliases: []string{"check", "checkpoint"},
 			cmdFn:   checkpoint,
@@ -516,53 +659,144 @@ func thread(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
-type byGoroutineID []*api.Goroutine
+func target(t *Term, ctx callContext, args string) error {
+	argv := strings.Fields(args)
+	if len(argv) == 0 {
+		return errors.New("wrong number of arguments to target")
+	}
+	switch argv[0] {
+	case "list":
+		if len(argv) != 1 {
+			return errors.New("too many arguments to target list")
+		}
+		targets, err := t.client.ListTargets()
+		if err != nil {
+			return err
+		}
+		for _, tgt := range targets {
+			prefix := "  "
+			if tgt.Current {
+				prefix = "* "
+			}
+			fmt.Printf("%sTarget %d thread %s\n", prefix, tgt.Pid, formatThread(tgt.CurrentThread))
+		}
+		return nil
+	case "switch":
+		if len(argv) != 2 {
+			return errors.New("wrong number of arguments to target switch")
+		}
+		pid, err := strconv.Atoi(argv[1])
+		if err != nil {
+			return err
+		}
+		state, err := t.client.SwitchTarget(pid)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Switched to target %d\n", pid)
+		return printcontext(t, state)
+	default:
+		return fmt.Errorf("unknown target subcommand %q", argv[0])
+	}
+}
+
+// goroutinesBatchSize is the number of goroutines, or groups of
+// goroutines, requested at a time by the goroutines command.
+const goroutinesBatchSize = 1000
 
-func (a byGoroutineID) Len() int           { return len(a) }
-func (a byGoroutineID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a byGoroutineID) Less(i, j int) bool { return a[i].ID < a[j].ID }
+// maxGroupMembers is the number of goroutines printed for each group by
+// goroutines -group.
+const maxGroupMembers = 5
+
+var goroutineFieldNames = map[string]api.GoroutineField{
+	"curloc":     api.GoroutineCurrentLoc,
+	"userloc":    api.GoroutineUserLoc,
+	"goloc":      api.GoroutineGoLoc,
+	"func":       api.GoroutineFunction,
+	"waitreason": api.GoroutineWaitReason,
+	"status":     api.GoroutineStatus,
+	"stack":      api.GoroutineStack,
+}
 
 func goroutines(t *Term, ctx callContext, argstr string) error {
-	args := strings.Split(argstr, " ")
+	args := strings.Fields(argstr)
 	var fgl = fglUserCurrent
+	var filters []api.ListGoroutinesFilter
+	var group *api.GoroutineGroupingOptions
 
-	switch len(args) {
-	case 0:
-		// nothing to do
-	case 1:
-		switch args[0] {
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
 		case "-u":
 			fgl = fglUserCurrent
 		case "-r":
 			fgl = fglRuntimeCurrent
 		case "-g":
 			fgl = fglGo
-		case "":
-			// nothing to do
+		case "-with", "-without":
+			if i+2 >= len(args) {
+				return fmt.Errorf("%s needs a field and an argument", args[i])
+			}
+			kind, ok := goroutineFieldNames[args[i+1]]
+			if !ok || kind == api.GoroutineStack {
+				return fmt.Errorf("can not filter goroutines on %q", args[i+1])
+			}
+			filters = append(filters, api.ListGoroutinesFilter{Kind: kind, Negated: args[i] == "-without", Arg: args[i+2]})
+			i += 2
+		case "-group":
+			if i+1 >= len(args) {
+				return errors.New("-group needs a field")
+			}
+			kind, ok := goroutineFieldNames[args[i+1]]
+			if !ok {
+				return fmt.Errorf("can not group goroutines by %q", args[i+1])
+			}
+			group = &api.GoroutineGroupingOptions{GroupBy: kind, MaxGroupMembers: maxGroupMembers}
+			i++
 		default:
-			return fmt.Errorf("wrong argument: '%s'", args[0])
+			return fmt.Errorf("wrong argument: '%s'", args[i])
 		}
-	default:
-		return fmt.Errorf("too many arguments")
 	}
 	state, err := t.client.GetState()
 	if err != nil {
 		return err
 	}
-	gs, err := t.client.ListGoroutines()
-	if err != nil {
-		return err
+
+	n := 0
+	for start := 0; start >= 0; {
+		gs, groups, nextg, err := t.client.ListGoroutinesWithFilter(start, goroutinesBatchSize, filters, group)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			printGoroutines(state, gs, fgl, "  ")
+			n += len(gs)
+		}
+		for i, grp := range groups {
+			fmt.Printf("Goroutine group %d: %s [%d goroutines]\n", start+i, strings.Replace(grp.Name, "\n", "\n\t", -1), grp.Total)
+			printGoroutines(state, gs[grp.Offset:grp.Offset+grp.Count], fgl, "\t")
+			if grp.Total > grp.Count {
+				fmt.Printf("\t...%d more goroutines\n", grp.Total-grp.Count)
+			}
+			n++
+		}
+		start = nextg
 	}
-	sort.Sort(byGoroutineID(gs))
-	fmt.Printf("[%d goroutines]\n", len(gs))
+	if group == nil {
+		fmt.Printf("[%d goroutines]\n", n)
+	} else {
+		fmt.Printf("[%d goroutine groups]\n", n)
+	}
+	return nil
+}
+
+func printGoroutines(state *api.DebuggerState, gs []*api.Goroutine, fgl formatGoroutineLoc, indent string) {
 	for _, g := range gs {
-		prefix := "  "
+		prefix := indent + "  "
 		if state.SelectedGoroutine != nil && g.ID == state.SelectedGoroutine.ID {
-			prefix = "* "
+			prefix = indent + "* "
 		}
 		fmt.Printf("%sGoroutine %s\n", prefix, formatGoroutine(g, fgl))
 	}
-	return nil
 }
 
 func selectedGID(state *api.DebuggerState) int {
@@ -613,6 +847,25 @@ func (c *Commands) goroutine(t *Term, ctx callContext, argstr string) error {
 	return c.CallWithContext(args[1], t, ctx)
 }
 
+func (c *Commands) deferredCommand(t *Term, ctx callContext, argstr string) error {
+	ctx.Prefix = deferredPrefix
+
+	args := strings.SplitN(argstr, " ", 2)
+	if len(args) != 2 {
+		return errors.New("not enough arguments")
+	}
+
+	var err error
+	ctx.Scope.DeferredCall, err = strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+	if ctx.Scope.DeferredCall <= 0 {
+		return errors.New("argument of deferred must be a number greater than 0 (use 'stack -defer' to see the list of deferred calls)")
+	}
+	return c.CallWithContext(args[1], t, ctx)
+}
+
 // Handle "frame", "up", "down" commands.
 func (c *Commands) frameCommand(t *Term, ctx callContext, argstr string, direction frameDirection) error {
 	frame := 1
@@ -644,7 +897,7 @@ func (c *Commands) frameCommand(t *Term, ctx callContext, argstr string, directi
 	if frame < 0 {
 		return fmt.Errorf("Invalid frame %d", frame)
 	}
-	stack, err := t.client.Stacktrace(ctx.Scope.GoroutineID, frame, nil)
+	stack, err := t.client.Stacktrace(ctx.Scope.GoroutineID, frame, false, nil)
 	if err != nil {
 		return err
 	}
@@ -720,7 +973,11 @@ func formatGoroutine(g *api.Goroutine, fgl formatGoroutineLoc) string {
 	if g.ThreadID != 0 {
 		thread = fmt.Sprintf(" (thread %d)", g.ThreadID)
 	}
-	return fmt.Sprintf("%d - %s: %s%s", g.ID, locname, formatLocation(loc), thread)
+	waitReason := ""
+	if g.WaitReason != "" {
+		waitReason = fmt.Sprintf(" [%s]", g.WaitReason)
+	}
+	return fmt.Sprintf("%d - %s: %s%s%s", g.ID, locname, formatLocation(loc), thread, waitReason)
 }
 
 func writeGoroutineLong(w io.Writer, g *api.Goroutine, prefix string) {
@@ -789,6 +1046,7 @@ func restart(t *Term, ctx callContext, args string) error {
 		}
 		printcontext(t, state)
 		printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+		t.printDisplays()
 	}
 	return nil
 }
@@ -800,6 +1058,9 @@ func printfileNoState(t *Term) {
 }
 
 func (c *Commands) cont(t *Term, ctx callContext, args string) error {
+	if ctx.Prefix == revPrefix {
+		return rewind(t, ctx, args)
+	}
 	c.frame = 0
 	stateChan := t.client.Continue()
 	var state *api.DebuggerState
@@ -811,12 +1072,14 @@ func (c *Commands) cont(t *Term, ctx callContext, args string) error {
 		printcontext(t, state)
 	}
 	printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+	t.printDisplays()
 	return nil
 }
 
 func continueUntilCompleteNext(t *Term, state *api.DebuggerState, op string) error {
 	if !state.NextInProgress {
 		printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+		t.printDisplays()
 		return nil
 	}
 	for {
@@ -832,6 +1095,7 @@ func continueUntilCompleteNext(t *Term, state *api.DebuggerState, op string) err
 		}
 		if !state.NextInProgress {
 			printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+			t.printDisplays()
 			return nil
 		}
 	}
@@ -859,7 +1123,11 @@ func (c *Commands) step(t *Term, ctx callContext, args string) error {
 		return err
 	}
 	c.frame = 0
-	state, err := exitedToError(t.client.Step())
+	stepfn := t.client.Step
+	if ctx.Prefix == revPrefix {
+		stepfn = t.client.ReverseStep
+	}
+	state, err := exitedToError(stepfn())
 	if err != nil {
 		printfileNoState(t)
 		return err
@@ -872,7 +1140,11 @@ func (c *Commands) stepInstruction(t *Term, ctx callContext, args string) error
 	if err := scopePrefixSwitch(t, ctx); err != nil {
 		return err
 	}
-	state, err := exitedToError(t.client.StepInstruction())
+	stepfn := t.client.StepInstruction
+	if ctx.Prefix == revPrefix {
+		stepfn = t.client.ReverseStepInstruction
+	}
+	state, err := exitedToError(stepfn())
 	c.frame = 0
 	if err != nil {
 		printfileNoState(t)
@@ -880,6 +1152,7 @@ func (c *Commands) stepInstruction(t *Term, ctx callContext, args string) error
 	}
 	printcontext(t, state)
 	printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+	t.printDisplays()
 	return nil
 }
 
@@ -887,7 +1160,11 @@ func (c *Commands) next(t *Term, ctx callContext, args string) error {
 	if err := scopePrefixSwitch(t, ctx); err != nil {
 		return err
 	}
-	state, err := exitedToError(t.client.Next())
+	nextfn := t.client.Next
+	if ctx.Prefix == revPrefix {
+		nextfn = t.client.ReverseNext
+	}
+	state, err := exitedToError(nextfn())
 	c.frame = 0
 	if err != nil {
 		printfileNoState(t)
@@ -901,7 +1178,11 @@ func (c *Commands) stepout(t *Term, ctx callContext, args string) error {
 	if err := scopePrefixSwitch(t, ctx); err != nil {
 		return err
 	}
-	state, err := exitedToError(t.client.StepOut())
+	stepoutfn := t.client.StepOut
+	if ctx.Prefix == revPrefix {
+		stepoutfn = t.client.ReverseStepOut
+	}
+	state, err := exitedToError(stepoutfn())
 	c.frame = 0
 	if err != nil {
 		printfileNoState(t)
@@ -911,6 +1192,23 @@ func (c *Commands) stepout(t *Term, ctx callContext, args string) error {
 	return continueUntilCompleteNext(t, state, "stepout")
 }
 
+func (c *Commands) call(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough arguments")
+	}
+	if err := scopePrefixSwitch(t, ctx); err != nil {
+		return err
+	}
+	state, err := exitedToError(t.client.Call(args))
+	c.frame = 0
+	if err != nil {
+		printfileNoState(t)
+		return err
+	}
+	printcontext(t, state)
+	return continueUntilCompleteNext(t, state, "call")
+}
+
 func clear(t *Term, ctx callContext, args string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("not enough arguments")
@@ -987,6 +1285,12 @@ func breakpoints(t *Term, ctx callContext, args string) error {
 		if bp.Cond != "" {
 			attrs = append(attrs, fmt.Sprintf("\tcond %s", bp.Cond))
 		}
+		if bp.HitCond != "" {
+			attrs = append(attrs, fmt.Sprintf("\tcond -hitcount %s", bp.HitCond))
+		}
+		if bp.LogMessage != "" {
+			attrs = append(attrs, fmt.Sprintf("\tlog %s", bp.LogMessage))
+		}
 		if bp.Stacktrace > 0 {
 			attrs = append(attrs, fmt.Sprintf("\tstack %d", bp.Stacktrace))
 		}
@@ -1050,6 +1354,7 @@ func setBreakpoint(t *Term, ctx callContext, tracepoint bool, argstr string) err
 			return err
 		}
 	}
+	requestedBp.LocExpr = locspec
 	for _, loc := range locs {
 		requestedBp.Addr = loc.PC
 
@@ -1071,15 +1376,43 @@ func tracepoint(t *Term, ctx callContext, args string) error {
 	return setBreakpoint(t, ctx, true, args)
 }
 
+func watchpoint(t *Term, ctx callContext, args string) error {
+	wtype := api.WatchWrite
+	if v := strings.SplitN(args, " ", 2); len(v) == 2 {
+		switch v[0] {
+		case "-r":
+			wtype, args = api.WatchRead, v[1]
+		case "-w":
+			wtype, args = api.WatchWrite, v[1]
+		case "-rw":
+			wtype, args = api.WatchRead|api.WatchWrite, v[1]
+		}
+	}
+	if args == "" {
+		return errors.New("not enough arguments")
+	}
+	bp, err := t.client.CreateWatchpoint(ctx.Scope, args, wtype)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), formatBreakpointLocation(bp))
+	return nil
+}
+
 func printVar(t *Term, ctx callContext, args string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("not enough arguments")
 	}
+	cfg := t.loadConfig()
+	if v := strings.SplitN(args, " ", 2); len(v) == 2 && v[0] == "-raw" {
+		cfg.Raw = true
+		args = strings.TrimSpace(v[1])
+	}
 	if ctx.Prefix == onPrefix {
 		ctx.Breakpoint.Variables = append(ctx.Breakpoint.Variables, args)
 		return nil
 	}
-	val, err := t.client.EvalVariable(ctx.Scope, args, t.loadConfig())
+	val, err := t.client.EvalVariable(ctx.Scope, args, cfg)
 	if err != nil {
 		return err
 	}
@@ -1088,6 +1421,35 @@ func printVar(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+func display(t *Term, ctx callContext, args string) error {
+	const (
+		addOption = "-a "
+		delOption = "-d "
+	)
+	switch {
+	case args == "":
+		t.printDisplays()
+	case strings.HasPrefix(args, addOption):
+		expr := strings.TrimSpace(args[len(addOption):])
+		if expr == "" {
+			return fmt.Errorf("not enough arguments")
+		}
+		if err := t.addDisplay(expr); err != nil {
+			return err
+		}
+		t.printDisplay(len(t.conf.Display) - 1)
+	case strings.HasPrefix(args, delOption):
+		n, err := strconv.Atoi(strings.TrimSpace(args[len(delOption):]))
+		if err != nil {
+			return fmt.Errorf("%q is not a number", args[len(delOption):])
+		}
+		return t.removeDisplay(n)
+	default:
+		return fmt.Errorf("wrong arguments")
+	}
+	return nil
+}
+
 func whatisCommand(t *Term, ctx callContext, args string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("not enough arguments")
@@ -1133,6 +1495,7 @@ func printFilteredVariables(varType string, vars []api.Variable, filter string,
 	if err != nil {
 		return err
 	}
+	cfg.Raw = false // only decides what the server returns
 	match := false
 	for _, v := range vars {
 		if reg == nil || reg.Match([]byte(v.Name)) {
@@ -1178,14 +1541,24 @@ func types(t *Term, ctx callContext, args string) error {
 }
 
 func parseVarArguments(args string, t *Term) (filter string, cfg api.LoadConfig) {
-	if v := strings.SplitN(args, " ", 2); len(v) >= 1 && v[0] == "-v" {
+	cfg = ShortLoadConfig
+	raw := false
+	for {
+		v := strings.SplitN(args, " ", 2)
+		switch v[0] {
+		case "-v":
+			cfg = t.loadConfig()
+		case "-raw":
+			raw = true
+		default:
+			cfg.Raw = raw
+			return args, cfg
+		}
+		args = ""
 		if len(v) == 2 {
-			return v[1], t.loadConfig()
-		} else {
-			return "", t.loadConfig()
+			args = v[1]
 		}
 	}
-	return args, ShortLoadConfig
 }
 
 func args(t *Term, ctx callContext, args string) error {
@@ -1255,7 +1628,7 @@ func stackCommand(t *Term, ctx callContext, args string) error {
 	if sa.full {
 		cfg = &ShortLoadConfig
 	}
-	stack, err := t.client.Stacktrace(ctx.Scope.GoroutineID, sa.depth, cfg)
+	stack, err := t.client.Stacktrace(ctx.Scope.GoroutineID, sa.depth, sa.readDefers, cfg)
 	if err != nil {
 		return err
 	}
@@ -1264,9 +1637,10 @@ func stackCommand(t *Term, ctx callContext, args string) error {
 }
 
 type stackArgs struct {
-	depth   int
-	full    bool
-	offsets bool
+	depth      int
+	full       bool
+	offsets    bool
+	readDefers bool
 }
 
 func parseStackArgs(argstr string) (stackArgs, error) {
@@ -1282,6 +1656,8 @@ func parseStackArgs(argstr string) (stackArgs, error) {
 				r.full = true
 			case "-offsets":
 				r.offsets = true
+			case "-defer":
+				r.readDefers = true
 			default:
 				n, err := strconv.Atoi(args[i])
 				if err != nil {
@@ -1308,7 +1684,7 @@ func listCommand(t *Term, ctx callContext, args string) error {
 		return printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
 
 	case len(args) == 0 && ctx.scoped():
-		locs, err := t.client.Stacktrace(ctx.Scope.GoroutineID, ctx.Scope.Frame, nil)
+		locs, err := t.client.Stacktrace(ctx.Scope.GoroutineID, ctx.Scope.Frame, false, nil)
 		if err != nil {
 			return err
 		}
@@ -1411,6 +1787,125 @@ func disassCommand(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+func dump(t *Term, ctx callContext, args string) error {
+	if args == "" {
+		return errors.New("not enough arguments")
+	}
+	dumpState, err := t.client.DumpStart(args)
+	if err != nil {
+		return err
+	}
+	for {
+		if dumpState.ThreadsTotal == 0 || dumpState.ThreadsDone < dumpState.ThreadsTotal {
+			fmt.Printf("\rDumping threads %d / %d...", dumpState.ThreadsDone, dumpState.ThreadsTotal)
+		} else {
+			fmt.Printf("\rDumping memory %d / %d...", dumpState.MemDone, dumpState.MemTotal)
+		}
+		if !dumpState.Dumping {
+			break
+		}
+		dumpState, err = t.client.DumpWait(1000)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("\n")
+	if dumpState.Err != "" {
+		return errors.New(dumpState.Err)
+	}
+	fmt.Printf("Core dump written to %s\n", args)
+	return nil
+}
+
+func examineMemoryCmd(t *Term, ctx callContext, args string) error {
+	format, count, size := byte('x'), 1, 1
+	rest := strings.TrimSpace(args)
+	for strings.HasPrefix(rest, "-") {
+		v := strings.SplitN(rest, " ", 3)
+		if len(v) < 2 {
+			return fmt.Errorf("missing value for %s", v[0])
+		}
+		switch v[0] {
+		case "-fmt":
+			switch v[1] {
+			case "hex", "oct", "dec", "bin", "char":
+				format = v[1][0]
+			default:
+				return fmt.Errorf("unknown format %q, expected hex, oct, dec, bin or char", v[1])
+			}
+		case "-count", "-len":
+			n, err := strconv.Atoi(v[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("wrong argument: %s is not a positive number", v[1])
+			}
+			count = n
+		case "-size":
+			n, err := strconv.Atoi(v[1])
+			if err != nil || (n != 1 && n != 2 && n != 4 && n != 8) {
+				return fmt.Errorf("wrong argument: size must be 1, 2, 4 or 8, not %s", v[1])
+			}
+			size = n
+		default:
+			return fmt.Errorf("unknown flag %s", v[0])
+		}
+		rest = ""
+		if len(v) == 3 {
+			rest = strings.TrimSpace(v[2])
+		}
+	}
+	if rest == "" {
+		return errors.New("no address specified")
+	}
+	if format == 'c' && size != 1 {
+		return errors.New("the char format only supports a size of 1")
+	}
+	if count*size > api.MaxExamineMemoryLength {
+		return fmt.Errorf("can not examine more than %d bytes of memory", api.MaxExamineMemoryLength)
+	}
+
+	address, err := examineMemoryAddress(t, ctx, rest)
+	if err != nil {
+		return err
+	}
+	mem, err := t.client.ExamineMemory(uintptr(address), count*size)
+	if err != nil {
+		return err
+	}
+	examineMemoryPrint(os.Stdout, address, mem, format, size)
+	return nil
+}
+
+// examineMemoryAddress returns the address specified by the argument of
+// examinemem, which is either a number or an expression.
+func examineMemoryAddress(t *Term, ctx callContext, expr string) (uint64, error) {
+	if address, err := strconv.ParseUint(expr, 0, 64); err == nil {
+		return address, nil
+	}
+	v, err := t.client.EvalVariable(ctx.Scope, expr, ShortLoadConfig)
+	if err != nil {
+		return 0, err
+	}
+	if v.Unreadable != "" {
+		return 0, fmt.Errorf("can not read %s: %s", expr, v.Unreadable)
+	}
+	switch v.Kind {
+	case reflect.Ptr, reflect.UnsafePointer:
+		if len(v.Children) != 1 || v.Children[0].Addr == 0 {
+			return 0, fmt.Errorf("%s is a nil pointer", expr)
+		}
+		return uint64(v.Children[0].Addr), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		address, err := strconv.ParseInt(v.Value, 0, 64)
+		if err != nil {
+			// values that do not fit an int64
+			uaddress, err := strconv.ParseUint(v.Value, 0, 64)
+			return uaddress, err
+		}
+		return uint64(address), nil
+	}
+	return 0, fmt.Errorf("can not use %s of type %s as an address", expr, v.Type)
+}
+
 func digits(n int) int {
 	if n <= 0 {
 		return 1
@@ -1448,10 +1943,33 @@ func printStack(stack []api.Stackframe, ind string, offsets bool) {
 		for j := range stack[i].Locals {
 			fmt.Printf("%s    %s = %s\n", s, stack[i].Locals[j].Name, stack[i].Locals[j].SinglelineString())
 		}
+
+		for j, d := range stack[i].Defers {
+			deferHeader := fmt.Sprintf("%s    defer %d: ", s, j+1)
+			s2 := strings.Repeat(" ", len(deferHeader))
+			if d.Unreadable != "" {
+				fmt.Printf("%s(unreadable defer: %s)\n", deferHeader, d.Unreadable)
+				continue
+			}
+			fmt.Printf("%s%#016x in %s\n", deferHeader, d.DeferredLoc.PC, formatLocationFunction(d.DeferredLoc))
+			fmt.Printf("%sat %s:%d\n", s2, ShortenFilePath(d.DeferredLoc.File), d.DeferredLoc.Line)
+			fmt.Printf("%sdeferred by %s at %s:%d\n", s2, formatLocationFunction(d.DeferLoc), ShortenFilePath(d.DeferLoc.File), d.DeferLoc.Line)
+		}
 	}
 }
 
+func formatLocationFunction(loc api.Location) string {
+	if loc.Function == nil {
+		return "(nil)"
+	}
+	return loc.Function.Name
+}
+
 func printcontext(t *Term, state *api.DebuggerState) error {
+	for _, bp := range state.WatchOutOfScope {
+		fmt.Printf("%s went out of scope and was cleared\n", formatBreakpointName(bp, true))
+	}
+
 	for i := range state.Threads {
 		if (state.CurrentThread != nil) && (state.Threads[i].ID == state.CurrentThread.ID) {
 			continue
@@ -1510,6 +2028,7 @@ func printcontextThread(t *Term, th *api.Thread) {
 
 	if th.Breakpoint == nil {
 		printcontextLocation(api.Location{PC: th.PC, File: th.File, Line: th.Line, Function: th.Function})
+		printReturnValues(th)
 		return
 	}
 
@@ -1527,6 +2046,15 @@ func printcontextThread(t *Term, th *api.Thread) {
 		bpname = fmt.Sprintf("[%s] ", th.Breakpoint.Name)
 	}
 
+	if th.Breakpoint.LogMessage != "" && th.BreakpointInfo != nil {
+		fmt.Printf("> %s%s:%d %s\n", bpname, ShortenFilePath(th.File), th.Line, th.BreakpointInfo.LogMessage)
+		return
+	}
+
+	if th.Breakpoint.Tracepoint && t.printTrace(th) {
+		return
+	}
+
 	if hitCount, ok := th.Breakpoint.HitCount[strconv.Itoa(th.GoroutineID)]; ok {
 		fmt.Printf("> %s%s(%s) %s:%d (hits goroutine(%d):%d total:%d) (PC: %#v)\n",
 			bpname,
@@ -1552,10 +2080,16 @@ func printcontextThread(t *Term, th *api.Thread) {
 		fmt.Println(optimizedFunctionWarning)
 	}
 
+	printReturnValues(th)
+
 	if th.BreakpointInfo != nil {
 		bp := th.Breakpoint
 		bpi := th.BreakpointInfo
 
+		if bpi.WatchNewValue != nil {
+			fmt.Printf("\told value: %s\n\tnew value: %s\n", bpi.WatchOldValue.SinglelineString(), bpi.WatchNewValue.SinglelineString())
+		}
+
 		if bpi.Goroutine != nil {
 			writeGoroutineLong(os.Stdout, bpi.Goroutine, "\t")
 		}
@@ -1585,6 +2119,17 @@ func printcontextThread(t *Term, th *api.Thread) {
 	}
 }
 
+func printReturnValues(th *api.Thread) {
+	if th.ReturnValues == nil {
+		return
+	}
+	fmt.Println("Values returned:")
+	for _, v := range th.ReturnValues {
+		fmt.Printf("\t%s: %s\n", v.Name, v.MultilineString("\t"))
+	}
+	fmt.Println()
+}
+
 func printfile(t *Term, filename string, line int, showArrow bool) error {
 	if filename == "" {
 		return nil
@@ -1680,6 +2225,29 @@ func conditionCmd(t *Term, ctx callContext, argstr string) error {
 		return fmt.Errorf("not enough arguments")
 	}
 
+	switch args[0] {
+	case "-hitcount":
+		args = strings.SplitN(args[1], " ", 2)
+		if len(args) < 2 {
+			return fmt.Errorf("not enough arguments")
+		}
+		bp, err := getBreakpointByIDOrName(t, args[0])
+		if err != nil {
+			return err
+		}
+		bp.HitCond = args[1]
+		return t.client.AmendBreakpoint(bp)
+
+	case "-clear":
+		bp, err := getBreakpointByIDOrName(t, args[1])
+		if err != nil {
+			return err
+		}
+		bp.Cond = ""
+		bp.HitCond = ""
+		return t.client.AmendBreakpoint(bp)
+	}
+
 	bp, err := getBreakpointByIDOrName(t, args[0])
 	if err != nil {
 		return err
@@ -1689,6 +2257,48 @@ func conditionCmd(t *Term, ctx callContext, argstr string) error {
 	return t.client.AmendBreakpoint(bp)
 }
 
+func logpointCmd(t *Term, ctx callContext, argstr string) error {
+	args := strings.SplitN(argstr, " ", 2)
+
+	if args[0] == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+
+	bp, err := getBreakpointByIDOrName(t, args[0])
+	if err != nil {
+		return err
+	}
+	setByLogpoint := t.logpointTracepoints[bp.ID]
+	if len(args) < 2 {
+		// Tracepoints stay tracepoints, only breakpoints that logpoint
+		// turned into tracepoints are turned back.
+		bp.LogMessage = ""
+		if setByLogpoint {
+			bp.Tracepoint = false
+		}
+		setByLogpoint = false
+	} else {
+		if !bp.Tracepoint {
+			setByLogpoint = true
+		}
+		bp.LogMessage = args[1]
+		bp.Tracepoint = true
+	}
+
+	if err := t.client.AmendBreakpoint(bp); err != nil {
+		return err
+	}
+	if t.logpointTracepoints == nil {
+		t.logpointTracepoints = make(map[int]bool)
+	}
+	if setByLogpoint {
+		t.logpointTracepoints[bp.ID] = true
+	} else {
+		delete(t.logpointTracepoints, bp.ID)
+	}
+	return nil
+}
+
 // ShortenFilePath take a full file path and attempts to shorten
 // it by replacing the current directory to './'.
 func ShortenFilePath(fullPath string) string {
@@ -1721,6 +2331,19 @@ func (c *Commands) executeFile(t *Term, name string) error {
 	return scanner.Err()
 }
 
+func (c *Commands) revCommand(t *Term, ctx callContext, args string) error {
+	if len(args) == 0 {
+		return errors.New("not enough arguments")
+	}
+	ctx.Prefix = revPrefix
+	err := c.CallWithContext(args, t, ctx)
+	// repeating the last command should keep going backwards
+	c.lastCmd = func(t *Term, ctx callContext, _ string) error {
+		return c.revCommand(t, ctx, args)
+	}
+	return err
+}
+
 func rewind(t *Term, ctx callContext, args string) error {
 	stateChan := t.client.Rewind()
 	var state *api.DebuggerState
@@ -1731,6 +2354,7 @@ func rewind(t *Term, ctx callContext, args string) error {
 		printcontext(t, state)
 	}
 	printfile(t, state.CurrentThread.File, state.CurrentThread.Line, true)
+	t.printDisplays()
 	return nil
 }
 
@@ -1794,6 +2418,12 @@ func formatBreakpointName(bp *api.Breakpoint, upcase bool) string {
 	if bp.Tracepoint {
 		thing = "tracepoint"
 	}
+	if bp.LogMessage != "" {
+		thing = "logpoint"
+	}
+	if bp.WatchExpr != "" {
+		thing = "watchpoint"
+	}
 	if upcase {
 		thing = strings.Title(thing)
 	}
@@ -1804,7 +2434,21 @@ func formatBreakpointName(bp *api.Breakpoint, upcase bool) string {
 	return fmt.Sprintf("%s %s", thing, id)
 }
 
+func formatWatchType(wtype api.WatchType) string {
+	switch wtype {
+	case api.WatchRead:
+		return "r"
+	case api.WatchWrite:
+		return "w"
+	default:
+		return "rw"
+	}
+}
+
 func formatBreakpointLocation(bp *api.Breakpoint) string {
+	if bp.WatchExpr != "" {
+		return fmt.Sprintf("%#x for %s (%s)", bp.Addr, bp.WatchExpr, formatWatchType(bp.WatchType))
+	}
 	p := ShortenFilePath(bp.File)
 	if bp.FunctionName != "" {
 		return fmt.Sprintf("%#v for %s() %s:%d", bp.Addr, bp.FunctionName, p, bp.Line)
@@ -1,6 +1,7 @@
 package terminal
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -218,7 +220,7 @@ func TestExecuteFile(t *testing.T) {
 
 func TestIssue354(t *testing.T) {
 	printStack([]api.Stackframe{}, "", false)
-	printStack([]api.Stackframe{{api.Location{PC: 0, File: "irrelevant.go", Line: 10, Function: nil}, nil, nil, 0, 0, ""}}, "", false)
+	printStack([]api.Stackframe{{api.Location{PC: 0, File: "irrelevant.go", Line: 10, Function: nil}, nil, nil, 0, 0, nil, ""}}, "", false)
 }
 
 func TestIssue411(t *testing.T) {
@@ -591,6 +593,53 @@ func TestReverseContinue(t *testing.T) {
 	})
 }
 
+func TestReverseNext(t *testing.T) {
+	test.AllowRecording(t)
+	if testBackend != "rr" {
+		return
+	}
+	withTestTerminal("continuetestprog", t, func(term *FakeTerminal) {
+		term.MustExec("break main.main")
+		listIsAt(t, term, "continue", 16, -1, -1)
+		listIsAt(t, term, "next", 17, -1, -1)
+		listIsAt(t, term, "next", 18, -1, -1)
+		listIsAt(t, term, "rev next", 17, -1, -1)
+		// repeating the last command keeps going backwards
+		listIsAt(t, term, "", 16, -1, -1)
+		listIsAt(t, term, "next", 17, -1, -1)
+	})
+}
+
+func TestRevPrefix(t *testing.T) {
+	type call struct {
+		prefix cmdPrefix
+		args   string
+	}
+	var calls []call
+	cmds := DebugCommands(nil)
+	cmds.cmds = append(cmds.cmds, command{aliases: []string{"probe"}, allowedPrefixes: revPrefix, cmdFn: func(t *Term, ctx callContext, args string) error {
+		calls = append(calls, call{ctx.Prefix, args})
+		return nil
+	}})
+	term := &Term{cmds: cmds}
+
+	if err := cmds.Call("rev", term); err == nil || err.Error() != "not enough arguments" {
+		t.Fatalf("rev without arguments: %v", err)
+	}
+	if err := cmds.Call("rev print x", term); err != noCmdError {
+		t.Fatalf("rev print: expected %v, got %v", noCmdError, err)
+	}
+	for _, cmdstr := range []string{"rev probe a", "", "probe b", ""} {
+		if err := cmds.Call(cmdstr, term); err != nil {
+			t.Fatalf("%q: %v", cmdstr, err)
+		}
+	}
+	want := []call{{revPrefix, "a"}, {revPrefix, "a"}, {noPrefix, "b"}, {noPrefix, ""}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("wrong calls, expected %v got %v", want, calls)
+	}
+}
+
 func TestCheckpoints(t *testing.T) {
 	test.AllowRecording(t)
 	if testBackend != "rr" {
@@ -783,3 +832,260 @@ func TestPrintContextParkedGoroutine(t *testing.T) {
 		}
 	})
 }
+
+func TestExamineMemoryPrint(t *testing.T) {
+	mem := []byte{0xef, 0xbe, 0xad, 0xde, 0x78, 0x56, 0x34, 0x12, 'a', '\n'}
+	mem2 := []byte{0xef, 0xbe, 0xad, 0xde, 0x78, 0x56, 0x34, 0x12, 0xef, 0xbe, 0xad, 0xde, 0x78, 0x56, 0x34, 0x12}
+	testcases := []struct {
+		format byte
+		size   int
+		mem    []byte
+		out    string
+	}{
+		{'x', 4, mem[:8], "0x1000: 0xdeadbeef 0x12345678\n"},
+		{'x', 2, mem[:4], "0x1000: 0xbeef 0xdead\n"},
+		{'d', 1, mem[:3], "0x1000: 239 190 173\n"},
+		{'o', 1, mem[:1], "0x1000: 0357\n"},
+		{'b', 1, mem[:2], "0x1000: 11101111 10111110\n"},
+		{'c', 1, mem[8:], "0x1000: 'a' '\\n'\n"},
+		{'x', 8, mem2, "0x1000: 0x12345678deadbeef 0x12345678deadbeef\n"},
+		{'x', 1, mem2[:10], "0x1000: 0xef 0xbe 0xad 0xde 0x78 0x56 0x34 0x12\n0x1008: 0xef 0xbe\n"},
+	}
+	for _, tc := range testcases {
+		var buf bytes.Buffer
+		examineMemoryPrint(&buf, 0x1000, tc.mem, tc.format, tc.size)
+		if buf.String() != tc.out {
+			t.Errorf("format %c size %d: expected %q got %q", tc.format, tc.size, tc.out, buf.String())
+		}
+	}
+}
+
+func TestExamineMemoryCmd(t *testing.T) {
+	withTestTerminal("examinememory", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		term.MustExec("frame 1")
+
+		out := term.MustExec("x -count 2 -size 4 &nums")
+		if !strings.Contains(out, "0xdeadbeef 0x12345678") {
+			t.Fatalf("wrong output of x on &nums: %q", out)
+		}
+		out = term.MustExec("x -fmt char -count 7 &buf[0]")
+		if !strings.Contains(out, "'e' 'x' 'a' 'm' 'i' 'n' 'e'") {
+			t.Fatalf("wrong output of x on &buf[0]: %q", out)
+		}
+		if _, err := term.Exec("x -fmt char -size 2 &buf[0]"); err == nil {
+			t.Fatalf("expected error for char format with size 2")
+		}
+		if _, err := term.Exec("x -count 1001 &buf[0]"); err == nil {
+			t.Fatalf("expected error reading more than 1000 bytes")
+		}
+	})
+}
+
+func TestGoroutinesFilterAndGroup(t *testing.T) {
+	withTestTerminal("goroutinestackprog", t, func(term *FakeTerminal) {
+		term.MustExec("break stacktraceme")
+		term.MustExec("continue")
+
+		out := term.MustExec("goroutines -with userloc main.agoroutine")
+		if n := strings.Count(out, "Goroutine "); n != 10 {
+			t.Fatalf("expected 10 goroutines in main.agoroutine, got %d:\n%s", n, out)
+		}
+		if !strings.Contains(out, "[10 goroutines]") {
+			t.Fatalf("missing goroutine count:\n%s", out)
+		}
+
+		out = term.MustExec("goroutines -without userloc main.agoroutine -with func ^main\\.")
+		if strings.Contains(out, "main.agoroutine") || !strings.Contains(out, "main.stacktraceme") {
+			t.Fatalf("wrong goroutines:\n%s", out)
+		}
+
+		out = term.MustExec("goroutines -with userloc main.agoroutine -group stack")
+		t.Logf("goroutines -group stack -> %s", out)
+		if !strings.Contains(out, "Goroutine group 0: main.agoroutine") || !strings.Contains(out, "[10 goroutines]") || !strings.Contains(out, "...5 more goroutines") {
+			t.Fatalf("wrong goroutine groups:\n%s", out)
+		}
+		if !strings.Contains(out, "[1 goroutine groups]") {
+			t.Fatalf("wrong number of groups:\n%s", out)
+		}
+	})
+}
+
+func TestDisplay(t *testing.T) {
+	withTestTerminal("testnextprog", t, func(term *FakeTerminal) {
+		term.MustExec("break testnextprog.go:24")
+		term.MustExec("continue")
+		term.AssertExec("display -a i", "0: i = 0\n")
+		term.AssertExec("display -a j", "1: j = 1\n")
+		out := term.MustExec("continue")
+		if !strings.HasSuffix(out, "0: i = 1\n1: j = 1\n") {
+			t.Fatalf("wrong output of continue: %q", out)
+		}
+		term.MustExec("display -d 0")
+		term.AssertExec("display", "0: j = 1\n")
+		term.AssertExecError("display -d 1", "1 is out of range")
+
+		// the display list is available to other clients
+		displays, err := term.client.ListDisplays(api.EvalScope{GoroutineID: -1}, api.LoadConfig{})
+		if err != nil {
+			t.Fatalf("ListDisplays: %v", err)
+		}
+		if len(displays) != 1 || displays[0].Expr != "j" || displays[0].Value == nil || displays[0].Value.Value != "1" {
+			t.Fatalf("wrong display list: %#v", displays)
+		}
+
+		// displays are kept across restarts
+		term.MustExec("restart")
+		out = term.MustExec("continue")
+		if !strings.HasSuffix(out, "0: j = 1\n") || strings.Contains(out, "i = ") {
+			t.Fatalf("wrong output of continue after restart: %q", out)
+		}
+	})
+}
+
+func TestDisplayWithoutConfig(t *testing.T) {
+	// dlv trace creates its terminal without a configuration
+	term := New(nil, nil)
+	defer term.Close()
+	if err := display(term, callContext{}, ""); err != nil {
+		t.Fatalf("display: %v", err)
+	}
+	if err := display(term, callContext{}, "-a x"); err != errNoDisplayConfig {
+		t.Fatalf("display -a: expected %v, got %v", errNoDisplayConfig, err)
+	}
+	if err := display(term, callContext{}, "-d 0"); err != errNoDisplayConfig {
+		t.Fatalf("display -d: expected %v, got %v", errNoDisplayConfig, err)
+	}
+}
+
+func TestStackDefers(t *testing.T) {
+	withTestTerminal("deferstack", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		out := term.MustExec("stack -defer")
+		for _, tgt := range []string{"defer 1: ", "defer 2: ", "in main.f1\n", "deferred by main.call3 at "} {
+			if !strings.Contains(out, tgt) {
+				t.Fatalf("could not find %q in output of stack -defer: %q", tgt, out)
+			}
+		}
+		term.MustExec("frame 2")
+		term.AssertExec("deferred 1 args", "a = 2\nb = 3\n")
+		term.AssertExec("deferred 1 print a + 1", "3\n")
+		term.AssertExecError("deferred 0 args", "argument of deferred must be a number greater than 0 (use 'stack -defer' to see the list of deferred calls)")
+		term.AssertExecError("deferred 1 stack", "command not available")
+	})
+}
+
+func TestHitCondAndLogpoint(t *testing.T) {
+	withTestTerminal("testnextprog", t, func(term *FakeTerminal) {
+		term.MustExec("break testnextprog.go:24")
+		term.MustExec("condition -hitcount 1 == 2")
+		term.MustExec("continue")
+		term.AssertExec("print i", "1\n")
+		out := term.MustExec("breakpoints")
+		if !strings.Contains(out, "\tcond -hitcount == 2\n") {
+			t.Fatalf("hit condition not listed: %q", out)
+		}
+		term.AssertExecError("condition -hitcount 1 ~ 2", "invalid hit condition \"~ 2\": unknown operator \"~\"")
+
+		term.MustExec("condition -clear 1")
+		term.MustExec("logpoint 1 i={i} {{j}}")
+		out = term.MustExec("continue")
+		if !strings.Contains(out, "testnextprog.go:24 i=2 {j}\n") {
+			t.Fatalf("log message not printed: %q", out)
+		}
+		if strings.Contains(out, "hits total") {
+			t.Fatalf("logpoint printed as a breakpoint: %q", out)
+		}
+
+		term.MustExec("logpoint 1")
+		term.MustExec("trace testnextprog.go:25")
+		term.MustExec("logpoint 2 i={i}")
+		term.MustExec("logpoint 2")
+		out = term.MustExec("breakpoints")
+		if !strings.Contains(out, "Breakpoint 1 at") || !strings.Contains(out, "Tracepoint 2 at") {
+			t.Fatalf("logpoints not turned back into what they were: %q", out)
+		}
+	})
+}
+
+func TestSaveLoadSession(t *testing.T) {
+	withTestTerminal("testnextprog", t, func(term *FakeTerminal) {
+		fh, err := ioutil.TempFile("", "session")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fh.Close()
+		defer os.Remove(fh.Name())
+
+		term.MustExec("break bp1 testnextprog.go:24")
+		term.MustExec("condition bp1 i == 1")
+		term.MustExec("on bp1 print j")
+		term.MustExec("trace main.helloworld")
+		term.MustExec("display -a j")
+		term.MustExec("config substitute-path /a /b")
+		term.MustExec("save-session " + fh.Name())
+
+		term.MustExec("clearall")
+		term.conf.Display = nil
+		term.conf.SubstitutePath = nil
+		term.MustExec("load-session " + fh.Name())
+
+		out := term.MustExec("breakpoints")
+		for _, tgt := range []string{"Breakpoint bp1 at ", "\tcond i == 1\n", "\tprint j\n", "Tracepoint 2 at ", "main.helloworld()"} {
+			if !strings.Contains(out, tgt) {
+				t.Fatalf("could not find %q in restored breakpoints: %q", tgt, out)
+			}
+		}
+		if len(term.conf.Display) != 1 || term.conf.Display[0].Expr != "j" {
+			t.Fatalf("display expressions not restored: %v", term.conf.Display)
+		}
+		if len(term.conf.SubstitutePath) != 1 || (term.conf.SubstitutePath[0] != config.SubstitutePathRule{From: "/a", To: "/b"}) {
+			t.Fatalf("substitute-path rules not restored: %v", term.conf.SubstitutePath)
+		}
+
+		// names already used in the session are not restored
+		err = ioutil.WriteFile(fh.Name(), []byte("breakpoints:\n- name: bp1\n  loc: main.testnext\n"), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = term.MustExec("load-session " + fh.Name())
+		if !strings.Contains(out, `Breakpoint name "bp1" is already in use`) || !strings.Contains(out, "Restored 1 of 1 breakpoints") {
+			t.Fatalf("wrong output of load-session: %q", out)
+		}
+
+		// locations that no longer resolve are reported
+		err = ioutil.WriteFile(fh.Name(), []byte("breakpoints:\n- loc: main.nonexistent\n- loc: main.sleepytime\n"), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = term.MustExec("load-session " + fh.Name())
+		if !strings.Contains(out, "Could not restore breakpoint at main.nonexistent: ") || !strings.Contains(out, "Restored 1 of 2 breakpoints") {
+			t.Fatalf("wrong output of load-session: %q", out)
+		}
+	})
+}
+
+func TestTraceCall(t *testing.T) {
+	term := &Term{}
+	fn := &api.Function{Name: "main.Increment"}
+	entry := &api.Thread{GoroutineID: 1, Function: fn, Breakpoint: &api.Breakpoint{Tracepoint: true}}
+	ret := &api.Thread{GoroutineID: 1, Function: fn, Breakpoint: &api.Breakpoint{Tracepoint: true, TraceReturn: true}}
+	other := &api.Thread{GoroutineID: 2, Function: fn, Breakpoint: ret.Breakpoint}
+
+	t0 := time.Unix(100, 0)
+	// recursive calls return in reverse order
+	term.traceCall(entry, t0)
+	term.traceCall(entry, t0.Add(time.Second))
+	if _, ok := term.traceCall(other, t0.Add(2*time.Second)); ok {
+		t.Fatal("return matched with a call on a different goroutine")
+	}
+	for _, tgt := range []time.Time{t0.Add(time.Second), t0} {
+		start, ok := term.traceCall(ret, t0.Add(3*time.Second))
+		if !ok || !start.Equal(tgt) {
+			t.Fatalf("wrong call time %v %v, expected %v", start, ok, tgt)
+		}
+	}
+	if _, ok := term.traceCall(ret, t0.Add(4*time.Second)); ok {
+		t.Fatal("return matched with a call that already returned")
+	}
+}
@@ -73,7 +73,19 @@ func configureList(t *Term) error {
 			continue
 		}
 
-		if field.Kind() == reflect.Ptr {
+		if display, ok := field.Interface().([]config.DisplayExpr); ok {
+			exprs := make([]string, len(display))
+			for i := range display {
+				exprs[i] = display[i].Expr
+			}
+			fmt.Fprintf(w, "%s\t%q\n", fieldName, exprs)
+		} else if printers, ok := field.Interface().([]config.PrettyPrinter); ok {
+			types := make([]string, len(printers))
+			for i := range printers {
+				types[i] = printers[i].Type
+			}
+			fmt.Fprintf(w, "%s\t%q\n", fieldName, types)
+		} else if field.Kind() == reflect.Ptr {
 			if !field.IsNil() {
 				fmt.Fprintf(w, "%s\t%v\n", fieldName, field.Elem())
 			} else {
@@ -0,0 +1,65 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// examineMemoryPrint prints mem, read starting at address, as a table of
+// units of size bytes in the format selected by examinemem: 'x' (hex), 'o'
+// (oct), 'd' (dec), 'b' (bin) or 'c' (char).
+func examineMemoryPrint(out io.Writer, address uint64, mem []byte, format byte, size int) {
+	bw := bufio.NewWriter(out)
+	defer bw.Flush()
+	tw := tabwriter.NewWriter(bw, 0, 8, 0, ' ', tabwriter.AlignRight)
+	defer tw.Flush()
+
+	perLine := 8
+	switch size {
+	case 4:
+		perLine = 4
+	case 8:
+		perLine = 2
+	}
+
+	for i := 0; i+size <= len(mem); i += size {
+		if (i/size)%perLine == 0 {
+			fmt.Fprintf(tw, "%#x:\t", address+uint64(i))
+		}
+		fmt.Fprintf(tw, " %s\t", formatMemoryUnit(mem[i:i+size], format))
+		if (i/size)%perLine == perLine-1 || i+2*size > len(mem) {
+			fmt.Fprintln(tw)
+		}
+	}
+}
+
+// formatMemoryUnit formats a little endian unit of memory.
+func formatMemoryUnit(unit []byte, format byte) string {
+	var v uint64
+	switch len(unit) {
+	case 1:
+		v = uint64(unit[0])
+	case 2:
+		v = uint64(binary.LittleEndian.Uint16(unit))
+	case 4:
+		v = uint64(binary.LittleEndian.Uint32(unit))
+	case 8:
+		v = binary.LittleEndian.Uint64(unit)
+	}
+	switch format {
+	case 'o':
+		return fmt.Sprintf("0%0*o", (len(unit)*8+2)/3, v)
+	case 'd':
+		return strconv.FormatUint(v, 10)
+	case 'b':
+		return fmt.Sprintf("%0*b", len(unit)*8, v)
+	case 'c':
+		return strconv.QuoteRuneToASCII(rune(v))
+	default:
+		return fmt.Sprintf("0x%0*x", len(unit)*2, v)
+	}
+}
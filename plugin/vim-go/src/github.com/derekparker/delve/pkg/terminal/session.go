@@ -0,0 +1,231 @@
+package terminal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/derekparker/delve/pkg/config"
+	"github.com/derekparker/delve/service/api"
+)
+
+// session is the state of a debugging session written by save-session
+// and restored by load-session.
+type session struct {
+	Breakpoints    []sessionBreakpoint        `yaml:"breakpoints"`
+	SubstitutePath config.SubstitutePathRules `yaml:"substitute-path,omitempty"`
+	Display        []config.DisplayExpr       `yaml:"display,omitempty"`
+}
+
+// sessionBreakpoint is a breakpoint saved in a session, Loc is the
+// location expression it was set from so that it can be set again on a
+// rebuilt executable.
+type sessionBreakpoint struct {
+	Name       string          `yaml:"name,omitempty"`
+	Loc        string          `yaml:"loc"`
+	Tracepoint bool            `yaml:"trace,omitempty"`
+	Cond       string          `yaml:"cond,omitempty"`
+	HitCond    string          `yaml:"hitcond,omitempty"`
+	LogMessage string          `yaml:"log,omitempty"`
+	Goroutine  bool            `yaml:"goroutine,omitempty"`
+	Stacktrace int             `yaml:"stack,omitempty"`
+	Variables  []string        `yaml:"print,omitempty"`
+	LoadArgs   *api.LoadConfig `yaml:"args,omitempty"`
+	LoadLocals *api.LoadConfig `yaml:"locals,omitempty"`
+}
+
+func saveSessionCmd(t *Term, ctx callContext, args string) error {
+	if args == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	return saveSession(t, args)
+}
+
+func loadSessionCmd(t *Term, ctx callContext, args string) error {
+	if args == "" {
+		return fmt.Errorf("not enough arguments")
+	}
+	return loadSession(t, args)
+}
+
+// saveSession writes the breakpoints, path substitution rules and display
+// expressions of the current session to path.
+func saveSession(t *Term, path string) error {
+	bps, err := t.client.ListBreakpoints()
+	if err != nil {
+		return err
+	}
+	sort.Sort(ByID(bps))
+
+	var s session
+	saved := map[string]bool{}
+	for _, bp := range bps {
+		if bp.ID < 0 {
+			continue
+		}
+		if bp.WatchExpr != "" {
+			fmt.Printf("%s not saved: watchpoints can not be saved\n", formatBreakpointName(bp, true))
+			continue
+		}
+		loc := sessionLocExpr(bp)
+		if saved[loc] {
+			// breakpoints set from the same location expression are all set again
+			// when it is loaded
+			continue
+		}
+		saved[loc] = true
+		s.Breakpoints = append(s.Breakpoints, sessionBreakpoint{
+			Name:       bp.Name,
+			Loc:        loc,
+			Tracepoint: bp.Tracepoint,
+			Cond:       bp.Cond,
+			HitCond:    bp.HitCond,
+			LogMessage: bp.LogMessage,
+			Goroutine:  bp.Goroutine,
+			Stacktrace: bp.Stacktrace,
+			Variables:  bp.Variables,
+			LoadArgs:   bp.LoadArgs,
+			LoadLocals: bp.LoadLocals,
+		})
+	}
+	s.SubstitutePath = t.conf.SubstitutePath
+	s.Display = t.conf.Display
+
+	out, err := yaml.Marshal(&s)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, out, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %d breakpoints to %s\n", len(s.Breakpoints), path)
+	return nil
+}
+
+// sessionLocExpr returns the location expression used to save bp.
+// Relative expressions, like "+1" or a bare line number, and breakpoints
+// created without one are saved as file:line.
+func sessionLocExpr(bp *api.Breakpoint) string {
+	loc := bp.LocExpr
+	if _, err := strconv.Atoi(loc); err == nil || strings.HasPrefix(loc, "+") || strings.HasPrefix(loc, "-") {
+		loc = ""
+	}
+	if loc == "" {
+		if bp.File != "" {
+			return fmt.Sprintf("%s:%d", bp.File, bp.Line)
+		}
+		return fmt.Sprintf("*%#x", bp.Addr)
+	}
+	return loc
+}
+
+// loadSession restores the session saved in path, breakpoints that can not
+// be set again are reported and skipped.
+func loadSession(t *Term, path string) error {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var s session
+	if err := yaml.Unmarshal(buf, &s); err != nil {
+		return fmt.Errorf("could not read session %s: %v", path, err)
+	}
+
+	for _, rule := range s.SubstitutePath {
+		found := false
+		for i := range t.conf.SubstitutePath {
+			if t.conf.SubstitutePath[i].From == rule.From {
+				t.conf.SubstitutePath[i].To = rule.To
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.conf.SubstitutePath = append(t.conf.SubstitutePath, rule)
+		}
+	}
+	for _, d := range s.Display {
+		found := false
+		for _, d2 := range t.conf.Display {
+			if d2.Expr == d.Expr {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.conf.Display = append(t.conf.Display, d)
+		}
+	}
+	if err := t.syncDisplays(); err != nil {
+		fmt.Printf("Could not restore display list: %v\n", err)
+	}
+
+	restored := 0
+	for _, sbp := range s.Breakpoints {
+		if err := loadSessionBreakpoint(t, sbp); err != nil {
+			fmt.Printf("Could not restore breakpoint at %s: %v\n", sbp.Loc, err)
+			continue
+		}
+		restored++
+	}
+	fmt.Printf("Restored %d of %d breakpoints from %s\n", restored, len(s.Breakpoints), path)
+	return nil
+}
+
+// loadSessionBreakpoint sets sbp again at every address its location
+// expression resolves to. Breakpoint names are unique, so only the first
+// breakpoint created gets the saved name, and none does if the name is
+// already used in the current session. An error is returned only if no
+// breakpoint could be set, addresses that fail otherwise are reported.
+func loadSessionBreakpoint(t *Term, sbp sessionBreakpoint) error {
+	locs, err := t.client.FindLocation(api.EvalScope{GoroutineID: -1}, sbp.Loc)
+	if err != nil {
+		return err
+	}
+	if len(locs) == 0 {
+		return fmt.Errorf("location not found")
+	}
+	name := sbp.Name
+	if name != "" {
+		if _, err := t.client.GetBreakpointByName(name); err == nil {
+			fmt.Printf("Breakpoint name %q is already in use, breakpoint at %s restored without a name\n", name, sbp.Loc)
+			name = ""
+		}
+	}
+	created := 0
+	var lastErr error
+	for _, loc := range locs {
+		bp, err := t.client.CreateBreakpoint(&api.Breakpoint{
+			Name:       name,
+			Addr:       loc.PC,
+			LocExpr:    sbp.Loc,
+			Tracepoint: sbp.Tracepoint,
+			Cond:       sbp.Cond,
+			HitCond:    sbp.HitCond,
+			LogMessage: sbp.LogMessage,
+			Goroutine:  sbp.Goroutine,
+			Stacktrace: sbp.Stacktrace,
+			Variables:  sbp.Variables,
+			LoadArgs:   sbp.LoadArgs,
+			LoadLocals: sbp.LoadLocals,
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		name = ""
+		created++
+		fmt.Printf("%s set at %s\n", formatBreakpointName(bp, true), formatBreakpointLocation(bp))
+	}
+	if created == 0 {
+		return lastErr
+	}
+	if created < len(locs) {
+		fmt.Printf("Breakpoint at %s partially restored, set at %d of %d addresses: %v\n", sbp.Loc, created, len(locs), lastErr)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package terminal
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	"syscall"
+	"time"
 
 	"github.com/peterh/liner"
 
@@ -33,6 +35,18 @@ type Term struct {
 	dumb     bool
 	stdout   io.Writer
 	InitFile string
+	// InitSession is a session file restored before InitFile is executed.
+	InitSession string
+	// TraceJSONL makes tracepoints print one JSON object per line, see
+	// traceRecord, instead of human readable text.
+	TraceJSONL bool
+
+	// traceCalls contains the times of the calls of traced functions that
+	// have not returned yet.
+	traceCalls map[traceCallKey][]time.Time
+	// logpointTracepoints contains the IDs of the breakpoints that were
+	// turned into tracepoints by the logpoint command.
+	logpointTracepoints map[int]bool
 }
 
 // New returns a new Term.
@@ -112,6 +126,12 @@ func (t *Term) Run() (int, error) {
 	f.Close()
 	fmt.Println("Type 'help' for list of commands.")
 
+	if t.InitSession != "" {
+		if err := loadSession(t, t.InitSession); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading session: %s\n", err)
+		}
+	}
+
 	if t.InitFile != "" {
 		err := t.cmds.executeFile(t, t.InitFile)
 		if err != nil {
@@ -245,7 +265,7 @@ func (t *Term) handleExit() (int, error) {
 // loadConfig returns an api.LoadConfig with the parameterss specified in
 // the configuration file.
 func (t *Term) loadConfig() api.LoadConfig {
-	r := api.LoadConfig{true, 1, 64, 64, -1}
+	r := api.LoadConfig{true, 1, 64, 64, -1, false}
 
 	if t.conf.MaxStringLen != nil {
 		r.MaxStringLen = *t.conf.MaxStringLen
@@ -256,3 +276,69 @@ func (t *Term) loadConfig() api.LoadConfig {
 
 	return r
 }
+
+var errNoDisplayConfig = errors.New("the display list needs a configuration")
+
+// addDisplay adds expr to the list of expressions printed by printDisplays,
+// saving the current load configuration with it.
+func (t *Term) addDisplay(expr string) error {
+	if t.conf == nil {
+		return errNoDisplayConfig
+	}
+	cfg := t.loadConfig()
+	t.conf.Display = append(t.conf.Display, config.DisplayExpr{
+		Expr:           expr,
+		MaxStringLen:   &cfg.MaxStringLen,
+		MaxArrayValues: &cfg.MaxArrayValues,
+	})
+	return t.syncDisplays()
+}
+
+func (t *Term) removeDisplay(n int) error {
+	if t.conf == nil {
+		return errNoDisplayConfig
+	}
+	if n < 0 || n >= len(t.conf.Display) {
+		return fmt.Errorf("%d is out of range", n)
+	}
+	t.conf.Display = append(t.conf.Display[:n], t.conf.Display[n+1:]...)
+	return t.syncDisplays()
+}
+
+// syncDisplays sends the display list to the server, so that other
+// clients can show it.
+func (t *Term) syncDisplays() error {
+	displays := make([]api.DisplayExpr, 0, len(t.conf.Display))
+	for _, d := range t.conf.Display {
+		displays = append(displays, api.DisplayExpr{Expr: d.Expr, MaxStringLen: d.MaxStringLen, MaxArrayValues: d.MaxArrayValues})
+	}
+	return t.client.SetDisplays(displays)
+}
+
+// printDisplays prints the value of all expressions added with the display
+// command.
+func (t *Term) printDisplays() {
+	if t.conf == nil {
+		return
+	}
+	for i := range t.conf.Display {
+		t.printDisplay(i)
+	}
+}
+
+func (t *Term) printDisplay(i int) {
+	d := t.conf.Display[i]
+	cfg := t.loadConfig()
+	if d.MaxStringLen != nil {
+		cfg.MaxStringLen = *d.MaxStringLen
+	}
+	if d.MaxArrayValues != nil {
+		cfg.MaxArrayValues = *d.MaxArrayValues
+	}
+	val, err := t.client.EvalVariable(api.EvalScope{GoroutineID: -1}, d.Expr, cfg)
+	if err != nil {
+		fmt.Printf("%d: %s = error %v\n", i, d.Expr, err)
+		return
+	}
+	fmt.Printf("%d: %s = %s\n", i, d.Expr, val.SinglelineString())
+}
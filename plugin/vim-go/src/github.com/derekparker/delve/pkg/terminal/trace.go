@@ -0,0 +1,189 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/derekparker/delve/service/api"
+)
+
+// traceCallKey identifies the calls of a traced function on a goroutine.
+type traceCallKey struct {
+	goroutineID int
+	fn          string
+}
+
+// traceRecord is an event printed by a tracepoint when the output of
+// tracepoints is JSON lines, see Term.TraceJSONL.
+type traceRecord struct {
+	// Event is "call" for tracepoints on the entry of a function and
+	// "return" for tracepoints on its return instructions.
+	Event       string            `json:"event"`
+	Time        time.Time         `json:"time"`
+	GoroutineID int               `json:"goroutine"`
+	Function    string            `json:"function"`
+	File        string            `json:"file"`
+	Line        int               `json:"line"`
+	Breakpoint  int               `json:"breakpoint"`
+	Args        []traceValue      `json:"args,omitempty"`
+	Results     []traceValue      `json:"results,omitempty"`
+	Stack       []traceStackframe `json:"stack,omitempty"`
+	// CallTime and Duration are set on return events whose call was traced.
+	CallTime *time.Time `json:"callTime,omitempty"`
+	Duration int64      `json:"durationNs,omitempty"`
+}
+
+type traceValue struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type traceStackframe struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// traceCall records that the tracepoint hit by th at time now was reached
+// by goroutine th.GoroutineID. Calls are matched with the tracepoints on
+// the return instructions of the same function, for those traceCall
+// returns the time of the call that returned.
+func (t *Term) traceCall(th *api.Thread, now time.Time) (time.Time, bool) {
+	if th.Function == nil {
+		return time.Time{}, false
+	}
+	if t.traceCalls == nil {
+		t.traceCalls = make(map[traceCallKey][]time.Time)
+	}
+	key := traceCallKey{th.GoroutineID, th.Function.Name}
+	calls := t.traceCalls[key]
+	if !th.Breakpoint.TraceReturn {
+		t.traceCalls[key] = append(calls, now)
+		return time.Time{}, false
+	}
+	if len(calls) == 0 {
+		return time.Time{}, false
+	}
+	start := calls[len(calls)-1]
+	if len(calls) == 1 {
+		delete(t.traceCalls, key)
+	} else {
+		t.traceCalls[key] = calls[:len(calls)-1]
+	}
+	return start, true
+}
+
+// traceArgs splits the arguments loaded by the tracepoint hit by th in
+// function arguments and return values.
+func traceArgs(th *api.Thread) (args, results []api.Variable) {
+	if th.BreakpointInfo == nil {
+		return nil, nil
+	}
+	for _, v := range th.BreakpointInfo.Arguments {
+		if v.Flags&api.VariableReturnArgument != 0 {
+			results = append(results, v)
+		} else {
+			args = append(args, v)
+		}
+	}
+	return args, results
+}
+
+// printTrace records the tracepoint hit by th and prints it if it is on
+// the return instructions of a function, or if the output of tracepoints
+// is JSON lines. It returns false if th should be printed like any other
+// breakpoint.
+func (t *Term) printTrace(th *api.Thread) bool {
+	now := time.Now()
+	start, returned := t.traceCall(th, now)
+	args, results := traceArgs(th)
+
+	if t.TraceJSONL {
+		t.printTraceRecord(th, now, start, returned, args, results)
+		return true
+	}
+	if !th.Breakpoint.TraceReturn {
+		return false
+	}
+
+	bpname := ""
+	if th.Breakpoint.Name != "" {
+		bpname = fmt.Sprintf("[%s] ", th.Breakpoint.Name)
+	}
+	fnname := "?"
+	if th.Function != nil {
+		fnname = th.Function.Name
+	}
+	duration := ""
+	if returned {
+		duration = fmt.Sprintf(" after %v", now.Sub(start))
+	}
+	fmt.Printf("> %s%s => (%s) %s:%d (goroutine(%d) returned%s)\n", bpname, fnname, formatTraceValues(results), ShortenFilePath(th.File), th.Line, th.GoroutineID, duration)
+	if th.BreakpointInfo != nil && th.BreakpointInfo.Stacktrace != nil {
+		fmt.Printf("\tStack:\n")
+		printStack(th.BreakpointInfo.Stacktrace, "\t\t", false)
+	}
+	return true
+}
+
+func formatTraceValues(vars []api.Variable) string {
+	s := make([]string, len(vars))
+	for i := range vars {
+		s[i] = vars[i].SinglelineString()
+	}
+	return strings.Join(s, ", ")
+}
+
+func (t *Term) printTraceRecord(th *api.Thread, now, start time.Time, returned bool, args, results []api.Variable) {
+	r := traceRecord{
+		Event:       "call",
+		Time:        now,
+		GoroutineID: th.GoroutineID,
+		File:        th.File,
+		Line:        th.Line,
+		Breakpoint:  th.Breakpoint.ID,
+	}
+	if th.Function != nil {
+		r.Function = th.Function.Name
+	}
+	if th.Breakpoint.TraceReturn {
+		r.Event = "return"
+		r.Results = traceValues(results)
+		if returned {
+			r.CallTime = &start
+			r.Duration = int64(now.Sub(start))
+		}
+	} else {
+		r.Args = traceValues(args)
+	}
+	if th.BreakpointInfo != nil {
+		for _, frame := range th.BreakpointInfo.Stacktrace {
+			sf := traceStackframe{File: frame.File, Line: frame.Line}
+			if frame.Function != nil {
+				sf.Function = frame.Function.Name
+			}
+			r.Stack = append(r.Stack, sf)
+		}
+	}
+	buf, err := json.Marshal(&r)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not encode trace record: %v\n", err)
+		return
+	}
+	fmt.Printf("%s\n", buf)
+}
+
+func traceValues(vars []api.Variable) []traceValue {
+	if len(vars) == 0 {
+		return nil
+	}
+	r := make([]traceValue, len(vars))
+	for i := range vars {
+		r[i] = traceValue{Name: vars[i].Name, Type: vars[i].Type, Value: vars[i].SinglelineString()}
+	}
+	return r
+}
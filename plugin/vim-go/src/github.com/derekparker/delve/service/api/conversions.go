@@ -19,16 +19,25 @@ func ConvertBreakpoint(bp *proc.Breakpoint) *Breakpoint {
 		Name:          bp.Name,
 		ID:            bp.ID,
 		FunctionName:  bp.FunctionName,
+		LocExpr:       bp.LocExpr,
 		File:          bp.File,
 		Line:          bp.Line,
 		Addr:          bp.Addr,
 		Tracepoint:    bp.Tracepoint,
+		TraceReturn:   bp.TraceReturn,
 		Stacktrace:    bp.Stacktrace,
 		Goroutine:     bp.Goroutine,
 		Variables:     bp.Variables,
 		LoadArgs:      LoadConfigFromProc(bp.LoadArgs),
 		LoadLocals:    LoadConfigFromProc(bp.LoadLocals),
 		TotalHitCount: bp.TotalHitCount,
+		WatchExpr:     bp.WatchExpr,
+		WatchType:     WatchType(bp.WatchType),
+		LogMessage:    bp.LogMessage,
+	}
+
+	if bp.HitCond != nil {
+		b.HitCond = bp.HitCond.String()
 	}
 
 	b.HitCount = map[string]uint64{}
@@ -123,6 +132,7 @@ func ConvertVar(v *proc.Variable) *Variable {
 		Base:     v.Base,
 
 		LocationExpr: v.LocationExpr,
+		Summary:      v.Summary,
 	}
 
 	r.Type = prettyTypeName(v.DwarfType)
@@ -235,6 +245,8 @@ func ConvertGoroutine(g *proc.G) *Goroutine {
 		UserCurrentLoc: ConvertLocation(g.UserCurrent()),
 		GoStatementLoc: ConvertLocation(g.Go()),
 		ThreadID:       tid,
+		Status:         g.Status,
+		WaitReason:     g.WaitReason,
 	}
 }
 
@@ -287,6 +299,7 @@ func LoadConfigFromProc(cfg *proc.LoadConfig) *LoadConfig {
 		cfg.MaxStringLen,
 		cfg.MaxArrayValues,
 		cfg.MaxStructFields,
+		false,
 	}
 }
 
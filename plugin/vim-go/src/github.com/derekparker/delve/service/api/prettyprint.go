@@ -43,6 +43,11 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 		return
 	}
 
+	if v.Flags&VariablePretty != 0 {
+		v.writePrettyTo(buf, newlines, includeType, indent)
+		return
+	}
+
 	switch v.Kind {
 	case reflect.Slice:
 		v.writeSliceTo(buf, newlines, includeType, indent)
@@ -130,6 +135,34 @@ func (v *Variable) writeTo(buf io.Writer, top, newlines, includeType bool, inden
 	}
 }
 
+// writePrettyTo writes the view of v produced by a pretty printer: its
+// summary followed by its synthetic children, if any.
+func (v *Variable) writePrettyTo(buf io.Writer, newlines, includeType bool, indent string) {
+	if includeType {
+		fmt.Fprintf(buf, "%s ", v.Type)
+	}
+	if v.Flags&VariablePrettyChildren == 0 || len(v.Children) != 1 {
+		fmt.Fprint(buf, v.Summary)
+		return
+	}
+	if v.Summary != "" {
+		fmt.Fprintf(buf, "%s ", v.Summary)
+	}
+	view := &v.Children[0]
+	switch view.Kind {
+	case reflect.Slice, reflect.Array:
+		view.writeSliceOrArrayTo(buf, newlines, indent)
+	case reflect.Map:
+		view.writeMapTo(buf, newlines, false, indent)
+	case reflect.Struct:
+		view.writeStructTo(buf, newlines, false, indent)
+	case reflect.String:
+		view.writeStringTo(buf)
+	default:
+		view.writeTo(buf, true, newlines, false, indent)
+	}
+}
+
 func (v *Variable) writeStringTo(buf io.Writer) {
 	s := v.Value
 	if len(s) != int(v.Len) {
@@ -31,6 +31,9 @@ type DebuggerState struct {
 	ExitStatus int  `json:"exitStatus"`
 	// When contains a description of the current position in a recording
 	When string
+	// WatchOutOfScope lists the watchpoints that were removed because the
+	// frame of the watched stack variable returned.
+	WatchOutOfScope []*Breakpoint `json:"watchOutOfScope,omitempty"`
 	// Filled by RPCClient.Continue, indicates an error
 	Err error `json:"-"`
 }
@@ -51,12 +54,28 @@ type Breakpoint struct {
 	// FunctionName is the name of the function at the current breakpoint, and
 	// may not always be available.
 	FunctionName string `json:"functionName,omitempty"`
+	// LocExpr is the location expression the breakpoint was created from,
+	// as written by the user, it is saved in sessions so that the
+	// breakpoint can be set again on a rebuilt executable.
+	LocExpr string `json:"locExpr,omitempty"`
 
 	// Breakpoint condition
 	Cond string
+	// HitCond is a condition on TotalHitCount, an operator (==, !=, >, <,
+	// >=, <= or %) followed by a number, "% n" is satisfied by the multiples
+	// of n.
+	HitCond string `json:"hitCond,omitempty"`
+	// LogMessage, if not empty, is printed every time the breakpoint is hit
+	// after replacing the expressions enclosed in curly braces with their
+	// values, see BreakpointInfo.LogMessage.
+	LogMessage string `json:"logMessage,omitempty"`
 
 	// tracepoint flag
 	Tracepoint bool `json:"continue"`
+	// TraceReturn is set on the tracepoints placed on the return
+	// instructions of a function, their arguments are the values returned
+	// by the function.
+	TraceReturn bool `json:"traceReturn,omitempty"`
 	// retrieve goroutine information
 	Goroutine bool `json:"goroutine"`
 	// number of stack frames to retrieve
@@ -71,8 +90,23 @@ type Breakpoint struct {
 	HitCount map[string]uint64 `json:"hitCount"`
 	// number of times a breakpoint has been reached
 	TotalHitCount uint64 `json:"totalHitCount"`
+
+	// WatchExpr is the expression watched by a watchpoint, empty for
+	// breakpoints.
+	WatchExpr string `json:"watchExpr,omitempty"`
+	// WatchType specifies whether the watchpoint stops on reads, writes or
+	// both.
+	WatchType WatchType `json:"watchType,omitempty"`
 }
 
+// WatchType is the kind of memory access that triggers a watchpoint.
+type WatchType uint8
+
+const (
+	WatchRead WatchType = 1 << iota
+	WatchWrite
+)
+
 func ValidBreakpointName(name string) error {
 	if _, err := strconv.Atoi(name); err == nil {
 		return errors.New("breakpoint name can not be a number")
@@ -107,6 +141,40 @@ type Thread struct {
 	Breakpoint *Breakpoint `json:"breakPoint,omitempty"`
 	// Informations requested by the current breakpoint
 	BreakpointInfo *BreakpointInfo `json:"breakPointInfo,omitempty"`
+
+	// ReturnValues contains the return values of the function injected by
+	// the last Call command, or the value it panicked with.
+	ReturnValues []Variable `json:"returnValues,omitempty"`
+}
+
+// Target is a process being debugged, when following child processes
+// there can be more than one.
+type Target struct {
+	// Pid is the process ID of the target.
+	Pid int `json:"pid"`
+	// Current is true for the target that the other commands act on.
+	Current bool `json:"current"`
+	// CurrentThread is the current thread of the target.
+	CurrentThread *Thread `json:"currentThread,omitempty"`
+}
+
+// DisplayExpr is an expression evaluated every time the target stops, see
+// the display command of the terminal.
+type DisplayExpr struct {
+	Expr string `json:"expr"`
+	// MaxStringLen and MaxArrayValues, if not nil, replace the corresponding
+	// fields of the load configuration used to evaluate Expr.
+	MaxStringLen   *int `json:"maxStringLen,omitempty"`
+	MaxArrayValues *int `json:"maxArrayValues,omitempty"`
+}
+
+// Display is the value of a DisplayExpr.
+type Display struct {
+	DisplayExpr
+	// Value is the value of Expr, nil if it could not be evaluated.
+	Value *Variable `json:"value,omitempty"`
+	// Err is the error evaluating Expr.
+	Err string `json:"err,omitempty"`
 }
 
 type Location struct {
@@ -124,9 +192,21 @@ type Stackframe struct {
 	FrameOffset        int64
 	FramePointerOffset int64
 
+	// Defers are the deferred calls registered by this frame, most recent
+	// first. Only filled in when requested.
+	Defers []Defer
+
 	Err string
 }
 
+// Defer is a deferred call.
+type Defer struct {
+	DeferredLoc Location // deferred function
+	DeferLoc    Location // location of the defer statement
+	SP          uint64   // value of SP when the function was deferred
+	Unreadable  string
+}
+
 func (frame *Stackframe) Var(name string) *Variable {
 	for i := range frame.Locals {
 		if frame.Locals[i].Name == name {
@@ -176,6 +256,14 @@ const (
 
 	// VariableReturnArgument means this variable is a function return value
 	VariableReturnArgument
+
+	// VariablePretty means this variable was printed by a pretty printer,
+	// see Summary
+	VariablePretty = VariableFlags(proc.VariablePretty)
+
+	// VariablePrettyChildren means that the children of this variable were
+	// replaced by a single child, the view produced by a pretty printer
+	VariablePrettyChildren = VariableFlags(proc.VariablePrettyChildren)
 )
 
 // Variable describes a variable.
@@ -222,6 +310,9 @@ type Variable struct {
 
 	// LocationExpr describes the location expression of this variable's address
 	LocationExpr string
+
+	// Summary is the description of the value produced by a pretty printer
+	Summary string `json:"summary,omitempty"`
 }
 
 // LoadConfig describes how to load values from target's memory
@@ -236,6 +327,8 @@ type LoadConfig struct {
 	MaxArrayValues int
 	// MaxStructFields is the maximum number of fields read from a struct, -1 will read all fields.
 	MaxStructFields int
+	// Raw disables the pretty printers.
+	Raw bool
 }
 
 // Goroutine represents the information relevant to Delve from the runtime's
@@ -251,6 +344,71 @@ type Goroutine struct {
 	GoStatementLoc Location `json:"goStatementLoc"`
 	// ID of the associated thread for running goroutines
 	ThreadID int `json:"threadID"`
+	// Status of the goroutine, see GoroutineStatusName
+	Status uint64 `json:"status"`
+	// Reason the goroutine is waiting, if it is
+	WaitReason string `json:"waitReason,omitempty"`
+}
+
+var goroutineStatusNames = []string{"idle", "runnable", "running", "syscall", "waiting", "moribund", "dead", "enqueue", "copystack"}
+
+// GoroutineStatusName returns the name of a goroutine status, as used by
+// the GoroutineStatus filter of ListGoroutines.
+func GoroutineStatusName(status uint64) string {
+	if status < uint64(len(goroutineStatusNames)) {
+		return goroutineStatusNames[status]
+	}
+	return fmt.Sprintf("unknown(%d)", status)
+}
+
+// GoroutineField is a property of goroutines used to filter and group the
+// goroutines returned by ListGoroutines.
+type GoroutineField uint8
+
+const (
+	GoroutineFieldNone  GoroutineField = iota
+	GoroutineCurrentLoc                // location of the topmost stack frame
+	GoroutineUserLoc                   // location of the topmost stack frame in user code
+	GoroutineGoLoc                     // location of the go statement that created the goroutine
+	GoroutineFunction                  // function of the topmost stack frame in user code
+	GoroutineWaitReason                // reason the goroutine is waiting
+	GoroutineStatus                    // status of the goroutine, see GoroutineStatusName
+	GoroutineStack                     // stack frames starting from the topmost one in user code, only for grouping
+)
+
+// ListGoroutinesFilter selects the goroutines returned by ListGoroutines.
+// Filters on locations match goroutines where Arg is a substring of the
+// function name or of file:line, GoroutineFunction filters match the
+// function name against the regular expression Arg, GoroutineWaitReason
+// filters match substrings of the wait reason and GoroutineStatus filters
+// match the name of the status.
+type ListGoroutinesFilter struct {
+	Kind    GoroutineField
+	Negated bool
+	Arg     string
+}
+
+// GoroutineGroupingOptions describes how ListGoroutines groups goroutines.
+type GoroutineGroupingOptions struct {
+	// GroupBy is the field goroutines are grouped by, GoroutineFieldNone
+	// disables grouping.
+	GroupBy GoroutineField
+	// MaxGroupMembers is the maximum number of goroutines returned for each
+	// group, 0 means no limit.
+	MaxGroupMembers int
+}
+
+// GoroutineGroup is a group of goroutines returned by ListGoroutines.
+type GoroutineGroup struct {
+	// Name is the value of the GroupBy field shared by all members.
+	Name string
+	// Offset is the index of the first member of the group in the list of
+	// goroutines returned with the group.
+	Offset int
+	// Count is the number of members returned.
+	Count int
+	// Total is the total number of members of the group.
+	Total int
 }
 
 // DebuggerCommand is a command which changes the debugger's execution state.
@@ -263,6 +421,14 @@ type DebuggerCommand struct {
 	// GoroutineID is used to specify which thread to use with the SwitchGoroutine
 	// command.
 	GoroutineID int `json:"goroutineID,omitempty"`
+	// TargetPid is used to specify which target to use with the SwitchTarget
+	// command.
+	TargetPid int `json:"targetPid,omitempty"`
+	// Expr is the function call expression evaluated by the Call command.
+	Expr string `json:"expr,omitempty"`
+	// ReturnInfoLoadConfig specifies how to load the values returned by the
+	// function called by the Call command.
+	ReturnInfoLoadConfig *LoadConfig
 }
 
 // Informations about the current breakpoint
@@ -272,11 +438,21 @@ type BreakpointInfo struct {
 	Variables  []Variable   `json:"variables,omitempty"`
 	Arguments  []Variable   `json:"arguments,omitempty"`
 	Locals     []Variable   `json:"locals,omitempty"`
+
+	// Values of the watched memory before and after the access that
+	// triggered a watchpoint.
+	WatchOldValue *Variable `json:"watchOldValue,omitempty"`
+	WatchNewValue *Variable `json:"watchNewValue,omitempty"`
+
+	// LogMessage is the log message of the breakpoint, with the values of
+	// its expressions.
+	LogMessage string `json:"logMessage,omitempty"`
 }
 
 type EvalScope struct {
-	GoroutineID int
-	Frame       int
+	GoroutineID  int
+	Frame        int
+	DeferredCall int // when DeferredCall is n > 0 this eval scope is relative to the nth deferred call in the current frame
 }
 
 const (
@@ -292,12 +468,29 @@ const (
 	StepInstruction = "stepInstruction"
 	// Next continues to the next source line, not entering function calls.
 	Next = "next"
+	// ReverseNext continues backwards to the previous source line, not
+	// entering function calls (target must be a recording).
+	ReverseNext = "reverseNext"
+	// ReverseStep continues backwards to the previous source line, entering
+	// function calls (target must be a recording).
+	ReverseStep = "reverseStep"
+	// ReverseStepOut continues backwards to the call instruction of the
+	// current function (target must be a recording).
+	ReverseStepOut = "reverseStepOut"
+	// ReverseStepInstruction steps backwards exactly 1 cpu instruction
+	// (target must be a recording).
+	ReverseStepInstruction = "reverseStepInstruction"
 	// SwitchThread switches the debugger's current thread context.
 	SwitchThread = "switchThread"
 	// SwitchGoroutine switches the debugger's current thread context to the thread running the specified goroutine
 	SwitchGoroutine = "switchGoroutine"
+	// SwitchTarget switches the debugger's current target, when following
+	// child processes.
+	SwitchTarget = "switchTarget"
 	// Halt suspends the process.
 	Halt = "halt"
+	// Call injects a function call on the selected goroutine.
+	Call = "call"
 )
 
 type AssemblyFlavour int
@@ -325,6 +518,24 @@ type AsmInstruction struct {
 
 type AsmInstructions []AsmInstruction
 
+// MaxExamineMemoryLength is the maximum number of bytes that can be read
+// by a single ExamineMemory request.
+const MaxExamineMemoryLength = 1000
+
+// DumpState describes the state of a core dump started by DumpStart.
+type DumpState struct {
+	// Dumping is true while the dump is being written.
+	Dumping bool
+	// AllDone is true when the dump was written successfully.
+	AllDone bool
+
+	ThreadsDone, ThreadsTotal int
+	MemDone, MemTotal         uint64
+
+	// Err is the error that stopped the dump, if any.
+	Err string
+}
+
 type GetVersionIn struct {
 }
 
@@ -36,11 +36,28 @@ type Client interface {
 	Step() (*api.DebuggerState, error)
 	// StepOut continues to the return address of the current function
 	StepOut() (*api.DebuggerState, error)
+	// Call injects a call to the function call expression expr on the
+	// selected goroutine and resumes the process until it returns.
+	Call(expr string) (*api.DebuggerState, error)
 
 	// SingleStep will step a single cpu instruction.
 	StepInstruction() (*api.DebuggerState, error)
+	// ReverseNext continues backwards to the previous source line, not
+	// entering function calls.
+	ReverseNext() (*api.DebuggerState, error)
+	// ReverseStep continues backwards to the previous source line, entering
+	// function calls.
+	ReverseStep() (*api.DebuggerState, error)
+	// ReverseStepOut continues backwards to the call instruction of the
+	// current function.
+	ReverseStepOut() (*api.DebuggerState, error)
+	// ReverseStepInstruction steps backwards a single cpu instruction.
+	ReverseStepInstruction() (*api.DebuggerState, error)
 	// SwitchThread switches the current thread context.
 	SwitchThread(threadID int) (*api.DebuggerState, error)
+	// SwitchTarget switches the current target, when following child
+	// processes.
+	SwitchTarget(pid int) (*api.DebuggerState, error)
 	// SwitchGoroutine switches the current goroutine (and the current thread as well)
 	SwitchGoroutine(goroutineID int) (*api.DebuggerState, error)
 	// Halt suspends the process.
@@ -52,6 +69,8 @@ type Client interface {
 	GetBreakpointByName(name string) (*api.Breakpoint, error)
 	// CreateBreakpoint creates a new breakpoint.
 	CreateBreakpoint(*api.Breakpoint) (*api.Breakpoint, error)
+	// CreateWatchpoint creates a watchpoint on the memory of expr, evaluated in scope.
+	CreateWatchpoint(scope api.EvalScope, expr string, wtype api.WatchType) (*api.Breakpoint, error)
 	// ListBreakpoints gets all breakpoints.
 	ListBreakpoints() ([]*api.Breakpoint, error)
 	// ClearBreakpoint deletes a breakpoint by ID.
@@ -66,6 +85,8 @@ type Client interface {
 
 	// ListThreads lists all threads.
 	ListThreads() ([]*api.Thread, error)
+	// ListTargets lists the processes being debugged.
+	ListTargets() ([]api.Target, error)
 	// GetThread gets a thread by its ID.
 	GetThread(id int) (*api.Thread, error)
 
@@ -81,6 +102,9 @@ type Client interface {
 	ListSources(filter string) ([]string, error)
 	// ListFunctions lists all functions in the process matching filter.
 	ListFunctions(filter string) ([]string, error)
+	// FunctionReturnLocations returns the addresses of the return
+	// instructions of function fnName.
+	FunctionReturnLocations(fnName string) ([]uint64, error)
 	// ListTypes lists all types in the process matching filter.
 	ListTypes(filter string) ([]string, error)
 	// ListLocals lists all local variables in scope.
@@ -89,12 +113,32 @@ type Client interface {
 	ListFunctionArgs(scope api.EvalScope, cfg api.LoadConfig) ([]api.Variable, error)
 	// ListRegisters lists registers and their values.
 	ListRegisters(threadID int, includeFp bool) (api.Registers, error)
+	// ExamineMemory returns length bytes of memory starting at address.
+	ExamineMemory(address uintptr, length int) ([]byte, error)
+
+	// ListDisplays evaluates the expressions of the display list in scope.
+	ListDisplays(scope api.EvalScope, cfg api.LoadConfig) ([]api.Display, error)
+	// SetDisplays replaces the display list.
+	SetDisplays(displays []api.DisplayExpr) error
+
+	// DumpStart starts writing a core dump of the target to dest.
+	DumpStart(dest string) (api.DumpState, error)
+	// DumpWait waits at most wait milliseconds for the core dump to finish
+	// and returns its state.
+	DumpWait(wait int) (api.DumpState, error)
+	// DumpCancel cancels the core dump in progress.
+	DumpCancel() error
 
 	// ListGoroutines lists all goroutines.
 	ListGoroutines() ([]*api.Goroutine, error)
+	// ListGoroutinesWithFilter lists a page of the goroutines matching all
+	// filters, starting at start, and the start of the next page or -1.
+	// If group is not nil the goroutines are grouped and the page selects
+	// groups instead.
+	ListGoroutinesWithFilter(start, count int, filters []api.ListGoroutinesFilter, group *api.GoroutineGroupingOptions) ([]*api.Goroutine, []api.GoroutineGroup, int, error)
 
 	// Returns stacktrace
-	Stacktrace(int, int, *api.LoadConfig) ([]api.Stackframe, error)
+	Stacktrace(goroutineID, depth int, readDefers bool, cfg *api.LoadConfig) ([]api.Stackframe, error)
 
 	// Returns whether we attached to a running process or not
 	AttachedToExistingProcess() bool
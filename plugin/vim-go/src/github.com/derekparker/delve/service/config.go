@@ -1,6 +1,11 @@
 package service
 
-import "net"
+import (
+	"net"
+
+	"github.com/derekparker/delve/pkg/proc"
+	"github.com/derekparker/delve/service/api"
+)
 
 // Config provides the configuration to start a Debugger and expose it with a
 // service.
@@ -32,6 +37,18 @@ type Config struct {
 	// Selects server backend.
 	Backend string
 
+	// FollowExec and FollowFork make the debugger follow the child
+	// processes of the target, see debugger.Config.
+	FollowExec bool
+	FollowFork bool
+
+	// PrettyPrinters are applied to the variables returned to clients.
+	PrettyPrinters []proc.PrettyPrinter
+
+	// Displays is the initial list of expressions evaluated by the display
+	// API, see debugger.Config.
+	Displays []api.DisplayExpr
+
 	// DisconnectChan will be closed by the server when the client disconnects
 	DisconnectChan chan<- struct{}
 }
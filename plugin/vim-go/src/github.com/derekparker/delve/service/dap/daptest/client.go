@@ -0,0 +1,130 @@
+// Package daptest provides a scripted Debug Adapter Protocol client, used
+// to test the DAP server.
+package daptest
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/derekparker/delve/service/dap"
+)
+
+// Message is a response or an event received from the server, Body is
+// decoded by the Expect methods.
+type Message struct {
+	dap.ProtocolMessage
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Command    string          `json:"command"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// Client is a DAP client connected to a server over a socket.
+type Client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	seq    int
+}
+
+// NewClient connects to the DAP server listening at addr.
+func NewClient(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Send sends a request for command with the given arguments, which can be
+// nil.
+func (c *Client) Send(command string, arguments interface{}) error {
+	c.seq++
+	req := struct {
+		dap.ProtocolMessage
+		Command   string      `json:"command"`
+		Arguments interface{} `json:"arguments,omitempty"`
+	}{dap.ProtocolMessage{Seq: c.seq, Type: "request"}, command, arguments}
+	return dap.WriteMessage(c.conn, &req)
+}
+
+// ReadMessage reads the next response or event.
+func (c *Client) ReadMessage() (*Message, error) {
+	var msg Message
+	if err := dap.ReadMessage(c.reader, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (c *Client) expect(t testing.TB, typ, name string) *Message {
+	t.Helper()
+	msg, err := c.ReadMessage()
+	if err != nil {
+		t.Fatalf("reading %s %s: %v", name, typ, err)
+	}
+	got := msg.Command
+	if msg.Type == "event" {
+		got = msg.Event
+	}
+	if msg.Type != typ || got != name {
+		t.Fatalf("expected %s %s, got %s %s (%s %s)", name, typ, got, msg.Type, msg.Message, msg.Body)
+	}
+	return msg
+}
+
+func decodeBody(t testing.TB, msg *Message, body interface{}) {
+	t.Helper()
+	if body == nil {
+		return
+	}
+	if err := json.Unmarshal(msg.Body, body); err != nil {
+		t.Fatalf("decoding body of %s%s: %v", msg.Command, msg.Event, err)
+	}
+}
+
+// ExpectResponse reads a successful response to command and decodes its
+// body into body, unless it is nil.
+func (c *Client) ExpectResponse(t testing.TB, command string, body interface{}) {
+	t.Helper()
+	msg := c.expect(t, "response", command)
+	if !msg.Success {
+		t.Fatalf("%s failed: %s", command, msg.Message)
+	}
+	decodeBody(t, msg, body)
+}
+
+// ExpectErrorResponse reads a failed response to command and returns its
+// error message.
+func (c *Client) ExpectErrorResponse(t testing.TB, command string) string {
+	t.Helper()
+	msg := c.expect(t, "response", command)
+	if msg.Success {
+		t.Fatalf("%s succeeded, expected an error", command)
+	}
+	return msg.Message
+}
+
+// ExpectEvent reads event and decodes its body into body, unless it is
+// nil.
+func (c *Client) ExpectEvent(t testing.TB, event string, body interface{}) {
+	t.Helper()
+	decodeBody(t, c.expect(t, "event", event), body)
+}
+
+// Call sends a request for command and reads its successful response.
+func (c *Client) Call(t testing.TB, command string, arguments, body interface{}) {
+	t.Helper()
+	if err := c.Send(command, arguments); err != nil {
+		t.Fatalf("sending %s: %v", command, err)
+	}
+	c.ExpectResponse(t, command, body)
+}
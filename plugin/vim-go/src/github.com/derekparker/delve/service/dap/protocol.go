@@ -0,0 +1,284 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// This file contains the subset of the Debug Adapter Protocol messages
+// used by the server, see:
+//   https://microsoft.github.io/debug-adapter-protocol/specification
+
+// ProtocolMessage is the base of all messages exchanged with the client.
+type ProtocolMessage struct {
+	Seq  int    `json:"seq"`
+	Type string `json:"type"`
+}
+
+// Request is a request sent by the client. Arguments are decoded by the
+// handler of Command.
+type Request struct {
+	ProtocolMessage
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// Response is the response to a Request.
+type Response struct {
+	ProtocolMessage
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+// Event is a notification sent to the client.
+type Event struct {
+	ProtocolMessage
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+// ErrorMessage is the body of a failed Response.
+type ErrorMessage struct {
+	Error struct {
+		ID     int    `json:"id"`
+		Format string `json:"format"`
+	} `json:"error"`
+}
+
+// Capabilities is the body of the response to the initialize request.
+type Capabilities struct {
+	SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest,omitempty"`
+	SupportsConditionalBreakpoints   bool `json:"supportsConditionalBreakpoints,omitempty"`
+	SupportsEvaluateForHovers        bool `json:"supportsEvaluateForHovers,omitempty"`
+	SupportsTerminateRequest         bool `json:"supportsTerminateRequest,omitempty"`
+}
+
+// LaunchArguments are the arguments of the launch request.
+type LaunchArguments struct {
+	// Mode is "exec" to run the executable in Program, or "core" to open
+	// the core dump in CoreFilePath, produced by Program.
+	Mode         string   `json:"mode"`
+	Program      string   `json:"program"`
+	Args         []string `json:"args"`
+	Cwd          string   `json:"cwd"`
+	CoreFilePath string   `json:"coreFilePath"`
+	StopOnEntry  bool     `json:"stopOnEntry"`
+	Backend      string   `json:"backend"`
+}
+
+// AttachArguments are the arguments of the attach request.
+type AttachArguments struct {
+	ProcessID   int    `json:"processId"`
+	Program     string `json:"program"`
+	StopOnEntry bool   `json:"stopOnEntry"`
+	Backend     string `json:"backend"`
+}
+
+// DisconnectArguments are the arguments of the disconnect request.
+type DisconnectArguments struct {
+	TerminateDebuggee *bool `json:"terminateDebuggee"`
+}
+
+// Source identifies a source file.
+type Source struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// SourceBreakpoint is a breakpoint requested by setBreakpoints.
+type SourceBreakpoint struct {
+	Line      int    `json:"line"`
+	Condition string `json:"condition,omitempty"`
+}
+
+// SetBreakpointsArguments are the arguments of the setBreakpoints request.
+type SetBreakpointsArguments struct {
+	Source      Source             `json:"source"`
+	Breakpoints []SourceBreakpoint `json:"breakpoints"`
+}
+
+// Breakpoint is the result of setting a SourceBreakpoint.
+type Breakpoint struct {
+	ID       int    `json:"id,omitempty"`
+	Verified bool   `json:"verified"`
+	Message  string `json:"message,omitempty"`
+	Source   Source `json:"source"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// SetBreakpointsResponseBody is the body of the response to setBreakpoints.
+type SetBreakpointsResponseBody struct {
+	Breakpoints []Breakpoint `json:"breakpoints"`
+}
+
+// Thread is a thread of the debuggee, the server reports goroutines as
+// threads.
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// ThreadsResponseBody is the body of the response to threads.
+type ThreadsResponseBody struct {
+	Threads []Thread `json:"threads"`
+}
+
+// ThreadArguments are the arguments of continue, next, stepIn, stepOut
+// and pause.
+type ThreadArguments struct {
+	ThreadID int `json:"threadId"`
+}
+
+// ContinueResponseBody is the body of the response to continue.
+type ContinueResponseBody struct {
+	AllThreadsContinued bool `json:"allThreadsContinued"`
+}
+
+// StackTraceArguments are the arguments of the stackTrace request.
+type StackTraceArguments struct {
+	ThreadID   int `json:"threadId"`
+	StartFrame int `json:"startFrame"`
+	Levels     int `json:"levels"`
+}
+
+// StackFrame is a frame of a stack trace.
+type StackFrame struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Source Source `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// StackTraceResponseBody is the body of the response to stackTrace.
+type StackTraceResponseBody struct {
+	StackFrames []StackFrame `json:"stackFrames"`
+	TotalFrames int          `json:"totalFrames"`
+}
+
+// ScopesArguments are the arguments of the scopes request.
+type ScopesArguments struct {
+	FrameID int `json:"frameId"`
+}
+
+// Scope is a group of variables of a stack frame.
+type Scope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+// ScopesResponseBody is the body of the response to scopes.
+type ScopesResponseBody struct {
+	Scopes []Scope `json:"scopes"`
+}
+
+// VariablesArguments are the arguments of the variables request.
+type VariablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+// Variable is a variable, or a child of a variable, shown by the client.
+// Children of variables with a non zero VariablesReference are loaded by
+// a further variables request.
+type Variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	EvaluateName       string `json:"evaluateName,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+	IndexedVariables   int    `json:"indexedVariables,omitempty"`
+	NamedVariables     int    `json:"namedVariables,omitempty"`
+}
+
+// VariablesResponseBody is the body of the response to variables.
+type VariablesResponseBody struct {
+	Variables []Variable `json:"variables"`
+}
+
+// EvaluateArguments are the arguments of the evaluate request.
+type EvaluateArguments struct {
+	Expression string `json:"expression"`
+	FrameID    int    `json:"frameId"`
+	Context    string `json:"context"`
+}
+
+// EvaluateResponseBody is the body of the response to evaluate.
+type EvaluateResponseBody struct {
+	Result             string `json:"result"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+// StoppedEventBody is the body of the stopped event.
+type StoppedEventBody struct {
+	Reason            string `json:"reason"`
+	Description       string `json:"description,omitempty"`
+	ThreadID          int    `json:"threadId"`
+	AllThreadsStopped bool   `json:"allThreadsStopped"`
+	Text              string `json:"text,omitempty"`
+}
+
+// ExitedEventBody is the body of the exited event.
+type ExitedEventBody struct {
+	ExitCode int `json:"exitCode"`
+}
+
+// OutputEventBody is the body of the output event.
+type OutputEventBody struct {
+	Category string `json:"category"`
+	Output   string `json:"output"`
+}
+
+const contentLengthHeader = "Content-Length: "
+
+// ReadMessage reads a message, framed by a Content-Length header, and
+// decodes it into msg.
+func ReadMessage(r *bufio.Reader, msg interface{}) error {
+	contentLength := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, contentLengthHeader) {
+			contentLength, err = strconv.Atoi(strings.TrimPrefix(line, contentLengthHeader))
+			if err != nil {
+				return fmt.Errorf("invalid header %q: %v", line, err)
+			}
+		}
+	}
+	if contentLength < 0 {
+		return fmt.Errorf("missing %sheader", contentLengthHeader)
+	}
+	buf := make([]byte, contentLength)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, msg)
+}
+
+// WriteMessage encodes msg as JSON and writes it to w, preceded by a
+// Content-Length header.
+func WriteMessage(w io.Writer, msg interface{}) error {
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s%d\r\n\r\n", contentLengthHeader, len(buf)); err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
@@ -0,0 +1,847 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/derekparker/delve/pkg/proc"
+	"github.com/derekparker/delve/service"
+	"github.com/derekparker/delve/service/api"
+	"github.com/derekparker/delve/service/debugger"
+)
+
+// Server implements a Debug Adapter Protocol server on top of
+// service/debugger.Debugger. It serves a single client, the target
+// process is started by the launch or attach request of the client.
+type Server struct {
+	// config is all the information necessary to start the server.
+	config *service.Config
+	// listener is used to accept the client connection.
+	listener net.Listener
+	// conn is the connection to the client.
+	conn net.Conn
+	// reader is used to read requests from conn.
+	reader *bufio.Reader
+	// debugger is the debugger service, it is nil until the client sends a
+	// launch or attach request, and after the target is detached.
+	debugger *debugger.Debugger
+
+	// sending protects seq and writes to conn, events are sent from the
+	// goroutine waiting for the target to stop.
+	sending sync.Mutex
+	seq     int
+
+	// mu protects conn, debugger and running, they are accessed by the
+	// goroutine waiting for the target to stop and by Stop.
+	mu sync.Mutex
+	// running is true while the target is running.
+	running bool
+	// interrupted is set by interrupt while it halts the target, it receives
+	// true if the target stopped because of the halt and can be resumed
+	// without telling the client.
+	interrupted chan bool
+
+	// stopOnEntry is set by the launch or attach request.
+	stopOnEntry bool
+	// frameHandles and variableHandles map the IDs of stack frames and
+	// variables sent to the client to their contents, they are only valid
+	// while the target is stopped.
+	frameHandles    *handlesMap
+	variableHandles *handlesMap
+
+	stopOnce sync.Once
+}
+
+// loadConfig is used to load variables and the result of evaluations,
+// children deeper than MaxVariableRecurse are loaded when the client
+// requests them, see reloadVariable.
+var loadConfig = proc.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 512, MaxArrayValues: 64, MaxStructFields: -1}
+
+// maxStackDepth is the number of frames returned when the stackTrace
+// request does not specify how many levels it wants.
+const maxStackDepth = 50
+
+var (
+	errNotStarted = errors.New("the target was not started")
+	errRunning    = errors.New("the target is running")
+)
+
+// NewServer creates a new DAP server.
+func NewServer(config *service.Config, logEnabled bool) *Server {
+	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+	if !logEnabled {
+		log.SetOutput(ioutil.Discard)
+	}
+	return &Server{
+		config:          config,
+		listener:        config.Listener,
+		frameHandles:    newHandlesMap(),
+		variableHandles: newHandlesMap(),
+	}
+}
+
+// Stop stops the server and detaches from the target, killing it if kill
+// is true or if it was launched by the server.
+func (s *Server) Stop(kill bool) error {
+	err := s.detach(kill)
+	s.close()
+	return err
+}
+
+// detach detaches from the target, if it was started.
+func (s *Server) detach(kill bool) error {
+	s.mu.Lock()
+	d := s.debugger
+	s.debugger = nil
+	running := s.running
+	s.mu.Unlock()
+	if d == nil {
+		return nil
+	}
+	if running {
+		d.Command(&api.DebuggerCommand{Name: api.Halt})
+	}
+	return d.Detach(kill)
+}
+
+// close closes the listener and the client connection.
+func (s *Server) close() {
+	s.stopOnce.Do(func() {
+		s.listener.Close()
+		s.mu.Lock()
+		if s.conn != nil {
+			s.conn.Close()
+		}
+		s.mu.Unlock()
+		if s.config.DisconnectChan != nil {
+			close(s.config.DisconnectChan)
+		}
+	})
+}
+
+// Run accepts a single client connection and serves it in a separate
+// goroutine. The server stops when the client disconnects.
+func (s *Server) Run() error {
+	go func() {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			log.Printf("error accepting client connection: %v", err)
+			s.Stop(false)
+			return
+		}
+		s.mu.Lock()
+		s.conn = conn
+		s.mu.Unlock()
+		s.reader = bufio.NewReader(conn)
+		s.serve()
+	}()
+	return nil
+}
+
+func (s *Server) serve() {
+	for {
+		var req Request
+		if err := ReadMessage(s.reader, &req); err != nil {
+			if err != io.EOF {
+				log.Printf("error reading request: %v", err)
+			}
+			s.Stop(false)
+			return
+		}
+		log.Printf("<- %s %s", req.Command, req.Arguments)
+		if req.Command == "disconnect" {
+			s.onDisconnect(&req)
+			return
+		}
+		s.handleRequest(&req)
+	}
+}
+
+func (s *Server) handleRequest(req *Request) {
+	var body interface{}
+	var err error
+	// resume restarts the target after the response is sent, so that the
+	// response precedes any event caused by the target.
+	var resume func()
+	switch req.Command {
+	case "initialize":
+		body = &Capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsConditionalBreakpoints:   true,
+			SupportsEvaluateForHovers:        true,
+		}
+	case "launch":
+		err = s.onLaunch(req)
+	case "attach":
+		err = s.onAttach(req)
+	case "configurationDone":
+		// the response must precede the stopped event, if any
+		s.sendResponse(req, nil)
+		s.onConfigurationDone()
+		return
+	case "setBreakpoints":
+		resume, err = s.interrupt()
+		if err == nil {
+			body, err = s.onSetBreakpoints(req)
+		}
+	case "threads":
+		resume, err = s.interrupt()
+		if err == nil {
+			body, err = s.onThreads()
+		}
+	case "stackTrace":
+		body, err = s.onStackTrace(req)
+	case "scopes":
+		body, err = s.onScopes(req)
+	case "variables":
+		body, err = s.onVariables(req)
+	case "evaluate":
+		body, err = s.onEvaluate(req)
+	case "continue":
+		body = &ContinueResponseBody{AllThreadsContinued: true}
+		resume, err = s.onResume(req, api.Continue)
+	case "next":
+		resume, err = s.onResume(req, api.Next)
+	case "stepIn":
+		resume, err = s.onResume(req, api.Step)
+	case "stepOut":
+		resume, err = s.onResume(req, api.StepOut)
+	case "pause":
+		err = s.onPause()
+	default:
+		err = fmt.Errorf("unsupported command %q", req.Command)
+	}
+	if err != nil {
+		s.sendErrorResponse(req, err)
+	} else {
+		s.sendResponse(req, body)
+		if req.Command == "launch" || req.Command == "attach" {
+			s.sendEvent("initialized", nil)
+		}
+	}
+	if resume != nil {
+		resume()
+	}
+}
+
+func (s *Server) onLaunch(req *Request) error {
+	if s.debugger != nil {
+		return errors.New("the target was already started")
+	}
+	var args LaunchArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return err
+	}
+	if args.Program == "" {
+		return errors.New("the program attribute is missing")
+	}
+	config := &debugger.Config{WorkingDir: args.Cwd, Backend: backend(args.Backend, s.config.Backend), PrettyPrinters: s.config.PrettyPrinters}
+	processArgs := append([]string{args.Program}, args.Args...)
+	switch args.Mode {
+	case "", "exec":
+	case "core":
+		if args.CoreFilePath == "" {
+			return errors.New("the coreFilePath attribute is missing")
+		}
+		config.CoreFile = args.CoreFilePath
+		processArgs = processArgs[:1]
+		// there is nothing to run in a core dump
+		args.StopOnEntry = true
+	default:
+		return fmt.Errorf("unsupported mode %q", args.Mode)
+	}
+	d, err := debugger.New(config, processArgs)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.debugger = d
+	s.mu.Unlock()
+	s.stopOnEntry = args.StopOnEntry
+	return nil
+}
+
+func (s *Server) onAttach(req *Request) error {
+	if s.debugger != nil {
+		return errors.New("the target was already started")
+	}
+	var args AttachArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return err
+	}
+	if args.ProcessID <= 0 {
+		return errors.New("the processId attribute is missing")
+	}
+	var processArgs []string
+	if args.Program != "" {
+		processArgs = []string{args.Program}
+	}
+	d, err := debugger.New(&debugger.Config{AttachPid: args.ProcessID, Backend: backend(args.Backend, s.config.Backend), PrettyPrinters: s.config.PrettyPrinters}, processArgs)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.debugger = d
+	s.mu.Unlock()
+	s.stopOnEntry = args.StopOnEntry
+	return nil
+}
+
+func backend(requested, configured string) string {
+	if requested != "" {
+		return requested
+	}
+	if configured != "" {
+		return configured
+	}
+	return "default"
+}
+
+func (s *Server) onConfigurationDone() {
+	d, err := s.stoppedDebugger()
+	if err != nil {
+		return
+	}
+	if !s.stopOnEntry {
+		s.startResume(d, api.Continue)
+		return
+	}
+	threadID := 1
+	if state, err := d.State(); err == nil {
+		threadID = stoppedGoroutineID(state)
+	}
+	s.sendEvent("stopped", &StoppedEventBody{Reason: "entry", ThreadID: threadID, AllThreadsStopped: true})
+}
+
+func (s *Server) onDisconnect(req *Request) {
+	var args DisconnectArguments
+	if len(req.Arguments) > 0 {
+		json.Unmarshal(req.Arguments, &args)
+	}
+	// processes started by the server are always killed by the debugger
+	kill := args.TerminateDebuggee != nil && *args.TerminateDebuggee
+	if err := s.detach(kill); err != nil {
+		s.sendErrorResponse(req, err)
+	} else {
+		s.sendResponse(req, nil)
+	}
+	s.close()
+}
+
+// stoppedDebugger returns the debugger, or an error if the target was not
+// started or is running.
+func (s *Server) stoppedDebugger() (*debugger.Debugger, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.debugger == nil {
+		return nil, errNotStarted
+	}
+	if s.running {
+		return nil, errRunning
+	}
+	return s.debugger, nil
+}
+
+// interrupt halts the target, if it is running, so that a request that
+// needs it stopped can be served. It returns the function that resumes the
+// target afterwards, or nil if the target was not running or stopped for
+// another reason, which is reported to the client as usual.
+func (s *Server) interrupt() (func(), error) {
+	s.mu.Lock()
+	d, running := s.debugger, s.running
+	interrupted := make(chan bool, 1)
+	if running {
+		s.interrupted = interrupted
+	}
+	s.mu.Unlock()
+	if !running {
+		return nil, nil
+	}
+	if _, err := d.Command(&api.DebuggerCommand{Name: api.Halt}); err != nil {
+		return nil, err
+	}
+	if !<-interrupted {
+		return nil, nil
+	}
+	return func() { s.startResume(d, api.Continue) }, nil
+}
+
+func (s *Server) onSetBreakpoints(req *Request) (interface{}, error) {
+	d, err := s.stoppedDebugger()
+	if err != nil {
+		return nil, err
+	}
+	var args SetBreakpointsArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+	path := args.Source.Path
+	if path == "" {
+		return nil, errors.New("the source path is missing")
+	}
+
+	// The request replaces all breakpoints in the file.
+	for _, bp := range d.Breakpoints() {
+		if bp.ID <= 0 || bp.File != path || bp.Tracepoint || bp.WatchExpr != "" {
+			continue
+		}
+		if _, err := d.ClearBreakpoint(bp); err != nil {
+			return nil, err
+		}
+	}
+
+	body := &SetBreakpointsResponseBody{Breakpoints: make([]Breakpoint, len(args.Breakpoints))}
+	for i, want := range args.Breakpoints {
+		bp, err := d.CreateBreakpoint(&api.Breakpoint{File: path, Line: want.Line, Cond: want.Condition})
+		if err != nil {
+			body.Breakpoints[i] = Breakpoint{Verified: false, Message: err.Error(), Source: args.Source, Line: want.Line}
+			continue
+		}
+		body.Breakpoints[i] = Breakpoint{ID: bp.ID, Verified: true, Source: args.Source, Line: bp.Line}
+	}
+	return body, nil
+}
+
+func (s *Server) onThreads() (interface{}, error) {
+	d, err := s.stoppedDebugger()
+	if err != nil {
+		return nil, err
+	}
+	gs, err := d.Goroutines()
+	if err != nil {
+		return nil, err
+	}
+	body := &ThreadsResponseBody{Threads: make([]Thread, 0, len(gs))}
+	for _, g := range gs {
+		loc := g.UserCurrentLoc
+		fnname := "?"
+		if loc.Function != nil {
+			fnname = loc.Function.Name
+		}
+		body.Threads = append(body.Threads, Thread{ID: g.ID, Name: fmt.Sprintf("[Go %d] %s", g.ID, fnname)})
+	}
+	if len(body.Threads) == 0 {
+		// Clients expect at least one thread, goroutines do not exist yet if
+		// the target was stopped on entry.
+		body.Threads = append(body.Threads, Thread{ID: 1, Name: "Dummy"})
+	}
+	return body, nil
+}
+
+// stackFrame identifies a frame of the stack of a goroutine.
+type stackFrame struct {
+	goroutineID int
+	frame       int
+}
+
+func (f stackFrame) scope() api.EvalScope {
+	return api.EvalScope{GoroutineID: f.goroutineID, Frame: f.frame}
+}
+
+func (s *Server) onStackTrace(req *Request) (interface{}, error) {
+	d, err := s.stoppedDebugger()
+	if err != nil {
+		return nil, err
+	}
+	var args StackTraceArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+	levels := args.Levels
+	if levels <= 0 {
+		levels = maxStackDepth
+	}
+	frames, err := d.Stacktrace(args.ThreadID, args.StartFrame+levels, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	body := &StackTraceResponseBody{StackFrames: []StackFrame{}, TotalFrames: len(frames)}
+	for i := args.StartFrame; i < len(frames); i++ {
+		frame := frames[i]
+		fnname := "?"
+		if frame.Function != nil {
+			fnname = frame.Function.Name
+		}
+		id := s.frameHandles.create(stackFrame{goroutineID: args.ThreadID, frame: i})
+		body.StackFrames = append(body.StackFrames, StackFrame{
+			ID:     id,
+			Name:   fnname,
+			Source: Source{Name: filepath.Base(frame.File), Path: frame.File},
+			Line:   frame.Line,
+		})
+	}
+	return body, nil
+}
+
+// scopeRef is the value of the handle of a scope, locals is false for the
+// arguments of the function.
+type scopeRef struct {
+	frame  stackFrame
+	locals bool
+}
+
+// variableRef is the value of the handle of a variable with children.
+type variableRef struct {
+	frame    stackFrame
+	v        *api.Variable
+	evalName string
+}
+
+func (s *Server) onScopes(req *Request) (interface{}, error) {
+	if _, err := s.stoppedDebugger(); err != nil {
+		return nil, err
+	}
+	var args ScopesArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+	frame, ok := s.frameHandles.get(args.FrameID)
+	if !ok {
+		return nil, fmt.Errorf("unknown frame id %d", args.FrameID)
+	}
+	return &ScopesResponseBody{Scopes: []Scope{
+		{Name: "Arguments", VariablesReference: s.variableHandles.create(scopeRef{frame: frame.(stackFrame)})},
+		{Name: "Locals", VariablesReference: s.variableHandles.create(scopeRef{frame: frame.(stackFrame), locals: true})},
+	}}, nil
+}
+
+func (s *Server) onVariables(req *Request) (interface{}, error) {
+	d, err := s.stoppedDebugger()
+	if err != nil {
+		return nil, err
+	}
+	var args VariablesArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+	ref, ok := s.variableHandles.get(args.VariablesReference)
+	if !ok {
+		return nil, fmt.Errorf("unknown variables reference %d", args.VariablesReference)
+	}
+	body := &VariablesResponseBody{Variables: []Variable{}}
+	switch ref := ref.(type) {
+	case scopeRef:
+		var vars []api.Variable
+		var err error
+		if ref.locals {
+			vars, err = d.LocalVariables(ref.frame.scope(), loadConfig, false)
+		} else {
+			vars, err = d.FunctionArguments(ref.frame.scope(), loadConfig, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := range vars {
+			body.Variables = append(body.Variables, s.convertVariable(ref.frame, &vars[i], vars[i].Name, vars[i].Name))
+		}
+	case *variableRef:
+		if err := reloadVariable(d, ref); err != nil {
+			return nil, err
+		}
+		body.Variables = s.childrenToDAP(ref)
+	}
+	return body, nil
+}
+
+// reloadVariable loads the children of ref that were not loaded because
+// of the maximum recursion depth of loadConfig.
+func reloadVariable(d *debugger.Debugger, ref *variableRef) error {
+	v := ref.v
+	target := v
+	if v.Kind == reflect.Ptr {
+		if len(v.Children) != 1 || !v.Children[0].OnlyAddr {
+			return nil
+		}
+		target = &v.Children[0]
+	} else if len(v.Children) > 0 || v.Len == 0 {
+		return nil
+	}
+	if target.Addr == 0 {
+		return nil
+	}
+	loaded, err := d.EvalVariableInScope(ref.frame.scope(), fmt.Sprintf("*(*%q)(%#x)", target.Type, target.Addr), loadConfig, false)
+	if err != nil {
+		return err
+	}
+	loaded.Name = target.Name
+	*target = *loaded
+	return nil
+}
+
+// hasChildren returns true if the client should be able to expand v.
+func hasChildren(v *api.Variable) bool {
+	if v.Unreadable != "" {
+		return false
+	}
+	switch v.Kind {
+	case reflect.Ptr:
+		return len(v.Children) == 1 && v.Children[0].Addr != 0
+	case reflect.Interface:
+		return len(v.Children) == 1 && v.Children[0].Kind != reflect.Invalid
+	case reflect.Struct, reflect.Array, reflect.Slice, reflect.Map:
+		return len(v.Children) > 0 || v.Len > 0
+	}
+	return false
+}
+
+func (s *Server) convertVariable(frame stackFrame, v *api.Variable, name, evalName string) Variable {
+	r := Variable{Name: name, Value: v.SinglelineString(), Type: v.Type, EvaluateName: evalName}
+	if v.Unreadable != "" {
+		r.Value = fmt.Sprintf("(unreadable %s)", v.Unreadable)
+	}
+	if hasChildren(v) {
+		r.VariablesReference = s.variableHandles.create(&variableRef{frame: frame, v: v, evalName: evalName})
+		switch v.Kind {
+		case reflect.Array, reflect.Slice, reflect.Map:
+			r.IndexedVariables = int(v.Len)
+		case reflect.Struct:
+			r.NamedVariables = int(v.Len)
+		}
+	}
+	return r
+}
+
+func (s *Server) childrenToDAP(ref *variableRef) []Variable {
+	v := ref.v
+	children := []Variable{}
+	switch v.Kind {
+	case reflect.Ptr:
+		children = append(children, s.convertVariable(ref.frame, &v.Children[0], "*"+v.Name, "*("+ref.evalName+")"))
+	case reflect.Interface:
+		children = append(children, s.convertVariable(ref.frame, &v.Children[0], "data", ref.evalName+".(data)"))
+	case reflect.Map:
+		for i := 0; i+1 < len(v.Children); i += 2 {
+			key, value := &v.Children[i], &v.Children[i+1]
+			children = append(children, s.convertVariable(ref.frame, value, key.SinglelineString(), ""))
+		}
+	case reflect.Array, reflect.Slice:
+		for i := range v.Children {
+			idx := strconv.Itoa(i)
+			children = append(children, s.convertVariable(ref.frame, &v.Children[i], "["+idx+"]", ref.evalName+"["+idx+"]"))
+		}
+	default:
+		for i := range v.Children {
+			child := &v.Children[i]
+			children = append(children, s.convertVariable(ref.frame, child, child.Name, ref.evalName+"."+child.Name))
+		}
+	}
+	return children
+}
+
+func (s *Server) onEvaluate(req *Request) (interface{}, error) {
+	d, err := s.stoppedDebugger()
+	if err != nil {
+		return nil, err
+	}
+	var args EvaluateArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+	frame := stackFrame{goroutineID: -1}
+	if args.FrameID != 0 {
+		f, ok := s.frameHandles.get(args.FrameID)
+		if !ok {
+			return nil, fmt.Errorf("unknown frame id %d", args.FrameID)
+		}
+		frame = f.(stackFrame)
+	}
+	v, err := d.EvalVariableInScope(frame.scope(), args.Expression, loadConfig, false)
+	if err != nil {
+		return nil, err
+	}
+	r := s.convertVariable(frame, v, args.Expression, args.Expression)
+	return &EvaluateResponseBody{Result: r.Value, Type: r.Type, VariablesReference: r.VariablesReference}, nil
+}
+
+// onResume prepares the target to run cmd and returns the function that
+// starts it.
+func (s *Server) onResume(req *Request, cmd string) (func(), error) {
+	d, err := s.stoppedDebugger()
+	if err != nil {
+		return nil, err
+	}
+	var args ThreadArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		return nil, err
+	}
+	if cmd != api.Continue {
+		// step commands act on the goroutine selected by the client
+		state, err := d.State()
+		if err != nil {
+			return nil, err
+		}
+		if state.SelectedGoroutine == nil || state.SelectedGoroutine.ID != args.ThreadID {
+			if _, err := d.Command(&api.DebuggerCommand{Name: api.SwitchGoroutine, GoroutineID: args.ThreadID}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return func() { s.startResume(d, cmd) }, nil
+}
+
+// startResume runs cmd in a new goroutine, the handles of frames and
+// variables are invalidated.
+func (s *Server) startResume(d *debugger.Debugger, cmd string) {
+	s.frameHandles.reset()
+	s.variableHandles.reset()
+	s.mu.Lock()
+	s.running = true
+	s.mu.Unlock()
+	go s.resume(d, cmd)
+}
+
+// resume runs cmd and reports to the client how the target stopped.
+func (s *Server) resume(d *debugger.Debugger, cmd string) {
+	state, err := d.Command(&api.DebuggerCommand{Name: cmd})
+
+	s.mu.Lock()
+	s.running = false
+	interrupted := s.interrupted
+	s.interrupted = nil
+	s.mu.Unlock()
+
+	if interrupted != nil {
+		// The target was halted to serve a request, it is resumed silently
+		// unless it stopped for another reason.
+		silent := err == nil && cmd == api.Continue && !state.Exited && (state.CurrentThread == nil || state.CurrentThread.Breakpoint == nil)
+		interrupted <- silent
+		if silent {
+			return
+		}
+	}
+
+	if err != nil {
+		s.sendEvent("output", &OutputEventBody{Category: "stderr", Output: err.Error() + "\n"})
+		s.sendEvent("stopped", &StoppedEventBody{Reason: "exception", Text: err.Error(), ThreadID: 1, AllThreadsStopped: true})
+		return
+	}
+	if state.Exited {
+		s.sendEvent("exited", &ExitedEventBody{ExitCode: state.ExitStatus})
+		s.sendEvent("terminated", nil)
+		return
+	}
+	if state.NextInProgress {
+		// A breakpoint interrupted the step, the client will decide how to
+		// go on from here.
+		if err := d.CancelNext(); err != nil {
+			log.Printf("could not cancel next: %v", err)
+		}
+	}
+
+	stopped := &StoppedEventBody{ThreadID: stoppedGoroutineID(state), AllThreadsStopped: true}
+	switch {
+	case state.CurrentThread != nil && state.CurrentThread.Breakpoint != nil:
+		stopped.Reason = "breakpoint"
+		if state.CurrentThread.Breakpoint.WatchExpr != "" {
+			stopped.Reason = "data breakpoint"
+		}
+	case cmd == api.Continue:
+		stopped.Reason = "pause"
+	default:
+		stopped.Reason = "step"
+	}
+	s.sendEvent("stopped", stopped)
+}
+
+// stoppedGoroutineID returns the ID of the goroutine reported in the
+// stopped event.
+func stoppedGoroutineID(state *api.DebuggerState) int {
+	switch {
+	case state.SelectedGoroutine != nil && state.SelectedGoroutine.ID > 0:
+		return state.SelectedGoroutine.ID
+	case state.CurrentThread != nil && state.CurrentThread.GoroutineID > 0:
+		return state.CurrentThread.GoroutineID
+	}
+	return 1
+}
+
+func (s *Server) onPause() error {
+	s.mu.Lock()
+	d, running := s.debugger, s.running
+	s.mu.Unlock()
+	if d == nil {
+		return errNotStarted
+	}
+	if !running {
+		return nil
+	}
+	// Command blocks until the target stops, the stopped event is sent by
+	// resume.
+	go func() {
+		if _, err := d.Command(&api.DebuggerCommand{Name: api.Halt}); err != nil {
+			log.Printf("could not halt: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) send(msg interface{}) {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	s.seq++
+	switch msg := msg.(type) {
+	case *Response:
+		msg.Seq = s.seq
+	case *Event:
+		msg.Seq = s.seq
+	}
+	if err := WriteMessage(s.conn, msg); err != nil {
+		log.Printf("error writing message: %v", err)
+	}
+}
+
+func (s *Server) sendResponse(req *Request, body interface{}) {
+	log.Printf("-> %s response", req.Command)
+	s.send(&Response{ProtocolMessage: ProtocolMessage{Type: "response"}, RequestSeq: req.Seq, Success: true, Command: req.Command, Body: body})
+}
+
+func (s *Server) sendErrorResponse(req *Request, err error) {
+	log.Printf("-> %s error: %v", req.Command, err)
+	var body ErrorMessage
+	body.Error.ID = 1
+	body.Error.Format = err.Error()
+	s.send(&Response{ProtocolMessage: ProtocolMessage{Type: "response"}, RequestSeq: req.Seq, Success: false, Command: req.Command, Message: err.Error(), Body: &body})
+}
+
+func (s *Server) sendEvent(event string, body interface{}) {
+	log.Printf("-> %s event", event)
+	s.send(&Event{ProtocolMessage: ProtocolMessage{Type: "event"}, Event: event, Body: body})
+}
+
+// handlesMap assigns IDs to values sent to the client, 0 is never used as
+// the protocol reserves it.
+type handlesMap struct {
+	next int
+	m    map[int]interface{}
+}
+
+func newHandlesMap() *handlesMap {
+	return &handlesMap{next: 1, m: map[int]interface{}{}}
+}
+
+func (h *handlesMap) create(value interface{}) int {
+	id := h.next
+	h.next++
+	h.m[id] = value
+	return id
+}
+
+func (h *handlesMap) get(id int) (interface{}, bool) {
+	v, ok := h.m[id]
+	return v, ok
+}
+
+func (h *handlesMap) reset() {
+	h.next = 1
+	h.m = map[int]interface{}{}
+}
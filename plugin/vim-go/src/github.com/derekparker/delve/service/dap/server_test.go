@@ -0,0 +1,309 @@
+package dap_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/derekparker/delve/pkg/proc"
+	"github.com/derekparker/delve/pkg/proc/core"
+	"github.com/derekparker/delve/pkg/proc/native"
+	protest "github.com/derekparker/delve/pkg/proc/test"
+	"github.com/derekparker/delve/service"
+	"github.com/derekparker/delve/service/dap"
+	"github.com/derekparker/delve/service/dap/daptest"
+)
+
+var testBackend string
+
+func TestMain(m *testing.M) {
+	flag.StringVar(&testBackend, "backend", "", "selects backend")
+	flag.Parse()
+	if testBackend == "" {
+		testBackend = os.Getenv("PROCTEST")
+		if testBackend == "" {
+			testBackend = "native"
+		}
+	}
+	os.Exit(protest.RunTestsWithFixtures(m))
+}
+
+func withDAPClient(name string, t *testing.T, fn func(c *daptest.Client, fixture protest.Fixture)) {
+	if testBackend == "rr" {
+		protest.MustHaveRecordingAllowed(t)
+	}
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("couldn't start listener: %s\n", err)
+	}
+	defer listener.Close()
+	disconnectChan := make(chan struct{})
+	server := dap.NewServer(&service.Config{
+		Listener:       listener,
+		Backend:        testBackend,
+		DisconnectChan: disconnectChan,
+	}, false)
+	if err := server.Run(); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop(true)
+	client, err := daptest.NewClient(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	fixture := protest.BuildFixture(name, 0)
+	client.Call(t, "initialize", map[string]interface{}{"adapterID": "go"}, nil)
+	fn(client, fixture)
+
+	client.Call(t, "disconnect", nil, nil)
+	<-disconnectChan
+}
+
+func launch(t *testing.T, c *daptest.Client, fixture protest.Fixture, stopOnEntry bool) {
+	c.Call(t, "launch", dap.LaunchArguments{Program: fixture.Path, StopOnEntry: stopOnEntry}, nil)
+	c.ExpectEvent(t, "initialized", nil)
+}
+
+func setBreakpoints(t *testing.T, c *daptest.Client, fixture protest.Fixture, bps ...dap.SourceBreakpoint) []dap.Breakpoint {
+	var body dap.SetBreakpointsResponseBody
+	c.Call(t, "setBreakpoints", dap.SetBreakpointsArguments{Source: dap.Source{Path: fixture.Source}, Breakpoints: bps}, &body)
+	if len(body.Breakpoints) != len(bps) {
+		t.Fatalf("wrong number of breakpoints: %#v", body.Breakpoints)
+	}
+	return body.Breakpoints
+}
+
+func expectStopped(t *testing.T, c *daptest.Client, reason string) dap.StoppedEventBody {
+	var stopped dap.StoppedEventBody
+	c.ExpectEvent(t, "stopped", &stopped)
+	if stopped.Reason != reason {
+		t.Fatalf("expected stopped event with reason %q, got %#v", reason, stopped)
+	}
+	return stopped
+}
+
+func topFrame(t *testing.T, c *daptest.Client, goroutineID int) dap.StackFrame {
+	var body dap.StackTraceResponseBody
+	c.Call(t, "stackTrace", dap.StackTraceArguments{ThreadID: goroutineID, Levels: 1}, &body)
+	if len(body.StackFrames) != 1 {
+		t.Fatalf("wrong number of frames: %#v", body)
+	}
+	return body.StackFrames[0]
+}
+
+func variables(t *testing.T, c *daptest.Client, ref int) map[string]dap.Variable {
+	var body dap.VariablesResponseBody
+	c.Call(t, "variables", dap.VariablesArguments{VariablesReference: ref}, &body)
+	vars := map[string]dap.Variable{}
+	for _, v := range body.Variables {
+		vars[v.Name] = v
+	}
+	return vars
+}
+
+func TestLaunchStopOnEntry(t *testing.T) {
+	withDAPClient("increment", t, func(c *daptest.Client, fixture protest.Fixture) {
+		launch(t, c, fixture, true)
+		c.Call(t, "configurationDone", nil, nil)
+		expectStopped(t, c, "entry")
+
+		var threads dap.ThreadsResponseBody
+		c.Call(t, "threads", nil, &threads)
+		if len(threads.Threads) == 0 {
+			t.Fatalf("no threads")
+		}
+
+		c.Call(t, "continue", dap.ThreadArguments{ThreadID: threads.Threads[0].ID}, nil)
+		var exited dap.ExitedEventBody
+		c.ExpectEvent(t, "exited", &exited)
+		if exited.ExitCode != 0 {
+			t.Fatalf("wrong exit code %d", exited.ExitCode)
+		}
+		c.ExpectEvent(t, "terminated", nil)
+	})
+}
+
+func TestConditionalBreakpointAndStep(t *testing.T) {
+	withDAPClient("increment", t, func(c *daptest.Client, fixture protest.Fixture) {
+		launch(t, c, fixture, false)
+		bps := setBreakpoints(t, c, fixture, dap.SourceBreakpoint{Line: 7, Condition: "y == 1"})
+		if !bps[0].Verified || bps[0].Line != 7 {
+			t.Fatalf("breakpoint not set: %#v", bps[0])
+		}
+		c.Call(t, "configurationDone", nil, nil)
+		stopped := expectStopped(t, c, "breakpoint")
+
+		frame := topFrame(t, c, stopped.ThreadID)
+		if frame.Name != "main.Increment" || frame.Line != 7 {
+			t.Fatalf("wrong frame %#v", frame)
+		}
+		var scopes dap.ScopesResponseBody
+		c.Call(t, "scopes", dap.ScopesArguments{FrameID: frame.ID}, &scopes)
+		if len(scopes.Scopes) != 2 || scopes.Scopes[0].Name != "Arguments" {
+			t.Fatalf("wrong scopes %#v", scopes)
+		}
+		if y := variables(t, c, scopes.Scopes[0].VariablesReference)["y"]; y.Value != "1" || y.Type != "uint" {
+			t.Fatalf("wrong value of y: %#v", y)
+		}
+		var eval dap.EvaluateResponseBody
+		c.Call(t, "evaluate", dap.EvaluateArguments{Expression: "y * 2", FrameID: frame.ID}, &eval)
+		if eval.Result != "2" {
+			t.Fatalf("wrong result of y * 2: %#v", eval)
+		}
+
+		c.Call(t, "next", dap.ThreadArguments{ThreadID: stopped.ThreadID}, nil)
+		stopped = expectStopped(t, c, "step")
+		if frame := topFrame(t, c, stopped.ThreadID); frame.Line != 10 {
+			t.Fatalf("wrong line after next: %#v", frame)
+		}
+
+		// the breakpoint is replaced by one that is never hit
+		setBreakpoints(t, c, fixture)
+		c.Call(t, "continue", dap.ThreadArguments{ThreadID: stopped.ThreadID}, nil)
+		c.ExpectEvent(t, "exited", nil)
+		c.ExpectEvent(t, "terminated", nil)
+	})
+}
+
+func TestVariablesLazyLoading(t *testing.T) {
+	withDAPClient("testvariables2", t, func(c *daptest.Client, fixture protest.Fixture) {
+		launch(t, c, fixture, false)
+		c.Call(t, "configurationDone", nil, nil)
+		stopped := expectStopped(t, c, "pause")
+		c.Call(t, "stepOut", dap.ThreadArguments{ThreadID: stopped.ThreadID}, nil)
+		stopped = expectStopped(t, c, "step")
+		frame := topFrame(t, c, stopped.ThreadID)
+
+		var eval dap.EvaluateResponseBody
+		c.Call(t, "evaluate", dap.EvaluateArguments{Expression: "c1", FrameID: frame.ID}, &eval)
+		if eval.VariablesReference == 0 {
+			t.Fatalf("c1 has no children: %#v", eval)
+		}
+		// c1.pb.a is deeper than the maximum recursion depth used by the
+		// server, it is loaded when it is expanded.
+		pb := variables(t, c, eval.VariablesReference)["pb"]
+		bstruct := variables(t, c, pb.VariablesReference)["*pb"]
+		a := variables(t, c, bstruct.VariablesReference)["a"]
+		if a.VariablesReference == 0 {
+			t.Fatalf("c1.pb.a has no children: %#v", a)
+		}
+		fields := variables(t, c, a.VariablesReference)
+		if fields["A"].Value != "1" || fields["B"].Value != "2" || fields["A"].EvaluateName != "*(c1.pb).a.A" {
+			t.Fatalf("wrong fields of c1.pb.a: %#v", fields)
+		}
+
+		if err := c.Send("evaluate", dap.EvaluateArguments{Expression: "nonexistent", FrameID: frame.ID}); err != nil {
+			t.Fatal(err)
+		}
+		c.ExpectErrorResponse(t, "evaluate")
+	})
+}
+
+func TestLaunchPause(t *testing.T) {
+	withDAPClient("loopprog", t, func(c *daptest.Client, fixture protest.Fixture) {
+		launch(t, c, fixture, false)
+		c.Call(t, "configurationDone", nil, nil)
+
+		// threads are listed by halting the target, which keeps running
+		var threads dap.ThreadsResponseBody
+		c.Call(t, "threads", nil, &threads)
+		if len(threads.Threads) == 0 {
+			t.Fatalf("no threads")
+		}
+
+		c.Call(t, "pause", dap.ThreadArguments{ThreadID: threads.Threads[0].ID}, nil)
+		stopped := expectStopped(t, c, "pause")
+		if frame := topFrame(t, c, stopped.ThreadID); frame.Name == "" {
+			t.Fatalf("wrong frame after pause: %#v", frame)
+		}
+	})
+}
+
+func TestAttachSetBreakpointsWhileRunning(t *testing.T) {
+	if testBackend == "rr" {
+		t.Skip("can not attach to processes with the rr backend")
+	}
+	fixture := protest.BuildFixture("loopprog", 0)
+	cmd := exec.Command(fixture.Path)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	// give the fixture time to reach main.loop
+	time.Sleep(time.Second)
+
+	withDAPClient("loopprog", t, func(c *daptest.Client, _ protest.Fixture) {
+		c.Call(t, "attach", dap.AttachArguments{ProcessID: cmd.Process.Pid, Program: fixture.Path}, nil)
+		c.ExpectEvent(t, "initialized", nil)
+		c.Call(t, "configurationDone", nil, nil)
+
+		// the target is halted to set the breakpoint and resumed afterwards
+		bps := setBreakpoints(t, c, fixture, dap.SourceBreakpoint{Line: 8})
+		if !bps[0].Verified {
+			t.Fatalf("breakpoint not set: %#v", bps[0])
+		}
+		stopped := expectStopped(t, c, "breakpoint")
+		if frame := topFrame(t, c, stopped.ThreadID); frame.Name != "main.loop" || frame.Line != 8 {
+			t.Fatalf("wrong frame %#v", frame)
+		}
+	})
+}
+
+func TestLaunchCore(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" || testBackend != "native" {
+		t.Skip("core dumps are only supported on linux/amd64 with the native backend")
+	}
+	fixture := protest.BuildFixture("testvariables2", 0)
+	p, err := native.Launch([]string{fixture.Path}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proc.Continue(p); err != nil {
+		t.Fatal(err)
+	}
+	fh, err := ioutil.TempFile("", "dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fh.Name())
+	err = core.Dump(p, fh, func(core.DumpProgress) bool { return true })
+	fh.Close()
+	p.Detach(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withDAPClient("testvariables2", t, func(c *daptest.Client, fixture protest.Fixture) {
+		c.Call(t, "launch", dap.LaunchArguments{Mode: "core", Program: fixture.Path, CoreFilePath: fh.Name()}, nil)
+		c.ExpectEvent(t, "initialized", nil)
+		c.Call(t, "configurationDone", nil, nil)
+		stopped := expectStopped(t, c, "entry")
+
+		var body dap.StackTraceResponseBody
+		c.Call(t, "stackTrace", dap.StackTraceArguments{ThreadID: stopped.ThreadID}, &body)
+		var mainFrame *dap.StackFrame
+		for i := range body.StackFrames {
+			if body.StackFrames[i].Name == "main.main" {
+				mainFrame = &body.StackFrames[i]
+			}
+		}
+		if mainFrame == nil {
+			t.Fatalf("main.main not found in %#v", body.StackFrames)
+		}
+		var eval dap.EvaluateResponseBody
+		c.Call(t, "evaluate", dap.EvaluateArguments{Expression: "a1[1]", FrameID: mainFrame.ID}, &eval)
+		if eval.Result != `"two"` {
+			t.Fatalf("wrong value of a1[1]: %#v", eval)
+		}
+	})
+}
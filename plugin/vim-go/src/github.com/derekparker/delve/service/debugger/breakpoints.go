@@ -0,0 +1,120 @@
+package debugger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/derekparker/delve/pkg/proc"
+	"github.com/derekparker/delve/service/api"
+)
+
+var hitCondOperators = map[string]token.Token{
+	"==": token.EQL,
+	"!=": token.NEQ,
+	">":  token.GTR,
+	"<":  token.LSS,
+	">=": token.GEQ,
+	"<=": token.LEQ,
+	"%":  token.REM,
+}
+
+// parseHitCondition parses the hit condition of a breakpoint, an operator
+// followed by a number, see api.Breakpoint.HitCond.
+func parseHitCondition(hitCond string) (*proc.HitCondition, error) {
+	hitCond = strings.TrimSpace(hitCond)
+	opend := strings.IndexFunc(hitCond, func(r rune) bool { return !strings.ContainsRune("=!<>%", r) })
+	if opend < 0 {
+		opend = len(hitCond)
+	}
+	op, ok := hitCondOperators[hitCond[:opend]]
+	if !ok {
+		return nil, fmt.Errorf("invalid hit condition %q: unknown operator %q", hitCond, hitCond[:opend])
+	}
+	val, err := strconv.ParseUint(strings.TrimSpace(hitCond[opend:]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hit condition %q: argument must be a positive number", hitCond)
+	}
+	if op == token.REM && val == 0 {
+		return nil, fmt.Errorf("invalid hit condition %q: division by zero", hitCond)
+	}
+	return &proc.HitCondition{Op: op, Val: val}, nil
+}
+
+// logMessagePart is a piece of the log message of a breakpoint, either
+// literal text or an expression.
+type logMessagePart struct {
+	text string
+	expr bool
+}
+
+// parseLogMessage splits the log message of a breakpoint in literal text
+// and the expressions enclosed in curly braces, '{{' and '}}' are literal
+// braces.
+func parseLogMessage(msg string) ([]logMessagePart, error) {
+	var parts []logMessagePart
+	var buf bytes.Buffer
+	flush := func(expr bool) {
+		if buf.Len() > 0 || expr {
+			parts = append(parts, logMessagePart{text: buf.String(), expr: expr})
+		}
+		buf.Reset()
+	}
+	for i := 0; i < len(msg); i++ {
+		switch {
+		case strings.HasPrefix(msg[i:], "{{") || strings.HasPrefix(msg[i:], "}}"):
+			buf.WriteByte(msg[i])
+			i++
+		case msg[i] == '}':
+			return nil, fmt.Errorf("unmatched '}' at offset %d in log message", i)
+		case msg[i] == '{':
+			flush(false)
+			// braces can be nested inside expressions, for composite literals
+			depth := 1
+			start := i + 1
+			for i++; i < len(msg) && depth > 0; i++ {
+				switch msg[i] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+			}
+			if depth > 0 {
+				return nil, fmt.Errorf("unmatched '{' at offset %d in log message", start-1)
+			}
+			i--
+			buf.WriteString(strings.TrimSpace(msg[start:i]))
+			if buf.Len() == 0 {
+				return nil, errors.New("empty expression in log message")
+			}
+			flush(true)
+		default:
+			buf.WriteByte(msg[i])
+		}
+	}
+	flush(false)
+	return parts, nil
+}
+
+// formatLogMessage formats the log message parts, evaluating expressions
+// in scope.
+func formatLogMessage(scope *proc.EvalScope, parts []logMessagePart) string {
+	var buf bytes.Buffer
+	for _, part := range parts {
+		if !part.expr {
+			buf.WriteString(part.text)
+			continue
+		}
+		v, err := scope.EvalVariable(part.text, proc.LoadConfig{true, 1, 64, 64, -1})
+		if err != nil {
+			fmt.Fprintf(&buf, "<%s: %v>", part.text, err)
+			continue
+		}
+		buf.WriteString(api.ConvertVar(v).SinglelineString())
+	}
+	return buf.String()
+}
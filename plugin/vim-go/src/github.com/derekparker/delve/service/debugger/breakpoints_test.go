@@ -0,0 +1,81 @@
+package debugger
+
+import (
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/derekparker/delve/pkg/proc"
+)
+
+func TestParseHitCondition(t *testing.T) {
+	testcases := []struct {
+		in  string
+		out *proc.HitCondition
+	}{
+		{"> 100", &proc.HitCondition{Op: token.GTR, Val: 100}},
+		{">=5", &proc.HitCondition{Op: token.GEQ, Val: 5}},
+		{" == 3 ", &proc.HitCondition{Op: token.EQL, Val: 3}},
+		{"% 10", &proc.HitCondition{Op: token.REM, Val: 10}},
+		{"!= 1", &proc.HitCondition{Op: token.NEQ, Val: 1}},
+		{"=> 1", nil},
+		{"100", nil},
+		{"> x", nil},
+		{"> -1", nil},
+		{"% 0", nil},
+	}
+	for _, tc := range testcases {
+		hc, err := parseHitCondition(tc.in)
+		if tc.out == nil {
+			if err == nil {
+				t.Errorf("%q: expected error, got %v", tc.in, hc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		if *hc != *tc.out {
+			t.Errorf("%q: got %v, expected %v", tc.in, hc, tc.out)
+		}
+	}
+
+	hc := &proc.HitCondition{Op: token.REM, Val: 10}
+	for count, tgt := range map[uint64]bool{1: false, 10: true, 15: false, 20: true} {
+		if hc.Check(count) != tgt {
+			t.Errorf("%v on %d: expected %v", hc, count, tgt)
+		}
+	}
+}
+
+func TestParseLogMessage(t *testing.T) {
+	testcases := []struct {
+		in  string
+		out []logMessagePart
+	}{
+		{"hello", []logMessagePart{{"hello", false}}},
+		{"{x.ID} got {len(buf)}", []logMessagePart{{"x.ID", true}, {" got ", false}, {"len(buf)", true}}},
+		{"a {{b}} {c}", []logMessagePart{{"a {b} ", false}, {"c", true}}},
+		{"v={ []int{1, 2}[0] }.", []logMessagePart{{"v=", false}, {"[]int{1, 2}[0]", true}, {".", false}}},
+		{"{x", nil},
+		{"x}", nil},
+		{"{ }", nil},
+	}
+	for _, tc := range testcases {
+		parts, err := parseLogMessage(tc.in)
+		if tc.out == nil {
+			if err == nil {
+				t.Errorf("%q: expected error, got %v", tc.in, parts)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(parts, tc.out) {
+			t.Errorf("%q: got %#v, expected %#v", tc.in, parts, tc.out)
+		}
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"go/parser"
 	"log"
+	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -34,6 +36,21 @@ type Debugger struct {
 	// TODO(DO NOT MERGE WITHOUT) rename to targetMutex
 	processMutex sync.Mutex
 	target       proc.Process
+	// callPending is true if a function call started by the Call command
+	// has not returned yet, its return values are reported by the command
+	// that completes it.
+	callPending bool
+
+	// dumpMutex protects the state of the core dump in progress, which
+	// holds processMutex until it is done.
+	dumpMutex  sync.Mutex
+	dumpState  api.DumpState
+	dumpCancel bool
+	dumpDone   chan struct{}
+
+	// displayMutex protects displays, the expressions listed by Displays.
+	displayMutex sync.Mutex
+	displays     []api.DisplayExpr
 }
 
 // Config provides the configuration to start a Debugger.
@@ -54,6 +71,21 @@ type Config struct {
 	CoreFile string
 	// Backend specifies the debugger backend.
 	Backend string
+
+	// FollowExec makes the programs executed by the target, and by its
+	// child processes, new targets.
+	FollowExec bool
+	// FollowFork makes the child processes created by the target with fork
+	// new targets.
+	FollowFork bool
+
+	// PrettyPrinters are applied to the variables returned by
+	// PackageVariables, LocalVariables, FunctionArguments and
+	// EvalVariableInScope unless the raw view is requested.
+	PrettyPrinters []proc.PrettyPrinter
+
+	// Displays is the initial list of expressions returned by Displays.
+	Displays []api.DisplayExpr
 }
 
 // New creates a new Debugger. ProcessArgs specify the commandline arguments for the
@@ -62,6 +94,11 @@ func New(config *Config, processArgs []string) (*Debugger, error) {
 	d := &Debugger{
 		config:      config,
 		processArgs: processArgs,
+		displays:    config.Displays,
+	}
+
+	if (config.FollowExec || config.FollowFork) && (config.Backend == "lldb" || config.Backend == "rr" || config.CoreFile != "") {
+		return nil, errors.New("following child processes is only supported by the native backend")
 	}
 
 	// Create the process by either attaching or launching.
@@ -111,7 +148,7 @@ func New(config *Config, processArgs []string) (*Debugger, error) {
 func (d *Debugger) Launch(processArgs []string, wd string) (proc.Process, error) {
 	switch d.config.Backend {
 	case "native":
-		return native.Launch(processArgs, wd)
+		return d.followChildren(native.Launch(processArgs, wd))
 	case "lldb":
 		return betterGdbserialLaunchError(gdbserial.LLDBLaunch(processArgs, wd))
 	case "rr":
@@ -121,7 +158,7 @@ func (d *Debugger) Launch(processArgs []string, wd string) (proc.Process, error)
 		if runtime.GOOS == "darwin" {
 			return betterGdbserialLaunchError(gdbserial.LLDBLaunch(processArgs, wd))
 		}
-		return native.Launch(processArgs, wd)
+		return d.followChildren(native.Launch(processArgs, wd))
 	default:
 		return nil, fmt.Errorf("unknown backend %q", d.config.Backend)
 	}
@@ -135,19 +172,43 @@ var ErrNoAttachPath = errors.New("must specify executable path on macOS")
 func (d *Debugger) Attach(pid int, path string) (proc.Process, error) {
 	switch d.config.Backend {
 	case "native":
-		return native.Attach(pid)
+		return d.followChildren(native.Attach(pid))
 	case "lldb":
 		return betterGdbserialLaunchError(gdbserial.LLDBAttach(pid, path))
 	case "default":
 		if runtime.GOOS == "darwin" {
 			return betterGdbserialLaunchError(gdbserial.LLDBAttach(pid, path))
 		}
-		return native.Attach(pid)
+		return d.followChildren(native.Attach(pid))
 	default:
 		return nil, fmt.Errorf("unknown backend %q", d.config.Backend)
 	}
 }
 
+// followChildren makes p a target group if the configuration asks to
+// follow its child processes.
+func (d *Debugger) followChildren(p *native.Process, err error) (proc.Process, error) {
+	if err != nil {
+		return nil, err
+	}
+	if !d.config.FollowExec && !d.config.FollowFork {
+		return p, nil
+	}
+	var mode native.FollowMode
+	if d.config.FollowExec {
+		mode |= native.FollowExec
+	}
+	if d.config.FollowFork {
+		mode |= native.FollowFork
+	}
+	g, err := native.NewTargetGroup(p, mode)
+	if err != nil {
+		p.Detach(d.config.AttachPid == 0)
+		return nil, err
+	}
+	return g, nil
+}
+
 var macOSBackendUnavailableErr = errors.New("debugserver or lldb-server not found: install XCode's command line tools or lldb-server")
 
 func betterGdbserialLaunchError(p proc.Process, err error) (proc.Process, error) {
@@ -228,6 +289,11 @@ func (d *Debugger) Restart(pos string, resetArgs bool, newArgs []string) ([]api.
 		if oldBp.ID < 0 {
 			continue
 		}
+		if oldBp.WatchExpr != "" {
+			// The watched memory belongs to the old process.
+			discarded = append(discarded, api.DiscardedBreakpoint{oldBp, "watchpoints are not preserved across restarts"})
+			continue
+		}
 		if len(oldBp.File) > 0 {
 			var err error
 			oldBp.Addr, err = proc.FindFileLocation(p, oldBp.File, oldBp.Line)
@@ -245,6 +311,7 @@ func (d *Debugger) Restart(pos string, resetArgs bool, newArgs []string) ([]api.
 		}
 	}
 	d.target = p
+	d.callPending = false
 	return discarded, nil
 }
 
@@ -288,6 +355,10 @@ func (d *Debugger) state() (*api.DebuggerState, error) {
 		state.When, _ = d.target.When()
 	}
 
+	for _, bp := range d.target.Breakpoints().WatchOutOfScope {
+		state.WatchOutOfScope = append(state.WatchOutOfScope, api.ConvertBreakpoint(bp))
+	}
+
 	return state, nil
 }
 
@@ -343,17 +414,55 @@ func (d *Debugger) CreateBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoin
 	if err != nil {
 		return nil, err
 	}
+	bp.LocExpr = requestedBp.LocExpr
 	if err := copyBreakpointInfo(bp, requestedBp); err != nil {
 		if _, err1 := d.target.ClearBreakpoint(bp.Addr); err1 != nil {
 			err = fmt.Errorf("error while creating breakpoint: %v, additionally the breakpoint could not be properly rolled back: %v", err, err1)
 		}
 		return nil, err
 	}
+	if err := d.syncBreakpoint(bp); err != nil {
+		return nil, err
+	}
 	createdBp = api.ConvertBreakpoint(bp)
 	log.Printf("created breakpoint: %#v", createdBp)
 	return createdBp, nil
 }
 
+// CreateWatchpoint creates a watchpoint on the memory of
+// requestedBp.WatchExpr, evaluated in scope.
+func (d *Debugger) CreateWatchpoint(scope api.EvalScope, requestedBp *api.Breakpoint) (*api.Breakpoint, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if requestedBp.Name != "" {
+		if err := api.ValidBreakpointName(requestedBp.Name); err != nil {
+			return nil, err
+		}
+		if d.findBreakpointByName(requestedBp.Name) != nil {
+			return nil, errors.New("breakpoint name already exists")
+		}
+	}
+
+	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame, scope.DeferredCall)
+	if err != nil {
+		return nil, err
+	}
+	bp, err := proc.Watch(d.target, s, requestedBp.WatchExpr, proc.WatchType(requestedBp.WatchType), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := copyBreakpointInfo(bp, requestedBp); err != nil {
+		if _, err1 := d.target.ClearBreakpoint(bp.Addr); err1 != nil {
+			err = fmt.Errorf("error while creating watchpoint: %v, additionally the watchpoint could not be properly rolled back: %v", err, err1)
+		}
+		return nil, err
+	}
+	createdBp := api.ConvertBreakpoint(bp)
+	log.Printf("created watchpoint: %#v", createdBp)
+	return createdBp, nil
+}
+
 func (d *Debugger) AmendBreakpoint(amend *api.Breakpoint) error {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
@@ -365,7 +474,19 @@ func (d *Debugger) AmendBreakpoint(amend *api.Breakpoint) error {
 	if err := api.ValidBreakpointName(amend.Name); err != nil {
 		return err
 	}
-	return copyBreakpointInfo(original, amend)
+	if err := copyBreakpointInfo(original, amend); err != nil {
+		return err
+	}
+	return d.syncBreakpoint(original)
+}
+
+// syncBreakpoint applies the changes made to bp to the other targets, when
+// following child processes.
+func (d *Debugger) syncBreakpoint(bp *proc.Breakpoint) error {
+	if g, ok := d.target.(proc.TargetGroup); ok {
+		return g.SyncBreakpoint(bp)
+	}
+	return nil
 }
 
 func (d *Debugger) CancelNext() error {
@@ -375,6 +496,7 @@ func (d *Debugger) CancelNext() error {
 func copyBreakpointInfo(bp *proc.Breakpoint, requested *api.Breakpoint) (err error) {
 	bp.Name = requested.Name
 	bp.Tracepoint = requested.Tracepoint
+	bp.TraceReturn = requested.TraceReturn
 	bp.Goroutine = requested.Goroutine
 	bp.Stacktrace = requested.Stacktrace
 	bp.Variables = requested.Variables
@@ -383,8 +505,22 @@ func copyBreakpointInfo(bp *proc.Breakpoint, requested *api.Breakpoint) (err err
 	bp.Cond = nil
 	if requested.Cond != "" {
 		bp.Cond, err = parser.ParseExpr(requested.Cond)
+		if err != nil {
+			return err
+		}
+	}
+	bp.HitCond = nil
+	if requested.HitCond != "" {
+		bp.HitCond, err = parseHitCondition(requested.HitCond)
+		if err != nil {
+			return err
+		}
 	}
-	return err
+	if _, err := parseLogMessage(requested.LogMessage); err != nil {
+		return err
+	}
+	bp.LogMessage = requested.LogMessage
+	return nil
 }
 
 // ClearBreakpoint clears a breakpoint.
@@ -397,6 +533,9 @@ func (d *Debugger) ClearBreakpoint(requestedBp *api.Breakpoint) (*api.Breakpoint
 	if err != nil {
 		return nil, fmt.Errorf("Can't clear breakpoint @%x: %s", requestedBp.Addr, err)
 	}
+	if err := d.syncBreakpoint(bp); err != nil {
+		return nil, err
+	}
 	clearedBp = api.ConvertBreakpoint(bp)
 	log.Printf("cleared breakpoint: %#v", clearedBp)
 	return clearedBp, err
@@ -472,6 +611,30 @@ func (d *Debugger) Threads() ([]*api.Thread, error) {
 	return threads, nil
 }
 
+// Targets returns the processes being debugged.
+func (d *Debugger) Targets() ([]api.Target, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if d.target.Exited() {
+		return nil, proc.ProcessExitedError{Pid: d.ProcessPid()}
+	}
+
+	targets := []proc.Process{d.target}
+	if g, ok := d.target.(proc.TargetGroup); ok {
+		targets = g.Targets()
+	}
+	r := make([]api.Target, 0, len(targets))
+	for _, p := range targets {
+		r = append(r, api.Target{
+			Pid:           p.Pid(),
+			Current:       p.Pid() == d.target.Pid(),
+			CurrentThread: api.ConvertThread(p.CurrentThread()),
+		})
+	}
+	return r, nil
+}
+
 // FindThread returns the thread for the given 'id'.
 func (d *Debugger) FindThread(id int) (*api.Thread, error) {
 	d.processMutex.Lock()
@@ -530,6 +693,30 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 	case api.StepOut:
 		log.Print("step out")
 		err = proc.StepOut(d.target)
+	case api.ReverseNext, api.ReverseStep, api.ReverseStepOut, api.ReverseStepInstruction:
+		log.Printf("reverse %s", command.Name)
+		if err := d.target.Direction(proc.Backward); err != nil {
+			return nil, err
+		}
+		switch command.Name {
+		case api.ReverseNext:
+			err = proc.Next(d.target)
+		case api.ReverseStep:
+			err = proc.Step(d.target)
+		case api.ReverseStepOut:
+			err = proc.StepOut(d.target)
+		case api.ReverseStepInstruction:
+			err = d.target.StepInstruction()
+		}
+		// A breakpoint hit in the middle of a reverse next leaves internal
+		// breakpoints behind, they must be cleared before changing direction
+		// again, so the operation can not be resumed.
+		if clearErr := d.target.ClearInternalBreakpoints(); clearErr != nil && err == nil {
+			err = clearErr
+		}
+		if dirErr := d.target.Direction(proc.Forward); dirErr != nil && err == nil {
+			err = dirErr
+		}
 	case api.SwitchThread:
 		log.Printf("switching to thread %d", command.ThreadID)
 		err = d.target.SwitchThread(command.ThreadID)
@@ -538,13 +725,26 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 		log.Printf("switching to goroutine %d", command.GoroutineID)
 		err = d.target.SwitchGoroutine(command.GoroutineID)
 		withBreakpointInfo = false
+	case api.SwitchTarget:
+		log.Printf("switching to target %d", command.TargetPid)
+		if g, ok := d.target.(proc.TargetGroup); ok {
+			err = g.SwitchTarget(command.TargetPid)
+		} else if command.TargetPid != d.target.Pid() {
+			err = fmt.Errorf("target %d does not exist", command.TargetPid)
+		}
+		withBreakpointInfo = false
 	case api.Halt:
 		// RequestManualStop already called
 		withBreakpointInfo = false
+	case api.Call:
+		log.Printf("calling %s", command.Expr)
+		err = proc.CallFunction(d.target, command.Expr, api.LoadConfigToProc(command.ReturnInfoLoadConfig))
+		d.callPending = err == nil
 	}
 
 	if err != nil {
-		if exitedErr, exited := err.(proc.ProcessExitedError); command.Name != api.SwitchGoroutine && command.Name != api.SwitchThread && exited {
+		if exitedErr, exited := err.(proc.ProcessExitedError); command.Name != api.SwitchGoroutine && command.Name != api.SwitchThread && command.Name != api.SwitchTarget && exited {
+			d.callPending = false
 			state := &api.DebuggerState{}
 			state.Exited = true
 			state.ExitStatus = exitedErr.Status
@@ -557,6 +757,16 @@ func (d *Debugger) Command(command *api.DebuggerCommand) (*api.DebuggerState, er
 	if stateErr != nil {
 		return state, stateErr
 	}
+	if caller, ok := d.target.(proc.FunctionCaller); ok && d.callPending && !caller.FunctionCallState().InProgress() {
+		d.callPending = false
+		fncall := caller.FunctionCallState()
+		if fncall.Err() != nil {
+			return nil, fncall.Err()
+		}
+		if state.CurrentThread != nil {
+			state.CurrentThread.ReturnValues = convertVars(fncall.ReturnValues())
+		}
+	}
 	if withBreakpointInfo {
 		err = d.collectBreakpointInformation(state)
 	}
@@ -601,7 +811,12 @@ func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error
 			return fmt.Errorf("could not find thread %d", state.Threads[i].ID)
 		}
 
-		if len(bp.Variables) == 0 && bp.LoadArgs == nil && bp.LoadLocals == nil {
+		if bpstate := thread.Breakpoint(); bpstate.WatchNewValue != nil {
+			bpi.WatchOldValue = api.ConvertVar(bpstate.WatchOldValue)
+			bpi.WatchNewValue = api.ConvertVar(bpstate.WatchNewValue)
+		}
+
+		if len(bp.Variables) == 0 && bp.LoadArgs == nil && bp.LoadLocals == nil && bp.LogMessage == "" {
 			// don't try to create goroutine scope if there is nothing to load
 			continue
 		}
@@ -632,6 +847,11 @@ func (d *Debugger) collectBreakpointInformation(state *api.DebuggerState) error
 				bpi.Locals = convertVars(locals)
 			}
 		}
+		if bp.LogMessage != "" {
+			// the message was validated when the breakpoint was created
+			parts, _ := parseLogMessage(bp.LogMessage)
+			bpi.LogMessage = formatLogMessage(s, parts)
+		}
 	}
 
 	return nil
@@ -664,6 +884,15 @@ func (d *Debugger) Functions(filter string) ([]string, error) {
 	return regexFilterFuncs(filter, d.target.BinInfo().Functions)
 }
 
+// FunctionReturnLocations returns the addresses of the return
+// instructions of function fnName.
+func (d *Debugger) FunctionReturnLocations(fnName string) ([]uint64, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	return proc.FunctionReturnLocations(d.target, fnName)
+}
+
 func (d *Debugger) Types(filter string) ([]string, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
@@ -705,7 +934,8 @@ func regexFilterFuncs(filter string, allFuncs []proc.Function) ([]string, error)
 
 // PackageVariables returns a list of package variables for the thread,
 // optionally regexp filtered using regexp described in 'filter'.
-func (d *Debugger) PackageVariables(threadID int, filter string, cfg proc.LoadConfig) ([]api.Variable, error) {
+// If raw is set the pretty printers are not applied.
+func (d *Debugger) PackageVariables(threadID int, filter string, cfg proc.LoadConfig, raw bool) ([]api.Variable, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
@@ -729,6 +959,7 @@ func (d *Debugger) PackageVariables(threadID int, filter string, cfg proc.LoadCo
 	}
 	for _, v := range pv {
 		if regex.Match([]byte(v.Name)) {
+			d.prettyPrint([]*proc.Variable{v}, cfg, raw)
 			vars = append(vars, *api.ConvertVar(v))
 		}
 	}
@@ -751,6 +982,15 @@ func (d *Debugger) Registers(threadID int, floatingPoint bool) (api.Registers, e
 	return api.ConvertRegisters(regs.Slice()), err
 }
 
+// prettyPrint applies the configured pretty printers to pv, unless raw is
+// set.
+func (d *Debugger) prettyPrint(pv []*proc.Variable, cfg proc.LoadConfig, raw bool) {
+	if raw {
+		return
+	}
+	proc.PrettyPrint(pv, d.config.PrettyPrinters, cfg)
+}
+
 func convertVars(pv []*proc.Variable) []api.Variable {
 	vars := make([]api.Variable, 0, len(pv))
 	for _, v := range pv {
@@ -759,12 +999,143 @@ func convertVars(pv []*proc.Variable) []api.Variable {
 	return vars
 }
 
+// SetDisplays replaces the list of expressions returned by Displays.
+func (d *Debugger) SetDisplays(displays []api.DisplayExpr) {
+	d.displayMutex.Lock()
+	defer d.displayMutex.Unlock()
+	d.displays = displays
+}
+
+// Displays evaluates the expressions set with SetDisplays in scope, using
+// cfg unless the expression overrides it. The list survives restarts.
+func (d *Debugger) Displays(scope api.EvalScope, cfg proc.LoadConfig) []api.Display {
+	d.displayMutex.Lock()
+	displays := d.displays
+	d.displayMutex.Unlock()
+
+	r := make([]api.Display, 0, len(displays))
+	for _, expr := range displays {
+		cfg := cfg
+		if expr.MaxStringLen != nil {
+			cfg.MaxStringLen = *expr.MaxStringLen
+		}
+		if expr.MaxArrayValues != nil {
+			cfg.MaxArrayValues = *expr.MaxArrayValues
+		}
+		display := api.Display{DisplayExpr: expr}
+		v, err := d.EvalVariableInScope(scope, expr.Expr, cfg, false)
+		if err != nil {
+			display.Err = err.Error()
+		} else {
+			display.Value = v
+		}
+		r = append(r, display)
+	}
+	return r
+}
+
+// ExamineMemory returns length bytes of the target's memory starting at
+// address.
+func (d *Debugger) ExamineMemory(address uintptr, length int) ([]byte, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	var mem proc.MemoryReader = d.target.CurrentThread()
+	data := make([]byte, length)
+	n, err := mem.ReadMemory(data, address)
+	if err != nil {
+		return nil, err
+	}
+	if n != length {
+		return nil, fmt.Errorf("could only read %d of %d bytes at %#x", n, length, address)
+	}
+	return data, nil
+}
+
+// DumpStart starts writing a core dump of the target to dest, the target
+// is locked until the dump is done. Use DumpWait to follow its progress.
+func (d *Debugger) DumpStart(dest string) error {
+	d.processMutex.Lock()
+	d.dumpMutex.Lock()
+	defer d.dumpMutex.Unlock()
+
+	if _, ok := d.target.(proc.MemoryMapper); !ok {
+		d.processMutex.Unlock()
+		return core.ErrDumpNotSupported
+	}
+	fh, err := os.Create(dest)
+	if err != nil {
+		d.processMutex.Unlock()
+		return err
+	}
+	d.dumpState = api.DumpState{Dumping: true}
+	d.dumpCancel = false
+	d.dumpDone = make(chan struct{})
+
+	go func() {
+		defer d.processMutex.Unlock()
+		err := core.Dump(d.target, fh, func(progress core.DumpProgress) bool {
+			d.dumpMutex.Lock()
+			defer d.dumpMutex.Unlock()
+			d.dumpState.ThreadsDone, d.dumpState.ThreadsTotal = progress.ThreadsDone, progress.ThreadsTotal
+			d.dumpState.MemDone, d.dumpState.MemTotal = progress.MemDone, progress.MemTotal
+			return !d.dumpCancel
+		})
+		if cerr := fh.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(dest)
+		}
+
+		d.dumpMutex.Lock()
+		defer d.dumpMutex.Unlock()
+		d.dumpState.Dumping = false
+		if err != nil {
+			d.dumpState.Err = err.Error()
+		} else {
+			d.dumpState.AllDone = true
+		}
+		close(d.dumpDone)
+	}()
+	return nil
+}
+
+// DumpWait waits at most wait for the core dump in progress to finish and
+// returns its state.
+func (d *Debugger) DumpWait(wait time.Duration) api.DumpState {
+	d.dumpMutex.Lock()
+	done := d.dumpDone
+	d.dumpMutex.Unlock()
+
+	if done != nil && wait > 0 {
+		select {
+		case <-done:
+		case <-time.After(wait):
+		}
+	}
+
+	d.dumpMutex.Lock()
+	defer d.dumpMutex.Unlock()
+	return d.dumpState
+}
+
+// DumpCancel stops the core dump in progress, the partially written file is
+// removed.
+func (d *Debugger) DumpCancel() error {
+	d.dumpMutex.Lock()
+	defer d.dumpMutex.Unlock()
+	d.dumpCancel = true
+	return nil
+}
+
 // LocalVariables returns a list of the local variables.
-func (d *Debugger) LocalVariables(scope api.EvalScope, cfg proc.LoadConfig) ([]api.Variable, error) {
+// If raw is set the pretty printers are not applied.
+func (d *Debugger) LocalVariables(scope api.EvalScope, cfg proc.LoadConfig, raw bool) ([]api.Variable, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame)
+	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame, scope.DeferredCall)
 	if err != nil {
 		return nil, err
 	}
@@ -772,15 +1143,17 @@ func (d *Debugger) LocalVariables(scope api.EvalScope, cfg proc.LoadConfig) ([]a
 	if err != nil {
 		return nil, err
 	}
+	d.prettyPrint(pv, cfg, raw)
 	return convertVars(pv), err
 }
 
 // FunctionArguments returns the arguments to the current function.
-func (d *Debugger) FunctionArguments(scope api.EvalScope, cfg proc.LoadConfig) ([]api.Variable, error) {
+// If raw is set the pretty printers are not applied.
+func (d *Debugger) FunctionArguments(scope api.EvalScope, cfg proc.LoadConfig, raw bool) ([]api.Variable, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame)
+	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame, scope.DeferredCall)
 	if err != nil {
 		return nil, err
 	}
@@ -788,16 +1161,18 @@ func (d *Debugger) FunctionArguments(scope api.EvalScope, cfg proc.LoadConfig) (
 	if err != nil {
 		return nil, err
 	}
+	d.prettyPrint(pv, cfg, raw)
 	return convertVars(pv), nil
 }
 
 // EvalVariableInScope will attempt to evaluate the variable represented by 'symbol'
 // in the scope provided.
-func (d *Debugger) EvalVariableInScope(scope api.EvalScope, symbol string, cfg proc.LoadConfig) (*api.Variable, error) {
+// If raw is set the pretty printers are not applied.
+func (d *Debugger) EvalVariableInScope(scope api.EvalScope, symbol string, cfg proc.LoadConfig, raw bool) (*api.Variable, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame)
+	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame, scope.DeferredCall)
 	if err != nil {
 		return nil, err
 	}
@@ -805,6 +1180,7 @@ func (d *Debugger) EvalVariableInScope(scope api.EvalScope, symbol string, cfg p
 	if err != nil {
 		return nil, err
 	}
+	d.prettyPrint([]*proc.Variable{v}, cfg, raw)
 	return api.ConvertVar(v), err
 }
 
@@ -814,7 +1190,7 @@ func (d *Debugger) SetVariableInScope(scope api.EvalScope, symbol, value string)
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
-	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame)
+	s, err := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame, scope.DeferredCall)
 	if err != nil {
 		return err
 	}
@@ -837,10 +1213,63 @@ func (d *Debugger) Goroutines() ([]*api.Goroutine, error) {
 	return goroutines, err
 }
 
+// ListGoroutines returns the goroutines matching all filters, sorted by
+// ID, between start and start+count; a count of 0 returns all of them.
+// If group.GroupBy is set goroutines are grouped by that field, start and
+// count select groups instead, and the goroutines returned are the members
+// of each group, at most group.MaxGroupMembers of them.
+// The last return value is the start of the next page, or -1.
+func (d *Debugger) ListGoroutines(start, count int, filters []api.ListGoroutinesFilter, group api.GoroutineGroupingOptions) ([]*api.Goroutine, []api.GoroutineGroup, int, error) {
+	d.processMutex.Lock()
+	defer d.processMutex.Unlock()
+
+	if start < 0 {
+		return nil, nil, -1, fmt.Errorf("invalid start %d", start)
+	}
+	pgs, err := proc.GoroutinesInfo(d.target)
+	if err != nil {
+		return nil, nil, -1, err
+	}
+	pgs = append([]*proc.G(nil), pgs...)
+	sort.Slice(pgs, func(i, j int) bool { return pgs[i].ID < pgs[j].ID })
+
+	gs := make([]*api.Goroutine, len(pgs))
+	for i, g := range pgs {
+		gs[i] = api.ConvertGoroutine(g)
+	}
+	gs, err = filterGoroutines(gs, filters)
+	if err != nil {
+		return nil, nil, -1, err
+	}
+
+	var stacks map[int]string
+	if group.GroupBy == api.GoroutineStack {
+		selected := make(map[int]bool, len(gs))
+		for _, g := range gs {
+			selected[g.ID] = true
+		}
+		stacks = make(map[int]string, len(gs))
+		for _, g := range pgs {
+			if selected[g.ID] {
+				stacks[g.ID] = goroutineStack(g)
+			}
+		}
+	}
+	var key func(*api.Goroutine) string
+	if group.GroupBy != api.GoroutineFieldNone {
+		key, err = goroutineGroupKey(group.GroupBy, stacks)
+		if err != nil {
+			return nil, nil, -1, err
+		}
+	}
+	gs, groups, nextg := pageGoroutines(gs, start, count, group, key)
+	return gs, groups, nextg, nil
+}
+
 // Stacktrace returns a list of Stackframes for the given goroutine. The
 // length of the returned list will be min(stack_len, depth).
 // If 'full' is true, then local vars, function args, etc will be returned as well.
-func (d *Debugger) Stacktrace(goroutineID, depth int, cfg *proc.LoadConfig) ([]api.Stackframe, error) {
+func (d *Debugger) Stacktrace(goroutineID, depth int, readDefers bool, cfg *proc.LoadConfig) ([]api.Stackframe, error) {
 	d.processMutex.Lock()
 	defer d.processMutex.Unlock()
 
@@ -855,9 +1284,12 @@ func (d *Debugger) Stacktrace(goroutineID, depth int, cfg *proc.LoadConfig) ([]a
 		return nil, err
 	}
 
-	if g == nil {
+	switch {
+	case g == nil:
 		rawlocs, err = proc.ThreadStacktrace(d.target.CurrentThread(), depth)
-	} else {
+	case readDefers:
+		rawlocs, err = g.StacktraceDefers(depth)
+	default:
 		rawlocs, err = g.Stacktrace(depth)
 	}
 	if err != nil {
@@ -894,12 +1326,34 @@ func (d *Debugger) convertStacktrace(rawlocs []proc.Stackframe, cfg *proc.LoadCo
 			frame.Locals = convertVars(locals)
 			frame.Arguments = convertVars(arguments)
 		}
+		if len(rawlocs[i].Defers) > 0 {
+			frame.Defers = d.convertDefers(rawlocs[i].Defers)
+		}
 		locations = append(locations, frame)
 	}
 
 	return locations, nil
 }
 
+func (d *Debugger) convertDefers(defers []*proc.Defer) []api.Defer {
+	r := make([]api.Defer, len(defers))
+	for i, d2 := range defers {
+		if d2.Unreadable != nil {
+			r[i].Unreadable = d2.Unreadable.Error()
+			continue
+		}
+		r[i].SP = d2.SP
+		r[i].DeferredLoc = d.pcToLocation(d2.DeferredPC)
+		r[i].DeferLoc = d.pcToLocation(d2.DeferPC)
+	}
+	return r
+}
+
+func (d *Debugger) pcToLocation(pc uint64) api.Location {
+	file, line, fn := d.target.BinInfo().PCToLine(pc)
+	return api.Location{PC: pc, File: file, Line: line, Function: api.ConvertFunction(fn)}
+}
+
 // FindLocation will find the location specified by 'locStr'.
 func (d *Debugger) FindLocation(scope api.EvalScope, locStr string) ([]api.Location, error) {
 	d.processMutex.Lock()
@@ -914,7 +1368,7 @@ func (d *Debugger) FindLocation(scope api.EvalScope, locStr string) ([]api.Locat
 		return nil, err
 	}
 
-	s, _ := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame)
+	s, _ := proc.ConvertEvalScope(d.target, scope.GoroutineID, scope.Frame, scope.DeferredCall)
 
 	locs, err := loc.Find(d, s, locStr)
 	for i := range locs {
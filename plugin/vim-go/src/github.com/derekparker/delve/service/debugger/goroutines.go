@@ -0,0 +1,213 @@
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/derekparker/delve/pkg/proc"
+	"github.com/derekparker/delve/service/api"
+)
+
+// groupStackDepth is the maximum depth of the stacks compared when
+// grouping goroutines by GoroutineStack.
+const groupStackDepth = 50
+
+// goroutineFilter is a compiled api.ListGoroutinesFilter.
+type goroutineFilter struct {
+	api.ListGoroutinesFilter
+	re *regexp.Regexp
+}
+
+func compileGoroutineFilters(filters []api.ListGoroutinesFilter) ([]goroutineFilter, error) {
+	r := make([]goroutineFilter, 0, len(filters))
+	for _, filter := range filters {
+		f := goroutineFilter{ListGoroutinesFilter: filter}
+		switch filter.Kind {
+		case api.GoroutineCurrentLoc, api.GoroutineUserLoc, api.GoroutineGoLoc, api.GoroutineWaitReason, api.GoroutineStatus:
+			// nothing to compile
+		case api.GoroutineFunction:
+			re, err := regexp.Compile(filter.Arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid function filter %q: %v", filter.Arg, err)
+			}
+			f.re = re
+		default:
+			return nil, fmt.Errorf("can not filter goroutines on field %d", filter.Kind)
+		}
+		r = append(r, f)
+	}
+	return r, nil
+}
+
+func (f *goroutineFilter) match(g *api.Goroutine) bool {
+	var r bool
+	switch f.Kind {
+	case api.GoroutineCurrentLoc:
+		r = locationContains(g.CurrentLoc, f.Arg)
+	case api.GoroutineUserLoc:
+		r = locationContains(g.UserCurrentLoc, f.Arg)
+	case api.GoroutineGoLoc:
+		r = locationContains(g.GoStatementLoc, f.Arg)
+	case api.GoroutineFunction:
+		r = g.UserCurrentLoc.Function != nil && f.re.MatchString(g.UserCurrentLoc.Function.Name)
+	case api.GoroutineWaitReason:
+		r = strings.Contains(g.WaitReason, f.Arg)
+	case api.GoroutineStatus:
+		r = api.GoroutineStatusName(g.Status) == f.Arg
+	}
+	return r != f.Negated
+}
+
+func locationContains(loc api.Location, s string) bool {
+	if loc.Function != nil && strings.Contains(loc.Function.Name, s) {
+		return true
+	}
+	return strings.Contains(fmt.Sprintf("%s:%d", loc.File, loc.Line), s)
+}
+
+// filterGoroutines returns the goroutines in gs that match all filters.
+func filterGoroutines(gs []*api.Goroutine, filters []api.ListGoroutinesFilter) ([]*api.Goroutine, error) {
+	if len(filters) == 0 {
+		return gs, nil
+	}
+	fs, err := compileGoroutineFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+	r := make([]*api.Goroutine, 0, len(gs))
+	for _, g := range gs {
+		matched := true
+		for i := range fs {
+			if !fs[i].match(g) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			r = append(r, g)
+		}
+	}
+	return r, nil
+}
+
+// goroutineGroupKey returns a function computing the name of the group of
+// a goroutine when grouping by field. Stacks, which are not part of
+// api.Goroutine, are looked up in stacks.
+func goroutineGroupKey(field api.GoroutineField, stacks map[int]string) (func(*api.Goroutine) string, error) {
+	formatLoc := func(loc api.Location) string {
+		fn := "?"
+		if loc.Function != nil {
+			fn = loc.Function.Name
+		}
+		return fmt.Sprintf("%s %s:%d", fn, loc.File, loc.Line)
+	}
+	switch field {
+	case api.GoroutineCurrentLoc:
+		return func(g *api.Goroutine) string { return formatLoc(g.CurrentLoc) }, nil
+	case api.GoroutineUserLoc:
+		return func(g *api.Goroutine) string { return formatLoc(g.UserCurrentLoc) }, nil
+	case api.GoroutineGoLoc:
+		return func(g *api.Goroutine) string { return formatLoc(g.GoStatementLoc) }, nil
+	case api.GoroutineFunction:
+		return func(g *api.Goroutine) string {
+			if g.UserCurrentLoc.Function == nil {
+				return "?"
+			}
+			return g.UserCurrentLoc.Function.Name
+		}, nil
+	case api.GoroutineWaitReason:
+		return func(g *api.Goroutine) string { return g.WaitReason }, nil
+	case api.GoroutineStatus:
+		return func(g *api.Goroutine) string { return api.GoroutineStatusName(g.Status) }, nil
+	case api.GoroutineStack:
+		return func(g *api.Goroutine) string { return stacks[g.ID] }, nil
+	default:
+		return nil, fmt.Errorf("can not group goroutines by field %d", field)
+	}
+}
+
+// groupGoroutines groups gs by the name returned by key. Groups are sorted
+// by decreasing size, goroutines keep their order within each group.
+func groupGoroutines(gs []*api.Goroutine, key func(*api.Goroutine) string) (names []string, members map[string][]*api.Goroutine) {
+	members = map[string][]*api.Goroutine{}
+	for _, g := range gs {
+		name := key(g)
+		if _, ok := members[name]; !ok {
+			names = append(names, name)
+		}
+		members[name] = append(members[name], g)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return len(members[names[i]]) > len(members[names[j]])
+	})
+	return names, members
+}
+
+// pageGoroutines returns the goroutines, or groups of goroutines, of gs
+// between start and start+count, and the start of the next page or -1, see
+// Debugger.ListGoroutines.
+func pageGoroutines(gs []*api.Goroutine, start, count int, group api.GoroutineGroupingOptions, key func(*api.Goroutine) string) ([]*api.Goroutine, []api.GoroutineGroup, int) {
+	if key == nil {
+		start, end, nextg := pageBounds(len(gs), start, count)
+		return gs[start:end], nil, nextg
+	}
+	names, members := groupGoroutines(gs, key)
+	start, end, nextg := pageBounds(len(names), start, count)
+	r := []*api.Goroutine{}
+	groups := make([]api.GoroutineGroup, 0, end-start)
+	for _, name := range names[start:end] {
+		ms := members[name]
+		grp := api.GoroutineGroup{Name: name, Offset: len(r), Count: len(ms), Total: len(ms)}
+		if group.MaxGroupMembers > 0 && grp.Count > group.MaxGroupMembers {
+			grp.Count = group.MaxGroupMembers
+		}
+		r = append(r, ms[:grp.Count]...)
+		groups = append(groups, grp)
+	}
+	return r, groups, nextg
+}
+
+// pageBounds returns the bounds of the page of count elements, or of all
+// remaining elements if count is 0, starting at start in a list of n
+// elements and the start of the next page, or -1 if there isn't one.
+func pageBounds(n, start, count int) (int, int, int) {
+	if start > n {
+		start = n
+	}
+	if count <= 0 || start+count >= n {
+		return start, n, -1
+	}
+	return start, start + count, start + count
+}
+
+// goroutineStack formats the stack of g starting from its topmost frame in
+// user code, as the key used to group goroutines by GoroutineStack.
+func goroutineStack(g *proc.G) string {
+	frames, err := g.Stacktrace(groupStackDepth)
+	if err != nil {
+		return fmt.Sprintf("error reading stack: %v", err)
+	}
+	userLoc := g.UserCurrent()
+	first := 0
+	for i := range frames {
+		if frames[i].Call.PC == userLoc.PC {
+			first = i
+			break
+		}
+	}
+	var buf bytes.Buffer
+	for _, frame := range frames[first:] {
+		name := "?"
+		if frame.Call.Fn != nil {
+			name = frame.Call.Fn.Name
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "%s %s:%d", name, frame.Call.File, frame.Call.Line)
+	}
+	return buf.String()
+}
@@ -0,0 +1,115 @@
+package debugger
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/derekparker/delve/service/api"
+)
+
+func testGoroutine(id int, fn string, line int, status uint64, waitReason string) *api.Goroutine {
+	loc := api.Location{File: "/src/main.go", Line: line, Function: &api.Function{Name: fn}}
+	return &api.Goroutine{
+		ID:             id,
+		CurrentLoc:     loc,
+		UserCurrentLoc: loc,
+		GoStatementLoc: api.Location{File: "/src/main.go", Line: 10, Function: &api.Function{Name: "main.main"}},
+		Status:         status,
+		WaitReason:     waitReason,
+	}
+}
+
+func goroutineIDs(gs []*api.Goroutine) []int {
+	ids := []int{}
+	for _, g := range gs {
+		ids = append(ids, g.ID)
+	}
+	return ids
+}
+
+func TestFilterGoroutines(t *testing.T) {
+	gs := []*api.Goroutine{
+		testGoroutine(1, "main.main", 20, 2, ""),
+		testGoroutine(2, "main.worker", 30, 4, "chan receive"),
+		testGoroutine(3, "main.worker", 30, 4, "chan receive"),
+		testGoroutine(4, "net/http.(*conn).serve", 40, 4, "select"),
+		testGoroutine(5, "main.producer", 50, 1, ""),
+	}
+
+	testcases := []struct {
+		filters []api.ListGoroutinesFilter
+		ids     []int
+	}{
+		{nil, []int{1, 2, 3, 4, 5}},
+		{[]api.ListGoroutinesFilter{{Kind: api.GoroutineUserLoc, Arg: "main.go:30"}}, []int{2, 3}},
+		{[]api.ListGoroutinesFilter{{Kind: api.GoroutineUserLoc, Arg: "worker"}}, []int{2, 3}},
+		{[]api.ListGoroutinesFilter{{Kind: api.GoroutineFunction, Arg: "^main\\.(worker|producer)$"}}, []int{2, 3, 5}},
+		{[]api.ListGoroutinesFilter{{Kind: api.GoroutineWaitReason, Arg: "chan"}}, []int{2, 3}},
+		{[]api.ListGoroutinesFilter{{Kind: api.GoroutineStatus, Arg: "waiting"}, {Kind: api.GoroutineWaitReason, Negated: true, Arg: "chan"}}, []int{4}},
+		{[]api.ListGoroutinesFilter{{Kind: api.GoroutineStatus, Arg: "running"}}, []int{1}},
+		{[]api.ListGoroutinesFilter{{Kind: api.GoroutineGoLoc, Negated: true, Arg: "main.main"}}, []int{}},
+	}
+
+	for _, tc := range testcases {
+		r, err := filterGoroutines(gs, tc.filters)
+		if err != nil {
+			t.Fatalf("filters %v: %v", tc.filters, err)
+		}
+		if ids := goroutineIDs(r); !reflect.DeepEqual(ids, tc.ids) {
+			t.Errorf("filters %v: got %v, expected %v", tc.filters, ids, tc.ids)
+		}
+	}
+
+	if _, err := filterGoroutines(gs, []api.ListGoroutinesFilter{{Kind: api.GoroutineFunction, Arg: "("}}); err == nil {
+		t.Errorf("invalid regular expression accepted")
+	}
+	if _, err := filterGoroutines(gs, []api.ListGoroutinesFilter{{Kind: api.GoroutineStack}}); err == nil {
+		t.Errorf("filter on stack accepted")
+	}
+}
+
+func TestPageGoroutines(t *testing.T) {
+	gs := []*api.Goroutine{}
+	for i := 1; i <= 10; i++ {
+		fn := "main.worker"
+		if i%4 == 0 {
+			fn = "main.producer"
+		}
+		gs = append(gs, testGoroutine(i, fn, 30, 4, "chan receive"))
+	}
+
+	r, groups, nextg := pageGoroutines(gs, 0, 4, api.GoroutineGroupingOptions{}, nil)
+	if ids := goroutineIDs(r); !reflect.DeepEqual(ids, []int{1, 2, 3, 4}) || groups != nil || nextg != 4 {
+		t.Errorf("first page: %v %v %d", ids, groups, nextg)
+	}
+	r, _, nextg = pageGoroutines(gs, 8, 4, api.GoroutineGroupingOptions{}, nil)
+	if ids := goroutineIDs(r); !reflect.DeepEqual(ids, []int{9, 10}) || nextg != -1 {
+		t.Errorf("last page: %v %d", ids, nextg)
+	}
+	r, _, nextg = pageGoroutines(gs, 20, 4, api.GoroutineGroupingOptions{}, nil)
+	if len(r) != 0 || nextg != -1 {
+		t.Errorf("page after the end: %v %d", goroutineIDs(r), nextg)
+	}
+
+	opts := api.GoroutineGroupingOptions{GroupBy: api.GoroutineFunction, MaxGroupMembers: 3}
+	key, err := goroutineGroupKey(opts.GroupBy, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, groups, nextg = pageGoroutines(gs, 0, 0, opts, key)
+	expected := []api.GoroutineGroup{
+		{Name: "main.worker", Offset: 0, Count: 3, Total: 8},
+		{Name: "main.producer", Offset: 3, Count: 2, Total: 2},
+	}
+	if !reflect.DeepEqual(groups, expected) || nextg != -1 {
+		t.Errorf("groups: %#v %d", groups, nextg)
+	}
+	if ids := goroutineIDs(r); !reflect.DeepEqual(ids, []int{1, 2, 3, 4, 8}) {
+		t.Errorf("group members: %v", ids)
+	}
+
+	_, groups, nextg = pageGoroutines(gs, 1, 1, opts, key)
+	if len(groups) != 1 || groups[0].Name != "main.producer" || nextg != -1 {
+		t.Errorf("second page of groups: %#v %d", groups, nextg)
+	}
+}
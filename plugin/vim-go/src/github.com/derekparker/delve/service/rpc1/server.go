@@ -83,7 +83,7 @@ func (s *RPCServer) StacktraceGoroutine(args *StacktraceGoroutineArgs, locations
 	if args.Full {
 		loadcfg = &defaultLoadConfig
 	}
-	locs, err := s.debugger.Stacktrace(args.Id, args.Depth, loadcfg)
+	locs, err := s.debugger.Stacktrace(args.Id, args.Depth, false, loadcfg)
 	if err != nil {
 		return err
 	}
@@ -164,7 +164,7 @@ func (s *RPCServer) ListPackageVars(filter string, variables *[]api.Variable) er
 		return fmt.Errorf("no current thread")
 	}
 
-	vars, err := s.debugger.PackageVariables(current.ID, filter, defaultLoadConfig)
+	vars, err := s.debugger.PackageVariables(current.ID, filter, defaultLoadConfig, false)
 	if err != nil {
 		return err
 	}
@@ -186,7 +186,7 @@ func (s *RPCServer) ListThreadPackageVars(args *ThreadListArgs, variables *[]api
 		return fmt.Errorf("no thread with id %d", args.Id)
 	}
 
-	vars, err := s.debugger.PackageVariables(args.Id, args.Filter, defaultLoadConfig)
+	vars, err := s.debugger.PackageVariables(args.Id, args.Filter, defaultLoadConfig, false)
 	if err != nil {
 		return err
 	}
@@ -209,7 +209,7 @@ func (s *RPCServer) ListRegisters(arg interface{}, registers *string) error {
 }
 
 func (s *RPCServer) ListLocalVars(scope api.EvalScope, variables *[]api.Variable) error {
-	vars, err := s.debugger.LocalVariables(scope, defaultLoadConfig)
+	vars, err := s.debugger.LocalVariables(scope, defaultLoadConfig, false)
 	if err != nil {
 		return err
 	}
@@ -218,7 +218,7 @@ func (s *RPCServer) ListLocalVars(scope api.EvalScope, variables *[]api.Variable
 }
 
 func (s *RPCServer) ListFunctionArgs(scope api.EvalScope, variables *[]api.Variable) error {
-	vars, err := s.debugger.FunctionArguments(scope, defaultLoadConfig)
+	vars, err := s.debugger.FunctionArguments(scope, defaultLoadConfig, false)
 	if err != nil {
 		return err
 	}
@@ -232,7 +232,7 @@ type EvalSymbolArgs struct {
 }
 
 func (s *RPCServer) EvalSymbol(args EvalSymbolArgs, variable *api.Variable) error {
-	v, err := s.debugger.EvalVariableInScope(args.Scope, args.Symbol, defaultLoadConfig)
+	v, err := s.debugger.EvalVariableInScope(args.Scope, args.Symbol, defaultLoadConfig, false)
 	if err != nil {
 		return err
 	}
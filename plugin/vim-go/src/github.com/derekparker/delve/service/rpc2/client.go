@@ -131,12 +131,43 @@ func (c *RPCClient) StepOut() (*api.DebuggerState, error) {
 	return &out.State, err
 }
 
+func (c *RPCClient) Call(expr string) (*api.DebuggerState, error) {
+	var out CommandOut
+	returnInfoLoadConfig := api.LoadConfig{true, 1, 64, 64, -1, false}
+	err := c.call("Command", api.DebuggerCommand{Name: api.Call, Expr: expr, ReturnInfoLoadConfig: &returnInfoLoadConfig}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) StepInstruction() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", api.DebuggerCommand{Name: api.StepInstruction}, &out)
 	return &out.State, err
 }
 
+func (c *RPCClient) ReverseNext() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseNext}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) ReverseStep() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseStep}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) ReverseStepOut() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseStepOut}, &out)
+	return &out.State, err
+}
+
+func (c *RPCClient) ReverseStepInstruction() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseStepInstruction}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) SwitchThread(threadID int) (*api.DebuggerState, error) {
 	var out CommandOut
 	cmd := api.DebuggerCommand{
@@ -147,6 +178,16 @@ func (c *RPCClient) SwitchThread(threadID int) (*api.DebuggerState, error) {
 	return &out.State, err
 }
 
+func (c *RPCClient) SwitchTarget(pid int) (*api.DebuggerState, error) {
+	var out CommandOut
+	cmd := api.DebuggerCommand{
+		Name:      api.SwitchTarget,
+		TargetPid: pid,
+	}
+	err := c.call("Command", cmd, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) SwitchGoroutine(goroutineID int) (*api.DebuggerState, error) {
 	var out CommandOut
 	cmd := api.DebuggerCommand{
@@ -177,7 +218,14 @@ func (c *RPCClient) GetBreakpointByName(name string) (*api.Breakpoint, error) {
 
 func (c *RPCClient) CreateBreakpoint(breakPoint *api.Breakpoint) (*api.Breakpoint, error) {
 	var out CreateBreakpointOut
-	err := c.call("CreateBreakpoint", CreateBreakpointIn{*breakPoint}, &out)
+	err := c.call("CreateBreakpoint", CreateBreakpointIn{*breakPoint, api.EvalScope{}}, &out)
+	return &out.Breakpoint, err
+}
+
+func (c *RPCClient) CreateWatchpoint(scope api.EvalScope, expr string, wtype api.WatchType) (*api.Breakpoint, error) {
+	var out CreateBreakpointOut
+	bp := api.Breakpoint{WatchExpr: expr, WatchType: wtype}
+	err := c.call("CreateBreakpoint", CreateBreakpointIn{bp, scope}, &out)
 	return &out.Breakpoint, err
 }
 
@@ -216,6 +264,12 @@ func (c *RPCClient) ListThreads() ([]*api.Thread, error) {
 	return out.Threads, err
 }
 
+func (c *RPCClient) ListTargets() ([]api.Target, error) {
+	var out ListTargetsOut
+	err := c.call("ListTargets", ListTargetsIn{}, &out)
+	return out.Targets, err
+}
+
 func (c *RPCClient) GetThread(id int) (*api.Thread, error) {
 	var out GetThreadOut
 	err := c.call("GetThread", GetThreadIn{id}, &out)
@@ -245,6 +299,12 @@ func (c *RPCClient) ListFunctions(filter string) ([]string, error) {
 	return funcs.Funcs, err
 }
 
+func (c *RPCClient) FunctionReturnLocations(fnName string) ([]uint64, error) {
+	var out FunctionReturnLocationsOut
+	err := c.call("FunctionReturnLocations", FunctionReturnLocationsIn{fnName}, &out)
+	return out.Addrs, err
+}
+
 func (c *RPCClient) ListTypes(filter string) ([]string, error) {
 	types := new(ListTypesOut)
 	err := c.call("ListTypes", ListTypesIn{filter}, types)
@@ -269,6 +329,40 @@ func (c *RPCClient) ListRegisters(threadID int, includeFp bool) (api.Registers,
 	return out.Regs, err
 }
 
+func (c *RPCClient) ExamineMemory(address uintptr, length int) ([]byte, error) {
+	out := new(ExamineMemoryOut)
+	err := c.call("ExamineMemory", ExamineMemoryIn{Address: address, Length: length}, out)
+	return out.Mem, err
+}
+
+func (c *RPCClient) ListDisplays(scope api.EvalScope, cfg api.LoadConfig) ([]api.Display, error) {
+	var out ListDisplaysOut
+	err := c.call("ListDisplays", ListDisplaysIn{Scope: scope, Cfg: cfg}, &out)
+	return out.Displays, err
+}
+
+func (c *RPCClient) SetDisplays(displays []api.DisplayExpr) error {
+	var out SetDisplaysOut
+	return c.call("SetDisplays", SetDisplaysIn{Displays: displays}, &out)
+}
+
+func (c *RPCClient) DumpStart(dest string) (api.DumpState, error) {
+	var out DumpStartOut
+	err := c.call("DumpStart", DumpStartIn{Destination: dest}, &out)
+	return out.State, err
+}
+
+func (c *RPCClient) DumpWait(wait int) (api.DumpState, error) {
+	var out DumpWaitOut
+	err := c.call("DumpWait", DumpWaitIn{Wait: wait}, &out)
+	return out.State, err
+}
+
+func (c *RPCClient) DumpCancel() error {
+	out := new(DumpCancelOut)
+	return c.call("DumpCancel", DumpCancelIn{}, out)
+}
+
 func (c *RPCClient) ListFunctionArgs(scope api.EvalScope, cfg api.LoadConfig) ([]api.Variable, error) {
 	var out ListFunctionArgsOut
 	err := c.call("ListFunctionArgs", ListFunctionArgsIn{scope, cfg}, &out)
@@ -281,9 +375,19 @@ func (c *RPCClient) ListGoroutines() ([]*api.Goroutine, error) {
 	return out.Goroutines, err
 }
 
-func (c *RPCClient) Stacktrace(goroutineId, depth int, cfg *api.LoadConfig) ([]api.Stackframe, error) {
+func (c *RPCClient) ListGoroutinesWithFilter(start, count int, filters []api.ListGoroutinesFilter, group *api.GoroutineGroupingOptions) ([]*api.Goroutine, []api.GoroutineGroup, int, error) {
+	in := ListGoroutinesIn{Start: start, Count: count, Filters: filters}
+	if group != nil {
+		in.GoroutineGroupingOptions = *group
+	}
+	var out ListGoroutinesOut
+	err := c.call("ListGoroutines", in, &out)
+	return out.Goroutines, out.Groups, out.Nextg, err
+}
+
+func (c *RPCClient) Stacktrace(goroutineId, depth int, readDefers bool, cfg *api.LoadConfig) ([]api.Stackframe, error) {
 	var out StacktraceOut
-	err := c.call("Stacktrace", StacktraceIn{goroutineId, depth, false, cfg}, &out)
+	err := c.call("Stacktrace", StacktraceIn{goroutineId, depth, false, readDefers, cfg}, &out)
 	return out.Locations, err
 }
 
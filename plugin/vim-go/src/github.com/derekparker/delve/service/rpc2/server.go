@@ -145,10 +145,11 @@ func (s *RPCServer) GetBreakpoint(arg GetBreakpointIn, out *GetBreakpointOut) er
 }
 
 type StacktraceIn struct {
-	Id    int
-	Depth int
-	Full  bool
-	Cfg   *api.LoadConfig
+	Id     int
+	Depth  int
+	Full   bool
+	Defers bool // read deferred functions
+	Cfg    *api.LoadConfig
 }
 
 type StacktraceOut struct {
@@ -159,12 +160,14 @@ type StacktraceOut struct {
 //
 // If Full is set it will also the variable of all local variables
 // and function arguments of all stack frames.
+// If Defers is set it will also return the deferred calls registered by
+// each stack frame.
 func (s *RPCServer) Stacktrace(arg StacktraceIn, out *StacktraceOut) error {
 	cfg := arg.Cfg
 	if cfg == nil && arg.Full {
-		cfg = &api.LoadConfig{true, 1, 64, 64, -1}
+		cfg = &api.LoadConfig{true, 1, 64, 64, -1, false}
 	}
-	locs, err := s.debugger.Stacktrace(arg.Id, arg.Depth, api.LoadConfigToProc(cfg))
+	locs, err := s.debugger.Stacktrace(arg.Id, arg.Depth, arg.Defers, api.LoadConfigToProc(cfg))
 	if err != nil {
 		return err
 	}
@@ -187,6 +190,8 @@ func (s *RPCServer) ListBreakpoints(arg ListBreakpointsIn, out *ListBreakpointsO
 
 type CreateBreakpointIn struct {
 	Breakpoint api.Breakpoint
+	// Scope is used to evaluate Breakpoint.WatchExpr.
+	Scope api.EvalScope
 }
 
 type CreateBreakpointOut struct {
@@ -204,9 +209,20 @@ type CreateBreakpointOut struct {
 // (line == 0) can have surprising consequences, it is advisable to
 // use line = -1 instead which will skip the prologue.
 //
+// - If arg.Breakpoint.WatchExpr is not an empty string a hardware
+// watchpoint will be created on the memory of the expression, evaluated
+// in arg.Scope. arg.Breakpoint.WatchType specifies whether reads, writes
+// or both stop execution.
+//
 // - Otherwise the value specified by arg.Breakpoint.Addr will be used.
 func (s *RPCServer) CreateBreakpoint(arg CreateBreakpointIn, out *CreateBreakpointOut) error {
-	createdbp, err := s.debugger.CreateBreakpoint(&arg.Breakpoint)
+	var createdbp *api.Breakpoint
+	var err error
+	if arg.Breakpoint.WatchExpr != "" {
+		createdbp, err = s.debugger.CreateWatchpoint(arg.Scope, &arg.Breakpoint)
+	} else {
+		createdbp, err = s.debugger.CreateBreakpoint(&arg.Breakpoint)
+	}
 	if err != nil {
 		return err
 	}
@@ -256,6 +272,9 @@ type AmendBreakpointOut struct {
 // AmendBreakpoint allows user to update an existing breakpoint
 // for example to change the information retrieved when the
 // breakpoint is hit or to change, add or remove the break condition.
+// Setting arg.Breakpoint.HitCond makes the breakpoint stop only when its
+// hit count satisfies the condition, setting arg.Breakpoint.LogMessage
+// and arg.Breakpoint.Tracepoint turns it into a logpoint.
 //
 // arg.Breakpoint.ID must be a valid breakpoint ID
 func (s *RPCServer) AmendBreakpoint(arg AmendBreakpointIn, out *AmendBreakpointOut) error {
@@ -285,6 +304,20 @@ func (s *RPCServer) ListThreads(arg ListThreadsIn, out *ListThreadsOut) (err err
 	return err
 }
 
+type ListTargetsIn struct {
+}
+
+type ListTargetsOut struct {
+	Targets []api.Target
+}
+
+// ListTargets lists the processes being debugged, when following child
+// processes there can be more than one.
+func (s *RPCServer) ListTargets(arg ListTargetsIn, out *ListTargetsOut) (err error) {
+	out.Targets, err = s.debugger.Targets()
+	return err
+}
+
 type GetThreadIn struct {
 	Id int
 }
@@ -327,7 +360,7 @@ func (s *RPCServer) ListPackageVars(arg ListPackageVarsIn, out *ListPackageVarsO
 		return fmt.Errorf("no current thread")
 	}
 
-	vars, err := s.debugger.PackageVariables(current.ID, arg.Filter, *api.LoadConfigToProc(&arg.Cfg))
+	vars, err := s.debugger.PackageVariables(current.ID, arg.Filter, *api.LoadConfigToProc(&arg.Cfg), arg.Cfg.Raw)
 	if err != nil {
 		return err
 	}
@@ -365,6 +398,108 @@ func (s *RPCServer) ListRegisters(arg ListRegistersIn, out *ListRegistersOut) er
 	return nil
 }
 
+type ExamineMemoryIn struct {
+	Address uintptr
+	Length  int
+}
+
+type ExamineMemoryOut struct {
+	Mem []byte
+}
+
+// ExamineMemory reads Length bytes of memory starting at Address.
+func (s *RPCServer) ExamineMemory(arg ExamineMemoryIn, out *ExamineMemoryOut) error {
+	if arg.Length <= 0 {
+		return fmt.Errorf("invalid length %d, it must be positive", arg.Length)
+	}
+	if arg.Length > api.MaxExamineMemoryLength {
+		return fmt.Errorf("can not read more than %d bytes of memory", api.MaxExamineMemoryLength)
+	}
+	mem, err := s.debugger.ExamineMemory(arg.Address, arg.Length)
+	if err != nil {
+		return err
+	}
+	out.Mem = mem
+	return nil
+}
+
+type ListDisplaysIn struct {
+	Scope api.EvalScope
+	Cfg   api.LoadConfig
+}
+
+type ListDisplaysOut struct {
+	Displays []api.Display
+}
+
+// ListDisplays evaluates the expressions of the display list in the
+// specified scope, see SetDisplays.
+func (s *RPCServer) ListDisplays(arg ListDisplaysIn, out *ListDisplaysOut) error {
+	out.Displays = s.debugger.Displays(arg.Scope, *api.LoadConfigToProc(&arg.Cfg))
+	return nil
+}
+
+type SetDisplaysIn struct {
+	Displays []api.DisplayExpr
+}
+
+type SetDisplaysOut struct {
+}
+
+// SetDisplays replaces the display list, the expressions that clients
+// evaluate every time the target stops.
+func (s *RPCServer) SetDisplays(arg SetDisplaysIn, out *SetDisplaysOut) error {
+	s.debugger.SetDisplays(arg.Displays)
+	return nil
+}
+
+type DumpStartIn struct {
+	Destination string
+}
+
+type DumpStartOut struct {
+	State api.DumpState
+}
+
+// DumpStart starts a core dump to arg.Destination. The dump is written in
+// the background, its progress can be followed with DumpWait.
+func (s *RPCServer) DumpStart(arg DumpStartIn, out *DumpStartOut) error {
+	if err := s.debugger.DumpStart(arg.Destination); err != nil {
+		return err
+	}
+	out.State = s.debugger.DumpWait(0)
+	return nil
+}
+
+type DumpWaitIn struct {
+	// Wait is the maximum time to wait for the dump to finish, in
+	// milliseconds.
+	Wait int
+}
+
+type DumpWaitOut struct {
+	State api.DumpState
+}
+
+// DumpWait waits for the core dump started by DumpStart to finish, or for
+// arg.Wait milliseconds to elapse, and returns the state of the dump.
+func (s *RPCServer) DumpWait(arg DumpWaitIn, cb service.RPCCallback) {
+	var out DumpWaitOut
+	out.State = s.debugger.DumpWait(time.Duration(arg.Wait) * time.Millisecond)
+	cb.Return(out, nil)
+}
+
+type DumpCancelIn struct {
+}
+
+type DumpCancelOut struct {
+}
+
+// DumpCancel cancels the core dump in progress.
+func (s *RPCServer) DumpCancel(arg DumpCancelIn, out *DumpCancelOut) error {
+	return s.debugger.DumpCancel()
+}
+
 type ListLocalVarsIn struct {
 	Scope api.EvalScope
 	Cfg   api.LoadConfig
@@ -376,7 +511,7 @@ type ListLocalVarsOut struct {
 
 // ListLocalVars lists all local variables in scope.
 func (s *RPCServer) ListLocalVars(arg ListLocalVarsIn, out *ListLocalVarsOut) error {
-	vars, err := s.debugger.LocalVariables(arg.Scope, *api.LoadConfigToProc(&arg.Cfg))
+	vars, err := s.debugger.LocalVariables(arg.Scope, *api.LoadConfigToProc(&arg.Cfg), arg.Cfg.Raw)
 	if err != nil {
 		return err
 	}
@@ -395,7 +530,7 @@ type ListFunctionArgsOut struct {
 
 // ListFunctionArgs lists all arguments to the current function
 func (s *RPCServer) ListFunctionArgs(arg ListFunctionArgsIn, out *ListFunctionArgsOut) error {
-	vars, err := s.debugger.FunctionArguments(arg.Scope, *api.LoadConfigToProc(&arg.Cfg))
+	vars, err := s.debugger.FunctionArguments(arg.Scope, *api.LoadConfigToProc(&arg.Cfg), arg.Cfg.Raw)
 	if err != nil {
 		return err
 	}
@@ -420,9 +555,9 @@ type EvalOut struct {
 func (s *RPCServer) Eval(arg EvalIn, out *EvalOut) error {
 	cfg := arg.Cfg
 	if cfg == nil {
-		cfg = &api.LoadConfig{true, 1, 64, 64, -1}
+		cfg = &api.LoadConfig{true, 1, 64, 64, -1, false}
 	}
-	v, err := s.debugger.EvalVariableInScope(arg.Scope, arg.Expr, *api.LoadConfigToProc(cfg))
+	v, err := s.debugger.EvalVariableInScope(arg.Scope, arg.Expr, *api.LoadConfigToProc(cfg), cfg.Raw)
 	if err != nil {
 		return err
 	}
@@ -481,6 +616,29 @@ func (s *RPCServer) ListFunctions(arg ListFunctionsIn, out *ListFunctionsOut) er
 	return nil
 }
 
+type FunctionReturnLocationsIn struct {
+	// FnName is the name of the function for which all
+	// return locations should be given.
+	FnName string
+}
+
+type FunctionReturnLocationsOut struct {
+	// Addrs is the list of all locations where the given function returns.
+	Addrs []uint64
+}
+
+// FunctionReturnLocations returns the addresses of all the return
+// instructions of function FnName, the breakpoints set by 'dlv trace' to
+// print return values are placed on them.
+func (s *RPCServer) FunctionReturnLocations(arg FunctionReturnLocationsIn, out *FunctionReturnLocationsOut) error {
+	addrs, err := s.debugger.FunctionReturnLocations(arg.FnName)
+	if err != nil {
+		return err
+	}
+	out.Addrs = addrs
+	return nil
+}
+
 type ListTypesIn struct {
 	Filter string
 }
@@ -500,19 +658,35 @@ func (s *RPCServer) ListTypes(arg ListTypesIn, out *ListTypesOut) error {
 }
 
 type ListGoroutinesIn struct {
+	// Start and Count select a page of goroutines, or of groups when
+	// grouping, a Count of 0 selects all of them.
+	Start int
+	Count int
+
+	Filters []api.ListGoroutinesFilter
+	api.GoroutineGroupingOptions
 }
 
 type ListGoroutinesOut struct {
 	Goroutines []*api.Goroutine
+	// Groups is set when goroutines are grouped, the members of each group
+	// are in Goroutines.
+	Groups []api.GoroutineGroup
+	// Nextg is the Start of the next page, or -1 if this is the last one.
+	Nextg int
 }
 
-// ListGoroutines lists all goroutines.
+// ListGoroutines lists the goroutines matching all arg.Filters, sorted by
+// ID. If arg.GroupBy is set the goroutines are grouped by that field and
+// arg.Start and arg.Count select groups instead of goroutines.
 func (s *RPCServer) ListGoroutines(arg ListGoroutinesIn, out *ListGoroutinesOut) error {
-	gs, err := s.debugger.Goroutines()
+	gs, groups, nextg, err := s.debugger.ListGoroutines(arg.Start, arg.Count, arg.Filters, arg.GoroutineGroupingOptions)
 	if err != nil {
 		return err
 	}
 	out.Goroutines = gs
+	out.Groups = groups
+	out.Nextg = nextg
 	return nil
 }
 
@@ -115,6 +115,11 @@ func (s *ServerImpl) Run() error {
 		WorkingDir: s.config.WorkingDir,
 		CoreFile:   s.config.CoreFile,
 		Backend:    s.config.Backend,
+		FollowExec: s.config.FollowExec,
+		FollowFork: s.config.FollowFork,
+
+		PrettyPrinters: s.config.PrettyPrinters,
+		Displays:       s.config.Displays,
 	},
 		s.config.ProcessArgs); err != nil {
 		return err
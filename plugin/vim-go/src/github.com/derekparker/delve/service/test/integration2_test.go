@@ -3,6 +3,7 @@ package service_test
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"net"
 	"os"
@@ -22,7 +23,7 @@ import (
 	"github.com/derekparker/delve/service/rpccommon"
 )
 
-var normalLoadConfig = api.LoadConfig{true, 1, 64, 64, -1}
+var normalLoadConfig = api.LoadConfig{true, 1, 64, 64, -1, false}
 var testBackend string
 
 func TestMain(m *testing.M) {
@@ -471,7 +472,7 @@ func TestClientServer_infoLocals(t *testing.T) {
 		if state.Err != nil {
 			t.Fatalf("Unexpected error: %v, state: %#v", state.Err, state)
 		}
-		locals, err := c.ListLocalVariables(api.EvalScope{-1, 0}, normalLoadConfig)
+		locals, err := c.ListLocalVariables(api.EvalScope{GoroutineID: -1, Frame: 0}, normalLoadConfig)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -500,7 +501,7 @@ func TestClientServer_infoArgs(t *testing.T) {
 		if len(regs) == 0 {
 			t.Fatal("Expected string showing registers values, got empty string")
 		}
-		locals, err := c.ListFunctionArgs(api.EvalScope{-1, 0}, normalLoadConfig)
+		locals, err := c.ListFunctionArgs(api.EvalScope{GoroutineID: -1, Frame: 0}, normalLoadConfig)
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
 		}
@@ -743,7 +744,7 @@ func TestClientServer_EvalVariable(t *testing.T) {
 			t.Fatalf("Continue(): %v\n", state.Err)
 		}
 
-		var1, err := c.EvalVariable(api.EvalScope{-1, 0}, "a1", normalLoadConfig)
+		var1, err := c.EvalVariable(api.EvalScope{GoroutineID: -1, Frame: 0}, "a1", normalLoadConfig)
 		assertNoError(err, t, "EvalVariable")
 
 		t.Logf("var1: %s", var1.SinglelineString())
@@ -762,9 +763,9 @@ func TestClientServer_SetVariable(t *testing.T) {
 			t.Fatalf("Continue(): %v\n", state.Err)
 		}
 
-		assertNoError(c.SetVariable(api.EvalScope{-1, 0}, "a2", "8"), t, "SetVariable()")
+		assertNoError(c.SetVariable(api.EvalScope{GoroutineID: -1, Frame: 0}, "a2", "8"), t, "SetVariable()")
 
-		a2, err := c.EvalVariable(api.EvalScope{-1, 0}, "a2", normalLoadConfig)
+		a2, err := c.EvalVariable(api.EvalScope{GoroutineID: -1, Frame: 0}, "a2", normalLoadConfig)
 		if err != nil {
 			t.Fatalf("Could not evaluate variable: %v", err)
 		}
@@ -793,7 +794,7 @@ func TestClientServer_FullStacktrace(t *testing.T) {
 		assertNoError(err, t, "GoroutinesInfo()")
 		found := make([]bool, 10)
 		for _, g := range gs {
-			frames, err := c.Stacktrace(g.ID, 10, &normalLoadConfig)
+			frames, err := c.Stacktrace(g.ID, 10, false, &normalLoadConfig)
 			assertNoError(err, t, fmt.Sprintf("Stacktrace(%d)", g.ID))
 			for i, frame := range frames {
 				if frame.Function == nil {
@@ -827,7 +828,7 @@ func TestClientServer_FullStacktrace(t *testing.T) {
 			t.Fatalf("Continue(): %v\n", state.Err)
 		}
 
-		frames, err := c.Stacktrace(-1, 10, &normalLoadConfig)
+		frames, err := c.Stacktrace(-1, 10, false, &normalLoadConfig)
 		assertNoError(err, t, "Stacktrace")
 
 		cur := 3
@@ -897,20 +898,20 @@ func TestIssue355(t *testing.T) {
 		assertError(err, t, "ListThreads()")
 		_, err = c.GetThread(tid)
 		assertError(err, t, "GetThread()")
-		assertError(c.SetVariable(api.EvalScope{gid, 0}, "a", "10"), t, "SetVariable()")
-		_, err = c.ListLocalVariables(api.EvalScope{gid, 0}, normalLoadConfig)
+		assertError(c.SetVariable(api.EvalScope{GoroutineID: gid, Frame: 0}, "a", "10"), t, "SetVariable()")
+		_, err = c.ListLocalVariables(api.EvalScope{GoroutineID: gid, Frame: 0}, normalLoadConfig)
 		assertError(err, t, "ListLocalVariables()")
-		_, err = c.ListFunctionArgs(api.EvalScope{gid, 0}, normalLoadConfig)
+		_, err = c.ListFunctionArgs(api.EvalScope{GoroutineID: gid, Frame: 0}, normalLoadConfig)
 		assertError(err, t, "ListFunctionArgs()")
 		_, err = c.ListRegisters(0, false)
 		assertError(err, t, "ListRegisters()")
 		_, err = c.ListGoroutines()
 		assertError(err, t, "ListGoroutines()")
-		_, err = c.Stacktrace(gid, 10, &normalLoadConfig)
+		_, err = c.Stacktrace(gid, 10, false, &normalLoadConfig)
 		assertError(err, t, "Stacktrace()")
-		_, err = c.FindLocation(api.EvalScope{gid, 0}, "+1")
+		_, err = c.FindLocation(api.EvalScope{GoroutineID: gid, Frame: 0}, "+1")
 		assertError(err, t, "FindLocation()")
-		_, err = c.DisassemblePC(api.EvalScope{-1, 0}, 0x40100, api.IntelFlavour)
+		_, err = c.DisassemblePC(api.EvalScope{GoroutineID: -1, Frame: 0}, 0x40100, api.IntelFlavour)
 		assertError(err, t, "DisassemblePC()")
 	})
 }
@@ -925,12 +926,12 @@ func TestDisasm(t *testing.T) {
 		state := <-ch
 		assertNoError(state.Err, t, "Continue()")
 
-		locs, err := c.FindLocation(api.EvalScope{-1, 0}, "main.main")
+		locs, err := c.FindLocation(api.EvalScope{GoroutineID: -1, Frame: 0}, "main.main")
 		assertNoError(err, t, "FindLocation()")
 		if len(locs) != 1 {
 			t.Fatalf("wrong number of locations for main.main: %d", len(locs))
 		}
-		d1, err := c.DisassemblePC(api.EvalScope{-1, 0}, locs[0].PC, api.IntelFlavour)
+		d1, err := c.DisassemblePC(api.EvalScope{GoroutineID: -1, Frame: 0}, locs[0].PC, api.IntelFlavour)
 		assertNoError(err, t, "DisassemblePC()")
 		if len(d1) < 2 {
 			t.Fatalf("wrong size of disassembly: %d", len(d1))
@@ -938,7 +939,7 @@ func TestDisasm(t *testing.T) {
 
 		pcstart := d1[0].Loc.PC
 		pcend := d1[len(d1)-1].Loc.PC + uint64(len(d1[len(d1)-1].Bytes))
-		d2, err := c.DisassembleRange(api.EvalScope{-1, 0}, pcstart, pcend, api.IntelFlavour)
+		d2, err := c.DisassembleRange(api.EvalScope{GoroutineID: -1, Frame: 0}, pcstart, pcend, api.IntelFlavour)
 		assertNoError(err, t, "DisassembleRange()")
 
 		if len(d1) != len(d2) {
@@ -947,7 +948,7 @@ func TestDisasm(t *testing.T) {
 			t.Fatal("mismatched length between disassemble pc and disassemble range")
 		}
 
-		d3, err := c.DisassemblePC(api.EvalScope{-1, 0}, state.CurrentThread.PC, api.IntelFlavour)
+		d3, err := c.DisassemblePC(api.EvalScope{GoroutineID: -1, Frame: 0}, state.CurrentThread.PC, api.IntelFlavour)
 		assertNoError(err, t, "DisassemblePC() - second call")
 
 		if len(d1) != len(d3) {
@@ -991,7 +992,7 @@ func TestDisasm(t *testing.T) {
 			state, err := c.StepInstruction()
 			assertNoError(err, t, fmt.Sprintf("StepInstruction() %d", count))
 
-			d3, err = c.DisassemblePC(api.EvalScope{-1, 0}, state.CurrentThread.PC, api.IntelFlavour)
+			d3, err = c.DisassemblePC(api.EvalScope{GoroutineID: -1, Frame: 0}, state.CurrentThread.PC, api.IntelFlavour)
 			assertNoError(err, t, fmt.Sprintf("StepInstruction() %d", count))
 
 			curinstr := getCurinstr(d3)
@@ -1029,7 +1030,7 @@ func TestNegativeStackDepthBug(t *testing.T) {
 		ch := c.Continue()
 		state := <-ch
 		assertNoError(state.Err, t, "Continue()")
-		_, err = c.Stacktrace(-1, -2, &normalLoadConfig)
+		_, err = c.Stacktrace(-1, -2, false, &normalLoadConfig)
 		assertError(err, t, "Stacktrace()")
 	})
 }
@@ -1056,7 +1057,7 @@ func TestClientServer_CondBreakpoint(t *testing.T) {
 		state := <-c.Continue()
 		assertNoError(state.Err, t, "Continue()")
 
-		nvar, err := c.EvalVariable(api.EvalScope{-1, 0}, "n", normalLoadConfig)
+		nvar, err := c.EvalVariable(api.EvalScope{GoroutineID: -1, Frame: 0}, "n", normalLoadConfig)
 		assertNoError(err, t, "EvalVariable()")
 
 		if nvar.SinglelineString() != "7" {
@@ -1162,14 +1163,14 @@ func TestTypesCommand(t *testing.T) {
 func TestIssue406(t *testing.T) {
 	protest.AllowRecording(t)
 	withTestClient2("issue406", t, func(c service.Client) {
-		locs, err := c.FindLocation(api.EvalScope{-1, 0}, "issue406.go:146")
+		locs, err := c.FindLocation(api.EvalScope{GoroutineID: -1, Frame: 0}, "issue406.go:146")
 		assertNoError(err, t, "FindLocation()")
 		_, err = c.CreateBreakpoint(&api.Breakpoint{Addr: locs[0].PC})
 		assertNoError(err, t, "CreateBreakpoint()")
 		ch := c.Continue()
 		state := <-ch
 		assertNoError(state.Err, t, "Continue()")
-		v, err := c.EvalVariable(api.EvalScope{-1, 0}, "cfgtree", normalLoadConfig)
+		v, err := c.EvalVariable(api.EvalScope{GoroutineID: -1, Frame: 0}, "cfgtree", normalLoadConfig)
 		assertNoError(err, t, "EvalVariable()")
 		vs := v.MultilineString("")
 		t.Logf("cfgtree formats to: %s\n", vs)
@@ -1181,7 +1182,7 @@ func TestEvalExprName(t *testing.T) {
 		state := <-c.Continue()
 		assertNoError(state.Err, t, "Continue()")
 
-		var1, err := c.EvalVariable(api.EvalScope{-1, 0}, "i1+1", normalLoadConfig)
+		var1, err := c.EvalVariable(api.EvalScope{GoroutineID: -1, Frame: 0}, "i1+1", normalLoadConfig)
 		assertNoError(err, t, "EvalVariable")
 
 		const name = "i1+1"
@@ -1415,3 +1416,163 @@ func TestClientServerConsistentExit(t *testing.T) {
 		}
 	})
 }
+
+func TestClientServerExamineMemoryLength(t *testing.T) {
+	withTestClient2("examinememory", t, func(c service.Client) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		pc := uintptr(state.CurrentThread.PC)
+		for _, length := range []int{0, -1, api.MaxExamineMemoryLength + 1} {
+			if _, err := c.ExamineMemory(pc, length); err == nil {
+				t.Fatalf("ExamineMemory(%#x, %d): expected an error", pc, length)
+			}
+		}
+		mem, err := c.ExamineMemory(pc, 1)
+		assertNoError(err, t, "ExamineMemory()")
+		if len(mem) != 1 {
+			t.Fatalf("wrong number of bytes read: %d", len(mem))
+		}
+	})
+}
+
+func TestClientServerFunctionCallBreakpoint(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("function calls are only supported on linux/amd64")
+	}
+	withTestClient2("fncall", t, func(c service.Client) {
+		_, err := c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.call1", Line: -1})
+		assertNoError(err, t, "CreateBreakpoint()")
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+
+		// the injected call stops on the same breakpoint, its return values
+		// are reported by the continue that completes it.
+		state, err = c.Call("call1(1, 2)")
+		assertNoError(err, t, "Call()")
+		if state.CurrentThread == nil || len(state.CurrentThread.ReturnValues) != 0 {
+			t.Fatalf("return values reported before the call completed: %#v", state.CurrentThread)
+		}
+		state = <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread == nil || len(state.CurrentThread.ReturnValues) != 1 || state.CurrentThread.ReturnValues[0].Value != "3" {
+			t.Fatalf("wrong return values after the call completed: %#v", state.CurrentThread)
+		}
+
+		state, err = c.Call("len(stringsJoin(nil, \"abc\"))")
+		assertNoError(err, t, "Call()")
+		if len(state.CurrentThread.ReturnValues) != 1 || state.CurrentThread.ReturnValues[0].Value != "0" {
+			t.Fatalf("wrong return values of builtin call: %#v", state.CurrentThread.ReturnValues)
+		}
+	})
+}
+
+func TestFollowExec(t *testing.T) {
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("following child processes is only supported by the native backend on linux")
+	}
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("couldn't start listener: %s\n", err)
+	}
+	defer listener.Close()
+	server := rpccommon.NewServer(&service.Config{
+		Listener:    listener,
+		ProcessArgs: []string{protest.BuildFixture("spawn", 0).Path},
+		Backend:     testBackend,
+		FollowExec:  true,
+	}, false)
+	if err := server.Run(); err != nil {
+		t.Fatal(err)
+	}
+	c := rpc2.NewClient(listener.Addr().String())
+	defer c.Detach(true)
+
+	parentPid := c.ProcessPid()
+	_, err = c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.traceme", Line: -1})
+	assertNoError(err, t, "CreateBreakpoint()")
+
+	assertStopped := func(wantChild bool) {
+		state := <-c.Continue()
+		assertNoError(state.Err, t, "Continue()")
+		if state.CurrentThread == nil || state.CurrentThread.Function == nil || state.CurrentThread.Function.Name != "main.traceme" {
+			t.Fatalf("not stopped at main.traceme: %#v", state.CurrentThread)
+		}
+		targets, err := c.ListTargets()
+		assertNoError(err, t, "ListTargets()")
+		var current api.Target
+		for _, tgt := range targets {
+			if tgt.Current {
+				current = tgt
+			}
+		}
+		if isChild := current.Pid != parentPid; isChild != wantChild {
+			t.Fatalf("stopped in the wrong target %d (parent %d), targets %#v", current.Pid, parentPid, targets)
+		}
+	}
+
+	// the child process executes first, while the parent waits for it.
+	assertStopped(true)
+	targets, err := c.ListTargets()
+	assertNoError(err, t, "ListTargets()")
+	if len(targets) != 2 {
+		t.Fatalf("wrong number of targets: %#v", targets)
+	}
+	if _, err := c.SwitchTarget(parentPid); err != nil {
+		t.Fatalf("SwitchTarget(): %v", err)
+	}
+	assertStopped(false)
+}
+
+func TestFollowForkDetachKill(t *testing.T) {
+	if runtime.GOOS != "linux" || testBackend != "native" {
+		t.Skip("following child processes is only supported by the native backend on linux")
+	}
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("couldn't start listener: %s\n", err)
+	}
+	defer listener.Close()
+	server := rpccommon.NewServer(&service.Config{
+		Listener:    listener,
+		ProcessArgs: []string{protest.BuildFixture("fork", 0).Path},
+		Backend:     testBackend,
+		FollowFork:  true,
+	}, false)
+	if err := server.Run(); err != nil {
+		t.Fatal(err)
+	}
+	c := rpc2.NewClient(listener.Addr().String())
+
+	_, err = c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.traceme", Line: -1})
+	assertNoError(err, t, "CreateBreakpoint()")
+	state := <-c.Continue()
+	assertNoError(state.Err, t, "Continue()")
+	targets, err := c.ListTargets()
+	assertNoError(err, t, "ListTargets()")
+	if len(targets) != 2 {
+		t.Fatalf("wrong number of targets: %#v", targets)
+	}
+
+	// the forked child is not the leader of a process group, it must be
+	// killed by pid.
+	assertNoError(c.Detach(true), t, "Detach(true)")
+	for _, tgt := range targets {
+		t0 := time.Now()
+		for processRunning(tgt.Pid) {
+			if time.Since(t0) > 5*time.Second {
+				t.Fatalf("target %d still running after Detach(true)", tgt.Pid)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+}
+
+// processRunning returns true if pid exists and is not a zombie.
+func processRunning(pid int) bool {
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	i := strings.LastIndex(string(stat), ") ")
+	return i >= 0 && i+2 < len(stat) && stat[i+2] != 'Z' && stat[i+2] != 'X'
+}
@@ -2,6 +2,8 @@ package service_test
 
 import (
 	"fmt"
+	"reflect"
+	"regexp"
 	"runtime"
 	"sort"
 	"strings"
@@ -535,7 +537,7 @@ func TestEvalExpression(t *testing.T) {
 		// channels
 		{"ch1", true, "chan int 4/10", "chan int 4/10", "chan int", nil},
 		{"chnil", true, "chan int nil", "chan int nil", "chan int", nil},
-		{"ch1+1", false, "", "", "", fmt.Errorf("can not convert 1 constant to chan int")},
+		{"ch1+1", false, "", "", "", fmt.Errorf("invalid operation: ch1 + 1 (can not convert 1 constant to chan int)")},
 
 		// maps
 		{"m1[\"Malone\"]", false, "main.astruct {A: 2, B: 3}", "main.astruct {A: 2, B: 3}", "main.astruct", nil},
@@ -558,7 +560,7 @@ func TestEvalExpression(t *testing.T) {
 		{"iface4", true, "interface {}([]go/constant.Value) [4]", "interface {}([]go/constant.Value) [...]", "interface {}", nil},
 		{"ifacenil", true, "interface {} nil", "interface {} nil", "interface {}", nil},
 		{"err1 == err2", false, "false", "false", "", nil},
-		{"err1 == iface1", false, "", "", "", fmt.Errorf("mismatched types \"error\" and \"interface {}\"")},
+		{"err1 == iface1", false, "", "", "", fmt.Errorf("invalid operation: err1 == iface1 (mismatched types \"error\" and \"interface {}\")")},
 		{"errnil == nil", false, "true", "true", "", nil},
 		{"errtypednil == nil", false, "false", "false", "", nil},
 		{"nil == errnil", false, "true", "true", "", nil},
@@ -641,11 +643,11 @@ func TestEvalExpression(t *testing.T) {
 
 		// nil
 		{"nil", false, "nil", "nil", "", nil},
-		{"nil+1", false, "", "", "", fmt.Errorf("operator + can not be applied to \"nil\"")},
+		{"nil+1", false, "", "", "", fmt.Errorf("invalid operation: nil + 1 (operator + can not be applied to \"nil\")")},
 		{"fn1", false, "main.afunc", "main.afunc", "main.functype", nil},
 		{"fn2", false, "nil", "nil", "main.functype", nil},
 		{"nilslice", false, "[]int len: 0, cap: 0, nil", "[]int len: 0, cap: 0, nil", "[]int", nil},
-		{"fn1 == fn2", false, "", "", "", fmt.Errorf("can not compare func variables")},
+		{"fn1 == fn2", false, "", "", "", fmt.Errorf("invalid operation: fn1 == fn2 (can not compare func variables)")},
 		{"fn1 == nil", false, "false", "false", "", nil},
 		{"fn1 != nil", false, "true", "true", "", nil},
 		{"fn2 == nil", false, "true", "true", "", nil},
@@ -663,22 +665,22 @@ func TestEvalExpression(t *testing.T) {
 		{"p1 != nil", false, "true", "true", "", nil},
 		{"ch1 == nil", false, "false", "false", "", nil},
 		{"chnil == nil", false, "true", "true", "", nil},
-		{"ch1 == chnil", false, "", "", "", fmt.Errorf("can not compare chan variables")},
+		{"ch1 == chnil", false, "", "", "", fmt.Errorf("invalid operation: ch1 == chnil (can not compare chan variables)")},
 		{"m1 == nil", false, "false", "false", "", nil},
-		{"mnil == m1", false, "", "", "", fmt.Errorf("can not compare map variables")},
+		{"mnil == m1", false, "", "", "", fmt.Errorf("invalid operation: mnil == m1 (can not compare map variables)")},
 		{"mnil == nil", false, "true", "true", "", nil},
-		{"nil == 2", false, "", "", "", fmt.Errorf("can not compare int to nil")},
-		{"2 == nil", false, "", "", "", fmt.Errorf("can not compare int to nil")},
+		{"nil == 2", false, "", "", "", fmt.Errorf("invalid operation: nil == 2 (can not compare int to nil)")},
+		{"2 == nil", false, "", "", "", fmt.Errorf("invalid operation: 2 == nil (can not compare int to nil)")},
 
 		// errors
 		{"&3", false, "", "", "", fmt.Errorf("can not take address of \"3\"")},
 		{"*3", false, "", "", "", fmt.Errorf("expression \"3\" (int) can not be dereferenced")},
 		{"&(i2 + i3)", false, "", "", "", fmt.Errorf("can not take address of \"(i2 + i3)\"")},
-		{"i2 + p1", false, "", "", "", fmt.Errorf("mismatched types \"int\" and \"*int\"")},
-		{"i2 + f1", false, "", "", "", fmt.Errorf("mismatched types \"int\" and \"float64\"")},
-		{"i2 << f1", false, "", "", "", fmt.Errorf("shift count type float64, must be unsigned integer")},
-		{"i2 << -1", false, "", "", "", fmt.Errorf("shift count type int, must be unsigned integer")},
-		{"i2 << i3", false, "", "", "int", fmt.Errorf("shift count type int, must be unsigned integer")},
+		{"i2 + p1", false, "", "", "", fmt.Errorf("invalid operation: i2 + p1 (mismatched types \"int\" and \"*int\")")},
+		{"i2 + f1", false, "", "", "", fmt.Errorf("invalid operation: i2 + f1 (mismatched types \"int\" and \"float64\")")},
+		{"i2 << f1", false, "", "", "", fmt.Errorf("invalid operation: i2 << f1 (shift count type float64, must be unsigned integer)")},
+		{"i2 << -1", false, "", "", "", fmt.Errorf("invalid operation: i2 << -1 (shift count type int, must be unsigned integer)")},
+		{"i2 << i3", false, "", "", "int", fmt.Errorf("invalid operation: i2 << i3 (shift count type int, must be unsigned integer)")},
 		{"*(i2 + i3)", false, "", "", "", fmt.Errorf("expression \"(i2 + i3)\" (int) can not be dereferenced")},
 		{"i2.member", false, "", "", "", fmt.Errorf("i2 (type int) is not a struct")},
 		{"fmt.Println(\"hello\")", false, "", "", "", fmt.Errorf("no type entry found, use 'types' for a list of valid types")},
@@ -739,6 +741,55 @@ func TestEvalExpression(t *testing.T) {
 		// shortcircuited logical operators
 		{"nilstruct != nil && nilstruct.A == 1", false, "false", "false", "", nil},
 		{"nilstruct == nil || nilstruct.A == 1", false, "true", "true", "", nil},
+
+		// string concatenation and slicing
+		{`str1 + "abc"`, false, `"01234567890abc"`, `"01234567890abc"`, "string", nil},
+		{`"a" + "b"`, false, `"ab"`, `"ab"`, "", nil},
+		{`longstr + "x"`, false, "", "", "", fmt.Errorf("string \"longstr\" too long for concatenation")},
+		{`(str1 + "abc")[9:13]`, false, `"90ab"`, `"90ab"`, "string", nil},
+		{`"hello"[1:3]`, false, `"el"`, `"el"`, "", nil},
+		{`"hello"[4:9]`, false, "", "", "", fmt.Errorf("index out of bounds")},
+		{"str1[11:]", false, `""`, `""`, "string", nil},
+
+		// slicing arrays
+		{"arr1[:]", false, "[]int len: 4, cap: 4, [0,1,2,3]", "[]int len: 4, cap: 4, [...]", "[]int", nil},
+		{"arr1[1:3]", false, "[]int len: 2, cap: 2, [1,2]", "[]int len: 2, cap: 2, [...]", "[]int", nil},
+		{"parr[1:3]", false, "[]int len: 2, cap: 2, [1,2]", "[]int len: 2, cap: 2, [...]", "[]int", nil},
+		{"parr[2:]", false, "[]int len: 2, cap: 2, [2,3]", "[]int len: 2, cap: 2, [...]", "[]int", nil},
+
+		// maps with struct and interface keys
+		{"m4[1]", false, "10", "10", "int", nil},
+		{`m4["two"]`, false, "20", "20", "int", nil},
+		{"m4[as2]", false, "30", "30", "int", nil},
+		{"m4[2]", false, "", "", "", fmt.Errorf("key not found")},
+		{"m4[int8(1)]", false, "", "", "", fmt.Errorf("key not found")},
+
+		// comparisons between interfaces and concrete values
+		{`iface2 == "test"`, false, "true", "true", "", nil},
+		{`iface2 != "test"`, false, "false", "false", "", nil},
+		{`"test" == iface2`, false, "true", "true", "", nil},
+		{"iface2 == str1", false, "false", "false", "", nil},
+		{"iface2 == 1", false, "false", "false", "", nil},
+		{"ifacenil == 1", false, "false", "false", "", nil},
+		{"iface1 == as1", false, "false", "false", "", nil},
+
+		// min and max builtins
+		{"min(i2, i3)", false, "2", "2", "int", nil},
+		{"max(i2, i3, 1)", false, "3", "3", "int", nil},
+		{"max(1, 2.5)", false, "2.5", "2.5", "", nil},
+		{"max(f1, 10)", false, "10", "10", "float64", nil},
+		{`min(str1, "1")`, false, `"01234567890"`, `"01234567890"`, "string", nil},
+		{"min()", false, "", "", "", fmt.Errorf("not enough arguments to min")},
+		{"min(i2, f1)", false, "", "", "", fmt.Errorf("invalid argument f1 to min: mismatched types \"int\" and \"float64\"")},
+		{"max(p1)", false, "", "", "", fmt.Errorf("invalid argument p1 (type *int) to max")},
+		{`max(i2, "a")`, false, "", "", "", fmt.Errorf("invalid argument \"a\" (type string) to max: mismatched types")},
+
+		// errors pointing at the offending sub-expression
+		{"s1[f1]", false, "", "", "", fmt.Errorf("invalid index f1: can not convert value of type float64 to int")},
+		{`1 + "a"`, false, "", "", "", fmt.Errorf("invalid operation: 1 + \"a\" (mismatched types \"int\" and \"string\")")},
+		{`i2 + "a"`, false, "", "", "", fmt.Errorf("invalid operation: i2 + \"a\" (can not convert \"a\" constant to int)")},
+		{"afunc(1, 2)", false, "", "", "", fmt.Errorf("function calls are not supported: afunc(1, 2)")},
+		{"[]int{1, 2}", false, "", "", "", fmt.Errorf("expression \"[]int{1, 2}\" (*ast.CompositeLit) not implemented")},
 	}
 
 	ver, _ := goversion.Parse(runtime.Version())
@@ -1006,3 +1057,48 @@ func TestIssue1075(t *testing.T) {
 		}
 	})
 }
+
+func TestPrettyPrinters(t *testing.T) {
+	printers := []proc.PrettyPrinter{
+		{Type: regexp.MustCompile(`^main\.Ring$`), Summary: "len={tail-head}", Children: "buf[head:tail]"},
+		{Type: regexp.MustCompile(`^main\.Pair$`), Summary: "{Key}={Value}"},
+	}
+	testcases := []struct {
+		name     string
+		printers []proc.PrettyPrinter
+		value    string
+	}{
+		{"r", printers, "main.Ring len=3 [20,30,40]"},
+		{"pr", printers, "*main.Ring len=3 [20,30,40]"},
+		{"rings", printers, "[]main.Ring len: 2, cap: 2, [len=3 [20,30,40],len=0 []]"},
+		{"p", printers, "main.Pair answer=42"},
+		{"p", nil, "main.Pair {Key: \"answer\", Value: 42}"},
+		{"r", printers[:0], "main.Ring {buf: [8]int [0,10,20,30,40,50,60,70], head: 2, tail: 5}"},
+	}
+
+	protest.AllowRecording(t)
+	withTestProcess("prettyprint", t, func(p proc.Process, fixture protest.Fixture) {
+		assertNoError(proc.Continue(p), t, "Continue()")
+		for _, tc := range testcases {
+			v, err := evalVariable(p, tc.name, pnormalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalVariable(%s)", tc.name))
+			proc.PrettyPrint([]*proc.Variable{v}, tc.printers, pnormalLoadConfig)
+			if s := api.ConvertVar(v).SinglelineString(); s != tc.value {
+				t.Errorf("%s: expected %q got %q", tc.name, tc.value, s)
+			}
+		}
+
+		// the pretty printed view is the only child, the type of the variable
+		// does not change.
+		v, err := evalVariable(p, "r", pnormalLoadConfig)
+		assertNoError(err, t, "EvalVariable(r)")
+		proc.PrettyPrint([]*proc.Variable{v}, printers, pnormalLoadConfig)
+		cv := api.ConvertVar(v)
+		if cv.Kind != reflect.Struct || cv.Type != "main.Ring" || cv.RealType != "main.Ring" || len(cv.Children) != 1 {
+			t.Fatalf("wrong pretty printed variable: %#v", cv)
+		}
+		if view := cv.Children[0]; view.Name != "buf[head:tail]" || view.Kind != reflect.Slice || view.Len != 3 {
+			t.Fatalf("wrong pretty printed view: %#v", view)
+		}
+	})
+}